@@ -1,19 +1,83 @@
 package main
 
 import (
+	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
 	"strconv"
+	"strings"
 	"text/tabwriter"
 	"time"
 
 	"github.com/dhfai/go-wallet/config"
+	"github.com/dhfai/go-wallet/internal/domain"
 	"github.com/dhfai/go-wallet/internal/service"
 	"github.com/dhfai/go-wallet/internal/storage"
+	"github.com/dhfai/go-wallet/pkg/crypto"
+	"github.com/dhfai/go-wallet/pkg/psbt"
+	"github.com/dhfai/go-wallet/pkg/txbuilder"
+	"golang.org/x/term"
 )
 
+// displayUnit is the denomination used to print and, without a suffix, parse amounts (--unit)
+var displayUnit = domain.UnitBTC
+
+// stdin is shared so prompts for the passphrase and the recovery phrase can read the same stream
+var stdin = bufio.NewReader(os.Stdin)
+
+// keyCommands need decrypted private keys and prompt for the passphrase when storage is encrypted
+var keyCommands = map[string]bool{
+	"create":     true,
+	"restore":    true,
+	"import":     true,
+	"send":       true,
+	"bumpfee":    true,
+	"cpfp":       true,
+	"export":     true,
+	"export-wif": true,
+
+	"import-descriptor": true,
+}
+
 func main() {
-	cfg := config.NewConfig()
+	// Settings come from the config file, its selected profile, GO_WALLET_*
+	// variables and global flags, each overriding the one before; the flags
+	// may appear anywhere on the command line
+	var configPath, profile string
+	os.Args, configPath = extractGlobalFlag(os.Args, "config")
+	if configPath == "" {
+		configPath = config.DefaultPath()
+	}
+	os.Args, profile = extractGlobalFlag(os.Args, "profile")
+	var overrides []settingFlag
+	os.Args, overrides = extractSettingFlags(os.Args)
+
+	if len(os.Args) >= 2 && os.Args[1] == "config" {
+		handleConfig(configPath, profile, overrides)
+		return
+	}
+
+	cfg, err := loadConfig(configPath, profile, overrides)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	displayUnit = cfg.Unit
+
+	params, err := cfg.NetworkParams()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	backend, err := cfg.ChainBackend(params)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
 
 	repo, err := storage.NewJSONWalletRepository(cfg.StoragePath)
 	if err != nil {
@@ -21,7 +85,11 @@ func main() {
 		os.Exit(1)
 	}
 
-	walletService := service.NewWalletService(repo)
+	walletService := service.NewWalletService(repo, params)
+	walletService.SetBackend(backend)
+	walletService.SetGapLimit(cfg.GapLimit)
+	walletService.SetFinalConfirmations(cfg.FinalConfs)
+	walletService.SetFeePolicy(cfg.FeePriority, cfg.MaxFeeRate)
 
 	if len(os.Args) < 2 {
 		printUsage()
@@ -30,19 +98,53 @@ func main() {
 
 	command := os.Args[1]
 
+	if keyCommands[command] {
+		unlockIfNeeded(walletService)
+		defer walletService.Lock()
+	}
+
+	// Ctrl+C cancels pending network requests so the command can fail cleanly;
+	// a second Ctrl+C, e.g. at a prompt, exits immediately
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
 	switch command {
 	case "create":
 		handleCreate(walletService)
+	case "restore":
+		handleRestore(walletService)
 	case "list":
 		handleList(walletService)
 	case "balance":
 		handleBalance(walletService)
+	case "new-address":
+		handleNewAddress(walletService)
+	case "addresses":
+		handleAddresses(walletService)
+	case "utxos":
+		handleUTXOs(walletService)
 	case "sync":
-		handleSync(walletService)
+		handleSync(ctx, walletService)
+	case "rescan":
+		handleRescan(ctx, walletService)
+	case "fees":
+		handleFees(ctx, walletService)
+	case "cpfp":
+		handleCPFP(ctx, walletService)
+	case "bumpfee":
+		handleBumpFee(ctx, walletService)
+	case "psbt":
+		handlePSBT(ctx, walletService)
+	case "multisig":
+		handleMultisig(walletService)
+	case "descriptor":
+		handleDescriptor(walletService)
 	case "send":
-		handleSend(walletService)
-	case "receive":
-		handleReceive(walletService)
+		handleSend(ctx, walletService)
 	case "history":
 		handleHistory(walletService)
 	case "export":
@@ -51,8 +153,14 @@ func main() {
 		handleExportWIF(walletService)
 	case "import":
 		handleImport(walletService)
+	case "import-watch":
+		handleImportWatch(walletService)
+	case "import-descriptor":
+		handleImportDescriptor(walletService)
 	case "delete":
 		handleDelete(walletService)
+	case "passphrase":
+		handlePassphrase(walletService)
 	case "help":
 		printUsage()
 	default:
@@ -65,37 +173,109 @@ func main() {
 func printUsage() {
 	fmt.Println("Go Bitcoin Wallet - Professional Bitcoin Wallet Management")
 	fmt.Println("\nUsage:")
-	fmt.Println("  go-wallet <command> [arguments]")
+	fmt.Println("  go-wallet [--profile <name>] [--network mainnet|testnet|signet|regtest] [--unit btc|mbtc|bits|sats] <command> [arguments]")
 	fmt.Println("\nCommands:")
-	fmt.Println("  create <name>                          Create a new wallet (SegWit bc1...)")
+	fmt.Println("  create <name> [--words 12|24] [--passphrase <p>] [--type <t>]  Create a new wallet with a recovery phrase")
+	fmt.Println("  restore <name> [--passphrase <p>] [--type <t>] [words...]      Restore a wallet from its recovery phrase")
 	fmt.Println("  list                                   List all wallets")
 	fmt.Println("  balance <wallet-id>                    Get wallet balance (local)")
-	fmt.Println("  sync <wallet-id>                       Sync with blockchain (check real balance)")
-	fmt.Println("  send <from-id> <to-address> <amount> <fee> [note]  Send Bitcoin")
-	fmt.Println("  receive <to-id> <from-address> <amount> [note]     Receive Bitcoin")
+	fmt.Println("  new-address <wallet-id> [--change]     Derive the next receive (or change) address")
+	fmt.Println("  addresses <wallet-id>                  List derived addresses")
+	fmt.Println("  sync <wallet-id> [--confirmations <n>] Sync with blockchain; transactions are final after n confirmations (default 6)")
+	fmt.Println("  rescan <wallet-id> [--gap-limit <n>]   Discover all used addresses of an HD wallet, then sync")
+	fmt.Println("  utxos <wallet-id>                      List unspent outputs from the last sync")
+	fmt.Println("  send <from-id> <to-address> <amount> [note] [--priority fast|normal|economy] [--feerate <sat/vB>] [--no-rbf]  Send Bitcoin")
+	fmt.Println("  bumpfee <wallet-id> <txid> --feerate <sat/vB>  Replace a pending transaction with a higher fee (RBF)")
+	fmt.Println("  cpfp <wallet-id> <txid> --feerate <sat/vB>     Speed up an incoming unconfirmed payment (CPFP)")
+	fmt.Println("  fees                                   Show recommended fee rates")
+	fmt.Println("  psbt create <wallet-id> <to-address> <amount> [--priority|--feerate] [--out <file>]  Create an unsigned PSBT")
+	fmt.Println("  psbt sign <wallet-id> <psbt> [--out <file>]       Add the wallet's signatures to a PSBT")
+	fmt.Println("  psbt combine <psbt> <psbt>... [--out <file>]      Merge signatures from several copies of a PSBT")
+	fmt.Println("  psbt finalize <psbt> [--out <file>] [--broadcast] Finalize a signed PSBT into a raw transaction")
+	fmt.Println("  psbt decode <psbt>                                Show the contents of a PSBT")
+	fmt.Println("  multisig key [--type <t>] [--passphrase <p>]      Show this signer's cosigner key for a recovery phrase")
+	fmt.Println("  multisig create <name> --threshold <m> <key>... [--type <t>] [--signer]  Create an m-of-n multisig wallet")
+	fmt.Println("  multisig import <file> [--name <n>] [--signer]    Create a multisig wallet from a setup file")
+	fmt.Println("  multisig export <wallet-id> [--out <file>]        Export the multisig setup file for other coordinators")
+	fmt.Println("  descriptor export <wallet-id> [--private] [--out <file>]  Export the wallet's output descriptors")
 	fmt.Println("  history <wallet-id> [limit]            Get transaction history")
 	fmt.Println("  export <wallet-id>                     Export private key (hex format)")
-	fmt.Println("  export-wif <wallet-id> [--testnet]     Export for Phantom import")
-	fmt.Println("  import <name> <private-key>            Import wallet from private key")
+	fmt.Println("  export-wif <wallet-id>                 Export private key as WIF (Phantom import)")
+	fmt.Println("  import <name> <private-key> [--type <t>]  Import wallet from private key")
+	fmt.Println("  import-watch <name> <address|xpub>... [--type <t>]  Import a watch-only wallet (no private keys)")
+	fmt.Println("  import-descriptor <name> <descriptor>...           Import a wallet from output descriptors")
 	fmt.Println("  delete <wallet-id>                     Delete wallet")
+	fmt.Println("  passphrase                             Encrypt private keys or change the passphrase")
+	fmt.Println("  config list                            Show the config file and its profiles")
+	fmt.Println("  config get [key]                       Show the settings in effect, or the value of one")
+	fmt.Println("  config set <key> <value> [--profile <name>]  Save a setting, in a profile if given (empty value removes it)")
+	fmt.Println("  config set profile <name>              Select the profile used by default")
 	fmt.Println("  help                                   Show this help message")
+	fmt.Println("\nBlockchain backend:")
+	fmt.Println("  --backend esplora|bitcoind|electrum  Source of blockchain data (default esplora)")
+	fmt.Println("  --rpc-url <url>              bitcoind JSON-RPC URL (default 127.0.0.1 on the network's RPC port)")
+	fmt.Println("  --rpc-user <u> --rpc-password <p>  bitcoind credentials (default: the .cookie file)")
+	fmt.Println("  --rpc-cookie <file>          bitcoind .cookie file")
+	fmt.Println("  --rpc-wallet <name>          Watch-only bitcoind wallet for history (default: scantxoutset)")
+	fmt.Println("  --electrum-server <url>      Electrum server, tcp://host:port or ssl://host:port")
+	fmt.Println("  --electrum-insecure          Accept a self-signed certificate of the Electrum server")
+	fmt.Println("  --explorer-url <url>[,<url>] Esplora API, e.g. a self-hosted Esplora or https://mempool.space/api;")
+	fmt.Println("                               further URLs are used when the first one fails")
+	fmt.Println("  --retries <n>                Esplora retries per URL on errors and 429 (default 3)")
+	fmt.Println("  --rate-limit <n>             Esplora requests per second per host (default 5, 0 for none)")
+	fmt.Println("  --cross-check                Compare balances across all Esplora URLs")
+	fmt.Println("  --proxy socks5://host:port   Send all network traffic through a SOCKS5 proxy")
+	fmt.Println("  --tor                        Use the local Tor SOCKS port (127.0.0.1:9050)")
+	fmt.Println("  --ca-file <pem>              Also trust the TLS certificates in this file")
+	fmt.Println("  --timeout <duration>         Per-request timeout, e.g. 30s or 2m")
+	fmt.Println("\nConfiguration:")
+	fmt.Println("  --config <file>              Config file (default ~/.go-wallet/config, or GO_WALLET_CONFIG)")
+	fmt.Println("  --profile <name>             Use a profile of the config file (or GO_WALLET_PROFILE)")
+	fmt.Println("  --storage <file>             Wallet storage file (default ~/.go-wallet/wallets.json)")
+	fmt.Println("  --fee-priority <p>           Priority of sends without --priority or --feerate (default normal)")
+	fmt.Println("  --max-fee-rate <sat/vB>      Refuse to pay more than this fee rate (default 0, no limit)")
+	fmt.Println("  Every setting of 'config set' is also a --<key> flag and a GO_WALLET_<KEY> variable;")
+	fmt.Println("  flags override variables, which override the profile and the file.")
+	fmt.Println("\nAddress types (--type):")
+	fmt.Println("  p2wpkh       Native SegWit, bc1q... (default)")
+	fmt.Println("  p2sh-p2wpkh  Nested SegWit, 3...")
+	fmt.Println("  p2tr         Taproot, bc1p...")
+	fmt.Println("  p2pkh        Legacy, 1...")
+	fmt.Println("  p2wsh        Multisig native SegWit, bc1q... (multisig default)")
+	fmt.Println("  p2sh-p2wsh   Multisig nested SegWit, 3...")
 	fmt.Println("\nExamples:")
 	fmt.Println("  go-wallet create MyWallet")
+	fmt.Println("  go-wallet --network testnet create TestWallet")
+	fmt.Println("  go-wallet config set --profile work-testnet network testnet")
+	fmt.Println("  go-wallet --profile work-testnet list")
 	fmt.Println("  go-wallet list")
 	fmt.Println("  go-wallet balance abc-123")
-	fmt.Println("  go-wallet send abc-123 1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa 0.5 0.0001 \"Payment for services\"")
+	fmt.Println("  go-wallet send abc-123 1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa 0.5 \"Payment for services\"")
+	fmt.Println("  go-wallet send abc-123 1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa 50000sats --priority fast")
+	fmt.Println("  go-wallet send abc-123 1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa 50000sats --feerate 12.5")
+	fmt.Println("  go-wallet psbt create abc-123 1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa 50000sats --out payment.psbt")
+	fmt.Println("  go-wallet multisig create Treasury --threshold 2 \"[f57ead4b/48'/0'/0'/2']xpub...\" \"[8e2f8a2c/48'/0'/0'/2']xpub...\" --signer")
+	fmt.Println("  go-wallet import-descriptor Core \"wpkh([d34db33f/84'/0'/0']xpub.../<0;1>/*)\"")
+	fmt.Println("  go-wallet import-watch Cold zpub6rFR7y4Q2AijBEqTUquhVz398htDFrtymD9xYYfG1m4wAcvPhXNfE3EfH1r1ADqtfSdVCToUG868RvUUkgDKf31mGDtKsAYz2oz2AGutZYs")
 }
 
 func handleCreate(service *service.WalletService) {
-	if len(os.Args) < 3 {
+	args, flags := splitArgs(os.Args[2:], "words", "passphrase", "type")
+	if len(args) < 1 {
 		fmt.Println("Error: wallet name is required")
-		fmt.Println("Usage: go-wallet create <name>")
+		fmt.Println("Usage: go-wallet create <name> [--words 12|24] [--passphrase <passphrase>] [--type <address-type>]")
 		os.Exit(1)
 	}
 
-	name := os.Args[2]
+	name := args[0]
+
+	opts, err := parseCreateOptions(flags)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
 
-	wallet, err := service.CreateWallet(name)
+	wallet, mnemonic, err := service.CreateWallet(name, opts)
 	if err != nil {
 		fmt.Printf("Error creating wallet: %v\n", err)
 		os.Exit(1)
@@ -105,11 +285,89 @@ func handleCreate(service *service.WalletService) {
 	fmt.Println("\n=== Wallet Details ===")
 	fmt.Printf("ID:         %s\n", wallet.ID)
 	fmt.Printf("Name:       %s\n", wallet.Name)
+	fmt.Printf("Network:    %s\n", wallet.NetworkName())
 	fmt.Printf("Address:    %s\n", wallet.Address)
-	fmt.Printf("Balance:    %.8f BTC\n", wallet.Balance)
+	fmt.Printf("Type:       %s (%s)\n", wallet.ScriptType(), wallet.DerivationPath)
+	fmt.Printf("Balance:    %s\n", formatAmount(wallet.Balance))
 	fmt.Printf("Created:    %s\n", wallet.CreatedAt.Format(time.RFC3339))
-	fmt.Println("\n⚠️  IMPORTANT: Please backup your private key securely!")
-	fmt.Println("Use 'go-wallet export <wallet-id>' to export your private key")
+	fmt.Println("\n=== Recovery Phrase ===")
+	printMnemonic(mnemonic)
+	fmt.Println("\n⚠️  IMPORTANT: Write these words down in order and store them offline!")
+	fmt.Println("They are shown only once. Anyone with them can spend your funds.")
+	if opts.Passphrase != "" {
+		fmt.Println("⚠️  Your passphrase is also required to restore this wallet.")
+	}
+	fmt.Println("Use 'go-wallet restore <name>' to recover the wallet from these words")
+	printEncryptionHint(service)
+}
+
+func parseCreateOptions(flags map[string]string) (service.CreateWalletOptions, error) {
+	opts := service.CreateWalletOptions{Passphrase: flags["passphrase"]}
+
+	addressType, err := domain.ParseAddressType(flags["type"])
+	if err != nil {
+		return opts, err
+	}
+	opts.AddressType = addressType
+
+	if words, ok := flags["words"]; ok {
+		count, err := strconv.Atoi(words)
+		if err != nil {
+			return opts, fmt.Errorf("invalid word count: %v", err)
+		}
+		opts.WordCount = count
+	}
+
+	return opts, nil
+}
+
+func printMnemonic(mnemonic string) {
+	words := strings.Fields(mnemonic)
+	for i, word := range words {
+		fmt.Printf("%2d. %-10s", i+1, word)
+		if (i+1)%4 == 0 || i == len(words)-1 {
+			fmt.Println()
+		}
+	}
+}
+
+func handleRestore(service *service.WalletService) {
+	args, flags := splitArgs(os.Args[2:], "passphrase", "type")
+	if len(args) < 1 {
+		fmt.Println("Error: wallet name is required")
+		fmt.Println("Usage: go-wallet restore <name> [--passphrase <passphrase>] [--type <address-type>] [word1 word2 ...]")
+		os.Exit(1)
+	}
+
+	addressType, err := domain.ParseAddressType(flags["type"])
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	name := args[0]
+	mnemonic := strings.Join(args[1:], " ")
+
+	// Read the words from stdin to keep them out of shell history
+	if mnemonic == "" {
+		mnemonic = promptMnemonic()
+	}
+
+	wallet, err := service.RestoreWallet(name, mnemonic, flags["passphrase"], addressType)
+	if err != nil {
+		fmt.Printf("Error restoring wallet: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println("✓ Wallet restored successfully!")
+	fmt.Println("\n=== Wallet Details ===")
+	fmt.Printf("ID:         %s\n", wallet.ID)
+	fmt.Printf("Name:       %s\n", wallet.Name)
+	fmt.Printf("Network:    %s\n", wallet.NetworkName())
+	fmt.Printf("Address:    %s\n", wallet.Address)
+	fmt.Printf("Type:       %s (%s)\n", wallet.ScriptType(), wallet.DerivationPath)
+	fmt.Println("\nRun 'go-wallet rescan <wallet-id>' to find every used address and fetch the balance from the blockchain")
+	printEncryptionHint(service)
 }
 
 func handleList(service *service.WalletService) {
@@ -127,15 +385,16 @@ func handleList(service *service.WalletService) {
 	fmt.Printf("\n=== Wallets (%d total) ===\n\n", len(wallets))
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
-	fmt.Fprintln(w, "ID\tName\tAddress\tBalance (BTC)\tTransactions")
-	fmt.Fprintln(w, "----\t----\t----\t----\t----")
+	fmt.Fprintf(w, "ID\tName\tNetwork\tAddress\tBalance (%s)\tTransactions\n", displayUnit)
+	fmt.Fprintln(w, "----\t----\t----\t----\t----\t----")
 
 	for _, wallet := range wallets {
-		fmt.Fprintf(w, "%s\t%s\t%s\t%.8f\t%d\n",
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\n",
 			wallet.ID,
-			wallet.Name,
+			walletLabel(wallet),
+			wallet.NetworkName(),
 			wallet.Address,
-			wallet.Balance,
+			wallet.Balance.Format(displayUnit),
 			len(wallet.Transactions),
 		)
 	}
@@ -159,91 +418,148 @@ func handleBalance(service *service.WalletService) {
 	}
 
 	wallet, _ := service.GetWallet(walletID)
+	balances, _ := service.GetBalances(walletID)
 
 	fmt.Printf("\n=== Wallet Balance ===\n")
-	fmt.Printf("Wallet:  %s (%s)\n", wallet.Name, wallet.ID)
+	fmt.Printf("Wallet:  %s (%s)\n", walletLabel(wallet), wallet.ID)
 	fmt.Printf("Address: %s\n", wallet.Address)
-	fmt.Printf("Balance: %.8f BTC\n", balance)
+	fmt.Printf("Balance: %s\n", formatAmount(balance))
+	printBalances(balances)
 }
 
-func handleSend(service *service.WalletService) {
-	if len(os.Args) < 6 {
-		fmt.Println("Error: insufficient arguments")
-		fmt.Println("Usage: go-wallet send <from-id> <to-address> <amount> <fee> [note]")
-		os.Exit(1)
+// printBalances prints the confirmed, unconfirmed and immature parts of a balance
+func printBalances(balances domain.Balances) {
+	fmt.Printf("  Confirmed:   %s\n", formatAmount(balances.Confirmed))
+	fmt.Printf("  Unconfirmed: %s\n", formatAmount(balances.Unconfirmed))
+	if balances.Immature > 0 {
+		fmt.Printf("  Immature:    %s\n", formatAmount(balances.Immature))
 	}
+}
 
-	fromID := os.Args[2]
-	toAddress := os.Args[3]
-	amount, err := strconv.ParseFloat(os.Args[4], 64)
-	if err != nil {
-		fmt.Printf("Error: invalid amount: %v\n", err)
+func handleNewAddress(service *service.WalletService) {
+	args, flags := splitArgs(os.Args[2:])
+	if len(args) < 1 {
+		fmt.Println("Error: wallet ID is required")
+		fmt.Println("Usage: go-wallet new-address <wallet-id> [--change]")
 		os.Exit(1)
 	}
 
-	fee, err := strconv.ParseFloat(os.Args[5], 64)
+	walletID := args[0]
+
+	derive := service.NewReceiveAddress
+	if flags["change"] == "true" {
+		derive = service.NewChangeAddress
+	}
+
+	derived, err := derive(walletID)
 	if err != nil {
-		fmt.Printf("Error: invalid fee: %v\n", err)
+		fmt.Printf("Error deriving address: %v\n", err)
 		os.Exit(1)
 	}
 
-	note := ""
-	if len(os.Args) > 6 {
-		note = os.Args[6]
+	wallet, _ := service.GetWallet(walletID)
+
+	fmt.Println("✓ New address derived!")
+	fmt.Printf("Address: %s\n", derived.Address)
+	fmt.Printf("Path:    %s/%d/%d\n", accountLabel(wallet), derived.Chain, derived.Index)
+}
+
+func handleAddresses(service *service.WalletService) {
+	if len(os.Args) < 3 {
+		fmt.Println("Error: wallet ID is required")
+		fmt.Println("Usage: go-wallet addresses <wallet-id>")
+		os.Exit(1)
 	}
 
-	tx, err := service.SendBitcoin(fromID, toAddress, amount, fee, note)
+	wallet, err := service.GetWallet(os.Args[2])
 	if err != nil {
-		fmt.Printf("Error sending Bitcoin: %v\n", err)
+		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	fmt.Println("✓ Transaction sent successfully!")
-	fmt.Println("\n=== Transaction Details ===")
-	fmt.Printf("TX ID:      %s\n", tx.ID)
-	fmt.Printf("From:       %s\n", tx.From)
-	fmt.Printf("To:         %s\n", tx.To)
-	fmt.Printf("Amount:     %.8f BTC\n", tx.Amount)
-	fmt.Printf("Fee:        %.8f BTC\n", tx.Fee)
-	fmt.Printf("Status:     %s\n", tx.Status)
-	fmt.Printf("Time:       %s\n", tx.Timestamp.Format(time.RFC3339))
-	if tx.Note != "" {
-		fmt.Printf("Note:       %s\n", tx.Note)
+	fmt.Printf("\n=== Addresses of %s ===\n", wallet.Name)
+	if !wallet.IsHD() {
+		for _, address := range wallet.AllAddresses() {
+			fmt.Printf("Address: %s\n", address)
+		}
+		return
+	}
+
+	if wallet.IsMultisig() {
+		fmt.Printf("Policy:  %d of %d (%s)\n", wallet.Multisig.Threshold, len(wallet.Multisig.Cosigners), wallet.ScriptType())
+		printCosigners(wallet)
+		fmt.Println()
+	} else {
+		fmt.Printf("Account: %s\n", accountLabel(wallet))
+		fmt.Printf("xpub:    %s\n\n", wallet.AccountXpub)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "Path\tType\tAddress")
+	fmt.Fprintln(w, "----\t----\t----")
+
+	for _, derived := range wallet.Addresses {
+		kind := "receive"
+		if derived.Chain == domain.ChainChange {
+			kind = "change"
+		}
+		fmt.Fprintf(w, "%s/%d/%d\t%s\t%s\n", accountLabel(wallet), derived.Chain, derived.Index, kind, derived.Address)
 	}
+
+	w.Flush()
 }
 
-func handleReceive(service *service.WalletService) {
-	if len(os.Args) < 5 {
+func handleSend(ctx context.Context, service *service.WalletService) {
+	args, flags := splitArgs(os.Args[2:], "priority", "feerate")
+	if len(args) < 3 {
 		fmt.Println("Error: insufficient arguments")
-		fmt.Println("Usage: go-wallet receive <to-id> <from-address> <amount> [note]")
+		fmt.Println("Usage: go-wallet send <from-id> <to-address> <amount> [note] [--priority fast|normal|economy] [--feerate <sat/vB>] [--no-rbf]")
 		os.Exit(1)
 	}
 
-	toID := os.Args[2]
-	fromAddress := os.Args[3]
-	amount, err := strconv.ParseFloat(os.Args[4], 64)
+	fromID := args[0]
+	toAddress := args[1]
+	amount, err := parseAmount(args[2])
 	if err != nil {
 		fmt.Printf("Error: invalid amount: %v\n", err)
 		os.Exit(1)
 	}
 
-	note := ""
-	if len(os.Args) > 5 {
-		note = os.Args[5]
+	opts, err := parseSendOptions(flags)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(args) > 3 {
+		opts.Note = args[3]
+	}
+
+	if opts.FeeRate == 0 {
+		estimate := service.EstimateFeeRate(ctx, opts.Priority)
+		switch {
+		case estimate.Capped:
+			fmt.Printf("⚠️  The %s fee rate is above max-fee-rate, paying %.1f sat/vB instead\n", estimate.Priority, estimate.FeeRate)
+		case estimate.Fallback:
+			fmt.Printf("⚠️  Fee estimates unavailable, using the default %s rate of %.1f sat/vB\n", estimate.Priority, estimate.FeeRate)
+		}
+		opts.FeeRate = estimate.FeeRate
 	}
 
-	tx, err := service.ReceiveBitcoin(toID, fromAddress, amount, note)
+	tx, err := service.SendBitcoin(ctx, fromID, toAddress, amount, opts)
 	if err != nil {
-		fmt.Printf("Error receiving Bitcoin: %v\n", err)
+		fmt.Printf("Error sending Bitcoin: %v\n", err)
+		printBroadcastHint(err)
 		os.Exit(1)
 	}
 
-	fmt.Println("✓ Bitcoin received successfully!")
+	fmt.Println("✓ Transaction sent successfully!")
+	printReportedTxID(tx)
 	fmt.Println("\n=== Transaction Details ===")
 	fmt.Printf("TX ID:      %s\n", tx.ID)
 	fmt.Printf("From:       %s\n", tx.From)
 	fmt.Printf("To:         %s\n", tx.To)
-	fmt.Printf("Amount:     %.8f BTC\n", tx.Amount)
+	fmt.Printf("Amount:     %s\n", formatAmount(tx.Amount))
+	fmt.Printf("Fee:        %s (%.1f sat/vB, %d vB)\n", formatAmount(tx.Fee), tx.FeeRate, tx.VSize)
 	fmt.Printf("Status:     %s\n", tx.Status)
 	fmt.Printf("Time:       %s\n", tx.Timestamp.Format(time.RFC3339))
 	if tx.Note != "" {
@@ -251,200 +567,990 @@ func handleReceive(service *service.WalletService) {
 	}
 }
 
-func handleHistory(service *service.WalletService) {
-	if len(os.Args) < 3 {
-		fmt.Println("Error: wallet ID is required")
-		fmt.Println("Usage: go-wallet history <wallet-id> [limit]")
+func handleBumpFee(ctx context.Context, service *service.WalletService) {
+	args, flags := splitArgs(os.Args[2:], "feerate")
+	if len(args) < 2 || flags["feerate"] == "" {
+		fmt.Println("Error: wallet ID, transaction ID and --feerate are required")
+		fmt.Println("Usage: go-wallet bumpfee <wallet-id> <txid> --feerate <sat/vB>")
 		os.Exit(1)
 	}
 
-	walletID := os.Args[2]
-	limit := 0
-
-	if len(os.Args) > 3 {
-		var err error
-		limit, err = strconv.Atoi(os.Args[3])
-		if err != nil {
-			fmt.Printf("Error: invalid limit: %v\n", err)
-			os.Exit(1)
-		}
-	}
-
-	transactions, err := service.GetTransactionHistory(walletID, limit)
+	opts, err := parseSendOptions(flags)
 	if err != nil {
-		fmt.Printf("Error getting transaction history: %v\n", err)
+		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	if len(transactions) == 0 {
-		fmt.Println("No transactions found.")
-		return
+	result, err := service.BumpFee(ctx, args[0], args[1], opts.FeeRate)
+	if err != nil {
+		fmt.Printf("Error bumping fee: %v\n", err)
+		printBroadcastHint(err)
+		os.Exit(1)
 	}
+	tx := &result.Replacement
 
-	fmt.Printf("\n=== Transaction History (%d transactions) ===\n\n", len(transactions))
-
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
-	fmt.Fprintln(w, "Time\tType\tAmount (BTC)\tFrom/To\tStatus")
-	fmt.Fprintln(w, "----\t----\t----\t----\t----")
-
-	for _, tx := range transactions {
-		address := tx.To
-		if tx.Type == "receive" {
-			address = tx.From
-		}
-
-		fmt.Fprintf(w, "%s\t%s\t%.8f\t%s...\t%s\n",
-			tx.Timestamp.Format("2006-01-02 15:04"),
-			tx.Type,
-			tx.Amount,
-			address[:10],
-			tx.Status,
-		)
+	fmt.Println("✓ Replacement transaction broadcast!")
+	printReportedTxID(tx)
+	fmt.Println("\n=== Transaction Details ===")
+	fmt.Printf("TX ID:      %s\n", tx.ID)
+	fmt.Printf("Replaces:   %s\n", tx.Replaces)
+	fmt.Printf("To:         %s\n", tx.To)
+	fmt.Printf("Amount:     %s\n", formatAmount(tx.Amount))
+	fmt.Printf("Fee:        %s (%.1f sat/vB, %d vB)\n", formatAmount(tx.Fee), tx.FeeRate, tx.VSize)
+	fmt.Printf("Status:     %s\n", tx.Status)
+	if result.DroppedChange > 0 {
+		fmt.Printf("\nThe remaining change of %s was below the dust limit and was added to the fee.\n", formatAmount(result.DroppedChange))
 	}
-
-	w.Flush()
 }
 
-func handleExport(service *service.WalletService) {
-	if len(os.Args) < 3 {
-		fmt.Println("Error: wallet ID is required")
-		fmt.Println("Usage: go-wallet export <wallet-id>")
+func handleCPFP(ctx context.Context, service *service.WalletService) {
+	args, flags := splitArgs(os.Args[2:], "feerate")
+	if len(args) < 2 || flags["feerate"] == "" {
+		fmt.Println("Error: wallet ID, transaction ID and --feerate are required")
+		fmt.Println("Usage: go-wallet cpfp <wallet-id> <txid> --feerate <sat/vB>")
 		os.Exit(1)
 	}
 
-	walletID := os.Args[2]
-
-	privateKey, err := service.ExportPrivateKey(walletID)
+	opts, err := parseSendOptions(flags)
 	if err != nil {
-		fmt.Printf("Error exporting private key: %v\n", err)
-		os.Exit(1)
-	}
-
-	wallet, _ := service.GetWallet(walletID)
-
-	fmt.Println("\n⚠️  WARNING: KEEP THIS PRIVATE KEY SECURE!")
-	fmt.Println("Anyone with this key can access your funds.")
-	fmt.Println("\n=== Private Key Export ===")
-	fmt.Printf("Wallet:      %s (%s)\n", wallet.Name, wallet.ID)
-	fmt.Printf("Address:     %s\n", wallet.Address)
-	fmt.Printf("Private Key: %s\n", privateKey)
-	fmt.Println("\n⚠️  Do NOT share this key with anyone!")
-}
-
-func handleImport(service *service.WalletService) {
-	if len(os.Args) < 4 {
-		fmt.Println("Error: insufficient arguments")
-		fmt.Println("Usage: go-wallet import <name> <private-key>")
+		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	name := os.Args[2]
-	privateKey := os.Args[3]
-
-	wallet, err := service.ImportWallet(name, privateKey)
+	result, err := service.ChildPaysForParent(ctx, args[0], args[1], opts.FeeRate)
 	if err != nil {
-		fmt.Printf("Error importing wallet: %v\n", err)
+		fmt.Printf("Error creating CPFP transaction: %v\n", err)
+		printBroadcastHint(err)
 		os.Exit(1)
 	}
 
-	fmt.Println("✓ Wallet imported successfully!")
-	fmt.Println("\n=== Wallet Details ===")
-	fmt.Printf("ID:         %s\n", wallet.ID)
-	fmt.Printf("Name:       %s\n", wallet.Name)
-	fmt.Printf("Address:    %s\n", wallet.Address)
-	fmt.Printf("Balance:    %.8f BTC\n", wallet.Balance)
+	fmt.Println("✓ Child transaction broadcast!")
+	printReportedTxID(&result.Child)
+	fmt.Println("\n=== Parent ===")
+	fmt.Printf("TX ID:      %s\n", result.Parent.ID)
+	fmt.Printf("Fee:        %s (%.1f sat/vB, %d vB)\n", formatAmount(result.Parent.Fee), result.Parent.FeeRate, result.Parent.VSize)
+	fmt.Println("\n=== Child ===")
+	fmt.Printf("TX ID:      %s\n", result.Child.ID)
+	fmt.Printf("To:         %s\n", result.Child.To)
+	fmt.Printf("Amount:     %s\n", formatAmount(result.Child.Amount))
+	fmt.Printf("Fee:        %s (%.1f sat/vB, %d vB)\n", formatAmount(result.Child.Fee), result.Child.FeeRate, result.Child.VSize)
+	fmt.Printf("\nPackage fee rate: %.1f sat/vB\n", result.PackageRate)
 }
 
-func handleDelete(service *service.WalletService) {
-	if len(os.Args) < 3 {
-		fmt.Println("Error: wallet ID is required")
-		fmt.Println("Usage: go-wallet delete <wallet-id>")
-		os.Exit(1)
-	}
-
-	walletID := os.Args[2]
+func parseSendOptions(flags map[string]string) (service.SendOptions, error) {
+	opts := service.SendOptions{DisableRBF: flags["no-rbf"] == "true"}
 
-	wallet, err := service.GetWallet(walletID)
-	if err != nil {
-		fmt.Printf("Error: %v\n", err)
-		os.Exit(1)
+	// Without --priority the fee policy of the configuration applies
+	if flags["priority"] != "" {
+		priority, err := domain.ParseFeePriority(flags["priority"])
+		if err != nil {
+			return opts, err
+		}
+		opts.Priority = priority
 	}
 
-	err = service.DeleteWallet(walletID)
-	if err != nil {
-		fmt.Printf("Error deleting wallet: %v\n", err)
-		os.Exit(1)
+	if value, ok := flags["feerate"]; ok {
+		if _, set := flags["priority"]; set {
+			return opts, fmt.Errorf("use either --priority or --feerate, not both")
+		}
+		rate, err := strconv.ParseFloat(strings.TrimSuffix(strings.ToLower(value), "sat/vb"), 64)
+		if err != nil || rate <= 0 {
+			return opts, fmt.Errorf("invalid fee rate %q: expected sat/vB, e.g. --feerate 12.5", value)
+		}
+		opts.FeeRate = rate
 	}
 
-	fmt.Printf("✓ Wallet deleted successfully!\n")
-	fmt.Printf("Deleted: %s (%s)\n", wallet.Name, wallet.Address)
-	fmt.Println("\n⚠️  Make sure you have backed up the private key if needed!")
+	return opts, nil
 }
 
-func handleSync(service *service.WalletService) {
-	if len(os.Args) < 3 {
-		fmt.Println("Error: wallet ID is required")
-		fmt.Println("Usage: go-wallet sync <wallet-id>")
+func handleFees(ctx context.Context, service *service.WalletService) {
+	fees, err := service.RecommendedFees(ctx)
+	if err != nil {
+		fmt.Printf("Error fetching fee estimates: %v\n", err)
 		os.Exit(1)
 	}
 
-	walletID := os.Args[2]
-
-	fmt.Println("🔄 Syncing wallet with Bitcoin blockchain (MAINNET)...")
-	fmt.Println("⚠️  WARNING: This is REAL Bitcoin mainnet - not test network")
-	fmt.Println()
+	fmt.Printf("\n=== Fee Estimates (%s) ===\n\n", service.Network().Name)
 
-	// Sync wallet with blockchain
-	wallet, err := service.SyncWallet(walletID)
-	if err != nil {
-		fmt.Printf("❌ Error syncing wallet: %v\n", err)
-		os.Exit(1)
-	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "Target\tPriority\tFee Rate")
+	fmt.Fprintln(w, "------\t--------\t--------")
+	fmt.Fprintf(w, "next block\t%s\t%.1f sat/vB\n", domain.FeePriorityFast, fees.NextBlock)
+	fmt.Fprintf(w, "3 blocks\t\t%.1f sat/vB\n", fees.ThreeBlocks)
+	fmt.Fprintf(w, "6 blocks\t%s\t%.1f sat/vB\n", domain.FeePriorityNormal, fees.SixBlocks)
+	fmt.Fprintf(w, "144 blocks\t%s\t%.1f sat/vB\n", domain.FeePriorityEconomy, fees.OneDay)
+	w.Flush()
 
-	fmt.Println("✅ Wallet synced successfully!")
-	fmt.Printf("\n📊 Wallet Details:\n")
-	fmt.Printf("   Name:     %s\n", wallet.Name)
-	fmt.Printf("   Address:  %s\n", wallet.Address)
-	fmt.Printf("   Balance:  %.8f BTC\n", wallet.Balance)
-	fmt.Printf("   Updated:  %s\n", wallet.UpdatedAt.Format("2006-01-02 15:04:05"))
-	fmt.Println()
-	fmt.Printf("🔍 View on blockchain: https://blockstream.info/address/%s\n", wallet.Address)
+	// A typical 1-input, 2-output transaction of the default address type
+	vsize := txbuilder.EstimateVSize(
+		[]txbuilder.ScriptType{txbuilder.ScriptP2WPKH},
+		[]txbuilder.ScriptType{txbuilder.ScriptP2WPKH, txbuilder.ScriptP2WPKH},
+	)
+	fmt.Printf("\nA typical SegWit payment (%d vB) costs %s at the normal rate\n",
+		vsize, formatAmount(domain.Amount(txbuilder.FeeForVSize(vsize, fees.SixBlocks))))
 }
 
-func handleExportWIF(service *service.WalletService) {
-	if len(os.Args) < 3 {
-		fmt.Println("Error: wallet ID is required")
-		fmt.Println("Usage: go-wallet export-wif <wallet-id> [--testnet]")
-		os.Exit(1)
+// printBroadcastHint explains why the network rejected a transaction
+// printReportedTxID warns when the backend answered a broadcast with another txid than the wallet computed
+func printReportedTxID(tx *domain.Transaction) {
+	if tx.ReportedTxID != "" {
+		fmt.Printf("⚠️  The backend reported txid %s; the wallet recorded the transaction as %s\n", tx.ReportedTxID, tx.ID)
 	}
+}
 
-	walletID := os.Args[2]
-	testnet := false
-
-	if len(os.Args) > 3 && os.Args[3] == "--testnet" {
-		testnet = true
+func printBroadcastHint(err error) {
+	switch {
+	case errors.Is(err, domain.ErrFeeTooLow):
+		fmt.Println("💡 The fee is too low for nodes to relay this transaction. Retry with a higher fee.")
+	case errors.Is(err, domain.ErrFeeTooHigh):
+		fmt.Println("💡 Lower the fee rate or raise the limit with 'go-wallet config set max-fee-rate <sat/vB>'.")
+	case errors.Is(err, domain.ErrInputsMissingOrSpent):
+		fmt.Println("💡 Some coins were already spent or are unknown to the network. Run 'go-wallet sync <wallet-id>' and retry.")
+	case errors.Is(err, domain.ErrMempoolConflict):
+		fmt.Println("💡 Another unconfirmed transaction already spends these coins. Wait for it to confirm before sending again.")
+	case errors.Is(err, domain.ErrBroadcastRejected):
+		fmt.Println("💡 The network rejected the transaction; see the reason above.")
+	case errors.Is(err, domain.ErrMultisig):
+		fmt.Println("💡 Create a PSBT with 'go-wallet psbt create', have enough cosigners sign it, then combine and finalize it.")
+	case errors.Is(err, domain.ErrWatchOnly):
+		fmt.Println("💡 Watch-only wallets cannot sign. Use 'go-wallet psbt create' and sign the PSBT where the keys are kept.")
 	}
+}
 
-	wallet, err := service.GetWallet(walletID)
-	if err != nil {
-		fmt.Printf("Error: %v\n", err)
+func handlePSBT(ctx context.Context, service *service.WalletService) {
+	if len(os.Args) < 3 {
+		fmt.Println("Error: PSBT subcommand required")
+		fmt.Println("Usage: go-wallet psbt <create|sign|combine|finalize|decode> [arguments]")
 		os.Exit(1)
 	}
 
-	// Convert to WIF format for Phantom import
-	fmt.Println("\n⚠️  WARNING: KEEP THIS PRIVATE KEY SECURE!")
-	fmt.Println("This is your WIF (Wallet Import Format) key")
-	fmt.Println("Use this to import into Phantom or other Bitcoin wallets")
+	args, flags := splitArgs(os.Args[3:], "priority", "feerate", "out")
 
-	network := "mainnet"
-	if testnet {
-		network = "testnet"
-	}
+	switch os.Args[2] {
+	case "create":
+		if len(args) < 3 {
+			fmt.Println("Error: insufficient arguments")
+			fmt.Println("Usage: go-wallet psbt create <wallet-id> <to-address> <amount> [--priority fast|normal|economy] [--feerate <sat/vB>] [--no-rbf] [--out <file>]")
+			os.Exit(1)
+		}
 
-	fmt.Printf("\n=== WIF Private Key Export (%s) ===\n", network)
-	fmt.Printf("Wallet:      %s (%s)\n", wallet.Name, wallet.ID)
-	fmt.Printf("Address:     %s\n", wallet.Address)
-	fmt.Printf("Private Key (hex): %s\n", wallet.PrivateKey)
+		amount, err := parseAmount(args[2])
+		if err != nil {
+			fmt.Printf("Error: invalid amount: %v\n", err)
+			os.Exit(1)
+		}
+		opts, err := parseSendOptions(flags)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		packet, err := service.CreatePSBT(ctx, args[0], args[1], amount, opts)
+		if err != nil {
+			fmt.Printf("Error creating PSBT: %v\n", err)
+			os.Exit(1)
+		}
+
+		fee, _ := packet.Fee()
+		fmt.Printf("✓ PSBT created: %d input(s), %d output(s), fee %s\n",
+			len(packet.Inputs), len(packet.Outputs), formatAmount(domain.Amount(fee)))
+		writePSBT(packet, flags["out"])
+
+	case "sign":
+		if len(args) < 2 {
+			fmt.Println("Error: wallet ID and PSBT are required")
+			fmt.Println("Usage: go-wallet psbt sign <wallet-id> <psbt> [--out <file>]")
+			os.Exit(1)
+		}
+
+		packet := readPSBT(args[1])
+
+		unlockIfNeeded(service)
+		defer service.Lock()
+
+		signed, err := service.SignPSBT(args[0], packet)
+		if err != nil {
+			fmt.Printf("Error signing PSBT: %v\n", err)
+			printBroadcastHint(err)
+			os.Exit(1)
+		}
+		if signed == 0 {
+			fmt.Println("Error: no input of this PSBT belongs to the wallet")
+			os.Exit(1)
+		}
+
+		fmt.Printf("✓ Signed %d of %d input(s)\n", signed, len(packet.Inputs))
+		writePSBT(packet, flags["out"])
+
+	case "combine":
+		if len(args) < 2 {
+			fmt.Println("Error: at least two PSBTs are required")
+			fmt.Println("Usage: go-wallet psbt combine <psbt> <psbt>... [--out <file>]")
+			os.Exit(1)
+		}
+
+		packets := make([]*psbt.Packet, 0, len(args))
+		for _, arg := range args {
+			packets = append(packets, readPSBT(arg))
+		}
+
+		combined, err := psbt.Combine(packets...)
+		if err != nil {
+			fmt.Printf("Error combining PSBTs: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("✓ Combined %d PSBTs\n", len(packets))
+		writePSBT(combined, flags["out"])
+
+	case "finalize":
+		if len(args) < 1 {
+			fmt.Println("Error: PSBT is required")
+			fmt.Println("Usage: go-wallet psbt finalize <psbt> [--out <file>] [--broadcast]")
+			os.Exit(1)
+		}
+
+		packet := readPSBT(args[0])
+
+		if flags["broadcast"] == "true" {
+			tx, err := service.BroadcastPSBT(ctx, packet)
+			if err != nil {
+				fmt.Printf("Error broadcasting PSBT: %v\n", err)
+				printBroadcastHint(err)
+				os.Exit(1)
+			}
+			fmt.Println("✓ Transaction broadcast!")
+			fmt.Printf("TX ID:      %s\n", tx.ID)
+			printReportedTxID(tx)
+			return
+		}
+
+		if err := packet.Finalize(); err != nil {
+			fmt.Printf("Error finalizing PSBT: %v\n", err)
+			os.Exit(1)
+		}
+		tx, err := packet.Extract()
+		if err != nil {
+			fmt.Printf("Error extracting transaction: %v\n", err)
+			os.Exit(1)
+		}
+
+		if out := flags["out"]; out != "" {
+			if err := os.WriteFile(out, []byte(tx.Hex()+"\n"), 0644); err != nil {
+				fmt.Printf("Error writing %s: %v\n", out, err)
+				os.Exit(1)
+			}
+			fmt.Printf("✓ Signed transaction %s written to %s\n", tx.TxID(), out)
+			return
+		}
+
+		fmt.Printf("TX ID: %s\n\n%s\n", tx.TxID(), tx.Hex())
+
+	case "decode":
+		if len(args) < 1 {
+			fmt.Println("Error: PSBT is required")
+			fmt.Println("Usage: go-wallet psbt decode <psbt>")
+			os.Exit(1)
+		}
+
+		printPSBT(readPSBT(args[0]), service)
+
+	default:
+		fmt.Printf("Unknown PSBT subcommand: %s\n", os.Args[2])
+		fmt.Println("Usage: go-wallet psbt <create|sign|combine|finalize|decode> [arguments]")
+		os.Exit(1)
+	}
+}
+
+// readPSBT loads a PSBT from a file (binary, base64 or hex) or from a base64 argument
+func readPSBT(arg string) *psbt.Packet {
+	data, err := os.ReadFile(arg)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			fmt.Printf("Error reading %s: %v\n", arg, err)
+			os.Exit(1)
+		}
+		data = []byte(arg)
+	}
+
+	packet, err := psbt.Decode(data)
+	if err != nil {
+		fmt.Printf("Error: %s is not a valid PSBT file or string: %v\n", arg, err)
+		os.Exit(1)
+	}
+	return packet
+}
+
+// writePSBT saves a PSBT to out, binary for *.psbt files (BIP174) and base64
+// otherwise, or prints it as base64 when out is empty
+func writePSBT(packet *psbt.Packet, out string) {
+	var data []byte
+	var err error
+	if strings.HasSuffix(strings.ToLower(out), ".psbt") {
+		data, err = packet.Serialize()
+	} else {
+		var encoded string
+		encoded, err = packet.B64Encode()
+		data = []byte(encoded + "\n")
+	}
+	if err != nil {
+		fmt.Printf("Error encoding PSBT: %v\n", err)
+		os.Exit(1)
+	}
+
+	if out == "" {
+		fmt.Printf("\n%s", data)
+		return
+	}
+	if err := os.WriteFile(out, data, 0644); err != nil {
+		fmt.Printf("Error writing %s: %v\n", out, err)
+		os.Exit(1)
+	}
+	fmt.Printf("PSBT written to %s\n", out)
+}
+
+func printPSBT(packet *psbt.Packet, service *service.WalletService) {
+	tx := packet.UnsignedTx
+	params := service.Network()
+
+	fmt.Println("\n=== PSBT ===")
+	fmt.Printf("TX ID:      %s\n", tx.TxID())
+	fmt.Printf("Version:    %d\n", tx.Version)
+	fmt.Printf("Locktime:   %d\n", tx.LockTime)
+	fmt.Printf("RBF:        %v\n", tx.SignalsRBF())
+	if fee, err := packet.Fee(); err == nil {
+		fmt.Printf("Fee:        %s\n", formatAmount(domain.Amount(fee)))
+	}
+	for _, xpub := range packet.XPubs {
+		fmt.Printf("XPub:       [%x%s]\n", xpub.Fingerprint, strings.TrimPrefix(crypto.FormatDerivationPath(xpub.Path), "m"))
+	}
+
+	fmt.Println("\n=== Inputs ===")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tOutpoint\tAmount\tAddress\tStatus")
+	for i, in := range tx.Inputs {
+		amount, address := "unknown", ""
+		if utxo, err := packet.InputUTXO(i); err == nil {
+			amount = formatAmount(domain.Amount(utxo.Value))
+			address, _ = txbuilder.ScriptAddress(utxo.PkScript, params)
+		}
+
+		pin := &packet.Inputs[i]
+		status := "unsigned"
+		switch {
+		case pin.IsFinalized():
+			status = "finalized"
+		case pin.TaprootKeySig != nil:
+			status = "signed"
+		case len(pin.PartialSigs) > 0:
+			status = fmt.Sprintf("%d signature(s)", len(pin.PartialSigs))
+			if threshold, _, err := txbuilder.ParseMultisigScript(pin.WitnessScript); err == nil {
+				status = fmt.Sprintf("%d of %d signatures", len(pin.PartialSigs), threshold)
+			}
+		}
+		fmt.Fprintf(w, "%d\t%s:%d\t%s\t%s\t%s\n", i, txbuilder.FormatTxID(in.PrevTxID), in.PrevIndex, amount, address, status)
+	}
+	w.Flush()
+
+	fmt.Println("\n=== Outputs ===")
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tAmount\tAddress\tKey Origin")
+	for i, out := range tx.Outputs {
+		address, err := txbuilder.ScriptAddress(out.PkScript, params)
+		if err != nil {
+			address = string(txbuilder.ClassifyScript(out.PkScript))
+		}
+
+		origin := ""
+		if derivations := packet.Outputs[i].Bip32Derivation; len(derivations) > 0 {
+			origin = fmt.Sprintf("[%x%s]", derivations[0].Fingerprint, strings.TrimPrefix(crypto.FormatDerivationPath(derivations[0].Path), "m"))
+		} else if derivations := packet.Outputs[i].TaprootBip32Derivation; len(derivations) > 0 {
+			origin = fmt.Sprintf("[%x%s]", derivations[0].Fingerprint, strings.TrimPrefix(crypto.FormatDerivationPath(derivations[0].Path), "m"))
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", i, formatAmount(domain.Amount(out.Value)), address, origin)
+	}
+	w.Flush()
+
+	if packet.IsComplete() {
+		fmt.Println("\nAll inputs are finalized; run 'go-wallet psbt finalize' to extract the transaction.")
+	}
+}
+
+func handleMultisig(service *service.WalletService) {
+	if len(os.Args) < 3 {
+		fmt.Println("Error: multisig subcommand required")
+		fmt.Println("Usage: go-wallet multisig <key|create|import|export> [arguments]")
+		os.Exit(1)
+	}
+
+	args, flags := splitArgs(os.Args[3:], "type", "passphrase", "threshold", "name", "out")
+
+	opts, err := parseMultisigOptions(flags)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// --signer keeps this machine's key so the wallet can sign its share of each PSBT
+	if flags["signer"] == "true" {
+		unlockIfNeeded(service)
+		defer service.Lock()
+		opts.Mnemonic = promptMnemonic()
+	}
+
+	switch os.Args[2] {
+	case "key":
+		key, err := service.MultisigKey(promptMnemonic(), opts.Passphrase, opts.AddressType)
+		if err != nil {
+			fmt.Printf("Error deriving cosigner key: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("\n=== Cosigner Key ===")
+		fmt.Println(key)
+		fmt.Println("\nShare this key with the other cosigners; it reveals balances but cannot spend.")
+
+	case "create":
+		if len(args) < 2 || opts.Threshold == 0 {
+			fmt.Println("Error: insufficient arguments")
+			fmt.Println("Usage: go-wallet multisig create <name> --threshold <m> <[fingerprint/path]xpub>... [--type p2wsh|p2sh-p2wsh] [--signer [--passphrase <p>]]")
+			os.Exit(1)
+		}
+		opts.Cosigners = args[1:]
+
+		wallet, err := service.CreateMultisig(args[0], opts)
+		if err != nil {
+			fmt.Printf("Error creating multisig wallet: %v\n", err)
+			os.Exit(1)
+		}
+		printMultisigWallet(wallet)
+
+	case "import":
+		if len(args) < 1 {
+			fmt.Println("Error: setup file is required")
+			fmt.Println("Usage: go-wallet multisig import <file> [--name <name>] [--signer [--passphrase <p>]]")
+			os.Exit(1)
+		}
+		config, err := os.ReadFile(args[0])
+		if err != nil {
+			fmt.Printf("Error reading %s: %v\n", args[0], err)
+			os.Exit(1)
+		}
+
+		wallet, err := service.ImportMultisigConfig(flags["name"], string(config), opts.Mnemonic, opts.Passphrase)
+		if err != nil {
+			fmt.Printf("Error importing multisig wallet: %v\n", err)
+			os.Exit(1)
+		}
+		printMultisigWallet(wallet)
+
+	case "export":
+		if len(args) < 1 {
+			fmt.Println("Error: wallet ID is required")
+			fmt.Println("Usage: go-wallet multisig export <wallet-id> [--out <file>]")
+			os.Exit(1)
+		}
+
+		config, err := service.ExportMultisigConfig(args[0])
+		if err != nil {
+			fmt.Printf("Error exporting multisig wallet: %v\n", err)
+			os.Exit(1)
+		}
+		if flags["out"] == "" {
+			fmt.Print(config)
+			return
+		}
+		if err := os.WriteFile(flags["out"], []byte(config), 0644); err != nil {
+			fmt.Printf("Error writing %s: %v\n", flags["out"], err)
+			os.Exit(1)
+		}
+		fmt.Printf("Setup file written to %s\n", flags["out"])
+
+	default:
+		fmt.Printf("Unknown multisig subcommand: %s\n", os.Args[2])
+		fmt.Println("Usage: go-wallet multisig <key|create|import|export> [arguments]")
+		os.Exit(1)
+	}
+}
+
+// parseMultisigOptions reads --threshold, --type and --passphrase
+func parseMultisigOptions(flags map[string]string) (service.MultisigOptions, error) {
+	var opts service.MultisigOptions
+
+	if flags["threshold"] != "" {
+		threshold, err := strconv.Atoi(flags["threshold"])
+		if err != nil || threshold < 1 {
+			return opts, fmt.Errorf("invalid threshold %q", flags["threshold"])
+		}
+		opts.Threshold = threshold
+	}
+
+	if flags["type"] != "" {
+		addressType, err := domain.ParseMultisigAddressType(flags["type"])
+		if err != nil {
+			return opts, err
+		}
+		opts.AddressType = addressType
+	}
+
+	opts.Passphrase = flags["passphrase"]
+	return opts, nil
+}
+
+func printMultisigWallet(wallet *domain.Wallet) {
+	fmt.Println("✓ Multisig wallet created successfully!")
+	fmt.Println("\n=== Wallet Details ===")
+	fmt.Printf("ID:         %s\n", wallet.ID)
+	fmt.Printf("Name:       %s\n", wallet.Name)
+	fmt.Printf("Network:    %s\n", wallet.NetworkName())
+	fmt.Printf("Policy:     %d of %d (%s)\n", wallet.Multisig.Threshold, len(wallet.Multisig.Cosigners), wallet.ScriptType())
+	fmt.Printf("Address:    %s\n", wallet.Address)
+	printCosigners(wallet)
+
+	if wallet.WatchOnly {
+		fmt.Println("\n👀 This coordinator holds no cosigner key; it creates PSBTs for the cosigners to sign.")
+	}
+	fmt.Println("\nRun 'go-wallet multisig export <wallet-id>' to share the setup with the other cosigners.")
+}
+
+// printCosigners lists the cosigner keys of a multisig wallet, marking the local signer
+func printCosigners(wallet *domain.Wallet) {
+	fmt.Println("\nCosigners:")
+	for i, cosigner := range wallet.Multisig.Cosigners {
+		marker := ""
+		if cosigner.Xpub == wallet.AccountXpub {
+			marker = "  (this wallet)"
+		}
+		fmt.Printf("  %d. %s%s\n", i+1, service.FormatCosigner(cosigner), marker)
+	}
+}
+
+func handleHistory(service *service.WalletService) {
+	if len(os.Args) < 3 {
+		fmt.Println("Error: wallet ID is required")
+		fmt.Println("Usage: go-wallet history <wallet-id> [limit]")
+		os.Exit(1)
+	}
+
+	walletID := os.Args[2]
+	limit := 0
+
+	if len(os.Args) > 3 {
+		var err error
+		limit, err = strconv.Atoi(os.Args[3])
+		if err != nil {
+			fmt.Printf("Error: invalid limit: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	transactions, err := service.GetTransactionHistory(walletID, limit)
+	if err != nil {
+		fmt.Printf("Error getting transaction history: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(transactions) == 0 {
+		fmt.Println("No transactions found.")
+		return
+	}
+
+	fmt.Printf("\n=== Transaction History (%d transactions) ===\n\n", len(transactions))
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintf(w, "Time\tType\tAmount (%s)\tFee\tNet\tFrom/To\tStatus\n", displayUnit)
+	fmt.Fprintln(w, "----\t----\t----\t----\t----\t----\t----")
+
+	for _, tx := range transactions {
+		address := tx.To
+		if tx.Type == "receive" {
+			address = tx.From
+		}
+
+		status := tx.Status
+		switch {
+		case tx.ReplacedBy != "" && tx.Status == "replaced":
+			status = fmt.Sprintf("replaced by %s", abbreviate(tx.ReplacedBy, 10))
+		case tx.ConflictedBy != "":
+			status = fmt.Sprintf("failed, double-spent by %s", abbreviate(tx.ConflictedBy, 10))
+		case tx.Confirmations > 0:
+			status = fmt.Sprintf("%s (%d conf, block %d)", status, tx.Confirmations, tx.BlockHeight)
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			tx.Timestamp.Format("2006-01-02 15:04"),
+			tx.Type,
+			tx.Amount.Format(displayUnit),
+			tx.Fee.Format(displayUnit),
+			tx.NetAmount().Format(displayUnit),
+			abbreviate(address, 10),
+			status,
+		)
+	}
+
+	w.Flush()
+}
+
+func handleExport(service *service.WalletService) {
+	if len(os.Args) < 3 {
+		fmt.Println("Error: wallet ID is required")
+		fmt.Println("Usage: go-wallet export <wallet-id>")
+		os.Exit(1)
+	}
+
+	walletID := os.Args[2]
+
+	privateKey, err := service.ExportPrivateKey(walletID)
+	if err != nil {
+		fmt.Printf("Error exporting private key: %v\n", err)
+		os.Exit(1)
+	}
+
+	wallet, _ := service.GetWallet(walletID)
+
+	fmt.Println("\n⚠️  WARNING: KEEP THIS PRIVATE KEY SECURE!")
+	fmt.Println("Anyone with this key can access your funds.")
+	fmt.Println("\n=== Private Key Export ===")
+	fmt.Printf("Wallet:      %s (%s)\n", wallet.Name, wallet.ID)
+	fmt.Printf("Address:     %s\n", wallet.Address)
+	fmt.Printf("Private Key: %s\n", privateKey)
+	fmt.Println("\n⚠️  Do NOT share this key with anyone!")
+}
+
+func handleImport(service *service.WalletService) {
+	args, flags := splitArgs(os.Args[2:], "type")
+	if len(args) < 2 {
+		fmt.Println("Error: insufficient arguments")
+		fmt.Println("Usage: go-wallet import <name> <private-key> [--type <address-type>]")
+		os.Exit(1)
+	}
+
+	name := args[0]
+	privateKey := args[1]
+
+	addressType, err := domain.ParseAddressType(flags["type"])
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	wallet, err := service.ImportWallet(name, privateKey, addressType)
+	if err != nil {
+		fmt.Printf("Error importing wallet: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println("✓ Wallet imported successfully!")
+	fmt.Println("\n=== Wallet Details ===")
+	fmt.Printf("ID:         %s\n", wallet.ID)
+	fmt.Printf("Name:       %s\n", wallet.Name)
+	fmt.Printf("Network:    %s\n", wallet.NetworkName())
+	fmt.Printf("Address:    %s\n", wallet.Address)
+	fmt.Printf("Type:       %s\n", wallet.ScriptType())
+	fmt.Printf("Balance:    %s\n", formatAmount(wallet.Balance))
+	printEncryptionHint(service)
+}
+
+func handleImportWatch(service *service.WalletService) {
+	args, flags := splitArgs(os.Args[2:], "type")
+	if len(args) < 2 {
+		fmt.Println("Error: insufficient arguments")
+		fmt.Println("Usage: go-wallet import-watch <name> <address>... | <xpub|ypub|zpub> [--type <address-type>]")
+		os.Exit(1)
+	}
+
+	// Without --type an xpub defaults to p2wpkh while a ypub or zpub implies its own type
+	var addressType domain.AddressType
+	if flags["type"] != "" {
+		var err error
+		if addressType, err = domain.ParseAddressType(flags["type"]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	wallet, err := service.ImportWatchOnly(args[0], args[1:], addressType)
+	if err != nil {
+		fmt.Printf("Error importing wallet: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println("✓ Watch-only wallet imported successfully!")
+	fmt.Println("\n=== Wallet Details ===")
+	fmt.Printf("ID:         %s\n", wallet.ID)
+	fmt.Printf("Name:       %s\n", wallet.Name)
+	fmt.Printf("Network:    %s\n", wallet.NetworkName())
+	fmt.Printf("Address:    %s\n", wallet.Address)
+	fmt.Printf("Type:       %s\n", wallet.ScriptType())
+	if len(wallet.ImportedAddresses) > 0 {
+		fmt.Printf("Tracking:   %d addresses\n", len(wallet.AllAddresses()))
+	}
+	fmt.Println("\n👀 This wallet holds no private keys. Run 'go-wallet sync <wallet-id>' to load its balance;")
+	fmt.Println("   payments are created with 'go-wallet psbt create' and signed elsewhere.")
+}
+
+func handleDescriptor(service *service.WalletService) {
+	if len(os.Args) < 3 || os.Args[2] != "export" {
+		fmt.Println("Error: descriptor subcommand required")
+		fmt.Println("Usage: go-wallet descriptor export <wallet-id> [--private] [--out <file>]")
+		os.Exit(1)
+	}
+
+	args, flags := splitArgs(os.Args[3:], "out")
+	if len(args) < 1 {
+		fmt.Println("Error: wallet ID is required")
+		fmt.Println("Usage: go-wallet descriptor export <wallet-id> [--private] [--out <file>]")
+		os.Exit(1)
+	}
+
+	// --private puts the wallet's private keys into the descriptors
+	includePrivate := flags["private"] == "true"
+	if includePrivate {
+		unlockIfNeeded(service)
+		defer service.Lock()
+	}
+
+	descs, err := service.ExportDescriptors(args[0], includePrivate)
+	if err != nil {
+		fmt.Printf("Error exporting descriptors: %v\n", err)
+		printBroadcastHint(err)
+		os.Exit(1)
+	}
+	output := strings.Join(descs, "\n") + "\n"
+
+	if flags["out"] != "" {
+		if err := os.WriteFile(flags["out"], []byte(output), 0600); err != nil {
+			fmt.Printf("Error writing %s: %v\n", flags["out"], err)
+			os.Exit(1)
+		}
+		fmt.Printf("Descriptors written to %s\n", flags["out"])
+		return
+	}
+
+	if includePrivate {
+		fmt.Println("⚠️  WARNING: these descriptors contain private keys. Anyone with them can spend your funds.")
+		fmt.Println()
+	}
+	fmt.Print(output)
+}
+
+func handleImportDescriptor(service *service.WalletService) {
+	args, _ := splitArgs(os.Args[2:])
+	if len(args) < 2 {
+		fmt.Println("Error: insufficient arguments")
+		fmt.Println("Usage: go-wallet import-descriptor <name> <descriptor> [change-descriptor]")
+		os.Exit(1)
+	}
+
+	wallet, err := service.ImportDescriptors(args[0], args[1:])
+	if err != nil {
+		fmt.Printf("Error importing wallet: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println("✓ Wallet imported successfully!")
+	fmt.Println("\n=== Wallet Details ===")
+	fmt.Printf("ID:         %s\n", wallet.ID)
+	fmt.Printf("Name:       %s\n", walletLabel(wallet))
+	fmt.Printf("Network:    %s\n", wallet.NetworkName())
+	fmt.Printf("Address:    %s\n", wallet.Address)
+	fmt.Printf("Type:       %s\n", wallet.ScriptType())
+	if wallet.IsMultisig() {
+		fmt.Printf("Policy:     %d of %d\n", wallet.Multisig.Threshold, len(wallet.Multisig.Cosigners))
+	}
+	if len(wallet.ImportedAddresses) > 0 {
+		fmt.Printf("Tracking:   %d addresses\n", len(wallet.AllAddresses()))
+	}
+	if wallet.WatchOnly {
+		fmt.Println("\n👀 This wallet holds no private keys. Payments are created with 'go-wallet psbt create' and signed elsewhere.")
+	} else {
+		printEncryptionHint(service)
+	}
+}
+
+func handleDelete(service *service.WalletService) {
+	if len(os.Args) < 3 {
+		fmt.Println("Error: wallet ID is required")
+		fmt.Println("Usage: go-wallet delete <wallet-id>")
+		os.Exit(1)
+	}
+
+	walletID := os.Args[2]
+
+	wallet, err := service.GetWallet(walletID)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	err = service.DeleteWallet(walletID)
+	if err != nil {
+		fmt.Printf("Error deleting wallet: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✓ Wallet deleted successfully!\n")
+	fmt.Printf("Deleted: %s (%s)\n", wallet.Name, wallet.Address)
+	fmt.Println("\n⚠️  Make sure you have backed up the private key if needed!")
+}
+
+func handleUTXOs(service *service.WalletService) {
+	if len(os.Args) < 3 {
+		fmt.Println("Error: wallet ID is required")
+		fmt.Println("Usage: go-wallet utxos <wallet-id>")
+		os.Exit(1)
+	}
+
+	wallet, err := service.GetWallet(os.Args[2])
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(wallet.UTXOs) == 0 {
+		fmt.Println("No unspent outputs. Run 'go-wallet sync <wallet-id>' to refresh them from the blockchain.")
+		return
+	}
+
+	fmt.Printf("\n=== Unspent outputs of %s ===\n\n", wallet.Name)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "Outpoint\tValue\tHeight\tType\tAddress")
+	fmt.Fprintln(w, "--------\t-----\t------\t----\t-------")
+
+	for _, utxo := range wallet.UTXOs {
+		height := "unconfirmed"
+		if utxo.IsConfirmed() {
+			height = fmt.Sprintf("%d", utxo.Height)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", utxo.Outpoint(), formatAmount(utxo.Value), height, utxo.ScriptType, utxo.Address)
+	}
+
+	w.Flush()
+	fmt.Printf("\nTotal: %s in %d outputs\n", formatAmount(wallet.UTXOBalance()), len(wallet.UTXOs))
+}
+
+func handleSync(ctx context.Context, service *service.WalletService) {
+	args, flags := splitArgs(os.Args[2:], "confirmations")
+	if len(args) < 1 {
+		fmt.Println("Error: wallet ID is required")
+		fmt.Println("Usage: go-wallet sync <wallet-id> [--confirmations <n>]")
+		os.Exit(1)
+	}
+
+	if flags["confirmations"] != "" {
+		confirmations, err := strconv.Atoi(flags["confirmations"])
+		if err != nil || confirmations < 1 {
+			fmt.Printf("Error: invalid confirmation count %q\n", flags["confirmations"])
+			os.Exit(1)
+		}
+		service.SetFinalConfirmations(confirmations)
+	}
+
+	walletID := args[0]
+
+	params := service.Network()
+	fmt.Printf("🔄 Syncing wallet with Bitcoin blockchain (%s)...\n", strings.ToUpper(params.Name))
+	if params.IsMainNet() {
+		fmt.Println("⚠️  WARNING: This is REAL Bitcoin mainnet - not test network")
+	}
+	fmt.Println()
+
+	// Sync wallet with blockchain
+	wallet, err := service.SyncWallet(ctx, walletID)
+	if err != nil {
+		fmt.Printf("❌ Error syncing wallet: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println("✅ Wallet synced successfully!")
+	fmt.Printf("\n📊 Wallet Details:\n")
+	fmt.Printf("   Name:     %s\n", wallet.Name)
+	fmt.Printf("   Address:  %s\n", wallet.Address)
+	fmt.Printf("   Balance:  %s\n", formatAmount(wallet.Balance))
+	balances := wallet.Balances()
+	fmt.Printf("     Confirmed:   %s\n", formatAmount(balances.Confirmed))
+	fmt.Printf("     Unconfirmed: %s\n", formatAmount(balances.Unconfirmed))
+	if balances.Immature > 0 {
+		fmt.Printf("     Immature:    %s\n", formatAmount(balances.Immature))
+	}
+	fmt.Printf("   UTXOs:    %d\n", len(wallet.UTXOs))
+	fmt.Printf("   Height:   %d\n", wallet.SyncHeight)
+	fmt.Printf("   Updated:  %s\n", wallet.UpdatedAt.Format("2006-01-02 15:04:05"))
+	fmt.Println()
+	fmt.Printf("🔍 View on blockchain: %s\n", params.AddressURL(wallet.Address))
+}
+
+func handleRescan(ctx context.Context, service *service.WalletService) {
+	// --gap-limit is a global setting, applied before the command runs
+	args, _ := splitArgs(os.Args[2:])
+	if len(args) < 1 {
+		fmt.Println("Error: wallet ID is required")
+		fmt.Println("Usage: go-wallet rescan <wallet-id> [--gap-limit <n>]")
+		os.Exit(1)
+	}
+
+	params := service.Network()
+	fmt.Printf("🔄 Rescanning wallet on %s (gap limit %d)...\n\n", strings.ToUpper(params.Name), service.GapLimit())
+
+	wallet, err := service.RescanWallet(ctx, args[0])
+	if err != nil {
+		fmt.Printf("❌ Error rescanning wallet: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println("✅ Rescan complete!")
+	if wallet.IsHD() {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(w, "\nPath\tAddress\tTxs\tBalance")
+		fmt.Fprintln(w, "----\t----\t----\t----")
+		for _, derived := range wallet.Addresses {
+			if derived.TxCount == 0 && derived.Balance == 0 {
+				continue
+			}
+			fmt.Fprintf(w, "%s/%d/%d\t%s\t%d\t%s\n", accountLabel(wallet), derived.Chain, derived.Index, derived.Address, derived.TxCount, formatAmount(derived.Balance))
+		}
+		w.Flush()
+	}
+
+	fmt.Printf("\n📊 Wallet Details:\n")
+	fmt.Printf("   Name:     %s\n", walletLabel(wallet))
+	fmt.Printf("   Balance:  %s\n", formatAmount(wallet.Balance))
+	fmt.Printf("   UTXOs:    %d\n", len(wallet.UTXOs))
+	if wallet.IsHD() {
+		fmt.Printf("   Next:     receive %d, change %d\n", wallet.ReceiveIndex, wallet.ChangeIndex)
+	}
+}
+
+func handleExportWIF(service *service.WalletService) {
+	if len(os.Args) < 3 {
+		fmt.Println("Error: wallet ID is required")
+		fmt.Println("Usage: go-wallet export-wif <wallet-id>")
+		os.Exit(1)
+	}
+
+	walletID := os.Args[2]
+
+	wallet, err := service.GetWallet(walletID)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	wif, err := service.ExportWIF(walletID)
+	if err != nil {
+		fmt.Printf("Error exporting private key: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Convert to WIF format for Phantom import
+	fmt.Println("\n⚠️  WARNING: KEEP THIS PRIVATE KEY SECURE!")
+	fmt.Println("This is your WIF (Wallet Import Format) key")
+	fmt.Println("Use this to import into Phantom or other Bitcoin wallets")
+
+	fmt.Printf("\n=== WIF Private Key Export (%s) ===\n", wallet.NetworkName())
+	fmt.Printf("Wallet:      %s (%s)\n", wallet.Name, wallet.ID)
+	fmt.Printf("Address:     %s\n", wallet.Address)
+	fmt.Printf("Private Key (WIF): %s\n", wif)
 	fmt.Println("\n📋 To import to Phantom:")
 	fmt.Println("1. Open Phantom")
 	fmt.Println("2. Settings → Add/Connect Wallet")
@@ -453,3 +1559,384 @@ func handleExportWIF(service *service.WalletService) {
 	fmt.Println("5. Paste the private key above")
 	fmt.Println("\n⚠️  Do NOT share this key with anyone!")
 }
+
+func handlePassphrase(service *service.WalletService) {
+	oldPassphrase := ""
+	if service.IsEncrypted() {
+		oldPassphrase = readPassphrase("Current passphrase: ")
+	} else {
+		fmt.Println("Private keys are currently stored in plaintext.")
+		fmt.Println("Setting a passphrase encrypts them (scrypt + XChaCha20-Poly1305).")
+	}
+
+	newPassphrase := readPassphrase("New passphrase: ")
+	if newPassphrase == "" {
+		fmt.Println("Error: passphrase cannot be empty")
+		os.Exit(1)
+	}
+	if readPassphrase("Repeat new passphrase: ") != newPassphrase {
+		fmt.Println("Error: passphrases do not match")
+		os.Exit(1)
+	}
+
+	if err := service.SetPassphrase(oldPassphrase, newPassphrase); err != nil {
+		fmt.Printf("Error setting passphrase: %v\n", err)
+		os.Exit(1)
+	}
+	service.Lock()
+
+	fmt.Println("✓ Private keys are encrypted!")
+	fmt.Println("\n⚠️  There is no way to recover a forgotten passphrase.")
+	fmt.Println("Keep your recovery phrases backed up separately.")
+}
+
+func handleConfig(path, profile string, overrides []settingFlag) {
+	if len(os.Args) < 3 {
+		fmt.Println("Error: config subcommand required")
+		fmt.Println("Usage: go-wallet config <list|get|set> [arguments]")
+		os.Exit(1)
+	}
+
+	args, _ := splitArgs(os.Args[3:])
+
+	switch os.Args[2] {
+	case "list":
+		file, err := config.LoadFile(path)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Config file: %s\n", path)
+		if file.Profile != "" {
+			fmt.Printf("Default profile: %s\n", file.Profile)
+		}
+		if len(file.Settings) == 0 && len(file.Profiles) == 0 {
+			fmt.Println("\nNo settings saved yet. Use 'go-wallet config get' to see every key and its value.")
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		printConfigValues(w, "Shared settings", file.Settings)
+		for _, name := range file.ProfileNames() {
+			title := "Profile " + name
+			if name == file.Profile {
+				title += " (default)"
+			}
+			printConfigValues(w, title, file.Profiles[name])
+		}
+		w.Flush()
+
+	case "get":
+		cfg, err := loadConfig(path, profile, overrides)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if len(args) > 0 {
+			if args[0] == "profile" {
+				fmt.Println(cfg.Profile)
+				return
+			}
+			value, err := cfg.Get(args[0])
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Println(value)
+			return
+		}
+
+		if cfg.Profile != "" {
+			fmt.Printf("Profile: %s\n\n", cfg.Profile)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(w, "Key\tValue\tDescription")
+		fmt.Fprintln(w, "---\t-----\t-----------")
+		for _, setting := range config.Settings() {
+			value, _ := cfg.Get(setting.Key)
+			if setting.Key == "rpc-password" && value != "" {
+				value = "********"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", setting.Key, value, setting.Usage)
+		}
+		w.Flush()
+
+	case "set":
+		if len(args) < 2 {
+			fmt.Println("Error: key and value are required")
+			fmt.Println("Usage: go-wallet config set <key> <value> [--profile <name>]")
+			fmt.Println("       go-wallet config set profile <name>")
+			os.Exit(1)
+		}
+
+		file, err := config.LoadFile(path)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		key, value := args[0], args[1]
+		if key == "profile" {
+			err = file.SetDefaultProfile(value)
+		} else {
+			err = file.Set(profile, key, value)
+		}
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := file.Save(path); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		switch {
+		case key == "profile" && value == "":
+			fmt.Println("✓ No profile is selected by default")
+		case key == "profile":
+			fmt.Printf("✓ Profile %s is used by default\n", value)
+		case value == "" && profile != "":
+			fmt.Printf("✓ Removed %s from profile %s\n", key, profile)
+		case value == "":
+			fmt.Printf("✓ Removed %s\n", key)
+		case profile != "":
+			fmt.Printf("✓ Set %s = %s in profile %s\n", key, value, profile)
+		default:
+			fmt.Printf("✓ Set %s = %s\n", key, value)
+		}
+
+	default:
+		fmt.Printf("Unknown config subcommand: %s\n", os.Args[2])
+		fmt.Println("Usage: go-wallet config <list|get|set> [arguments]")
+		os.Exit(1)
+	}
+}
+
+// printConfigValues writes a titled block of the settings in values, in the order of config.Settings
+func printConfigValues(w *tabwriter.Writer, title string, values map[string]string) {
+	fmt.Fprintf(w, "\n%s:\n", title)
+	if len(values) == 0 {
+		fmt.Fprintln(w, "  (none)")
+		return
+	}
+	for _, setting := range config.Settings() {
+		value, ok := values[setting.Key]
+		if !ok {
+			continue
+		}
+		if setting.Key == "rpc-password" {
+			value = "********"
+		}
+		fmt.Fprintf(w, "  %s\t%s\n", setting.Key, value)
+	}
+}
+
+// unlockIfNeeded prompts for the storage passphrase when private keys are encrypted
+func unlockIfNeeded(service *service.WalletService) {
+	if !service.IsLocked() {
+		return
+	}
+
+	if err := service.Unlock(readPassphrase("Passphrase: ")); err != nil {
+		fmt.Printf("Error unlocking wallets: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// readPassphrase reads a passphrase without echo from a terminal, or a line from piped stdin
+func readPassphrase(prompt string) string {
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		fmt.Print(prompt)
+		passphrase, err := term.ReadPassword(fd)
+		fmt.Println()
+		if err != nil {
+			fmt.Printf("Error reading passphrase: %v\n", err)
+			os.Exit(1)
+		}
+		return string(passphrase)
+	}
+
+	line, err := stdin.ReadString('\n')
+	if err != nil && line == "" {
+		fmt.Printf("Error reading passphrase: %v\n", err)
+		os.Exit(1)
+	}
+	return strings.TrimRight(line, "\r\n")
+}
+
+// promptMnemonic reads a recovery phrase from stdin
+func promptMnemonic() string {
+	fmt.Print("Enter your recovery phrase: ")
+	line, err := stdin.ReadString('\n')
+	if err != nil && line == "" {
+		fmt.Printf("Error reading recovery phrase: %v\n", err)
+		os.Exit(1)
+	}
+	return strings.TrimSpace(line)
+}
+
+func printEncryptionHint(service *service.WalletService) {
+	if !service.IsEncrypted() {
+		fmt.Println("\n🔓 Private keys are stored unencrypted. Run 'go-wallet passphrase' to encrypt them.")
+	}
+}
+
+// walletLabel returns the wallet name, marked for multisig and watch-only wallets
+func walletLabel(wallet *domain.Wallet) string {
+	if wallet.IsMultisig() {
+		return fmt.Sprintf("%s (%d-of-%d)", wallet.Name, wallet.Multisig.Threshold, len(wallet.Multisig.Cosigners))
+	}
+	if wallet.WatchOnly {
+		return wallet.Name + " (watch-only)"
+	}
+	return wallet.Name
+}
+
+// accountLabel returns the account derivation path, "xpub" for an imported key
+// of unknown origin or "multisig" for the cosigner keys of a multisig wallet
+func accountLabel(wallet *domain.Wallet) string {
+	if wallet.IsMultisig() {
+		return "multisig"
+	}
+	if wallet.DerivationPath == "" {
+		return "xpub"
+	}
+	return wallet.DerivationPath
+}
+
+// parseAmount parses an amount such as "0.5", "12.5mbtc" or "25000sats"; plain numbers use displayUnit
+func parseAmount(value string) (domain.Amount, error) {
+	return domain.ParseAmountWithUnit(value, displayUnit)
+}
+
+// formatAmount renders an amount in displayUnit with its symbol
+func formatAmount(amount domain.Amount) string {
+	return amount.FormatWithUnit(displayUnit)
+}
+
+// extractGlobalFlag removes "--name value" or "--name=value" from args and returns its value
+func extractGlobalFlag(args []string, name string) ([]string, string) {
+	flag := "--" + name
+	rest := make([]string, 0, len(args))
+	value := ""
+
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == flag && i+1 < len(args):
+			value = args[i+1]
+			i++
+		case strings.HasPrefix(args[i], flag+"="):
+			value = strings.TrimPrefix(args[i], flag+"=")
+		default:
+			rest = append(rest, args[i])
+		}
+	}
+
+	return rest, value
+}
+
+// settingFlag is a configuration setting given on the command line
+type settingFlag struct {
+	key   string
+	value string
+}
+
+// extractSettingFlags removes every --<key> of a configuration setting from
+// args; switches such as --tor are recorded with the value "true"
+func extractSettingFlags(args []string) ([]string, []settingFlag) {
+	var flags []settingFlag
+	for _, setting := range config.Settings() {
+		var value string
+		if setting.Switch {
+			var found bool
+			if args, found = extractGlobalSwitch(args, setting.Key); found {
+				value = "true"
+			}
+		} else {
+			args, value = extractGlobalFlag(args, setting.Key)
+		}
+		if value != "" {
+			flags = append(flags, settingFlag{key: setting.Key, value: value})
+		}
+	}
+	return args, flags
+}
+
+// loadConfig loads the config file, profile and environment, then applies the flags
+func loadConfig(path, profile string, overrides []settingFlag) (*config.Config, error) {
+	cfg, err := config.Load(path, profile)
+	if err != nil {
+		return nil, err
+	}
+	for _, flag := range overrides {
+		if err := cfg.Set(flag.key, flag.value); err != nil {
+			return nil, fmt.Errorf("--%s: %w", flag.key, err)
+		}
+	}
+	return cfg, nil
+}
+
+// extractGlobalSwitch removes every --name from args and reports whether it was present
+func extractGlobalSwitch(args []string, name string) ([]string, bool) {
+	flag := "--" + name
+	rest := make([]string, 0, len(args))
+	found := false
+
+	for _, arg := range args {
+		if arg == flag {
+			found = true
+			continue
+		}
+		rest = append(rest, arg)
+	}
+
+	return rest, found
+}
+
+// splitArgs separates positional arguments from --flags. Flags listed in
+// valueFlags consume a value ("--name value" or "--name=value"); any other
+// flag is treated as a boolean and stored as "true".
+func splitArgs(args []string, valueFlags ...string) ([]string, map[string]string) {
+	takesValue := make(map[string]bool, len(valueFlags))
+	for _, name := range valueFlags {
+		takesValue[name] = true
+	}
+
+	var positional []string
+	flags := make(map[string]string)
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "--") || arg == "--" {
+			positional = append(positional, arg)
+			continue
+		}
+
+		name := strings.TrimPrefix(arg, "--")
+		if eq := strings.Index(name, "="); eq >= 0 {
+			flags[name[:eq]] = name[eq+1:]
+			continue
+		}
+
+		if takesValue[name] && i+1 < len(args) {
+			flags[name] = args[i+1]
+			i++
+			continue
+		}
+
+		flags[name] = "true"
+	}
+
+	return positional, flags
+}
+
+// abbreviate shortens s to its first n characters followed by an ellipsis
+func abbreviate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
@@ -1,13 +1,49 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dhfai/go-wallet/internal/domain"
+	"github.com/dhfai/go-wallet/pkg/chaincfg"
+	"github.com/dhfai/go-wallet/pkg/network"
 )
 
 type Config struct {
+	Path    string // Config file the settings were loaded from; empty when built by NewConfig
+	Profile string // Profile of the config file in use; empty when none is selected
+
 	StoragePath string
 	Network     string
+	GapLimit    int // Unused addresses in a row after which address discovery stops
+	FinalConfs  int // Confirmations after which a transaction is final
+
+	Unit        domain.AmountUnit  // Denomination amounts are shown and, without a suffix, parsed in
+	FeePriority domain.FeePriority // Priority of sends that name neither a priority nor a fee rate
+	MaxFeeRate  float64            // Highest fee rate in sat/vB the wallet pays; 0 for no limit
+
+	Backend     string // Blockchain data source: "esplora", "bitcoind" or "electrum"
+	RPCURL      string // bitcoind JSON-RPC URL; empty selects the network's default port on localhost
+	RPCUser     string // bitcoind rpcuser; empty uses the cookie file
+	RPCPassword string // bitcoind rpcpassword
+	RPCCookie   string // bitcoind .cookie file; empty selects the default data directory
+	RPCWallet   string // bitcoind watch-only wallet; empty scans the UTXO set
+
+	ElectrumServer   string // Electrum server as tcp://host:port or ssl://host:port
+	ElectrumInsecure bool   // Accept self-signed TLS certificates of the Electrum server
+
+	ExplorerURL string        // Esplora API base URLs, comma-separated and tried in order; empty selects the network's default
+	Proxy       string        // SOCKS5 proxy for all network calls, socks5://host:port
+	Tor         bool          // Route network calls through the local Tor SOCKS port when Proxy is empty
+	CAFile      string        // PEM file with extra trusted TLS root certificates
+	Timeout     time.Duration // Per-request timeout; 0 keeps each backend's default
+
+	Retries    int     // Esplora retries per endpoint on network errors, 429 and 5xx
+	RateLimit  float64 // Esplora requests per second per host; 0 disables the limit
+	CrossCheck bool    // Compare address balances across all Esplora endpoints
 }
 
 func NewConfig() *Config {
@@ -21,6 +57,13 @@ func NewConfig() *Config {
 	return &Config{
 		StoragePath: storagePath,
 		Network:     "mainnet",
+		GapLimit:    20,
+		FinalConfs:  6,
+		Unit:        domain.UnitBTC,
+		FeePriority: domain.DefaultFeePriority,
+		Backend:     "esplora",
+		Retries:     network.DefaultRetryPolicy.MaxRetries,
+		RateLimit:   network.DefaultRateLimit.PerSecond,
 	}
 }
 
@@ -31,3 +74,118 @@ func (c *Config) SetStoragePath(path string) {
 func (c *Config) SetNetwork(network string) {
 	c.Network = network
 }
+
+func (c *Config) SetGapLimit(limit int) {
+	c.GapLimit = limit
+}
+
+func (c *Config) SetFinalConfs(confirmations int) {
+	c.FinalConfs = confirmations
+}
+
+func (c *Config) SetBackend(backend string) {
+	c.Backend = backend
+}
+
+func (c *Config) SetExplorerURL(url string) {
+	c.ExplorerURL = url
+}
+
+func (c *Config) SetProxy(proxy string) {
+	c.Proxy = proxy
+}
+
+// Transport returns the proxy, TLS roots and timeout shared by all backends
+// Transport mengembalikan pengaturan koneksi yang dipakai semua backend
+func (c *Config) Transport() (network.TransportConfig, error) {
+	transport := network.TransportConfig{Timeout: c.Timeout}
+
+	proxy := c.Proxy
+	if proxy == "" && c.Tor {
+		proxy = network.TorProxy
+	}
+	if proxy != "" {
+		parsed, err := network.ParseProxy(proxy)
+		if err != nil {
+			return transport, err
+		}
+		transport.Proxy = parsed
+	}
+
+	if c.CAFile != "" {
+		pool, err := network.LoadCertPool(c.CAFile)
+		if err != nil {
+			return transport, err
+		}
+		transport.RootCAs = pool
+	}
+	return transport, nil
+}
+
+// NetworkParams returns the chain parameters of the configured network
+// NetworkParams mengembalikan parameter jaringan yang dikonfigurasi
+func (c *Config) NetworkParams() (*chaincfg.Params, error) {
+	return chaincfg.ParamsForName(c.Network)
+}
+
+// ExplorerConfig returns the Esplora endpoints with the retry, rate limit and
+// cross-check settings
+// ExplorerConfig mengembalikan daftar endpoint Esplora beserta pengaturan retry dan rate limit
+func (c *Config) ExplorerConfig(params *chaincfg.Params, transport network.TransportConfig) network.ExplorerConfig {
+	var endpoints []string
+	for _, endpoint := range strings.Split(c.ExplorerURL, ",") {
+		if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	if len(endpoints) == 0 {
+		endpoints = []string{params.ExplorerURL}
+	}
+
+	retry := network.DefaultRetryPolicy
+	retry.MaxRetries = c.Retries
+	rateLimit := network.DefaultRateLimit
+	rateLimit.PerSecond = c.RateLimit
+
+	return network.ExplorerConfig{
+		Endpoints:  endpoints,
+		Transport:  transport,
+		Retry:      retry,
+		RateLimit:  rateLimit,
+		CrossCheck: c.CrossCheck,
+	}
+}
+
+// ChainBackend returns the configured source of blockchain data for params
+// ChainBackend mengembalikan sumber data blockchain yang dikonfigurasi
+func (c *Config) ChainBackend(params *chaincfg.Params) (network.ChainBackend, error) {
+	transport, err := c.Transport()
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(c.Backend) {
+	case "", "esplora":
+		return network.NewBlockchainExplorerWithConfig(c.ExplorerConfig(params, transport)), nil
+	case "bitcoind", "core", "rpc":
+		return network.NewBitcoindClient(network.BitcoindConfig{
+			URL:        c.RPCURL,
+			User:       c.RPCUser,
+			Password:   c.RPCPassword,
+			CookieFile: c.RPCCookie,
+			Wallet:     c.RPCWallet,
+			Transport:  transport,
+		}, params), nil
+	case "electrum":
+		if c.ElectrumServer == "" {
+			return nil, fmt.Errorf("the electrum backend needs a server (--electrum-server ssl://host:port)")
+		}
+		return network.NewElectrumClient(network.ElectrumConfig{
+			Server:     c.ElectrumServer,
+			SkipVerify: c.ElectrumInsecure,
+			Transport:  transport,
+		}, params)
+	default:
+		return nil, fmt.Errorf("unknown backend %q (use esplora, bitcoind or electrum)", c.Backend)
+	}
+}
@@ -0,0 +1,217 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// envPrefix starts the environment variables overriding settings, e.g. GO_WALLET_NETWORK
+const envPrefix = "GO_WALLET_"
+
+// File is the persistent configuration at ~/.go-wallet/config. Settings
+// apply to every profile; the selected profile overrides them.
+//
+//	{
+//	  "profile": "personal-mainnet",
+//	  "settings": {"unit": "sats"},
+//	  "profiles": {
+//	    "personal-mainnet": {"fee-priority": "economy"},
+//	    "work-testnet": {"network": "testnet", "storage": "~/.go-wallet/work.json"}
+//	  }
+//	}
+//
+// File adalah konfigurasi tersimpan beserta profil-profilnya
+type File struct {
+	Profile  string                       `json:"profile,omitempty"` // Profile used when none is selected
+	Settings map[string]string            `json:"settings,omitempty"`
+	Profiles map[string]map[string]string `json:"profiles,omitempty"`
+}
+
+// DefaultPath returns the config file named by GO_WALLET_CONFIG, or ~/.go-wallet/config
+// DefaultPath mengembalikan lokasi file config
+func DefaultPath() string {
+	if path := os.Getenv(envPrefix + "CONFIG"); path != "" {
+		return expandHome(path)
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		homeDir = "."
+	}
+	return filepath.Join(homeDir, ".go-wallet", "config")
+}
+
+// LoadFile reads the config file at path; a missing file yields an empty configuration
+// LoadFile membaca file config
+func LoadFile(path string) (*File, error) {
+	file := &File{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return file, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	if err := json.Unmarshal(data, file); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return file, nil
+}
+
+// Save writes the file atomically and readable only by its owner, as it may hold RPC passwords
+// Save menyimpan file config
+func (f *File) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+// Get returns the value of key in profile, or in the shared settings when profile is empty
+// Get mengembalikan nilai kunci dari profil atau pengaturan bersama
+func (f *File) Get(profile, key string) (string, bool) {
+	values := f.Settings
+	if profile != "" {
+		values = f.Profiles[profile]
+	}
+	value, ok := values[key]
+	return value, ok
+}
+
+// Set stores value under key in profile, or in the shared settings when
+// profile is empty, creating the profile if needed. The value is checked
+// before it is stored; an empty value removes the key.
+// Set menyimpan nilai kunci ke profil atau pengaturan bersama
+func (f *File) Set(profile, key, value string) error {
+	if value != "" {
+		if err := NewConfig().Set(key, value); err != nil {
+			return err
+		}
+	} else if _, ok := LookupSetting(key); !ok {
+		return fmt.Errorf("unknown setting %q", key)
+	}
+
+	if profile == "" {
+		f.Settings = setValue(f.Settings, key, value)
+		return nil
+	}
+	if f.Profiles == nil {
+		f.Profiles = make(map[string]map[string]string)
+	}
+	values := f.Profiles[profile]
+	if values == nil {
+		values = make(map[string]string)
+	}
+	// An emptied profile is kept so it can still be selected
+	f.Profiles[profile] = setValue(values, key, value)
+	return nil
+}
+
+// SetDefaultProfile selects the profile used when none is named; empty selects none
+// SetDefaultProfile memilih profil default
+func (f *File) SetDefaultProfile(profile string) error {
+	if _, ok := f.Profiles[profile]; profile != "" && !ok {
+		return fmt.Errorf("unknown profile %q", profile)
+	}
+	f.Profile = profile
+	return nil
+}
+
+// ProfileNames returns the names of the profiles in alphabetical order
+func (f *File) ProfileNames() []string {
+	names := make([]string, 0, len(f.Profiles))
+	for name := range f.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func setValue(values map[string]string, key, value string) map[string]string {
+	if value == "" {
+		delete(values, key)
+		return values
+	}
+	if values == nil {
+		values = make(map[string]string)
+	}
+	values[key] = value
+	return values
+}
+
+// Load builds the configuration from the defaults, then the shared settings
+// of the config file at path, then the selected profile and finally the
+// GO_WALLET_* environment variables, each overriding the one before. An empty
+// profile selects GO_WALLET_PROFILE or else the file's default profile.
+// Load membaca konfigurasi dari file, profil dan variabel lingkungan
+func Load(path, profile string) (*Config, error) {
+	file, err := LoadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := NewConfig()
+	cfg.Path = path
+	if err := cfg.apply(file.Settings, path); err != nil {
+		return nil, err
+	}
+
+	if profile == "" {
+		profile = os.Getenv(envPrefix + "PROFILE")
+	}
+	if profile == "" {
+		profile = file.Profile
+	}
+	if profile != "" {
+		values, ok := file.Profiles[profile]
+		if !ok {
+			return nil, fmt.Errorf("unknown profile %q in %s", profile, path)
+		}
+		if err := cfg.apply(values, fmt.Sprintf("profile %s of %s", profile, path)); err != nil {
+			return nil, err
+		}
+		cfg.Profile = profile
+	}
+
+	for _, setting := range settings {
+		if value := os.Getenv(setting.EnvVar()); value != "" {
+			if err := cfg.Set(setting.Key, value); err != nil {
+				return nil, fmt.Errorf("%s: %w", setting.EnvVar(), err)
+			}
+		}
+	}
+	return cfg, nil
+}
+
+// apply sets the values read from source in the order of the settings table
+func (c *Config) apply(values map[string]string, source string) error {
+	for key := range values {
+		if _, ok := LookupSetting(key); !ok {
+			return fmt.Errorf("unknown setting %q in %s", key, source)
+		}
+	}
+	for _, setting := range settings {
+		if value, ok := values[setting.Key]; ok {
+			if err := c.Set(setting.Key, value); err != nil {
+				return fmt.Errorf("%s: %w", source, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeConfig writes a config file into a fresh directory and clears the
+// GO_WALLET_* variables of the environment running the test
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	for _, setting := range settings {
+		t.Setenv(setting.EnvVar(), "")
+	}
+	t.Setenv(envPrefix+"PROFILE", "")
+
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const testFile = `{
+  "profile": "personal",
+  "settings": {"unit": "sats", "gap-limit": "30", "timeout": "10s"},
+  "profiles": {
+    "personal": {"fee-priority": "economy", "gap-limit": "40"},
+    "work": {"network": "testnet", "unit": "bits"}
+  }
+}`
+
+func TestLoadPrecedence(t *testing.T) {
+	path := writeConfig(t, testFile)
+	t.Setenv("GO_WALLET_GAP_LIMIT", "50")
+	t.Setenv("GO_WALLET_MAX_FEE_RATE", "100")
+
+	cfg, err := Load(path, "")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	// A flag is applied after Load
+	if err := cfg.Set("max-fee-rate", "200"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		key, want string
+		source    string
+	}{
+		{"network", "mainnet", "default"},
+		{"final-confirmations", "6", "default"},
+		{"timeout", "10s", "settings"},
+		{"unit", "sats", "settings"},
+		{"fee-priority", "economy", "profile"},
+		{"gap-limit", "50", "environment over profile over settings"},
+		{"max-fee-rate", "200", "flag over environment"},
+	} {
+		if got, _ := cfg.Get(tc.key); got != tc.want {
+			t.Errorf("%s = %s, want %s from the %s", tc.key, got, tc.want, tc.source)
+		}
+	}
+	if cfg.Profile != "personal" || cfg.Path != path {
+		t.Errorf("profile, path = %q, %q, want personal, %q", cfg.Profile, cfg.Path, path)
+	}
+}
+
+func TestLoadProfileSelection(t *testing.T) {
+	path := writeConfig(t, testFile)
+
+	// GO_WALLET_PROFILE overrides the file's default, a named profile overrides both
+	t.Setenv("GO_WALLET_PROFILE", "work")
+	cfg, err := Load(path, "")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Profile != "work" || cfg.Network != "testnet" || cfg.GapLimit != 30 {
+		t.Errorf("GO_WALLET_PROFILE=work loaded profile %q on %s with gap limit %d", cfg.Profile, cfg.Network, cfg.GapLimit)
+	}
+	if cfg, err = Load(path, "personal"); err != nil || cfg.Profile != "personal" || cfg.Network != "mainnet" {
+		t.Errorf("Load of profile personal = %+v, %v", cfg, err)
+	}
+
+	if _, err := Load(path, "missing"); err == nil || !strings.Contains(err.Error(), `unknown profile "missing"`) {
+		t.Errorf("Load of an unknown profile error = %v", err)
+	}
+	t.Setenv("GO_WALLET_PROFILE", "missing")
+	if _, err := Load(path, ""); err == nil {
+		t.Error("Load with GO_WALLET_PROFILE naming an unknown profile succeeded")
+	}
+
+	// Without a file every value is a default
+	t.Setenv("GO_WALLET_PROFILE", "")
+	cfg, err = Load(filepath.Join(t.TempDir(), "none"), "")
+	if err != nil || cfg.Profile != "" || cfg.GapLimit != 20 {
+		t.Errorf("Load without a file = %+v, %v", cfg, err)
+	}
+}
+
+func TestLoadRejectsBadValues(t *testing.T) {
+	for _, tc := range []struct {
+		name, content, env string
+	}{
+		{"unknown setting", `{"settings": {"colour": "blue"}}`, ""},
+		{"unknown profile setting", `{"profiles": {"p": {"gap": "5"}}, "profile": "p"}`, ""},
+		{"invalid value", `{"settings": {"gap-limit": "0"}}`, ""},
+		{"invalid environment value", `{}`, "GO_WALLET_NETWORK"},
+		{"malformed file", `{"settings": `, ""},
+	} {
+		path := writeConfig(t, tc.content)
+		if tc.env != "" {
+			t.Setenv(tc.env, "moonnet")
+		}
+		if _, err := Load(path, ""); err == nil {
+			t.Errorf("%s: Load succeeded", tc.name)
+		}
+	}
+
+	file := &File{}
+	if err := file.Set("", "colour", "blue"); err == nil {
+		t.Error("Set of an unknown key succeeded")
+	}
+	if err := file.Set("p", "network", "moonnet"); err == nil || file.Profiles["p"] != nil {
+		t.Errorf("Set of an invalid value = %v, profiles %v", err, file.Profiles)
+	}
+	if err := file.SetDefaultProfile("p"); err == nil {
+		t.Error("SetDefaultProfile of an unknown profile succeeded")
+	}
+}
+
+func TestFileSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "config")
+	file := &File{}
+	if err := file.Set("", "unit", "sats"); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.Set("work", "rpc-password", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.SetDefaultProfile("work"); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Errorf("config file mode = %o, want 600", mode)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temporary file left behind: %v", err)
+	}
+
+	loaded, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	if value, _ := loaded.Get("work", "rpc-password"); value != "secret" || loaded.Profile != "work" || loaded.Settings["unit"] != "sats" {
+		t.Errorf("reloaded file = %+v", loaded)
+	}
+
+	// Saving over the file replaces it, and an emptied key is removed
+	if err := loaded.Set("work", "rpc-password", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := loaded.Save(path); err != nil {
+		t.Fatalf("second Save: %v", err)
+	}
+	if reloaded, err := LoadFile(path); err != nil || len(reloaded.Profiles["work"]) != 0 {
+		t.Errorf("reloaded after removing the password = %+v, %v", reloaded, err)
+	}
+	if _, ok := loaded.Profiles["work"]; !ok {
+		t.Error("emptied profile was dropped")
+	}
+}
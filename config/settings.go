@@ -0,0 +1,278 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dhfai/go-wallet/internal/domain"
+	"github.com/dhfai/go-wallet/pkg/chaincfg"
+	"github.com/dhfai/go-wallet/pkg/network"
+)
+
+// Setting is a configuration key. The same key names it in the config file,
+// as a global flag (--key) and as an environment variable (GO_WALLET_KEY).
+// Setting adalah kunci konfigurasi untuk file config, flag global dan variabel lingkungan
+type Setting struct {
+	Key    string
+	Usage  string
+	Switch bool // Boolean given on the command line without a value, e.g. --tor
+
+	get func(c *Config) string
+	set func(c *Config, value string) error
+}
+
+// settings lists every key in the order they are applied and listed
+var settings = []Setting{
+	{
+		Key:   "storage",
+		Usage: "Wallet storage file",
+		get:   func(c *Config) string { return c.StoragePath },
+		set: func(c *Config, value string) error {
+			c.StoragePath = expandHome(value)
+			return nil
+		},
+	},
+	{
+		Key:   "network",
+		Usage: "mainnet, testnet, signet or regtest",
+		get:   func(c *Config) string { return c.Network },
+		set: func(c *Config, value string) error {
+			params, err := chaincfg.ParamsForName(value)
+			if err != nil {
+				return err
+			}
+			c.Network = params.Name
+			return nil
+		},
+	},
+	{
+		Key:   "unit",
+		Usage: "Display unit: btc, mbtc, bits or sats",
+		get:   func(c *Config) string { return strings.ToLower(c.Unit.String()) },
+		set: func(c *Config, value string) (err error) {
+			c.Unit, err = domain.ParseAmountUnit(value)
+			return err
+		},
+	},
+	{
+		Key:   "fee-priority",
+		Usage: "Priority of sends without --priority or --feerate: fast, normal or economy",
+		get:   func(c *Config) string { return string(c.FeePriority) },
+		set: func(c *Config, value string) (err error) {
+			c.FeePriority, err = domain.ParseFeePriority(value)
+			return err
+		},
+	},
+	{
+		Key:   "max-fee-rate",
+		Usage: "Highest fee rate in sat/vB the wallet pays; 0 for no limit",
+		get:   func(c *Config) string { return strconv.FormatFloat(c.MaxFeeRate, 'f', -1, 64) },
+		set: func(c *Config, value string) error {
+			return parseFloat(value, &c.MaxFeeRate)
+		},
+	},
+	{
+		Key:   "gap-limit",
+		Usage: "Unused addresses in a row after which address discovery stops",
+		get:   func(c *Config) string { return strconv.Itoa(c.GapLimit) },
+		set: func(c *Config, value string) error {
+			return parsePositiveInt(value, &c.GapLimit)
+		},
+	},
+	{
+		Key:   "final-confirmations",
+		Usage: "Confirmations after which a transaction is final",
+		get:   func(c *Config) string { return strconv.Itoa(c.FinalConfs) },
+		set: func(c *Config, value string) error {
+			return parsePositiveInt(value, &c.FinalConfs)
+		},
+	},
+	{
+		Key:   "backend",
+		Usage: "Source of blockchain data: esplora, bitcoind or electrum",
+		get:   func(c *Config) string { return c.Backend },
+		set: func(c *Config, value string) error {
+			switch strings.ToLower(value) {
+			case "", "esplora", "bitcoind", "core", "rpc", "electrum":
+				c.Backend = strings.ToLower(value)
+				return nil
+			default:
+				return fmt.Errorf("unknown backend %q (use esplora, bitcoind or electrum)", value)
+			}
+		},
+	},
+	stringSetting("rpc-url", "bitcoind JSON-RPC URL", func(c *Config) *string { return &c.RPCURL }),
+	stringSetting("rpc-user", "bitcoind rpcuser", func(c *Config) *string { return &c.RPCUser }),
+	stringSetting("rpc-password", "bitcoind rpcpassword", func(c *Config) *string { return &c.RPCPassword }),
+	stringSetting("rpc-cookie", "bitcoind .cookie file", func(c *Config) *string { return &c.RPCCookie }),
+	stringSetting("rpc-wallet", "Watch-only bitcoind wallet", func(c *Config) *string { return &c.RPCWallet }),
+	stringSetting("electrum-server", "Electrum server, tcp://host:port or ssl://host:port", func(c *Config) *string { return &c.ElectrumServer }),
+	switchSetting("electrum-insecure", "Accept a self-signed certificate of the Electrum server", func(c *Config) *bool { return &c.ElectrumInsecure }),
+	stringSetting("explorer-url", "Esplora API URLs, comma-separated and tried in order", func(c *Config) *string { return &c.ExplorerURL }),
+	{
+		Key:   "proxy",
+		Usage: "SOCKS5 proxy, socks5://host:port",
+		get:   func(c *Config) string { return c.Proxy },
+		set: func(c *Config, value string) error {
+			if value != "" {
+				if _, err := network.ParseProxy(value); err != nil {
+					return err
+				}
+			}
+			c.Proxy = value
+			return nil
+		},
+	},
+	switchSetting("tor", "Use the local Tor SOCKS port", func(c *Config) *bool { return &c.Tor }),
+	{
+		Key:   "ca-file",
+		Usage: "PEM file with extra trusted TLS certificates",
+		get:   func(c *Config) string { return c.CAFile },
+		set: func(c *Config, value string) error {
+			c.CAFile = expandHome(value)
+			return nil
+		},
+	},
+	{
+		Key:   "timeout",
+		Usage: "Per-request timeout, e.g. 30s or 2m; 0 for each backend's default",
+		get:   func(c *Config) string { return c.Timeout.String() },
+		set: func(c *Config, value string) error {
+			timeout, err := time.ParseDuration(value)
+			if err != nil || timeout < 0 {
+				return fmt.Errorf("invalid duration %q (e.g. 30s or 2m)", value)
+			}
+			c.Timeout = timeout
+			return nil
+		},
+	},
+	{
+		Key:   "retries",
+		Usage: "Esplora retries per URL on errors and 429",
+		get:   func(c *Config) string { return strconv.Itoa(c.Retries) },
+		set: func(c *Config, value string) error {
+			retries, err := strconv.Atoi(value)
+			if err != nil || retries < 0 {
+				return fmt.Errorf("invalid number %q", value)
+			}
+			c.Retries = retries
+			return nil
+		},
+	},
+	{
+		Key:   "rate-limit",
+		Usage: "Esplora requests per second per host; 0 for no limit",
+		get:   func(c *Config) string { return strconv.FormatFloat(c.RateLimit, 'f', -1, 64) },
+		set: func(c *Config, value string) error {
+			return parseFloat(value, &c.RateLimit)
+		},
+	},
+	switchSetting("cross-check", "Compare balances across all Esplora URLs", func(c *Config) *bool { return &c.CrossCheck }),
+}
+
+// Settings returns every configuration key
+// Settings mengembalikan semua kunci konfigurasi
+func Settings() []Setting {
+	return settings
+}
+
+// LookupSetting returns the setting named key
+func LookupSetting(key string) (Setting, bool) {
+	for _, setting := range settings {
+		if setting.Key == key {
+			return setting, true
+		}
+	}
+	return Setting{}, false
+}
+
+// EnvVar returns the environment variable overriding the setting, e.g. GO_WALLET_EXPLORER_URL
+func (s Setting) EnvVar() string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(s.Key, "-", "_"))
+}
+
+// Get returns the value of a setting as it would be written in the config file
+// Get mengembalikan nilai sebuah pengaturan
+func (c *Config) Get(key string) (string, error) {
+	setting, ok := LookupSetting(key)
+	if !ok {
+		return "", fmt.Errorf("unknown setting %q", key)
+	}
+	return setting.get(c), nil
+}
+
+// Set parses value and assigns it to the setting named key
+// Set mem-parsing nilai lalu menyimpannya ke pengaturan
+func (c *Config) Set(key, value string) error {
+	setting, ok := LookupSetting(key)
+	if !ok {
+		return fmt.Errorf("unknown setting %q", key)
+	}
+	if err := setting.set(c, strings.TrimSpace(value)); err != nil {
+		return fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return nil
+}
+
+func stringSetting(key, usage string, field func(c *Config) *string) Setting {
+	return Setting{
+		Key:   key,
+		Usage: usage,
+		get:   func(c *Config) string { return *field(c) },
+		set: func(c *Config, value string) error {
+			*field(c) = value
+			return nil
+		},
+	}
+}
+
+func switchSetting(key, usage string, field func(c *Config) *bool) Setting {
+	return Setting{
+		Key:    key,
+		Usage:  usage,
+		Switch: true,
+		get:    func(c *Config) string { return strconv.FormatBool(*field(c)) },
+		set: func(c *Config, value string) error {
+			enabled, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("expected true or false, got %q", value)
+			}
+			*field(c) = enabled
+			return nil
+		},
+	}
+}
+
+func parsePositiveInt(value string, target *int) error {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return fmt.Errorf("expected a positive number, got %q", value)
+	}
+	*target = n
+	return nil
+}
+
+func parseFloat(value string, target *float64) error {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f < 0 {
+		return fmt.Errorf("expected a number of at least 0, got %q", value)
+	}
+	*target = f
+	return nil
+}
+
+// expandHome replaces a leading ~/ with the home directory
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[2:])
+}
@@ -4,4 +4,10 @@ go 1.21
 
 require golang.org/x/crypto v0.31.0
 
-require github.com/google/uuid v1.6.0 // indirect
+require (
+	github.com/google/uuid v1.6.0
+	golang.org/x/term v0.27.0
+	golang.org/x/text v0.21.0
+)
+
+require golang.org/x/sys v0.28.0 // indirect
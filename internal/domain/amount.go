@@ -0,0 +1,209 @@
+package domain
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Amount is a quantity of bitcoin in satoshis
+// Amount adalah jumlah bitcoin dalam satoshi
+type Amount int64
+
+// AmountUnit is the number of satoshis in one unit of a denomination
+type AmountUnit int64
+
+const (
+	UnitSatoshi  AmountUnit = 1
+	UnitBit      AmountUnit = 100
+	UnitMilliBTC AmountUnit = 100000
+	UnitBTC      AmountUnit = 100000000
+)
+
+// MaxAmount is the total supply limit of 21 million BTC
+const MaxAmount = Amount(21000000 * int64(UnitBTC))
+
+// decimals returns the number of fractional digits representable in the unit
+func (u AmountUnit) decimals() int {
+	switch u {
+	case UnitBTC:
+		return 8
+	case UnitMilliBTC:
+		return 5
+	case UnitBit:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// String returns the unit symbol
+func (u AmountUnit) String() string {
+	switch u {
+	case UnitBTC:
+		return "BTC"
+	case UnitMilliBTC:
+		return "mBTC"
+	case UnitBit:
+		return "bits"
+	default:
+		return "sats"
+	}
+}
+
+// ParseAmountUnit parses a unit name such as btc, mbtc, bits or sats
+func ParseAmountUnit(name string) (AmountUnit, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "btc":
+		return UnitBTC, nil
+	case "mbtc":
+		return UnitMilliBTC, nil
+	case "bit", "bits", "ubtc", "µbtc":
+		return UnitBit, nil
+	case "sat", "sats", "satoshi", "satoshis":
+		return UnitSatoshi, nil
+	default:
+		return 0, fmt.Errorf("unknown unit %q (use btc, mbtc, bits or sats)", name)
+	}
+}
+
+// ParseAmount parses a decimal number in the given unit exactly, e.g. "0.001" BTC = 100000 sats.
+// Amounts with more precision than one satoshi are rejected.
+// ParseAmount mem-parsing angka desimal dalam unit tertentu secara eksak
+func ParseAmount(value string, unit AmountUnit) (Amount, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, fmt.Errorf("%w: empty amount", ErrInvalidAmount)
+	}
+
+	negative := strings.HasPrefix(value, "-")
+	digits := strings.TrimPrefix(strings.TrimPrefix(value, "-"), "+")
+
+	whole, frac, _ := strings.Cut(digits, ".")
+	if whole == "" && frac == "" || !isDigits(whole) || !isDigits(frac) {
+		return 0, fmt.Errorf("%w: %q is not a decimal number", ErrInvalidAmount, value)
+	}
+
+	frac = strings.TrimRight(frac, "0")
+	if len(frac) > unit.decimals() {
+		return 0, fmt.Errorf("%w: %q has more precision than 1 satoshi", ErrInvalidAmount, value)
+	}
+
+	// Scale to satoshis: whole * unit + frac padded to the unit's decimals
+	sats := new(big.Int)
+	if whole != "" {
+		sats.SetString(whole, 10)
+	}
+	sats.Mul(sats, big.NewInt(int64(unit)))
+	if frac != "" {
+		fracSats, _ := new(big.Int).SetString(frac+strings.Repeat("0", unit.decimals()-len(frac)), 10)
+		sats.Add(sats, fracSats)
+	}
+
+	if !sats.IsInt64() || sats.Int64() > int64(MaxAmount) {
+		return 0, fmt.Errorf("%w: %q exceeds the 21 million BTC supply", ErrInvalidAmount, value)
+	}
+
+	amount := Amount(sats.Int64())
+	if negative {
+		amount = -amount
+	}
+	return amount, nil
+}
+
+// ParseAmountWithUnit parses an amount with an optional unit suffix such as
+// "0.5", "0.5btc", "12.5 mBTC", "100bits" or "25000 sats". defaultUnit applies when no suffix is given.
+// ParseAmountWithUnit mem-parsing jumlah dengan akhiran unit opsional
+func ParseAmountWithUnit(value string, defaultUnit AmountUnit) (Amount, error) {
+	value = strings.TrimSpace(value)
+
+	end := len(value)
+	for end > 0 && !isDigit(value[end-1]) && value[end-1] != '.' {
+		end--
+	}
+
+	unit := defaultUnit
+	if suffix := strings.TrimSpace(value[end:]); suffix != "" {
+		parsed, err := ParseAmountUnit(suffix)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %v", ErrInvalidAmount, err)
+		}
+		unit = parsed
+	}
+
+	return ParseAmount(value[:end], unit)
+}
+
+// AmountFromBTCDecimal converts a decimal BTC string (as written by older
+// wallet files, possibly in exponent form) to the nearest satoshi
+func AmountFromBTCDecimal(value string) (Amount, error) {
+	btc, ok := new(big.Rat).SetString(strings.TrimSpace(value))
+	if !ok {
+		return 0, fmt.Errorf("%w: %q is not a number", ErrInvalidAmount, value)
+	}
+
+	// Round half away from zero to whole satoshis
+	sats := new(big.Rat).Mul(btc, new(big.Rat).SetInt64(int64(UnitBTC)))
+	quo, rem := new(big.Int).QuoRem(sats.Num(), sats.Denom(), new(big.Int))
+	if new(big.Int).Mul(new(big.Int).Abs(rem), big.NewInt(2)).Cmp(sats.Denom()) >= 0 {
+		quo.Add(quo, big.NewInt(int64(rem.Sign())))
+	}
+
+	if !quo.IsInt64() || new(big.Int).Abs(quo).Cmp(big.NewInt(int64(MaxAmount))) > 0 {
+		return 0, fmt.Errorf("%w: %q exceeds the 21 million BTC supply", ErrInvalidAmount, value)
+	}
+
+	return Amount(quo.Int64()), nil
+}
+
+// Format renders the amount in unit with the unit's full precision and no symbol
+// Format menampilkan jumlah dalam unit tertentu tanpa simbol
+func (a Amount) Format(unit AmountUnit) string {
+	sign := ""
+	sats := int64(a)
+	if sats < 0 {
+		sign = "-"
+		sats = -sats
+	}
+
+	whole := sats / int64(unit)
+	frac := sats % int64(unit)
+
+	if unit.decimals() == 0 {
+		return fmt.Sprintf("%s%d", sign, whole)
+	}
+	return fmt.Sprintf("%s%d.%0*d", sign, whole, unit.decimals(), frac)
+}
+
+// FormatWithUnit renders the amount followed by the unit symbol, e.g. "0.00100000 BTC"
+func (a Amount) FormatWithUnit(unit AmountUnit) string {
+	return a.Format(unit) + " " + unit.String()
+}
+
+// BTC renders the amount in BTC with 8 decimals
+func (a Amount) BTC() string {
+	return a.Format(UnitBTC)
+}
+
+// String renders the amount in BTC with its symbol
+func (a Amount) String() string {
+	return a.FormatWithUnit(UnitBTC)
+}
+
+// Satoshis returns the amount as an integer number of satoshis
+func (a Amount) Satoshis() int64 {
+	return int64(a)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !isDigit(s[i]) {
+			return false
+		}
+	}
+	return true
+}
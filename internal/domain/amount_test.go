@@ -0,0 +1,158 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseAmountRoundTrip(t *testing.T) {
+	for _, tc := range []struct {
+		value string
+		unit  AmountUnit
+		sats  int64
+		text  string // Format(unit) of the parsed amount
+	}{
+		{"0.1", UnitBTC, 10000000, "0.10000000"},
+		{"0.00000001", UnitBTC, 1, "0.00000001"},
+		{"21000000", UnitBTC, 2100000000000000, "21000000.00000000"},
+		{"1.23456789", UnitBTC, 123456789, "1.23456789"},
+		{"0.10000000000", UnitBTC, 10000000, "0.10000000"},
+		{".5", UnitBTC, 50000000, "0.50000000"},
+		{"-0.001", UnitBTC, -100000, "-0.00100000"},
+		{"12.34567", UnitMilliBTC, 1234567, "12.34567"},
+		{"100.01", UnitBit, 10001, "100.01"},
+		{"25000", UnitSatoshi, 25000, "25000"},
+	} {
+		amount, err := ParseAmount(tc.value, tc.unit)
+		if err != nil {
+			t.Errorf("ParseAmount(%q, %s): %v", tc.value, tc.unit, err)
+			continue
+		}
+		if amount.Satoshis() != tc.sats {
+			t.Errorf("ParseAmount(%q, %s) = %d sats, want %d", tc.value, tc.unit, amount.Satoshis(), tc.sats)
+		}
+
+		text := amount.Format(tc.unit)
+		if text != tc.text {
+			t.Errorf("Format(%s) = %s, want %s", tc.unit, text, tc.text)
+		}
+		if again, err := ParseAmount(text, tc.unit); err != nil || again != amount {
+			t.Errorf("ParseAmount(%q, %s) = %d, %v, want %d", text, tc.unit, again, err, amount)
+		}
+	}
+}
+
+func TestParseAmountRejects(t *testing.T) {
+	for _, tc := range []struct {
+		value string
+		unit  AmountUnit
+	}{
+		// More decimal places than one satoshi
+		{"0.000000001", UnitBTC},
+		{"1.123456789", UnitBTC},
+		{"0.000000011", UnitBTC},
+		{"0.000001", UnitMilliBTC},
+		{"0.001", UnitBit},
+		{"1.5", UnitSatoshi},
+		// Not decimal numbers
+		{"", UnitBTC},
+		{".", UnitBTC},
+		{"1e-8", UnitBTC},
+		{"1,5", UnitBTC},
+		{"0x10", UnitBTC},
+		// Above the supply limit
+		{"21000000.00000001", UnitBTC},
+		{"99999999999999999999", UnitSatoshi},
+	} {
+		if amount, err := ParseAmount(tc.value, tc.unit); !errors.Is(err, ErrInvalidAmount) {
+			t.Errorf("ParseAmount(%q, %s) = %d, %v, want %v", tc.value, tc.unit, amount, err, ErrInvalidAmount)
+		}
+	}
+}
+
+func TestParseAmountWithUnit(t *testing.T) {
+	for _, tc := range []struct {
+		value string
+		sats  int64
+	}{
+		{"0.5", 50000000},
+		{"0.5btc", 50000000},
+		{"12.5 mBTC", 1250000},
+		{"100bits", 10000},
+		{"25000 sats", 25000},
+		{"1 sat", 1},
+	} {
+		amount, err := ParseAmountWithUnit(tc.value, UnitBTC)
+		if err != nil {
+			t.Errorf("ParseAmountWithUnit(%q): %v", tc.value, err)
+			continue
+		}
+		if amount.Satoshis() != tc.sats {
+			t.Errorf("ParseAmountWithUnit(%q) = %d sats, want %d", tc.value, amount.Satoshis(), tc.sats)
+		}
+	}
+
+	for _, value := range []string{"0.000000001 btc", "1.5 sats", "1 doge"} {
+		if _, err := ParseAmountWithUnit(value, UnitBTC); !errors.Is(err, ErrInvalidAmount) {
+			t.Errorf("ParseAmountWithUnit(%q) error = %v, want %v", value, err, ErrInvalidAmount)
+		}
+	}
+}
+
+func TestFormatWithUnit(t *testing.T) {
+	amount := Amount(100000)
+	for _, tc := range []struct {
+		unit AmountUnit
+		want string
+	}{
+		{UnitBTC, "0.00100000 BTC"},
+		{UnitMilliBTC, "1.00000 mBTC"},
+		{UnitBit, "1000.00 bits"},
+		{UnitSatoshi, "100000 sats"},
+	} {
+		if got := amount.FormatWithUnit(tc.unit); got != tc.want {
+			t.Errorf("FormatWithUnit(%s) = %s, want %s", tc.unit, got, tc.want)
+		}
+
+		unit, err := ParseAmountUnit(tc.unit.String())
+		if err != nil || unit != tc.unit {
+			t.Errorf("ParseAmountUnit(%s) = %v, %v, want %v", tc.unit.String(), unit, err, tc.unit)
+		}
+		if again, err := ParseAmountWithUnit(tc.want, UnitSatoshi); err != nil || again != amount {
+			t.Errorf("ParseAmountWithUnit(%q) = %d, %v, want %d", tc.want, again, err, amount)
+		}
+	}
+}
+
+func TestAmountFromBTCDecimal(t *testing.T) {
+	for _, tc := range []struct {
+		value string
+		sats  int64
+	}{
+		{"0.1", 10000000},
+		{"0.00000001", 1},
+		{"21000000", 2100000000000000},
+		{"1e-8", 1},
+		{"1E-8", 1},
+		{"2.1e7", 2100000000000000},
+		{"0.30000000000000004", 30000000},
+		{"0.000000005", 1},
+		{"-0.000000005", -1},
+		{"0", 0},
+	} {
+		amount, err := AmountFromBTCDecimal(tc.value)
+		if err != nil {
+			t.Errorf("AmountFromBTCDecimal(%q): %v", tc.value, err)
+			continue
+		}
+		if amount.Satoshis() != tc.sats {
+			t.Errorf("AmountFromBTCDecimal(%q) = %d sats, want %d", tc.value, amount.Satoshis(), tc.sats)
+		}
+	}
+
+	for _, value := range []string{"", "abc", "21000000.00000001"} {
+		if _, err := AmountFromBTCDecimal(value); !errors.Is(err, ErrInvalidAmount) {
+			t.Errorf("AmountFromBTCDecimal(%q) error = %v, want %v", value, err, ErrInvalidAmount)
+		}
+	}
+}
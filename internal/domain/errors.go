@@ -17,5 +17,39 @@ var (
 
 	ErrKeyGeneration = errors.New("failed to generate keys")
 
+	ErrInvalidMnemonic = errors.New("invalid mnemonic phrase")
+
+	ErrNotHDWallet = errors.New("wallet does not support address derivation")
+
 	ErrStorageOperation = errors.New("storage operation failed")
+
+	ErrNetworkMismatch = errors.New("wallet belongs to a different network")
+
+	ErrWalletLocked = errors.New("wallet storage is locked, unlock it with your passphrase")
+
+	ErrInvalidPassphrase = errors.New("invalid passphrase")
+
+	ErrBroadcastRejected = errors.New("transaction rejected by the network")
+
+	ErrFeeTooLow = errors.New("transaction fee is below the minimum relay fee")
+
+	ErrFeeTooHigh = errors.New("fee rate exceeds the configured maximum")
+
+	ErrInputsMissingOrSpent = errors.New("transaction inputs are missing or already spent")
+
+	ErrMempoolConflict = errors.New("transaction conflicts with a transaction already in the mempool")
+
+	ErrTransactionNotFound = errors.New("transaction not found")
+
+	ErrNotReplaceable = errors.New("transaction cannot be replaced")
+
+	ErrTransactionConfirmed = errors.New("transaction is already confirmed")
+
+	ErrWatchOnly = errors.New("wallet is watch-only and has no private keys to sign with")
+
+	ErrMultisig = errors.New("multisig wallets spend through PSBTs signed by their cosigners")
+
+	ErrInvalidMultisig = errors.New("invalid multisig configuration")
+
+	ErrInvalidDescriptor = errors.New("invalid output descriptor")
 )
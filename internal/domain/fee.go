@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FeePriority selects how quickly a transaction should confirm
+// FeePriority menentukan seberapa cepat transaksi dikonfirmasi
+type FeePriority string
+
+const (
+	FeePriorityFast    FeePriority = "fast"    // Next block
+	FeePriorityNormal  FeePriority = "normal"  // Within 6 blocks (~1 hour)
+	FeePriorityEconomy FeePriority = "economy" // Within 144 blocks (~1 day)
+)
+
+// DefaultFeePriority is used when neither a priority nor a fee rate is given
+const DefaultFeePriority = FeePriorityNormal
+
+// ParseFeePriority parses a fee priority name
+// ParseFeePriority mem-parsing nama prioritas fee
+func ParseFeePriority(name string) (FeePriority, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "normal", "medium":
+		return FeePriorityNormal, nil
+	case "fast", "high", "fastest":
+		return FeePriorityFast, nil
+	case "economy", "low", "slow":
+		return FeePriorityEconomy, nil
+	default:
+		return "", fmt.Errorf("unknown fee priority %q (use fast, normal or economy)", name)
+	}
+}
+
+// ConfirmationTarget returns the number of blocks the priority aims to confirm within
+func (p FeePriority) ConfirmationTarget() int {
+	switch p {
+	case FeePriorityFast:
+		return 1
+	case FeePriorityEconomy:
+		return 144
+	default:
+		return 6
+	}
+}
+
+// FallbackFeeRate returns a conservative fee rate in sat/vB used when no
+// estimate is available from the network
+func (p FeePriority) FallbackFeeRate() float64 {
+	switch p {
+	case FeePriorityFast:
+		return 25
+	case FeePriorityEconomy:
+		return 2
+	default:
+		return 10
+	}
+}
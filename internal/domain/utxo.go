@@ -0,0 +1,92 @@
+package domain
+
+import "fmt"
+
+// UTXO is an unspent output owned by a wallet address
+// UTXO adalah output yang belum dibelanjakan milik alamat wallet
+type UTXO struct {
+	TxID       string      `json:"txid"`               // Transaction ID of the output
+	Vout       uint32      `json:"vout"`               // Output index within the transaction
+	Value      Amount      `json:"value"`              // Output value in satoshis
+	Height     int64       `json:"height"`             // Confirmation block height, 0 while unconfirmed
+	ScriptType AddressType `json:"script_type"`        // Output script type
+	Address    string      `json:"address"`            // Wallet address the output pays to
+	Change     bool        `json:"change,omitempty"`   // Created by the wallet as change of its own transaction
+	Coinbase   bool        `json:"coinbase,omitempty"` // Output of a block reward transaction
+}
+
+// CoinbaseMaturity is the number of confirmations a coinbase output needs before it can be spent
+const CoinbaseMaturity = 100
+
+// Balances splits a wallet balance by how spendable its outputs are
+type Balances struct {
+	Confirmed   Amount `json:"confirmed"`   // Confirmed outputs that can be spent
+	Unconfirmed Amount `json:"unconfirmed"` // Outputs still in the mempool
+	Immature    Amount `json:"immature"`    // Coinbase outputs with fewer than CoinbaseMaturity confirmations
+}
+
+// Total returns the sum of confirmed, unconfirmed and immature amounts
+func (b Balances) Total() Amount {
+	return b.Confirmed + b.Unconfirmed + b.Immature
+}
+
+// Outpoint returns the output reference in txid:vout form
+func (u UTXO) Outpoint() string {
+	return fmt.Sprintf("%s:%d", u.TxID, u.Vout)
+}
+
+// IsConfirmed reports whether the output is included in a block
+func (u UTXO) IsConfirmed() bool {
+	return u.Height > 0
+}
+
+// IsMature reports whether the output can be spent in the block after tipHeight;
+// only coinbase outputs have to mature
+func (u UTXO) IsMature(tipHeight int64) bool {
+	if !u.Coinbase {
+		return true
+	}
+	return u.IsConfirmed() && tipHeight-u.Height+1 >= CoinbaseMaturity
+}
+
+// Balances returns the wallet's unspent outputs split into confirmed,
+// unconfirmed and immature amounts as of the last sync
+func (w *Wallet) Balances() Balances {
+	var balances Balances
+	for _, utxo := range w.UTXOs {
+		switch {
+		case !utxo.IsConfirmed():
+			balances.Unconfirmed += utxo.Value
+		case !utxo.IsMature(w.SyncHeight):
+			balances.Immature += utxo.Value
+		default:
+			balances.Confirmed += utxo.Value
+		}
+	}
+	return balances
+}
+
+// UTXOBalance returns the total value of the wallet's unspent outputs
+func (w *Wallet) UTXOBalance() Amount {
+	var total Amount
+	for _, utxo := range w.UTXOs {
+		total += utxo.Value
+	}
+	return total
+}
+
+// SpendUTXOs removes the outputs referenced by outpoints from the UTXO set
+func (w *Wallet) SpendUTXOs(outpoints []string) {
+	spent := make(map[string]bool, len(outpoints))
+	for _, outpoint := range outpoints {
+		spent[outpoint] = true
+	}
+
+	remaining := w.UTXOs[:0]
+	for _, utxo := range w.UTXOs {
+		if !spent[utxo.Outpoint()] {
+			remaining = append(remaining, utxo)
+		}
+	}
+	w.UTXOs = remaining
+}
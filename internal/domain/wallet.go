@@ -1,31 +1,162 @@
 package domain
 
 import (
+	"fmt"
+	"strings"
 	"time"
+
+	"github.com/dhfai/go-wallet/pkg/chaincfg"
+	"github.com/dhfai/go-wallet/pkg/crypto"
+)
+
+// Derivation chains of a BIP32 account (BIP44: external/internal)
+const (
+	ChainReceive uint32 = 0
+	ChainChange  uint32 = 1
 )
 
+// AddressType is the output script type a wallet receives to
+// AddressType adalah jenis script output yang digunakan wallet
+type AddressType string
+
+const (
+	AddressP2PKH      AddressType = "p2pkh"       // Legacy base58, starts with 1 (BIP44)
+	AddressP2SHP2WPKH AddressType = "p2sh-p2wpkh" // Nested SegWit, starts with 3 (BIP49)
+	AddressP2WPKH     AddressType = "p2wpkh"      // Native SegWit bech32, starts with bc1q (BIP84)
+	AddressP2TR       AddressType = "p2tr"        // Taproot bech32m, starts with bc1p (BIP86)
+	AddressP2WSH      AddressType = "p2wsh"       // Native SegWit multisig, starts with bc1q (BIP48 script type 2')
+	AddressP2SHP2WSH  AddressType = "p2sh-p2wsh"  // Nested SegWit multisig, starts with 3 (BIP48 script type 1')
+)
+
+// DefaultMultisigAddressType is used for multisig wallets when no address type is requested
+const DefaultMultisigAddressType = AddressP2WSH
+
+// DefaultAddressType is used when no address type is requested
+const DefaultAddressType = AddressP2WPKH
+
+// ParseAddressType parses an address type name or one of its common aliases
+// ParseAddressType mem-parsing nama jenis alamat atau aliasnya
+func ParseAddressType(name string) (AddressType, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "p2wpkh", "segwit", "bech32", "native-segwit":
+		return AddressP2WPKH, nil
+	case "p2pkh", "legacy":
+		return AddressP2PKH, nil
+	case "p2sh-p2wpkh", "nested-segwit", "p2sh-segwit":
+		return AddressP2SHP2WPKH, nil
+	case "p2tr", "taproot", "bech32m":
+		return AddressP2TR, nil
+	default:
+		return "", fmt.Errorf("unknown address type %q (use p2pkh, p2sh-p2wpkh, p2wpkh or p2tr)", name)
+	}
+}
+
+// ParseMultisigAddressType parses a multisig address type name or one of its common aliases
+// ParseMultisigAddressType mem-parsing nama jenis alamat multisig atau aliasnya
+func ParseMultisigAddressType(name string) (AddressType, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "p2wsh", "wsh", "native-segwit":
+		return AddressP2WSH, nil
+	case "p2sh-p2wsh", "p2wsh-p2sh", "sh-wsh", "nested-segwit":
+		return AddressP2SHP2WSH, nil
+	default:
+		return "", fmt.Errorf("unknown multisig address type %q (use p2wsh or p2sh-p2wsh)", name)
+	}
+}
+
+// IsMultisig reports whether the address type pays to a multisig witness script
+func (t AddressType) IsMultisig() bool {
+	return t == AddressP2WSH || t == AddressP2SHP2WSH
+}
+
+// Purpose returns the BIP43 purpose used to derive accounts of this type
+func (t AddressType) Purpose() uint32 {
+	switch t {
+	case AddressP2WSH, AddressP2SHP2WSH:
+		return 48
+	case AddressP2PKH:
+		return 44
+	case AddressP2SHP2WPKH:
+		return 49
+	case AddressP2TR:
+		return 86
+	default:
+		return 84
+	}
+}
+
 type Wallet struct {
-	ID           string        `json:"id"`           // Unique identifier for the wallet
-	Name         string        `json:"name"`         // User-friendly name for the wallet
-	PrivateKey   string        `json:"private_key"`  // Private key in WIF format
-	PublicKey    string        `json:"public_key"`   // Public key in hex format
-	Address      string        `json:"address"`      // Bitcoin address
-	Balance      float64       `json:"balance"`      // Current balance in BTC
-	Transactions []Transaction `json:"transactions"` // Transaction history
-	CreatedAt    time.Time     `json:"created_at"`   // Wallet creation timestamp
-	UpdatedAt    time.Time     `json:"updated_at"`   // Last update timestamp
+	ID                string           `json:"id"`                           // Unique identifier for the wallet
+	Name              string           `json:"name"`                         // User-friendly name for the wallet
+	PrivateKey        string           `json:"private_key,omitempty"`        // Private key in hex format
+	PublicKey         string           `json:"public_key"`                   // Public key in hex format
+	Address           string           `json:"address"`                      // Bitcoin address
+	AddressType       AddressType      `json:"address_type,omitempty"`       // Output script type of the wallet addresses
+	Network           string           `json:"network,omitempty"`            // Bitcoin network: mainnet, testnet, signet or regtest
+	Balance           Amount           `json:"balance"`                      // Current balance in satoshis
+	SyncHeight        int64            `json:"sync_height,omitempty"`        // Chain tip height at the last sync
+	Transactions      []Transaction    `json:"transactions"`                 // Transaction history
+	UTXOs             []UTXO           `json:"utxos,omitempty"`              // Unspent outputs as of the last sync
+	MasterFingerprint string           `json:"master_fingerprint,omitempty"` // BIP32 master key fingerprint (hex)
+	DerivationPath    string           `json:"derivation_path,omitempty"`    // Account derivation path, e.g. m/84'/0'/0'
+	AccountXpub       string           `json:"account_xpub,omitempty"`       // Account extended public key
+	AccountXprv       string           `json:"account_xprv,omitempty"`       // Account extended private key
+	ReceiveIndex      uint32           `json:"receive_index,omitempty"`      // Next unused receive address index
+	ChangeIndex       uint32           `json:"change_index,omitempty"`       // Next unused change address index
+	Addresses         []DerivedAddress `json:"addresses,omitempty"`          // Addresses derived from the account key
+	WatchOnly         bool             `json:"watch_only,omitempty"`         // Tracks addresses without holding private keys
+	ImportedAddresses []string         `json:"imported_addresses,omitempty"` // Further addresses of a watch-only wallet imported from a list
+	Multisig          *MultisigConfig  `json:"multisig,omitempty"`           // Cosigners and threshold of a multisig wallet
+	CreatedAt         time.Time        `json:"created_at"`                   // Wallet creation timestamp
+	UpdatedAt         time.Time        `json:"updated_at"`                   // Last update timestamp
+}
+
+// MultisigConfig describes the cosigners and threshold of an m-of-n multisig wallet
+type MultisigConfig struct {
+	Threshold int        `json:"threshold"` // Signatures required to spend (m)
+	Cosigners []Cosigner `json:"cosigners"` // Account keys of all cosigners (n)
+}
+
+// Cosigner is the account key of one multisig participant with its BIP32 origin
+type Cosigner struct {
+	Fingerprint    string `json:"fingerprint"`     // Master key fingerprint (hex)
+	DerivationPath string `json:"derivation_path"` // Account derivation path, e.g. m/48'/0'/0'/2'
+	Xpub           string `json:"xpub"`            // Account extended public key
+}
+
+type DerivedAddress struct {
+	Address   string `json:"address"`            // Bitcoin address
+	PublicKey string `json:"public_key"`         // Compressed public key in hex format
+	Chain     uint32 `json:"chain"`              // 0 = receive, 1 = change
+	Index     uint32 `json:"index"`              // Address index within the chain
+	Balance   Amount `json:"balance,omitempty"`  // Balance as of the last sync or rescan
+	TxCount   int    `json:"tx_count,omitempty"` // Transactions touching the address as of the last sync or rescan
 }
 
 type Transaction struct {
-	ID        string    `json:"id"`        // Transaction ID (hash)
-	From      string    `json:"from"`      // Sender address
-	To        string    `json:"to"`        // Recipient address
-	Amount    float64   `json:"amount"`    // Amount in BTC
-	Fee       float64   `json:"fee"`       // Transaction fee in BTC
-	Type      string    `json:"type"`      // Type: "send" or "receive"
-	Status    string    `json:"status"`    // Status: "pending", "confirmed", "failed"
-	Timestamp time.Time `json:"timestamp"` // Transaction timestamp
-	Note      string    `json:"note"`      // Optional note/memo
+	ID            string    `json:"id"`                      // Transaction ID (hash)
+	From          string    `json:"from"`                    // Sender address
+	To            string    `json:"to"`                      // Recipient address
+	Amount        Amount    `json:"amount"`                  // Amount in satoshis
+	Fee           Amount    `json:"fee"`                     // Transaction fee in satoshis
+	FeeRate       float64   `json:"fee_rate,omitempty"`      // Effective fee rate in sat/vB
+	VSize         int       `json:"vsize,omitempty"`         // Virtual size in vbytes
+	Type          string    `json:"type"`                    // Type: "send", "receive" or "self"
+	Status        string    `json:"status"`                  // Status: "pending", "confirmed", "final", "failed", "replaced"
+	Timestamp     time.Time `json:"timestamp"`               // Transaction timestamp
+	BlockHeight   int64     `json:"block_height,omitempty"`  // Height of the confirming block, 0 while unconfirmed
+	BlockHash     string    `json:"block_hash,omitempty"`    // Hash of the confirming block
+	Confirmations int64     `json:"confirmations,omitempty"` // Blocks on top of and including the confirming block as of the last sync
+	Coinbase      bool      `json:"coinbase,omitempty"`      // Block reward transaction whose outputs mature after CoinbaseMaturity blocks
+	ConflictedBy  string    `json:"conflicted_by,omitempty"` // ID of the transaction that double-spent this one
+	Note          string    `json:"note"`                    // Optional note/memo
+	RBF           bool      `json:"rbf,omitempty"`           // Signals BIP125 replace-by-fee
+	Hex           string    `json:"hex,omitempty"`           // Signed raw transaction of outgoing transactions
+	Inputs        []UTXO    `json:"inputs,omitempty"`        // Outputs spent by outgoing transactions
+	Replaces      string    `json:"replaces,omitempty"`      // ID of the transaction this one replaced
+	ReplacedBy    string    `json:"replaced_by,omitempty"`   // ID of the transaction that replaced this one
+	CPFPParent    string    `json:"cpfp_parent,omitempty"`   // ID of the unconfirmed parent this child accelerates
+	ReportedTxID  string    `json:"reported_txid,omitempty"` // Other txid the backend answered the broadcast with
 }
 
 type Key struct {
@@ -38,7 +169,7 @@ type WalletInfo struct {
 	ID        string    `json:"id"`
 	Name      string    `json:"name"`
 	Address   string    `json:"address"`
-	Balance   float64   `json:"balance"`
+	Balance   Amount    `json:"balance"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
@@ -52,6 +183,120 @@ func (w *Wallet) ToInfo() WalletInfo {
 	}
 }
 
+// DefaultNetwork is the network of wallets saved before the network was recorded
+const DefaultNetwork = "mainnet"
+
+// NetworkName returns the network the wallet belongs to
+func (w *Wallet) NetworkName() string {
+	if w.Network == "" {
+		return DefaultNetwork
+	}
+	return w.Network
+}
+
+// ScriptType returns the wallet's address type, inferring it from the primary
+// address for wallets saved before the type was recorded
+func (w *Wallet) ScriptType() AddressType {
+	if w.AddressType != "" {
+		return w.AddressType
+	}
+
+	params, err := chaincfg.ParamsForName(w.NetworkName())
+	if err != nil {
+		return DefaultAddressType
+	}
+	bc := crypto.NewBitcoinCryptoForNetwork(params)
+
+	if version, program, err := bc.DecodeSegWitAddress(params.Bech32HRP, w.Address); err == nil {
+		switch {
+		case version == 1 && len(program) == 32:
+			return AddressP2TR
+		case version == 0 && len(program) == 32:
+			return AddressP2WSH
+		}
+		return AddressP2WPKH
+	}
+	if version, payload, err := bc.DecodeBase58Check(w.Address); err == nil && len(payload) == 20 {
+		switch version {
+		case params.ScriptHashAddrID:
+			return AddressP2SHP2WPKH
+		case params.PubKeyHashAddrID:
+			return AddressP2PKH
+		}
+	}
+	return DefaultAddressType
+}
+
+// IsHD reports whether the wallet derives its addresses from BIP32 account keys
+func (w *Wallet) IsHD() bool {
+	return w.AccountXpub != "" || w.IsMultisig()
+}
+
+// IsMultisig reports whether spending from the wallet needs signatures of several cosigners
+func (w *Wallet) IsMultisig() bool {
+	return w.Multisig != nil
+}
+
+// AllAddresses returns the primary address followed by every derived address
+func (w *Wallet) AllAddresses() []string {
+	addresses := []string{w.Address}
+	for _, derived := range w.Addresses {
+		if derived.Address != w.Address {
+			addresses = append(addresses, derived.Address)
+		}
+	}
+	for _, imported := range w.ImportedAddresses {
+		if imported != w.Address {
+			addresses = append(addresses, imported)
+		}
+	}
+	return addresses
+}
+
+// FindAddress returns the derived address entry for address, if any
+func (w *Wallet) FindAddress(address string) (DerivedAddress, bool) {
+	for _, derived := range w.Addresses {
+		if derived.Address == address {
+			return derived, true
+		}
+	}
+	return DerivedAddress{}, false
+}
+
+// OwnsAddress reports whether address belongs to the wallet
+func (w *Wallet) OwnsAddress(address string) bool {
+	if address == w.Address {
+		return true
+	}
+	for _, imported := range w.ImportedAddresses {
+		if imported == address {
+			return true
+		}
+	}
+	_, ok := w.FindAddress(address)
+	return ok
+}
+
+// NetAmount returns the transaction's effect on the wallet balance: positive
+// for receives, negative for sends and self-transfers
+func (t Transaction) NetAmount() Amount {
+	switch t.Type {
+	case "receive":
+		return t.Amount
+	case "send":
+		return -(t.Amount + t.Fee)
+	case "self":
+		return -t.Fee
+	}
+	return 0
+}
+
+// IsOnChain reports whether the transaction was broadcast by the wallet or seen
+// on the blockchain, as opposed to a manual record kept from older wallet files
+func (t Transaction) IsOnChain() bool {
+	return t.Hex != "" || t.VSize > 0 || t.BlockHeight > 0
+}
+
 func (w *Wallet) AddTransaction(tx Transaction) {
 	w.Transactions = append(w.Transactions, tx)
 	w.UpdatedAt = time.Now()
@@ -60,7 +305,42 @@ func (w *Wallet) AddTransaction(tx Transaction) {
 		w.Balance += tx.Amount
 	} else if tx.Type == "send" {
 		w.Balance -= (tx.Amount + tx.Fee)
+	} else if tx.Type == "self" {
+		w.Balance -= tx.Fee
+	}
+}
+
+// FindTransaction returns the wallet's record of transaction id
+func (w *Wallet) FindTransaction(id string) (*Transaction, bool) {
+	for i := range w.Transactions {
+		if w.Transactions[i].ID == id {
+			return &w.Transactions[i], true
+		}
+	}
+	return nil, false
+}
+
+// ReplaceTransaction marks transaction oldID as replaced by replacement, reverses
+// its effect on the balance and records the replacement
+func (w *Wallet) ReplaceTransaction(oldID string, replacement Transaction) error {
+	original, ok := w.FindTransaction(oldID)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrTransactionNotFound, oldID)
+	}
+
+	if original.Type == "receive" {
+		w.Balance -= original.Amount
+	} else if original.Type == "send" {
+		w.Balance += original.Amount + original.Fee
+	} else if original.Type == "self" {
+		w.Balance += original.Fee
 	}
+	original.Status = "replaced"
+	original.ReplacedBy = replacement.ID
+
+	replacement.Replaces = oldID
+	w.AddTransaction(replacement)
+	return nil
 }
 
 func (w *Wallet) GetTransactionHistory(limit int) []Transaction {
@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dhfai/go-wallet/pkg/chaincfg"
+	"github.com/dhfai/go-wallet/pkg/crypto"
+)
+
+func TestScriptTypeFromAddress(t *testing.T) {
+	hash20 := bytes.Repeat([]byte{0x11}, 20)
+	hash32 := bytes.Repeat([]byte{0x22}, 32)
+
+	for _, params := range []*chaincfg.Params{&chaincfg.MainNetParams, &chaincfg.TestNet3Params, &chaincfg.SigNetParams, &chaincfg.RegressionNetParams} {
+		bc := crypto.NewBitcoinCryptoForNetwork(params)
+		segwit := func(version byte, program []byte) string {
+			address, err := bc.EncodeSegWitAddress(params.Bech32HRP, version, program)
+			if err != nil {
+				t.Fatalf("EncodeSegWitAddress: %v", err)
+			}
+			return address
+		}
+
+		for _, tc := range []struct {
+			address string
+			want    AddressType
+		}{
+			{bc.EncodeBase58Check(params.PubKeyHashAddrID, hash20), AddressP2PKH},
+			{bc.EncodeBase58Check(params.ScriptHashAddrID, hash20), AddressP2SHP2WPKH},
+			{segwit(0, hash20), AddressP2WPKH},
+			{segwit(0, hash32), AddressP2WSH},
+			{segwit(1, hash32), AddressP2TR},
+			{"not an address", DefaultAddressType},
+		} {
+			wallet := &Wallet{Address: tc.address, Network: params.Name}
+			if got := wallet.ScriptType(); got != tc.want {
+				t.Errorf("%s: ScriptType(%s) = %s, want %s", params.Name, tc.address, got, tc.want)
+			}
+		}
+	}
+
+	// A recorded type wins, and wallets saved before the network was recorded are mainnet
+	wallet := &Wallet{Address: "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", AddressType: AddressP2TR}
+	if got := wallet.ScriptType(); got != AddressP2TR {
+		t.Errorf("ScriptType with recorded type = %s, want %s", got, AddressP2TR)
+	}
+	wallet.AddressType = ""
+	if got := wallet.ScriptType(); got != AddressP2PKH {
+		t.Errorf("ScriptType of a legacy mainnet wallet = %s, want %s", got, AddressP2PKH)
+	}
+	// A testnet address does not decode as a mainnet wallet's
+	wallet.Address = "mipcBbFg9gMiCh81Kj8tqqdgoZub1ZJRfn"
+	if got := wallet.ScriptType(); got != DefaultAddressType {
+		t.Errorf("ScriptType of a testnet address on mainnet = %s, want %s", got, DefaultAddressType)
+	}
+}
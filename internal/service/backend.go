@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/dhfai/go-wallet/internal/domain"
+	"github.com/dhfai/go-wallet/pkg/network"
+)
+
+// reportedTxID returns the txid a backend answered a broadcast with when it
+// differs from the locally computed one, or "" when they match. The
+// transaction is on the network once the broadcast succeeded, so callers
+// record it under the local txid and keep the other one only as a warning.
+func reportedTxID(reported, local string) string {
+	if strings.EqualFold(strings.TrimSpace(reported), local) {
+		return ""
+	}
+	return reported
+}
+
+// descriptorWatchRange is the minimum number of addresses per chain a
+// descriptor importing backend is asked to watch, so that the rescan an
+// import triggers is rarely repeated as the wallet hands out addresses
+const descriptorWatchRange = 1000
+
+// watchWallet imports the wallet's descriptors into backends that only see
+// what they were told to watch, such as a bitcoind wallet. Ranged descriptors
+// cover at least the gap limit past the next unused address of each chain.
+func (s *WalletService) watchWallet(ctx context.Context, wallet *domain.Wallet) error {
+	importer, ok := s.backend.(network.DescriptorImporter)
+	if !ok {
+		return nil
+	}
+
+	descs, err := s.walletDescriptors(wallet, false)
+	if err != nil {
+		return err
+	}
+
+	imports := make([]network.DescriptorImport, len(descs))
+	for i, desc := range descs {
+		imports[i] = network.DescriptorImport{Descriptor: desc}
+		if wallet.IsHD() {
+			next := wallet.ReceiveIndex
+			if i == 1 {
+				next = wallet.ChangeIndex
+			}
+			end := next + uint32(s.gapLimit)
+			if end < descriptorWatchRange {
+				end = descriptorWatchRange
+			}
+			imports[i].RangeEnd = end - 1
+		}
+	}
+
+	if err := importer.ImportDescriptors(ctx, imports); err != nil {
+		return fmt.Errorf("failed to import wallet descriptors into the backend: %w", err)
+	}
+	return nil
+}
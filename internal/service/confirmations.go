@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/dhfai/go-wallet/internal/domain"
+	"github.com/dhfai/go-wallet/pkg/network"
+)
+
+// DefaultFinalConfirmations is the number of confirmations after which a
+// transaction is considered final and no longer tracked for reorgs
+const DefaultFinalConfirmations = 6
+
+// SetFinalConfirmations sets how many confirmations move a transaction from
+// confirmed to final; values below 1 restore DefaultFinalConfirmations
+// SetFinalConfirmations mengatur jumlah konfirmasi agar transaksi dianggap final
+func (s *WalletService) SetFinalConfirmations(confirmations int) {
+	if confirmations < 1 {
+		confirmations = DefaultFinalConfirmations
+	}
+	s.finality = int64(confirmations)
+}
+
+// FinalConfirmations returns the number of confirmations after which a transaction is final
+func (s *WalletService) FinalConfirmations() int {
+	return int(s.finality)
+}
+
+// GetBalances returns the wallet balance split into confirmed, unconfirmed
+// and immature coinbase amounts as of the last sync
+// GetBalances mengembalikan saldo wallet yang dipisah menjadi confirmed, unconfirmed dan immature
+func (s *WalletService) GetBalances(walletID string) (domain.Balances, error) {
+	wallet, err := s.repo.FindByID(walletID)
+	if err != nil {
+		return domain.Balances{}, err
+	}
+	return wallet.Balances(), nil
+}
+
+// updateConfirmations records the block and confirmation count of status on
+// tx and moves it through pending, confirmed and final
+func (s *WalletService) updateConfirmations(tx *domain.Transaction, status network.TxStatus, tip int64) {
+	if !status.Confirmed {
+		tx.Status = "pending"
+		tx.BlockHeight = 0
+		tx.BlockHash = ""
+		tx.Confirmations = 0
+		return
+	}
+
+	tx.BlockHeight = status.BlockHeight
+	tx.BlockHash = status.BlockHash
+	tx.Confirmations = tip - status.BlockHeight + 1
+	if tx.Confirmations < 1 {
+		// The block arrived after the tip was fetched
+		tx.Confirmations = 1
+	}
+
+	tx.Status = "confirmed"
+	if tx.Confirmations >= s.finality {
+		tx.Status = "final"
+	}
+}
+
+// checkMissingTransactions looks up the pending and confirmed transactions of
+// the wallet that were not in its on-chain history. Those the backend still
+// knows get their status refreshed; the others were dropped from the mempool,
+// double-spent or reorganised out and are marked failed. A backend that cannot
+// tell a dropped transaction from an unindexed one leaves the status alone.
+func (s *WalletService) checkMissingTransactions(ctx context.Context, wallet *domain.Wallet, seen map[string]bool, tip int64) error {
+	for i := range wallet.Transactions {
+		tx := &wallet.Transactions[i]
+		if seen[tx.ID] || !tx.IsOnChain() || (tx.Status != "pending" && tx.Status != "confirmed") {
+			continue
+		}
+
+		info, err := s.backend.GetTransaction(ctx, tx.ID)
+		if err == nil {
+			s.updateConfirmations(tx, info.Status, tip)
+			continue
+		}
+		if errors.Is(err, network.ErrNotIndexed) {
+			continue
+		}
+		if !errors.Is(err, network.ErrNotFound) {
+			return fmt.Errorf("failed to fetch transaction %s from blockchain: %w", tx.ID, err)
+		}
+
+		conflict, err := s.findConflict(ctx, tx)
+		if err != nil {
+			return err
+		}
+		tx.Status = "failed"
+		tx.ConflictedBy = conflict
+		tx.BlockHeight = 0
+		tx.BlockHash = ""
+		tx.Confirmations = 0
+	}
+	return nil
+}
+
+// findConflict returns the ID of another transaction spending one of the
+// inputs of tx, or "" when none of its inputs are spent
+func (s *WalletService) findConflict(ctx context.Context, tx *domain.Transaction) (string, error) {
+	for _, input := range tx.Inputs {
+		outspend, err := s.backend.GetOutspend(ctx, input.TxID, input.Vout)
+		if errors.Is(err, network.ErrNotFound) || errors.Is(err, network.ErrNotIndexed) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to fetch spending status of %s from blockchain: %w", input.Outpoint(), err)
+		}
+		if outspend.Spent && outspend.TxID != tx.ID {
+			return outspend.TxID, nil
+		}
+	}
+	return "", nil
+}
+
+// markCoinbaseOutputs flags the wallet UTXOs created by coinbase transactions
+// so that they count as immature until CoinbaseMaturity
+func markCoinbaseOutputs(wallet *domain.Wallet) {
+	for i := range wallet.UTXOs {
+		if tx, ok := wallet.FindTransaction(wallet.UTXOs[i].TxID); ok && tx.Coinbase {
+			wallet.UTXOs[i].Coinbase = true
+		}
+	}
+}
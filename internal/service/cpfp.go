@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dhfai/go-wallet/internal/domain"
+	"github.com/dhfai/go-wallet/pkg/txbuilder"
+)
+
+// CPFPResult holds the unconfirmed parent and the child spending it
+// CPFPResult berisi transaksi induk yang belum terkonfirmasi dan transaksi anaknya
+type CPFPResult struct {
+	Parent      domain.Transaction
+	Child       domain.Transaction
+	PackageRate float64 // Combined fee rate of parent and child in sat/vB
+}
+
+// ChildPaysForParent speeds up an unconfirmed incoming transaction with
+// child-pays-for-parent: the wallet's outputs of the parent are spent to a new
+// wallet address with a fee that lifts the fee rate of parent and child
+// together to feeRate sat/vB.
+// ChildPaysForParent mempercepat transaksi masuk dengan child-pays-for-parent
+func (s *WalletService) ChildPaysForParent(ctx context.Context, walletID, parentTxID string, feeRate float64) (*CPFPResult, error) {
+	wallet, err := s.repo.FindByID(walletID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkNetwork(wallet); err != nil {
+		return nil, err
+	}
+
+	if err := requireSingleSigner(wallet); err != nil {
+		return nil, err
+	}
+
+	if err := s.requireUnlocked(); err != nil {
+		return nil, err
+	}
+
+	if feeRate < txbuilder.MinRelayFeeRate {
+		return nil, fmt.Errorf("%w: %.2f sat/vB is below the minimum relay fee rate of %.0f sat/vB",
+			domain.ErrFeeTooLow, feeRate, txbuilder.MinRelayFeeRate)
+	}
+	if err := s.checkMaxFeeRate(feeRate); err != nil {
+		return nil, err
+	}
+
+	parent, err := s.backend.GetTransaction(ctx, parentTxID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", domain.ErrTransactionNotFound, parentTxID, err)
+	}
+	if parent.Status.Confirmed {
+		return nil, fmt.Errorf("%w: %s", domain.ErrTransactionConfirmed, parentTxID)
+	}
+
+	parentVSize := parent.VSize()
+	parentRate := float64(parent.Fee) / float64(parentVSize)
+	if parentRate >= feeRate {
+		return nil, fmt.Errorf("parent already pays %.1f sat/vB, at or above the requested %.1f sat/vB", parentRate, feeRate)
+	}
+
+	// Spend every output of the parent the wallet owns
+	if err := s.refreshUTXOs(ctx, wallet); err != nil {
+		return nil, err
+	}
+
+	var inputs []domain.UTXO
+	var inputTypes []txbuilder.ScriptType
+	var received int64
+	for _, utxo := range wallet.UTXOs {
+		if utxo.TxID == parentTxID && !utxo.IsConfirmed() {
+			inputs = append(inputs, utxo)
+			inputTypes = append(inputTypes, scriptTypeForAddress(utxo.ScriptType))
+			received += utxo.Value.Satoshis()
+		}
+	}
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("%w: %s pays no unspent output to this wallet", domain.ErrTransactionNotFound, parentTxID)
+	}
+
+	// Child fee covers the whole package at feeRate minus what the parent already pays
+	childAddress := wallet.Address
+	childType := wallet.ScriptType()
+	childVSize := txbuilder.EstimateVSize(inputTypes, []txbuilder.ScriptType{scriptTypeForAddress(childType)})
+	childFee := txbuilder.FeeForVSize(parentVSize+childVSize, feeRate) - parent.Fee
+	if minFee := txbuilder.FeeForVSize(childVSize, txbuilder.MinRelayFeeRate); childFee < minFee {
+		childFee = minFee
+	}
+
+	value := received - childFee
+	if value < txbuilder.DustLimit {
+		return nil, fmt.Errorf("%w: received %d sats cannot pay a %d sats child fee", domain.ErrInsufficientBalance, received, childFee)
+	}
+
+	if wallet.IsHD() {
+		derived, err := s.nextAddress(wallet, domain.ChainChange)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive address: %w", err)
+		}
+		childAddress = derived.Address
+	}
+
+	builder := txbuilder.NewBuilder(s.params)
+	for _, input := range inputs {
+		script, err := txbuilder.PayToAddrScript(input.Address, s.params)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", domain.ErrInvalidAddress, err)
+		}
+		if err := builder.AddInput(input.TxID, input.Vout, input.Value.Satoshis(), script); err != nil {
+			return nil, fmt.Errorf("failed to add input: %w", err)
+		}
+	}
+	if err := builder.AddOutput(childAddress, value); err != nil {
+		return nil, fmt.Errorf("failed to add output: %w", err)
+	}
+
+	for i, input := range inputs {
+		privKey, err := s.privateKeyForAddress(wallet, input.Address)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", domain.ErrInvalidPrivateKey, err)
+		}
+		if err := builder.SignInput(i, privKey); err != nil {
+			return nil, fmt.Errorf("failed to sign transaction: %w", err)
+		}
+	}
+
+	tx := builder.Transaction()
+	reported, err := s.backend.BroadcastTransaction(ctx, tx.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("failed to broadcast transaction: %w", err)
+	}
+	childTxID := tx.TxID()
+
+	// Record the parent if the wallet has not seen it yet, then the child. A
+	// parent missing from the records was not part of the last sync either, so
+	// it is added with its effect on the balance.
+	parentRecord, ok := wallet.FindTransaction(parentTxID)
+	if !ok {
+		from := ""
+		if len(parent.Vin) > 0 && parent.Vin[0].Prevout != nil {
+			from = parent.Vin[0].Prevout.ScriptPubKeyAddress
+		}
+		wallet.AddTransaction(domain.Transaction{
+			ID:        parentTxID,
+			From:      from,
+			To:        inputs[0].Address,
+			Amount:    domain.Amount(received),
+			Fee:       domain.Amount(parent.Fee),
+			FeeRate:   parentRate,
+			VSize:     parentVSize,
+			Type:      "receive",
+			Status:    "pending",
+			Timestamp: time.Now(),
+		})
+		parentRecord, _ = wallet.FindTransaction(parentTxID)
+	}
+
+	child := domain.Transaction{
+		ID:         childTxID,
+		From:       inputs[0].Address,
+		To:         childAddress,
+		Amount:     domain.Amount(value),
+		Fee:        domain.Amount(childFee),
+		FeeRate:    float64(childFee) / float64(tx.VSize()),
+		VSize:      tx.VSize(),
+		Type:       "self",
+		Status:     "pending",
+		Timestamp:  time.Now(),
+		Note:       fmt.Sprintf("CPFP for %s", parentTxID),
+		RBF:        tx.SignalsRBF(),
+		Hex:        tx.Hex(),
+		Inputs:     inputs,
+		CPFPParent: parentTxID,
+
+		ReportedTxID: reportedTxID(reported, childTxID),
+	}
+	result := &CPFPResult{
+		Parent:      *parentRecord,
+		Child:       child,
+		PackageRate: float64(parent.Fee+childFee) / float64(parentVSize+tx.VSize()),
+	}
+
+	wallet.AddTransaction(child)
+
+	// The parent outputs are now spent by the child
+	spent := make([]string, 0, len(inputs))
+	for _, input := range inputs {
+		spent = append(spent, input.Outpoint())
+	}
+	wallet.SpendUTXOs(spent)
+	wallet.UTXOs = append(wallet.UTXOs, domain.UTXO{
+		TxID:       childTxID,
+		Vout:       0,
+		Value:      domain.Amount(value),
+		ScriptType: childType,
+		Address:    childAddress,
+		Change:     wallet.IsHD(),
+	})
+
+	if err := s.repo.Update(wallet); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrStorageOperation, err)
+	}
+
+	return result, nil
+}
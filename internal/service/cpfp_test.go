@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/dhfai/go-wallet/internal/domain"
+	"github.com/dhfai/go-wallet/pkg/network"
+)
+
+// addPendingParent makes the backend report an unconfirmed transaction of
+// vsize vB paying fee sats that sends value sats to address at output 1
+func addPendingParent(backend *fakeBackend, address string, value, fee int64, vsize int) string {
+	txID := fmt.Sprintf("%064x", 0xaa00+len(backend.txs))
+	backend.txs[txID] = &network.TxInfo{
+		TxID:   txID,
+		Vin:    []network.TxInput{{Prevout: &network.TxOutput{ScriptPubKeyAddress: testRecipient, Value: value + 10000 + fee}}},
+		Vout:   []network.TxOutput{{ScriptPubKeyAddress: testRecipient, Value: 10000}, {ScriptPubKeyAddress: address, Value: value}},
+		Weight: vsize * 4,
+		Fee:    fee,
+	}
+	backend.utxos[address] = append(backend.utxos[address], network.UTXOInfo{TxID: txID, Vout: 1, Value: value})
+	return txID
+}
+
+func TestChildPaysForParent(t *testing.T) {
+	ctx := context.Background()
+	for _, tc := range []struct {
+		addressType domain.AddressType
+		feeRate     float64
+	}{
+		{domain.AddressP2WPKH, 10},
+		{domain.AddressP2WPKH, 55.5},
+		{domain.AddressP2TR, 10},
+		{domain.AddressP2SHP2WPKH, 10},
+		{domain.AddressP2PKH, 25},
+	} {
+		service, _, backend := newTestService(t)
+		wallet, _, err := service.CreateWallet("cpfp", CreateWalletOptions{AddressType: tc.addressType})
+		if err != nil {
+			t.Fatal(err)
+		}
+		const parentFee, parentVSize, received = 200, 200, 50000
+		parentID := addPendingParent(backend, wallet.Address, received, parentFee, parentVSize)
+
+		result, err := service.ChildPaysForParent(ctx, wallet.ID, parentID, tc.feeRate)
+		if err != nil {
+			t.Errorf("%s at %.1f: ChildPaysForParent: %v", tc.addressType, tc.feeRate, err)
+			continue
+		}
+		child := backend.lastBroadcast(t)
+		if len(child.Inputs) != 1 || child.Inputs[0].PrevIndex != 1 || len(child.Outputs) != 1 {
+			t.Errorf("%s: child spends %d inputs into %d outputs, want parent output 1 into one output", tc.addressType, len(child.Inputs), len(child.Outputs))
+			continue
+		}
+
+		// The child fee lifts parent and child together to the target rate
+		childFee := received - child.Outputs[0].Value
+		packageRate := float64(parentFee+childFee) / float64(parentVSize+child.VSize())
+		if packageRate < tc.feeRate {
+			t.Errorf("%s: package rate = %.3f sat/vB, want at least %.1f", tc.addressType, packageRate, tc.feeRate)
+		}
+		if overpaid := packageRate - tc.feeRate; overpaid > 0.1 {
+			t.Errorf("%s: package rate = %.3f sat/vB, overpays %.1f sat/vB by %.3f", tc.addressType, packageRate, tc.feeRate, overpaid)
+		}
+		if math.Abs(result.PackageRate-packageRate) > 1e-9 {
+			t.Errorf("%s: reported package rate = %.3f, want %.3f", tc.addressType, result.PackageRate, packageRate)
+		}
+
+		// Parent and child are recorded with the child's effect on the balance
+		if result.Parent.ID != parentID || result.Parent.Type != "receive" || result.Parent.Amount != received || result.Parent.Fee != parentFee {
+			t.Errorf("%s: recorded parent = %+v", tc.addressType, result.Parent)
+		}
+		recorded, ok := wallet.FindTransaction(child.TxID())
+		if !ok {
+			t.Errorf("%s: child %s not recorded", tc.addressType, child.TxID())
+			continue
+		}
+		if recorded.Type != "self" || recorded.CPFPParent != parentID || recorded.Fee.Satoshis() != childFee || len(recorded.Inputs) != 1 {
+			t.Errorf("%s: recorded child = %+v", tc.addressType, *recorded)
+		}
+		if wallet.Balance.Satoshis() != received-childFee {
+			t.Errorf("%s: balance = %d, want %d", tc.addressType, wallet.Balance.Satoshis(), received-childFee)
+		}
+		if len(wallet.UTXOs) != 1 || wallet.UTXOs[0].TxID != child.TxID() || wallet.UTXOs[0].Value.Satoshis() != child.Outputs[0].Value {
+			t.Errorf("%s: UTXOs = %+v, want the child output", tc.addressType, wallet.UTXOs)
+		}
+	}
+}
+
+func TestChildPaysForKnownParent(t *testing.T) {
+	ctx := context.Background()
+	service, _, backend := newTestService(t)
+	wallet, _, err := service.CreateWallet("cpfp", CreateWalletOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	parentID := addPendingParent(backend, wallet.Address, 50000, 150, 150)
+
+	// A parent recorded by the last sync is not added a second time
+	wallet.AddTransaction(domain.Transaction{ID: parentID, Amount: 50000, Type: "receive", Status: "pending"})
+	if _, err := service.ChildPaysForParent(ctx, wallet.ID, parentID, 5); err != nil {
+		t.Fatalf("ChildPaysForParent: %v", err)
+	}
+	if len(wallet.Transactions) != 2 {
+		t.Errorf("wallet has %d transactions, want the parent and the child", len(wallet.Transactions))
+	}
+
+	// A parent already at the target rate or confirmed needs no child
+	if _, err := service.ChildPaysForParent(ctx, wallet.ID, parentID, 1); err == nil {
+		t.Error("ChildPaysForParent below the parent's own rate succeeded")
+	}
+	backend.txs[parentID].Status.Confirmed = true
+	if _, err := service.ChildPaysForParent(ctx, wallet.ID, parentID, 20); !errors.Is(err, domain.ErrTransactionConfirmed) {
+		t.Errorf("ChildPaysForParent of a confirmed parent error = %v, want ErrTransactionConfirmed", err)
+	}
+}
@@ -0,0 +1,420 @@
+package service
+
+import (
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/dhfai/go-wallet/internal/domain"
+	"github.com/dhfai/go-wallet/pkg/crypto"
+	"github.com/dhfai/go-wallet/pkg/descriptor"
+	"github.com/google/uuid"
+)
+
+// ExportDescriptors returns the output descriptors (BIP380) of a wallet with
+// their checksums: the receive and change descriptors of an HD or multisig
+// wallet, a single descriptor for a single-key wallet, or one addr() per
+// address of a watch-only address list. With includePrivate the wallet's own
+// private keys replace its public keys.
+// ExportDescriptors mengekspor output descriptor dari wallet
+func (s *WalletService) ExportDescriptors(walletID string, includePrivate bool) ([]string, error) {
+	wallet, err := s.repo.FindByID(walletID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkNetwork(wallet); err != nil {
+		return nil, err
+	}
+
+	if includePrivate {
+		if err := requireSigningKeys(wallet); err != nil {
+			return nil, err
+		}
+		if err := s.requireUnlocked(); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.walletDescriptors(wallet, includePrivate)
+}
+
+// walletDescriptors renders the descriptors of wallet; see ExportDescriptors
+func (s *WalletService) walletDescriptors(wallet *domain.Wallet, includePrivate bool) ([]string, error) {
+	descType, err := descriptorType(wallet.ScriptType())
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case wallet.IsHD():
+		var descs []string
+		for _, chain := range []uint32{domain.ChainReceive, domain.ChainChange} {
+			desc, err := s.rangedDescriptor(wallet, descType, chain, includePrivate)
+			if err != nil {
+				return nil, err
+			}
+			descs = append(descs, desc.String())
+		}
+		return descs, nil
+
+	case wallet.WatchOnly && wallet.PublicKey == "":
+		var descs []string
+		for _, address := range wallet.AllAddresses() {
+			desc := &descriptor.Descriptor{Type: descriptor.TypeAddr, Address: address}
+			descs = append(descs, desc.String())
+		}
+		return descs, nil
+
+	default:
+		key := descriptor.Key{Encoded: wallet.PublicKey}
+		if includePrivate {
+			if key.Encoded, err = s.crypto.ConvertToWIF(wallet.PrivateKey, true); err != nil {
+				return nil, fmt.Errorf("%w: %v", domain.ErrInvalidPrivateKey, err)
+			}
+		}
+		desc := &descriptor.Descriptor{Type: descType, Keys: []descriptor.Key{key}}
+		return []string{desc.String()}, nil
+	}
+}
+
+// rangedDescriptor builds the descriptor of one chain (receive or change) of an HD or multisig wallet
+func (s *WalletService) rangedDescriptor(wallet *domain.Wallet, descType descriptor.Type, chain uint32, includePrivate bool) (*descriptor.Descriptor, error) {
+	if !wallet.IsMultisig() {
+		encoded := wallet.AccountXpub
+		if includePrivate {
+			encoded = wallet.AccountXprv
+		}
+		key, err := rangedKey(wallet.MasterFingerprint, wallet.DerivationPath, encoded, chain)
+		if err != nil {
+			return nil, err
+		}
+		return &descriptor.Descriptor{Type: descType, Keys: []descriptor.Key{key}}, nil
+	}
+
+	desc := &descriptor.Descriptor{Type: descType, Threshold: wallet.Multisig.Threshold, Sorted: true}
+	for _, cosigner := range wallet.Multisig.Cosigners {
+		encoded := cosigner.Xpub
+		if includePrivate && cosigner.Xpub == wallet.AccountXpub {
+			encoded = wallet.AccountXprv
+		}
+		key, err := rangedKey(cosigner.Fingerprint, cosigner.DerivationPath, encoded, chain)
+		if err != nil {
+			return nil, err
+		}
+		desc.Keys = append(desc.Keys, key)
+	}
+	return desc, nil
+}
+
+// rangedKey builds the [fingerprint/path]key/chain/* expression of an account key
+func rangedKey(fingerprint, path, encoded string, chain uint32) (descriptor.Key, error) {
+	key := descriptor.Key{Encoded: encoded, Path: []uint32{chain}, Wildcard: descriptor.WildcardUnhardened}
+	if fingerprint == "" {
+		return key, nil
+	}
+
+	raw, err := hex.DecodeString(fingerprint)
+	if err != nil || len(raw) != 4 {
+		return key, fmt.Errorf("%w: invalid fingerprint %q", domain.ErrInvalidDescriptor, fingerprint)
+	}
+	origin := &descriptor.KeyOrigin{}
+	copy(origin.Fingerprint[:], raw)
+	if path != "" {
+		if origin.Path, err = crypto.ParseDerivationPath(path); err != nil {
+			return key, fmt.Errorf("%w: %v", domain.ErrInvalidDescriptor, err)
+		}
+	}
+	key.Origin = origin
+	return key, nil
+}
+
+// ImportDescriptors creates a wallet from output descriptors, such as those
+// written by ExportDescriptors, Bitcoin Core's listdescriptors or Sparrow.
+// A ranged descriptor gives an HD or multisig wallet; its change descriptor
+// (the same keys ending in /1/*) may follow it, or both can be given as one
+// .../<0;1>/* descriptor. A single-key descriptor gives a single-key wallet
+// and addr() descriptors a watch-only address list. Descriptors with public
+// keys only create watch-only wallets.
+// ImportDescriptors membuat wallet dari output descriptor
+func (s *WalletService) ImportDescriptors(name string, texts []string) (*domain.Wallet, error) {
+	if name == "" {
+		return nil, fmt.Errorf("wallet name cannot be empty")
+	}
+	if len(texts) == 0 {
+		return nil, fmt.Errorf("%w: no descriptor given", domain.ErrInvalidDescriptor)
+	}
+
+	var descs []*descriptor.Descriptor
+	for _, text := range texts {
+		desc, err := descriptor.Parse(text, s.params)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", domain.ErrInvalidDescriptor, err)
+		}
+		descs = append(descs, desc.Expand()...)
+	}
+
+	receive := descs[0]
+	if receive.Type == descriptor.TypeAddr {
+		var addresses []string
+		for _, desc := range descs {
+			if desc.Type != descriptor.TypeAddr {
+				return nil, fmt.Errorf("%w: addr() descriptors cannot be mixed with key descriptors", domain.ErrInvalidDescriptor)
+			}
+			addresses = append(addresses, desc.Address)
+		}
+		return s.ImportWatchOnly(name, addresses, "")
+	}
+
+	if receive.HasPrivateKeys() {
+		if err := s.requireUnlocked(); err != nil {
+			return nil, err
+		}
+	}
+
+	if !receive.IsRange() {
+		if len(descs) > 1 {
+			return nil, fmt.Errorf("%w: expected a single descriptor for a single-key wallet", domain.ErrInvalidDescriptor)
+		}
+		return s.importSingleKeyDescriptor(name, receive)
+	}
+
+	for _, change := range descs[1:] {
+		if !isChangeDescriptor(receive, change) {
+			return nil, fmt.Errorf("%w: %s is not the change descriptor of %s", domain.ErrInvalidDescriptor, change, receive)
+		}
+	}
+
+	wallet, err := s.walletFromRangedDescriptor(name, receive)
+	if err != nil {
+		return nil, err
+	}
+
+	// The wallet must derive exactly the addresses the descriptor describes
+	expected, err := receive.AddressAt(0)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidDescriptor, err)
+	}
+	if wallet.Address != expected {
+		return nil, fmt.Errorf("%w: derived %s, descriptor gives %s", domain.ErrInvalidDescriptor, wallet.Address, expected)
+	}
+
+	for _, address := range wallet.AllAddresses() {
+		if existing, err := s.repo.FindByAddress(address); err == nil && existing != nil {
+			return nil, fmt.Errorf("%w: %s is already tracked by %s", domain.ErrWalletExists, address, existing.Name)
+		}
+	}
+
+	if err := s.repo.Save(wallet); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrStorageOperation, err)
+	}
+
+	return wallet, nil
+}
+
+// importSingleKeyDescriptor imports pkh(KEY), wpkh(KEY), sh(wpkh(KEY)) or tr(KEY) with a fixed key
+func (s *WalletService) importSingleKeyDescriptor(name string, desc *descriptor.Descriptor) (*domain.Wallet, error) {
+	if desc.IsMultisig() {
+		return nil, fmt.Errorf("%w: multisig descriptors need ranged extended keys", domain.ErrInvalidDescriptor)
+	}
+
+	addressType, err := addressTypeForDescriptor(desc.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	key := desc.Keys[0]
+	if key.Extended != nil {
+		return nil, fmt.Errorf("%w: extended keys must end in /0/* or /<0;1>/*", domain.ErrInvalidDescriptor)
+	}
+	if len(key.PubKey) != 33 {
+		// Wallets derive their addresses from compressed keys only
+		return nil, fmt.Errorf("%w: uncompressed keys cannot be imported as a wallet", domain.ErrInvalidDescriptor)
+	}
+	if key.PrivKey != "" {
+		return s.ImportWallet(name, key.PrivKey, addressType)
+	}
+
+	publicKey := hex.EncodeToString(key.PubKey)
+	address, err := s.addressFor(publicKey, addressType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate address: %w", err)
+	}
+	if existing, err := s.repo.FindByAddress(address); err == nil && existing != nil {
+		return nil, fmt.Errorf("%w: %s is already tracked by %s", domain.ErrWalletExists, address, existing.Name)
+	}
+
+	wallet := &domain.Wallet{
+		ID:           uuid.New().String(),
+		Name:         name,
+		PublicKey:    publicKey,
+		Address:      address,
+		AddressType:  addressType,
+		Network:      s.params.Name,
+		Transactions: []domain.Transaction{},
+		WatchOnly:    true,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+
+	if err := s.repo.Save(wallet); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrStorageOperation, err)
+	}
+
+	return wallet, nil
+}
+
+// walletFromRangedDescriptor builds an unsaved HD or multisig wallet from a receive descriptor
+func (s *WalletService) walletFromRangedDescriptor(name string, desc *descriptor.Descriptor) (*domain.Wallet, error) {
+	addressType, err := addressTypeForDescriptor(desc.Type)
+	if err != nil {
+		return nil, err
+	}
+	if desc.IsMultisig() && !desc.Sorted {
+		return nil, fmt.Errorf("%w: multisig wallets use sortedmulti(), not multi()", domain.ErrInvalidDescriptor)
+	}
+
+	wallet := &domain.Wallet{
+		ID:           uuid.New().String(),
+		Name:         name,
+		AddressType:  addressType,
+		Network:      s.params.Name,
+		Transactions: []domain.Transaction{},
+		WatchOnly:    true,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+
+	if desc.IsMultisig() {
+		wallet.Multisig = &domain.MultisigConfig{Threshold: desc.Threshold}
+	}
+
+	for _, key := range desc.Keys {
+		if key.Extended == nil || len(key.Path) != 1 || key.Path[0] != domain.ChainReceive || key.Wildcard != descriptor.WildcardUnhardened {
+			return nil, fmt.Errorf("%w: key %s must be an account key ending in /0/* or /<0;1>/*", domain.ErrInvalidDescriptor, key)
+		}
+
+		fingerprint, path := "", ""
+		if key.Origin != nil {
+			fingerprint = hex.EncodeToString(key.Origin.Fingerprint[:])
+			path = crypto.FormatDerivationPath(key.Origin.Path)
+		}
+		account := key.Extended.Neuter().WithVersion(s.params.HDPublicKeyID).String()
+
+		if key.IsPrivate() {
+			if !wallet.WatchOnly {
+				return nil, fmt.Errorf("%w: only one key can be a private key", domain.ErrInvalidDescriptor)
+			}
+			wallet.WatchOnly = false
+			wallet.AccountXprv = key.Extended.String()
+		}
+
+		// The wallet's own account key: the only key, or the local cosigner's
+		if !desc.IsMultisig() || key.IsPrivate() {
+			wallet.MasterFingerprint = fingerprint
+			wallet.DerivationPath = path
+			wallet.AccountXpub = account
+		}
+		if !desc.IsMultisig() {
+			continue
+		}
+
+		if key.Origin == nil {
+			return nil, fmt.Errorf("%w: cosigner key %s needs its origin, e.g. [d34db33f/48'/0'/0'/2']xpub...", domain.ErrInvalidMultisig, key.Encoded)
+		}
+		cosigner, err := s.cosignerFromKey(fingerprint, path, account)
+		if err != nil {
+			return nil, err
+		}
+		wallet.Multisig.Cosigners = append(wallet.Multisig.Cosigners, cosigner)
+	}
+
+	if wallet.IsMultisig() {
+		if err := validateMultisig(wallet.Multisig); err != nil {
+			return nil, err
+		}
+	}
+
+	// The first receive address (0/0) is the wallet's primary address
+	first, err := s.nextAddress(wallet, domain.ChainReceive)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrKeyGeneration, err)
+	}
+	wallet.Address = first.Address
+
+	if !wallet.IsMultisig() {
+		wallet.PublicKey = first.PublicKey
+		if wallet.AccountXprv != "" {
+			account, err := s.crypto.ParseExtendedKey(wallet.AccountXprv)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", domain.ErrKeyGeneration, err)
+			}
+			firstKey, err := account.DerivePath(first.Chain, first.Index)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", domain.ErrKeyGeneration, err)
+			}
+			if wallet.PrivateKey, err = firstKey.PrivateKeyHex(); err != nil {
+				return nil, fmt.Errorf("%w: %v", domain.ErrKeyGeneration, err)
+			}
+		}
+	}
+
+	return wallet, nil
+}
+
+// isChangeDescriptor reports whether change has the keys of receive on the change chain (/1/*)
+func isChangeDescriptor(receive, change *descriptor.Descriptor) bool {
+	if change.Type != receive.Type || change.Threshold != receive.Threshold ||
+		change.Sorted != receive.Sorted || len(change.Keys) != len(receive.Keys) {
+		return false
+	}
+
+	for i, key := range change.Keys {
+		other := receive.Keys[i]
+		if key.Extended == nil || other.Extended == nil ||
+			key.Extended.Neuter().String() != other.Extended.Neuter().String() ||
+			len(key.Path) != 1 || key.Path[0] != domain.ChainChange || key.Wildcard != other.Wildcard {
+			return false
+		}
+	}
+	return true
+}
+
+// descriptorType returns the descriptor template of an address type
+func descriptorType(addressType domain.AddressType) (descriptor.Type, error) {
+	switch addressType {
+	case domain.AddressP2PKH:
+		return descriptor.TypePKH, nil
+	case domain.AddressP2WPKH:
+		return descriptor.TypeWPKH, nil
+	case domain.AddressP2SHP2WPKH:
+		return descriptor.TypeSHWPKH, nil
+	case domain.AddressP2TR:
+		return descriptor.TypeTR, nil
+	case domain.AddressP2WSH:
+		return descriptor.TypeWSH, nil
+	case domain.AddressP2SHP2WSH:
+		return descriptor.TypeSHWSH, nil
+	default:
+		return "", fmt.Errorf("%w: no descriptor for address type %s", domain.ErrInvalidDescriptor, addressType)
+	}
+}
+
+// addressTypeForDescriptor returns the address type of a descriptor template
+func addressTypeForDescriptor(descType descriptor.Type) (domain.AddressType, error) {
+	switch descType {
+	case descriptor.TypePKH:
+		return domain.AddressP2PKH, nil
+	case descriptor.TypeWPKH:
+		return domain.AddressP2WPKH, nil
+	case descriptor.TypeSHWPKH:
+		return domain.AddressP2SHP2WPKH, nil
+	case descriptor.TypeTR:
+		return domain.AddressP2TR, nil
+	case descriptor.TypeWSH:
+		return domain.AddressP2WSH, nil
+	case descriptor.TypeSHWSH:
+		return domain.AddressP2SHP2WSH, nil
+	default:
+		return "", fmt.Errorf("%w: %s descriptors cannot be imported as a wallet", domain.ErrInvalidDescriptor, descType)
+	}
+}
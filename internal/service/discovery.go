@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/dhfai/go-wallet/internal/domain"
+	"github.com/dhfai/go-wallet/pkg/network"
+)
+
+// DefaultGapLimit is the number of unused addresses in a row after which
+// address discovery stops (BIP44)
+const DefaultGapLimit = 20
+
+// SetGapLimit sets how many unused addresses in a row RescanWallet checks
+// before it stops; values below 1 restore DefaultGapLimit
+// SetGapLimit mengatur batas alamat kosong berturut-turut saat rescan
+func (s *WalletService) SetGapLimit(limit int) {
+	if limit < 1 {
+		limit = DefaultGapLimit
+	}
+	s.gapLimit = limit
+}
+
+// GapLimit returns the gap limit used by RescanWallet
+func (s *WalletService) GapLimit() int {
+	return s.gapLimit
+}
+
+// RescanWallet discovers the used addresses of an HD or multisig wallet by
+// walking its receive and change chains until GapLimit addresses in a row have
+// no history, then records every used address with its balance and
+// transaction count and syncs the wallet. Wallets without an account key are
+// simply synced.
+// RescanWallet mencari semua alamat terpakai dari wallet HD lalu sinkronisasi
+func (s *WalletService) RescanWallet(ctx context.Context, walletID string) (*domain.Wallet, error) {
+	wallet, err := s.repo.FindByID(walletID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkNetwork(wallet); err != nil {
+		return nil, err
+	}
+
+	if err := s.watchWallet(ctx, wallet); err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]*network.AddressInfo)
+	if wallet.IsHD() {
+		for _, chain := range []uint32{domain.ChainReceive, domain.ChainChange} {
+			if err := s.discoverChain(ctx, wallet, chain, known); err != nil {
+				return nil, err
+			}
+		}
+		sort.SliceStable(wallet.Addresses, func(i, j int) bool {
+			a, b := wallet.Addresses[i], wallet.Addresses[j]
+			if a.Chain != b.Chain {
+				return a.Chain < b.Chain
+			}
+			return a.Index < b.Index
+		})
+	}
+
+	balance, used, err := s.updateAddressStats(ctx, wallet, known)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.refreshUTXOs(ctx, wallet); err != nil {
+		return nil, err
+	}
+
+	if err := s.syncTransactions(ctx, wallet, used); err != nil {
+		return nil, err
+	}
+
+	wallet.Balance = balance
+	wallet.UpdatedAt = time.Now()
+
+	if err := s.repo.Update(wallet); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrStorageOperation, err)
+	}
+
+	return wallet, nil
+}
+
+// discoverChain queries addresses of chain from index 0 until the gap limit is
+// reached past both the last used address and the addresses already handed
+// out, records the newly found used addresses and advances the chain's next index
+func (s *WalletService) discoverChain(ctx context.Context, wallet *domain.Wallet, chain uint32, known map[string]*network.AddressInfo) error {
+	next := wallet.ReceiveIndex
+	if chain == domain.ChainChange {
+		next = wallet.ChangeIndex
+	}
+
+	recorded := make(map[uint32]domain.DerivedAddress)
+	for _, derived := range wallet.Addresses {
+		if derived.Chain == chain {
+			recorded[derived.Index] = derived
+		}
+	}
+
+	var found []domain.DerivedAddress
+	lastUsed := int64(-1)
+	for index, unused := uint32(0), 0; unused < s.gapLimit; index++ {
+		derived, ok := recorded[index]
+		if !ok {
+			var err error
+			if derived, err = s.deriveWalletAddress(wallet, chain, index); err != nil {
+				return fmt.Errorf("%w: %v", domain.ErrKeyGeneration, err)
+			}
+		}
+
+		info, err := s.backend.GetAddressInfo(ctx, derived.Address)
+		if err != nil {
+			return fmt.Errorf("failed to fetch address from blockchain: %w", err)
+		}
+		known[derived.Address] = info
+
+		// The gap only counts from the first address not yet handed out
+		switch {
+		case info.IsUsed():
+			lastUsed = int64(index)
+			unused = 0
+		case index >= next:
+			unused++
+		}
+		if !ok {
+			found = append(found, derived)
+		}
+	}
+
+	// Addresses past the last used one stay underived until they are handed out
+	for _, derived := range found {
+		if int64(derived.Index) <= lastUsed {
+			wallet.Addresses = append(wallet.Addresses, derived)
+		}
+	}
+
+	if uint32(lastUsed+1) > next {
+		if chain == domain.ChainChange {
+			wallet.ChangeIndex = uint32(lastUsed + 1)
+		} else {
+			wallet.ReceiveIndex = uint32(lastUsed + 1)
+		}
+	}
+	return nil
+}
+
+// updateAddressStats fetches the statistics of every wallet address not in
+// known, records balance and transaction count on derived addresses and
+// returns the wallet's total balance and the addresses that have history
+func (s *WalletService) updateAddressStats(ctx context.Context, wallet *domain.Wallet, known map[string]*network.AddressInfo) (domain.Amount, []string, error) {
+	var balance domain.Amount
+	var used []string
+	for _, address := range wallet.AllAddresses() {
+		info, ok := known[address]
+		if !ok {
+			var err error
+			if info, err = s.backend.GetAddressInfo(ctx, address); err != nil {
+				return 0, nil, fmt.Errorf("failed to fetch balance from blockchain: %w", err)
+			}
+		}
+		balance += domain.Amount(info.Balance())
+		if info.IsUsed() {
+			used = append(used, address)
+		}
+
+		for i := range wallet.Addresses {
+			if wallet.Addresses[i].Address == address {
+				wallet.Addresses[i].Balance = domain.Amount(info.Balance())
+				wallet.Addresses[i].TxCount = int(info.TxCount())
+			}
+		}
+	}
+	return balance, used, nil
+}
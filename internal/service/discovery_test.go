@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dhfai/go-wallet/internal/domain"
+	"github.com/dhfai/go-wallet/pkg/network"
+)
+
+func TestDiscoverChain(t *testing.T) {
+	ctx := context.Background()
+	for _, tc := range []struct {
+		name      string
+		chain     uint32
+		gapLimit  int
+		handedOut uint32   // Addresses of the chain handed out before the rescan
+		used      []uint32 // Indexes with on-chain history
+		queried   int      // Addresses the walk looks up
+		next      uint32   // Next index after the rescan
+	}{
+		{"scattered", domain.ChainReceive, 20, 1, []uint32{0, 5, 24}, 45, 25},
+		{"unused", domain.ChainReceive, 20, 1, nil, 21, 1},
+		{"gap just too wide", domain.ChainReceive, 5, 1, []uint32{0, 6}, 6, 1},
+		{"gap just wide enough", domain.ChainReceive, 5, 1, []uint32{0, 5}, 11, 6},
+		{"used below handed out", domain.ChainReceive, 20, 10, []uint32{3}, 30, 10},
+		{"used past handed out", domain.ChainReceive, 20, 10, []uint32{12}, 33, 13},
+		{"change", domain.ChainChange, 20, 0, []uint32{2}, 23, 3},
+	} {
+		service, _, backend := newTestService(t)
+		service.SetGapLimit(tc.gapLimit)
+		wallet, _, err := service.CreateWallet("discovery", CreateWalletOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for wallet.ReceiveIndex < tc.handedOut {
+			if _, err := service.NewReceiveAddress(wallet.ID); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for wallet.ChangeIndex < tc.handedOut && tc.chain == domain.ChainChange {
+			if _, err := service.NewChangeAddress(wallet.ID); err != nil {
+				t.Fatal(err)
+			}
+		}
+		recorded := len(wallet.Addresses)
+
+		for _, index := range tc.used {
+			derived, err := service.deriveWalletAddress(wallet, tc.chain, index)
+			if err != nil {
+				t.Fatal(err)
+			}
+			backend.history[derived.Address] = []network.TxInfo{{TxID: "used"}}
+		}
+
+		backend.queried = nil
+		if err := service.discoverChain(ctx, wallet, tc.chain, make(map[string]*network.AddressInfo)); err != nil {
+			t.Errorf("%s: discoverChain: %v", tc.name, err)
+			continue
+		}
+
+		if len(backend.queried) != tc.queried {
+			t.Errorf("%s: queried %d addresses, want %d", tc.name, len(backend.queried), tc.queried)
+		}
+		if last, err := service.deriveWalletAddress(wallet, tc.chain, uint32(tc.queried-1)); err != nil || backend.queried[len(backend.queried)-1] != last.Address {
+			t.Errorf("%s: walk did not end at index %d", tc.name, tc.queried-1)
+		}
+
+		next := wallet.ReceiveIndex
+		if tc.chain == domain.ChainChange {
+			next = wallet.ChangeIndex
+		}
+		if next != tc.next {
+			t.Errorf("%s: next index = %d, want %d", tc.name, next, tc.next)
+		}
+
+		// Newly found addresses are kept up to the last used one, but not past it
+		for _, derived := range wallet.Addresses {
+			if derived.Chain == tc.chain && derived.Index >= tc.next {
+				t.Errorf("%s: address %d past the last used one was recorded", tc.name, derived.Index)
+			}
+		}
+		if added := len(wallet.Addresses) - recorded; added != int(tc.next-tc.handedOut) {
+			t.Errorf("%s: %d addresses recorded, want %d", tc.name, added, tc.next-tc.handedOut)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dhfai/go-wallet/internal/domain"
+	"github.com/dhfai/go-wallet/pkg/network"
+	"github.com/dhfai/go-wallet/pkg/txbuilder"
+)
+
+// SendOptions configures the fee and memo of SendBitcoin
+// SendOptions mengatur fee dan catatan SendBitcoin
+type SendOptions struct {
+	FeeRate    float64            // Fee rate in sat/vB; when zero it is estimated for Priority
+	Priority   domain.FeePriority // Confirmation priority (default normal)
+	Note       string             // Optional note/memo
+	DisableRBF bool               // Do not signal BIP125 replace-by-fee
+}
+
+// FeeEstimate is a fee rate chosen for a transaction
+// FeeEstimate adalah fee rate yang dipilih untuk transaksi
+type FeeEstimate struct {
+	Priority domain.FeePriority
+	FeeRate  float64 // sat/vB
+	Fallback bool    // The backend was unavailable and the local default rate is used
+	Capped   bool    // The estimate was above the maximum fee rate and lowered to it
+}
+
+// RecommendedFees returns the backend's fee rates for the next block and 3, 6 and 144 blocks
+// RecommendedFees mengembalikan fee rate dari backend per target konfirmasi
+func (s *WalletService) RecommendedFees(ctx context.Context) (*network.RecommendedFees, error) {
+	estimates, err := s.backend.GetFeeEstimates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return estimates.Recommended()
+}
+
+// SetFeePolicy sets the priority of sends that name neither a priority nor a
+// fee rate, and the highest fee rate in sat/vB the wallet pays (0 for no limit)
+// SetFeePolicy mengatur prioritas fee default dan fee rate maksimum
+func (s *WalletService) SetFeePolicy(priority domain.FeePriority, maxFeeRate float64) {
+	if priority == "" {
+		priority = domain.DefaultFeePriority
+	}
+	s.feePriority = priority
+	s.maxFeeRate = maxFeeRate
+}
+
+// EstimateFeeRate returns the fee rate for priority, the fee policy's when
+// empty, falling back to a local default when the backend cannot be reached.
+// Estimates above the maximum fee rate are lowered to it.
+// EstimateFeeRate mengembalikan fee rate untuk prioritas tertentu
+func (s *WalletService) EstimateFeeRate(ctx context.Context, priority domain.FeePriority) FeeEstimate {
+	if priority == "" {
+		priority = s.feePriority
+	}
+
+	estimate := FeeEstimate{Priority: priority}
+	estimates, err := s.backend.GetFeeEstimates(ctx)
+	if rate, ok := estimates.RateForTarget(priority.ConfirmationTarget()); err == nil && ok {
+		estimate.FeeRate = rate
+	} else {
+		estimate.FeeRate = priority.FallbackFeeRate()
+		estimate.Fallback = true
+	}
+
+	if estimate.FeeRate < txbuilder.MinRelayFeeRate {
+		estimate.FeeRate = txbuilder.MinRelayFeeRate
+	}
+	if s.maxFeeRate > 0 && estimate.FeeRate > s.maxFeeRate {
+		estimate.FeeRate = s.maxFeeRate
+		estimate.Capped = true
+	}
+	return estimate
+}
+
+// checkMaxFeeRate rejects an explicit fee rate above the fee policy's maximum
+func (s *WalletService) checkMaxFeeRate(feeRate float64) error {
+	if s.maxFeeRate > 0 && feeRate > s.maxFeeRate {
+		return fmt.Errorf("%w: %.2f sat/vB is above the maximum of %.2f sat/vB", domain.ErrFeeTooHigh, feeRate, s.maxFeeRate)
+	}
+	return nil
+}
+
+// feeRateFor resolves the fee rate of a send from an explicit rate or a priority
+func (s *WalletService) feeRateFor(ctx context.Context, opts SendOptions) (float64, error) {
+	if opts.FeeRate == 0 {
+		return s.EstimateFeeRate(ctx, opts.Priority).FeeRate, nil
+	}
+	if opts.FeeRate < txbuilder.MinRelayFeeRate {
+		return 0, fmt.Errorf("%w: %.2f sat/vB is below the minimum relay fee rate of %.0f sat/vB",
+			domain.ErrFeeTooLow, opts.FeeRate, txbuilder.MinRelayFeeRate)
+	}
+	if err := s.checkMaxFeeRate(opts.FeeRate); err != nil {
+		return 0, err
+	}
+	return opts.FeeRate, nil
+}
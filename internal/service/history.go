@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/dhfai/go-wallet/internal/domain"
+	"github.com/dhfai/go-wallet/pkg/network"
+)
+
+// syncTransactions fetches the on-chain history of the given wallet addresses
+// and merges it into the wallet's transactions. Known transactions get their
+// confirmation status, fee and size refreshed while keeping their note and
+// local details; unknown ones are classified and appended oldest first.
+// Transactions that vanished from the history are checked for being dropped
+// or double-spent.
+func (s *WalletService) syncTransactions(ctx context.Context, wallet *domain.Wallet, addresses []string) error {
+	tip, err := s.backend.GetTipHeight(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch block height from blockchain: %w", err)
+	}
+	wallet.SyncHeight = tip
+
+	seen := make(map[string]bool)
+	var onChain []network.TxInfo
+	for _, address := range addresses {
+		txs, err := s.backend.GetTransactionHistory(ctx, address)
+		if err != nil {
+			return fmt.Errorf("failed to fetch transaction history from blockchain: %w", err)
+		}
+		for _, tx := range txs {
+			if !seen[tx.TxID] {
+				seen[tx.TxID] = true
+				onChain = append(onChain, tx)
+			}
+		}
+	}
+
+	var added []domain.Transaction
+	for _, tx := range onChain {
+		merged := classifyTransaction(wallet, tx)
+		s.updateConfirmations(&merged, tx.Status, tip)
+		if existing, ok := wallet.FindTransaction(tx.TxID); ok {
+			existing.Status = merged.Status
+			existing.BlockHeight = merged.BlockHeight
+			existing.BlockHash = merged.BlockHash
+			existing.Confirmations = merged.Confirmations
+			existing.Coinbase = merged.Coinbase
+			existing.ConflictedBy = ""
+			if merged.Fee > 0 {
+				existing.Fee = merged.Fee
+				existing.VSize = merged.VSize
+				existing.FeeRate = merged.FeeRate
+			}
+			if tx.Status.Confirmed {
+				existing.Timestamp = merged.Timestamp
+			}
+			continue
+		}
+		added = append(added, merged)
+	}
+
+	// Esplora lists the newest transactions first; the wallet keeps them oldest first
+	sort.SliceStable(added, func(i, j int) bool {
+		a, b := added[i], added[j]
+		if (a.BlockHeight == 0) != (b.BlockHeight == 0) {
+			return b.BlockHeight == 0
+		}
+		if a.BlockHeight != b.BlockHeight {
+			return a.BlockHeight < b.BlockHeight
+		}
+		return a.Timestamp.Before(b.Timestamp)
+	})
+	wallet.Transactions = append(wallet.Transactions, added...)
+
+	if err := s.checkMissingTransactions(ctx, wallet, seen, tip); err != nil {
+		return err
+	}
+	markCoinbaseOutputs(wallet)
+	return nil
+}
+
+// classifyTransaction turns an on-chain transaction into the wallet's view of
+// it: a receive when none of its inputs are ours, a self-transfer when all
+// inputs and outputs are ours and a send otherwise. Amount is what left or
+// reached the wallet excluding the fee, which only counts when the wallet paid it.
+func classifyTransaction(wallet *domain.Wallet, tx network.TxInfo) domain.Transaction {
+	var ownedIn, ownedOut, external int64
+	var ownedInputs int
+	var ownInput, foreignInput, ownOutput, externalOutput string
+	var spent []domain.UTXO
+	for _, in := range tx.Vin {
+		if in.Prevout == nil {
+			if foreignInput == "" {
+				foreignInput = "coinbase"
+			}
+			continue
+		}
+		address := in.Prevout.ScriptPubKeyAddress
+		if wallet.OwnsAddress(address) {
+			ownedIn += in.Prevout.Value
+			ownedInputs++
+			spent = append(spent, domain.UTXO{
+				TxID:    in.TxID,
+				Vout:    in.Vout,
+				Value:   domain.Amount(in.Prevout.Value),
+				Address: address,
+			})
+			if ownInput == "" {
+				ownInput = address
+			}
+		} else if foreignInput == "" {
+			foreignInput = address
+		}
+	}
+	for _, out := range tx.Vout {
+		address := out.ScriptPubKeyAddress
+		if address != "" && wallet.OwnsAddress(address) {
+			ownedOut += out.Value
+			if ownOutput == "" {
+				ownOutput = address
+			}
+			continue
+		}
+		external += out.Value
+		if externalOutput == "" && address != "" {
+			externalOutput = address
+		}
+	}
+
+	record := domain.Transaction{
+		ID:        tx.TxID,
+		VSize:     tx.VSize(),
+		Status:    "pending",
+		Timestamp: time.Now(),
+		Inputs:    spent,
+	}
+	for _, in := range tx.Vin {
+		if in.Sequence < 0xfffffffe {
+			record.RBF = true
+		}
+		if in.IsCoinbase || in.Prevout == nil {
+			record.Coinbase = true
+		}
+	}
+	if tx.Status.Confirmed {
+		record.Timestamp = time.Unix(tx.Status.BlockTime, 0)
+	}
+
+	allInputsOwned := ownedInputs > 0 && ownedInputs == len(tx.Vin)
+	switch {
+	case ownedInputs == 0:
+		record.Type = "receive"
+		record.Amount = domain.Amount(ownedOut)
+		record.From = foreignInput
+		record.To = ownOutput
+	case allInputsOwned && external == 0:
+		record.Type = "self"
+		record.Amount = domain.Amount(ownedOut)
+		record.Fee = domain.Amount(tx.Fee)
+		record.From = ownInput
+		record.To = ownOutput
+	case allInputsOwned:
+		record.Type = "send"
+		record.Amount = domain.Amount(external)
+		record.Fee = domain.Amount(tx.Fee)
+		record.From = ownInput
+		record.To = externalOutput
+	default:
+		// Inputs from several parties, e.g. a coinjoin or payjoin: record the net change
+		if net := ownedOut - ownedIn; net >= 0 {
+			record.Type = "receive"
+			record.Amount = domain.Amount(net)
+			record.From = foreignInput
+			record.To = ownOutput
+		} else {
+			record.Type = "send"
+			record.Amount = domain.Amount(-net)
+			record.From = ownInput
+			record.To = externalOutput
+		}
+	}
+
+	if record.Fee > 0 && record.VSize > 0 {
+		record.FeeRate = float64(record.Fee) / float64(record.VSize)
+	}
+	return record
+}
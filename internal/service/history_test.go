@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/dhfai/go-wallet/internal/domain"
+	"github.com/dhfai/go-wallet/pkg/network"
+)
+
+// newHistoryWallet returns an HD wallet with its primary receive address and one change address
+func newHistoryWallet(t *testing.T, service *WalletService) (*domain.Wallet, string, string) {
+	t.Helper()
+	wallet, _, err := service.CreateWallet("history", CreateWalletOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	change, err := service.NewChangeAddress(wallet.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return wallet, wallet.Address, change.Address
+}
+
+func TestClassifyTransaction(t *testing.T) {
+	service, _, _ := newTestService(t)
+	wallet, own, change := newHistoryWallet(t, service)
+	const ext1, ext2 = "bcrt1qexternal1", "bcrt1qexternal2"
+
+	in := func(address string, value int64) network.TxInput {
+		return network.TxInput{TxID: fmt.Sprintf("%064x", value), Prevout: &network.TxOutput{ScriptPubKeyAddress: address, Value: value}, Sequence: 0xffffffff}
+	}
+	out := func(address string, value int64) network.TxOutput {
+		return network.TxOutput{ScriptPubKeyAddress: address, Value: value}
+	}
+
+	for _, tc := range []struct {
+		name     string
+		vin      []network.TxInput
+		vout     []network.TxOutput
+		fee      int64
+		kind     string
+		amount   int64
+		txFee    int64 // Fee recorded on the wallet's transaction
+		from, to string
+		inputs   int
+	}{
+		{"receive", []network.TxInput{in(ext1, 60000)}, []network.TxOutput{out(own, 50000), out(ext2, 9800)}, 200,
+			"receive", 50000, 0, ext1, own, 0},
+		{"receive to several addresses", []network.TxInput{in(ext1, 60000)}, []network.TxOutput{out(ext2, 44800), out(own, 10000), out(change, 5000)}, 200,
+			"receive", 15000, 0, ext1, own, 0},
+		{"multi-input send", []network.TxInput{in(own, 30000), in(change, 40000)}, []network.TxOutput{out(ext1, 50000), out(change, 19700)}, 300,
+			"send", 50000, 300, own, ext1, 2},
+		{"change only", []network.TxInput{in(own, 30000)}, []network.TxOutput{out(change, 29800)}, 200,
+			"self", 29800, 200, own, change, 1},
+		{"joint, net send", []network.TxInput{in(own, 50000), in(ext1, 50000)}, []network.TxOutput{out(ext2, 49900), out(change, 20000), out(ext1, 29900)}, 200,
+			"send", 30000, 0, own, ext2, 1},
+		{"joint, net receive", []network.TxInput{in(own, 10000), in(ext1, 60000)}, []network.TxOutput{out(change, 40000), out(ext1, 29800)}, 200,
+			"receive", 30000, 0, ext1, change, 1},
+		{"coinbase", []network.TxInput{{IsCoinbase: true, Sequence: 0xffffffff}}, []network.TxOutput{out(own, 5000000000), {Value: 0}}, 0,
+			"receive", 5000000000, 0, "coinbase", own, 0},
+	} {
+		tx := network.TxInfo{TxID: "t", Vin: tc.vin, Vout: tc.vout, Fee: tc.fee, Weight: 800}
+		got := classifyTransaction(wallet, tx)
+
+		if got.Type != tc.kind || got.Amount.Satoshis() != tc.amount || got.Fee.Satoshis() != tc.txFee {
+			t.Errorf("%s: type, amount, fee = %s, %d, %d, want %s, %d, %d", tc.name, got.Type, got.Amount, got.Fee, tc.kind, tc.amount, tc.txFee)
+		}
+		if got.From != tc.from || got.To != tc.to {
+			t.Errorf("%s: from, to = %s, %s, want %s, %s", tc.name, got.From, got.To, tc.from, tc.to)
+		}
+		if len(got.Inputs) != tc.inputs {
+			t.Errorf("%s: %d wallet inputs recorded, want %d", tc.name, len(got.Inputs), tc.inputs)
+		}
+		if got.Coinbase != (tc.name == "coinbase") {
+			t.Errorf("%s: coinbase = %v", tc.name, got.Coinbase)
+		}
+		if got.RBF {
+			t.Errorf("%s: final sequence numbers reported as RBF", tc.name)
+		}
+	}
+
+	// Any input below 0xfffffffe signals replace-by-fee
+	rbf := network.TxInfo{TxID: "r", Vin: []network.TxInput{in(own, 30000), {Prevout: &network.TxOutput{ScriptPubKeyAddress: own, Value: 1000}, Sequence: 0xfffffffd}}}
+	if !classifyTransaction(wallet, rbf).RBF {
+		t.Error("transaction with a BIP125 sequence not marked RBF")
+	}
+}
+
+func TestSyncTransactionsMerge(t *testing.T) {
+	ctx := context.Background()
+	service, _, backend := newTestService(t)
+	wallet, own, change := newHistoryWallet(t, service)
+	backend.tip = 100
+
+	txID := func(n int) string { return fmt.Sprintf("%064x", n) }
+	funding := network.TxInfo{
+		TxID:   txID(1),
+		Vin:    []network.TxInput{{TxID: txID(100), Prevout: &network.TxOutput{ScriptPubKeyAddress: testRecipient, Value: 80000}}},
+		Vout:   []network.TxOutput{{ScriptPubKeyAddress: own, Value: 70000}},
+		Status: network.TxStatus{Confirmed: true, BlockHeight: 90, BlockTime: 1700000000},
+	}
+	spend := network.TxInfo{
+		TxID:   txID(2),
+		Vin:    []network.TxInput{{TxID: txID(1), Prevout: &network.TxOutput{ScriptPubKeyAddress: own, Value: 70000}}},
+		Vout:   []network.TxOutput{{ScriptPubKeyAddress: testRecipient, Value: 20000}, {ScriptPubKeyAddress: change, Value: 49800}},
+		Fee:    200,
+		Weight: 561,
+		Status: network.TxStatus{Confirmed: true, BlockHeight: 98, BlockTime: 1700005000},
+	}
+	incoming := network.TxInfo{
+		TxID: txID(3),
+		Vin:  []network.TxInput{{TxID: txID(101), Prevout: &network.TxOutput{ScriptPubKeyAddress: testRecipient, Value: 9000}}},
+		Vout: []network.TxOutput{{ScriptPubKeyAddress: change, Value: 8000}},
+	}
+
+	// The wallet recorded its own send before it confirmed
+	wallet.AddTransaction(domain.Transaction{ID: spend.TxID, Type: "send", Amount: 20000, Fee: 200, Status: "pending", Note: "rent"})
+
+	// Newest first per address, with the spend listed under both addresses
+	backend.history[own] = []network.TxInfo{spend, funding}
+	backend.history[change] = []network.TxInfo{incoming, spend}
+
+	if err := service.syncTransactions(ctx, wallet, []string{own, change}); err != nil {
+		t.Fatalf("syncTransactions: %v", err)
+	}
+	if wallet.SyncHeight != 100 {
+		t.Errorf("sync height = %d, want 100", wallet.SyncHeight)
+	}
+
+	// The known send keeps its note and gets its confirmation; the others
+	// are appended oldest first and each listed once
+	var ids []string
+	for _, tx := range wallet.Transactions {
+		ids = append(ids, tx.ID)
+	}
+	if want := []string{spend.TxID, funding.TxID, incoming.TxID}; fmt.Sprint(ids) != fmt.Sprint(want) {
+		t.Fatalf("transactions = %v, want %v", ids, want)
+	}
+	sent := wallet.Transactions[0]
+	if sent.Note != "rent" || sent.Status != "confirmed" || sent.BlockHeight != 98 || sent.Confirmations != 3 {
+		t.Errorf("merged send = note %q, %s at %d with %d confirmations, want rent, confirmed at 98 with 3", sent.Note, sent.Status, sent.BlockHeight, sent.Confirmations)
+	}
+	if received := wallet.Transactions[1]; received.Type != "receive" || received.Amount != 70000 || received.Status != "final" {
+		t.Errorf("funding = %s of %d, %s, want a final receive of 70000", received.Type, received.Amount, received.Status)
+	}
+	if pending := wallet.Transactions[2]; pending.Type != "receive" || pending.Amount != 8000 || pending.Status != "pending" {
+		t.Errorf("incoming = %s of %d, %s, want a pending receive of 8000", pending.Type, pending.Amount, pending.Status)
+	}
+
+	// A second sync changes nothing
+	if err := service.syncTransactions(ctx, wallet, []string{own, change}); err != nil {
+		t.Fatalf("second syncTransactions: %v", err)
+	}
+	if len(wallet.Transactions) != 3 {
+		t.Errorf("second sync left %d transactions, want 3", len(wallet.Transactions))
+	}
+}
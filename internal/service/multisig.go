@@ -0,0 +1,482 @@
+package service
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dhfai/go-wallet/internal/domain"
+	"github.com/dhfai/go-wallet/pkg/crypto"
+	"github.com/dhfai/go-wallet/pkg/psbt"
+	"github.com/dhfai/go-wallet/pkg/txbuilder"
+	"github.com/google/uuid"
+)
+
+// MultisigOptions configures a new m-of-n multisig wallet
+// MultisigOptions berisi pengaturan wallet multisig m-of-n
+type MultisigOptions struct {
+	Threshold   int                // Signatures required to spend (m)
+	AddressType domain.AddressType // p2wsh (default) or p2sh-p2wsh
+	Cosigners   []string           // Cosigner keys as [fingerprint/path]xpub
+	Mnemonic    string             // Optional recovery phrase of a cosigner whose key signs locally
+	Passphrase  string             // Optional BIP39 passphrase of Mnemonic
+}
+
+// CreateMultisig creates a multisig wallet from the account keys of its
+// cosigners. Addresses pay to a P2WSH (or P2SH-P2WSH) script of the sorted
+// cosigner keys (BIP67). With a recovery phrase, the local cosigner's BIP48
+// account key is added and kept so the wallet can sign PSBTs; otherwise the
+// wallet is watch-only and acts as a coordinator.
+// CreateMultisig membuat wallet multisig dari account key para cosigner
+func (s *WalletService) CreateMultisig(name string, opts MultisigOptions) (*domain.Wallet, error) {
+	if name == "" {
+		return nil, fmt.Errorf("wallet name cannot be empty")
+	}
+
+	addressType := opts.AddressType
+	if addressType == "" {
+		addressType = domain.DefaultMultisigAddressType
+	}
+	if !addressType.IsMultisig() {
+		return nil, fmt.Errorf("%w: %s is not a multisig address type", domain.ErrInvalidMultisig, addressType)
+	}
+
+	config := &domain.MultisigConfig{Threshold: opts.Threshold}
+	for _, expr := range opts.Cosigners {
+		cosigner, err := s.parseCosigner(expr)
+		if err != nil {
+			return nil, err
+		}
+		config.Cosigners = append(config.Cosigners, cosigner)
+	}
+
+	wallet := &domain.Wallet{
+		ID:           uuid.New().String(),
+		Name:         name,
+		AddressType:  addressType,
+		Network:      s.params.Name,
+		Transactions: []domain.Transaction{},
+		WatchOnly:    true,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+
+	// The local cosigner keeps its account key to sign its share of each PSBT
+	if opts.Mnemonic != "" {
+		if err := s.requireUnlocked(); err != nil {
+			return nil, err
+		}
+
+		local, account, err := s.localCosigner(config.Cosigners, opts.Mnemonic, opts.Passphrase, addressType)
+		if err != nil {
+			return nil, err
+		}
+		if !containsCosigner(config.Cosigners, local) {
+			config.Cosigners = append(config.Cosigners, local)
+		}
+
+		wallet.WatchOnly = false
+		wallet.MasterFingerprint = local.Fingerprint
+		wallet.DerivationPath = local.DerivationPath
+		wallet.AccountXpub = local.Xpub
+		wallet.AccountXprv = account.String()
+	}
+
+	if err := validateMultisig(config); err != nil {
+		return nil, err
+	}
+	wallet.Multisig = config
+
+	// The first receive address (0/0) is the wallet's primary address
+	first, err := s.nextAddress(wallet, domain.ChainReceive)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrKeyGeneration, err)
+	}
+	wallet.Address = first.Address
+
+	if existing, err := s.repo.FindByAddress(wallet.Address); err == nil && existing != nil {
+		return nil, fmt.Errorf("%w: %s is already tracked by %s", domain.ErrWalletExists, wallet.Address, existing.Name)
+	}
+
+	if err := s.repo.Save(wallet); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrStorageOperation, err)
+	}
+
+	return wallet, nil
+}
+
+// MultisigKey returns the BIP48 account key of a recovery phrase as
+// [fingerprint/path]xpub, the form cosigners share to set up a multisig wallet
+// MultisigKey mengembalikan account key BIP48 dari recovery phrase untuk dibagikan ke cosigner lain
+func (s *WalletService) MultisigKey(mnemonic, passphrase string, addressType domain.AddressType) (string, error) {
+	if addressType == "" {
+		addressType = domain.DefaultMultisigAddressType
+	}
+	if !addressType.IsMultisig() {
+		return "", fmt.Errorf("%w: %s is not a multisig address type", domain.ErrInvalidMultisig, addressType)
+	}
+
+	cosigner, _, err := s.multisigAccount(mnemonic, passphrase, s.multisigAccountPath(addressType))
+	if err != nil {
+		return "", err
+	}
+	return FormatCosigner(cosigner), nil
+}
+
+// ExportMultisigConfig renders a multisig wallet as a setup file in the text
+// format read by Coldcard, Sparrow, Specter and other coordinators
+// ExportMultisigConfig mengekspor konfigurasi wallet multisig sebagai file teks
+func (s *WalletService) ExportMultisigConfig(walletID string) (string, error) {
+	wallet, err := s.repo.FindByID(walletID)
+	if err != nil {
+		return "", err
+	}
+	if !wallet.IsMultisig() {
+		return "", fmt.Errorf("%w: %s is not a multisig wallet", domain.ErrInvalidMultisig, wallet.Name)
+	}
+
+	format := "P2WSH"
+	if wallet.ScriptType() == domain.AddressP2SHP2WSH {
+		format = "P2SH-P2WSH"
+	}
+
+	var sb strings.Builder
+	sb.WriteString("# Multisig setup file (exported by go-wallet)\n#\n")
+	fmt.Fprintf(&sb, "Name: %s\n", wallet.Name)
+	fmt.Fprintf(&sb, "Policy: %d of %d\n", wallet.Multisig.Threshold, len(wallet.Multisig.Cosigners))
+	fmt.Fprintf(&sb, "Format: %s\n", format)
+	for _, cosigner := range wallet.Multisig.Cosigners {
+		fmt.Fprintf(&sb, "\nDerivation: %s\n", cosigner.DerivationPath)
+		fmt.Fprintf(&sb, "%s: %s\n", strings.ToUpper(cosigner.Fingerprint), cosigner.Xpub)
+	}
+
+	return sb.String(), nil
+}
+
+// ImportMultisigConfig creates a multisig wallet from a setup file written by
+// ExportMultisigConfig or another coordinator. name overrides the file's name
+// when set; mnemonic and passphrase work as in CreateMultisig.
+// ImportMultisigConfig membuat wallet multisig dari file konfigurasi
+func (s *WalletService) ImportMultisigConfig(name, config, mnemonic, passphrase string) (*domain.Wallet, error) {
+	fileName, opts, err := parseMultisigConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		name = fileName
+	}
+
+	opts.Mnemonic = mnemonic
+	opts.Passphrase = passphrase
+	return s.CreateMultisig(name, opts)
+}
+
+// FormatCosigner renders a cosigner key as [fingerprint/path]xpub
+func FormatCosigner(cosigner domain.Cosigner) string {
+	path := strings.TrimPrefix(strings.TrimPrefix(cosigner.DerivationPath, "m"), "/")
+	if path == "" {
+		return fmt.Sprintf("[%s]%s", cosigner.Fingerprint, cosigner.Xpub)
+	}
+	return fmt.Sprintf("[%s/%s]%s", cosigner.Fingerprint, path, cosigner.Xpub)
+}
+
+// parseCosigner parses a cosigner key written as [fingerprint/path]xpub.
+// The origin is required so that hardware signers can find their key.
+func (s *WalletService) parseCosigner(expr string) (domain.Cosigner, error) {
+	expr = strings.TrimSpace(expr)
+	end := strings.Index(expr, "]")
+	if !strings.HasPrefix(expr, "[") || end < 0 {
+		return domain.Cosigner{}, fmt.Errorf("%w: cosigner key %q needs its origin, e.g. [d34db33f/48'/0'/0'/2']xpub...", domain.ErrInvalidMultisig, expr)
+	}
+
+	origin := strings.SplitN(expr[1:end], "/", 2)
+	fingerprint, err := hex.DecodeString(origin[0])
+	if err != nil || len(fingerprint) != 4 {
+		return domain.Cosigner{}, fmt.Errorf("%w: invalid fingerprint %q", domain.ErrInvalidMultisig, origin[0])
+	}
+
+	path := "m"
+	if len(origin) == 2 {
+		path += "/" + origin[1]
+	}
+	indices, err := crypto.ParseDerivationPath(path)
+	if err != nil {
+		return domain.Cosigner{}, fmt.Errorf("%w: %v", domain.ErrInvalidMultisig, err)
+	}
+
+	return s.cosignerFromKey(hex.EncodeToString(fingerprint), crypto.FormatDerivationPath(indices), expr[end+1:])
+}
+
+// cosignerFromKey validates an account xpub of the service network and normalizes its version
+func (s *WalletService) cosignerFromKey(fingerprint, path, encoded string) (domain.Cosigner, error) {
+	key, err := s.crypto.ParseExtendedKey(encoded)
+	if err != nil {
+		return domain.Cosigner{}, fmt.Errorf("%w: %v", domain.ErrInvalidMultisig, err)
+	}
+	if key.IsPrivate() {
+		return domain.Cosigner{}, fmt.Errorf("%w: cosigners share extended public keys, not private keys", domain.ErrInvalidMultisig)
+	}
+	if !key.IsForNetwork(s.params) {
+		return domain.Cosigner{}, fmt.Errorf("%w: cosigner key is not for %s", domain.ErrNetworkMismatch, s.params.Name)
+	}
+
+	return domain.Cosigner{
+		Fingerprint:    strings.ToLower(fingerprint),
+		DerivationPath: path,
+		Xpub:           key.WithVersion(s.params.HDPublicKeyID).String(),
+	}, nil
+}
+
+// multisigAccountPath returns the BIP48 account path for a multisig address type on the service network
+func (s *WalletService) multisigAccountPath(addressType domain.AddressType) string {
+	scriptType := 2
+	if addressType == domain.AddressP2SHP2WSH {
+		scriptType = 1
+	}
+	return fmt.Sprintf("m/%d'/%d'/0'/%d'", addressType.Purpose(), s.params.HDCoinType, scriptType)
+}
+
+// localCosigner derives the account key of a recovery phrase. When a cosigner
+// has the phrase's master fingerprint its path is used and its key must match;
+// otherwise the key is derived at the BIP48 account path of addressType.
+func (s *WalletService) localCosigner(cosigners []domain.Cosigner, mnemonic, passphrase string, addressType domain.AddressType) (domain.Cosigner, *crypto.ExtendedKey, error) {
+	local, account, err := s.multisigAccount(mnemonic, passphrase, s.multisigAccountPath(addressType))
+	if err != nil {
+		return domain.Cosigner{}, nil, err
+	}
+
+	for _, cosigner := range cosigners {
+		if cosigner.Fingerprint != local.Fingerprint || cosigner.Xpub == local.Xpub {
+			continue
+		}
+		local, account, err = s.multisigAccount(mnemonic, passphrase, cosigner.DerivationPath)
+		if err != nil {
+			return domain.Cosigner{}, nil, err
+		}
+		if local.Xpub != cosigner.Xpub {
+			return domain.Cosigner{}, nil, fmt.Errorf("%w: the recovery phrase does not produce the key of cosigner %s", domain.ErrInvalidMultisig, cosigner.Fingerprint)
+		}
+		break
+	}
+
+	return local, account, nil
+}
+
+// multisigAccount derives the account key at path of a recovery phrase
+func (s *WalletService) multisigAccount(mnemonic, passphrase, path string) (domain.Cosigner, *crypto.ExtendedKey, error) {
+	if err := s.crypto.ValidateMnemonic(mnemonic); err != nil {
+		return domain.Cosigner{}, nil, fmt.Errorf("%w: %v", domain.ErrInvalidMnemonic, err)
+	}
+
+	master, err := s.crypto.NewMasterKey(s.crypto.MnemonicToSeed(mnemonic, passphrase))
+	if err != nil {
+		return domain.Cosigner{}, nil, fmt.Errorf("%w: %v", domain.ErrKeyGeneration, err)
+	}
+
+	indices, err := crypto.ParseDerivationPath(path)
+	if err != nil {
+		return domain.Cosigner{}, nil, fmt.Errorf("%w: %v", domain.ErrKeyGeneration, err)
+	}
+	account, err := master.DerivePath(indices...)
+	if err != nil {
+		return domain.Cosigner{}, nil, fmt.Errorf("%w: %v", domain.ErrKeyGeneration, err)
+	}
+
+	cosigner := domain.Cosigner{
+		Fingerprint:    hex.EncodeToString(master.Fingerprint()),
+		DerivationPath: path,
+		Xpub:           account.Neuter().String(),
+	}
+	return cosigner, account, nil
+}
+
+// validateMultisig checks the threshold and that no cosigner key appears twice
+func validateMultisig(config *domain.MultisigConfig) error {
+	n := len(config.Cosigners)
+	if n == 0 || n > txbuilder.MaxMultisigKeys {
+		return fmt.Errorf("%w: need 1 to %d cosigners, got %d", domain.ErrInvalidMultisig, txbuilder.MaxMultisigKeys, n)
+	}
+	if config.Threshold < 1 || config.Threshold > n {
+		return fmt.Errorf("%w: threshold must be between 1 and %d, got %d", domain.ErrInvalidMultisig, n, config.Threshold)
+	}
+
+	seen := make(map[string]bool, n)
+	for _, cosigner := range config.Cosigners {
+		if seen[cosigner.Xpub] {
+			return fmt.Errorf("%w: cosigner %s appears twice", domain.ErrInvalidMultisig, cosigner.Fingerprint)
+		}
+		seen[cosigner.Xpub] = true
+	}
+	return nil
+}
+
+func containsCosigner(cosigners []domain.Cosigner, cosigner domain.Cosigner) bool {
+	for _, existing := range cosigners {
+		if existing.Xpub == cosigner.Xpub {
+			return true
+		}
+	}
+	return false
+}
+
+// multisigWitnessScript returns the sorted multisig script of the cosigner keys at chain/index
+// together with the key and origin of each cosigner, in cosigner order
+func (s *WalletService) multisigWitnessScript(config *domain.MultisigConfig, chain, index uint32) ([]byte, []psbt.Bip32Derivation, error) {
+	pubKeys := make([][]byte, 0, len(config.Cosigners))
+	origins := make([]psbt.Bip32Derivation, 0, len(config.Cosigners))
+	for _, cosigner := range config.Cosigners {
+		account, err := s.crypto.ParseExtendedKey(cosigner.Xpub)
+		if err != nil {
+			return nil, nil, err
+		}
+		child, err := account.DerivePath(chain, index)
+		if err != nil {
+			return nil, nil, err
+		}
+		pubKey := child.PublicKeyBytes()
+		pubKeys = append(pubKeys, pubKey)
+
+		origin := psbt.Bip32Derivation{PubKey: pubKey}
+		fingerprint, fpErr := hex.DecodeString(cosigner.Fingerprint)
+		path, pathErr := crypto.ParseDerivationPath(cosigner.DerivationPath)
+		if fpErr == nil && pathErr == nil && len(fingerprint) == 4 {
+			copy(origin.Fingerprint[:], fingerprint)
+			origin.Path = append(path, chain, index)
+		}
+		origins = append(origins, origin)
+	}
+
+	script, err := txbuilder.MultisigScript(config.Threshold, pubKeys)
+	if err != nil {
+		return nil, nil, err
+	}
+	return script, origins, nil
+}
+
+// deriveMultisigAddress derives the multisig address at chain/index
+func (s *WalletService) deriveMultisigAddress(wallet *domain.Wallet, chain, index uint32) (domain.DerivedAddress, error) {
+	witnessScript, _, err := s.multisigWitnessScript(wallet.Multisig, chain, index)
+	if err != nil {
+		return domain.DerivedAddress{}, err
+	}
+
+	pkScript := txbuilder.P2WSHScript(witnessScript)
+	if wallet.ScriptType() == domain.AddressP2SHP2WSH {
+		pkScript = txbuilder.P2SHScript(s.crypto.Hash160(pkScript))
+	}
+
+	address, err := txbuilder.ScriptAddress(pkScript, s.params)
+	if err != nil {
+		return domain.DerivedAddress{}, err
+	}
+
+	return domain.DerivedAddress{Address: address, Chain: chain, Index: index}, nil
+}
+
+// multisigScripts returns the redeem script (nested only), witness script and
+// cosigner key origins of a multisig wallet address
+func (s *WalletService) multisigScripts(wallet *domain.Wallet, address string) ([]byte, []byte, []psbt.Bip32Derivation, bool) {
+	derived, ok := wallet.FindAddress(address)
+	if !ok {
+		return nil, nil, nil, false
+	}
+
+	witnessScript, origins, err := s.multisigWitnessScript(wallet.Multisig, derived.Chain, derived.Index)
+	if err != nil {
+		return nil, nil, nil, false
+	}
+
+	var redeemScript []byte
+	if wallet.ScriptType() == domain.AddressP2SHP2WSH {
+		redeemScript = txbuilder.P2WSHScript(witnessScript)
+	}
+	return redeemScript, witnessScript, origins, true
+}
+
+// parseMultisigConfig reads a Coldcard-style multisig setup file
+func parseMultisigConfig(config string) (string, MultisigOptions, error) {
+	var name string
+	var opts MultisigOptions
+	n := 0
+	path := ""
+
+	scanner := bufio.NewScanner(strings.NewReader(config))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			return "", opts, fmt.Errorf("%w: unexpected line %q", domain.ErrInvalidMultisig, line)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+
+		switch strings.ToLower(key) {
+		case "name":
+			name = value
+		case "policy":
+			m, total, err := parsePolicy(value)
+			if err != nil {
+				return "", opts, err
+			}
+			opts.Threshold, n = m, total
+		case "format":
+			addressType, err := domain.ParseMultisigAddressType(value)
+			if err != nil {
+				return "", opts, fmt.Errorf("%w: %v", domain.ErrInvalidMultisig, err)
+			}
+			opts.AddressType = addressType
+		case "derivation":
+			path = value
+		default:
+			// Any other line is "<fingerprint>: <xpub>" for the last derivation path
+			if len(key) != 8 {
+				return "", opts, fmt.Errorf("%w: unexpected line %q", domain.ErrInvalidMultisig, line)
+			}
+			if path == "" {
+				return "", opts, fmt.Errorf("%w: key %s has no derivation path", domain.ErrInvalidMultisig, key)
+			}
+			origin := strings.TrimPrefix(strings.TrimPrefix(path, "m"), "/")
+			if origin != "" {
+				origin = "/" + origin
+			}
+			opts.Cosigners = append(opts.Cosigners, fmt.Sprintf("[%s%s]%s", strings.ToLower(key), origin, value))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", opts, err
+	}
+
+	if n != 0 && n != len(opts.Cosigners) {
+		return "", opts, fmt.Errorf("%w: policy names %d cosigners but the file lists %d", domain.ErrInvalidMultisig, n, len(opts.Cosigners))
+	}
+	return name, opts, nil
+}
+
+// parsePolicy parses a policy such as "2 of 3" or "2/3"
+func parsePolicy(policy string) (int, int, error) {
+	fields := strings.FieldsFunc(strings.ToLower(policy), func(r rune) bool {
+		return r == ' ' || r == '/'
+	})
+	if len(fields) == 3 && fields[1] == "of" {
+		fields = []string{fields[0], fields[2]}
+	}
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("%w: invalid policy %q", domain.ErrInvalidMultisig, policy)
+	}
+
+	m, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: invalid policy %q", domain.ErrInvalidMultisig, policy)
+	}
+	n, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: invalid policy %q", domain.ErrInvalidMultisig, policy)
+	}
+	return m, n, nil
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dhfai/go-wallet/internal/domain"
+	"github.com/dhfai/go-wallet/pkg/chaincfg"
+)
+
+// Master keys of BIP32 test vectors 1 and 2. At 0/0 the key of the first
+// sorts after the key of the second, so BIP67 ordering changes the script.
+var testCosigners = []string{
+	"[3442193e/48'/0'/0'/2']xpub661MyMwAqRbcFtXgS5sYJABqqG9YLmC4Q1Rdap9gSE8NqtwybGhePY2gZ29ESFjqJoCu1Rupje8YtGqsefD265TMg7usUDFdp6W1EGMcet8",
+	"[bd16bee5/48'/0'/0'/2']xpub661MyMwAqRbcFW31YEwpkMuc5THy2PSt5bDMsktWQcFF8syAmRUapSCGu8ED9W6oDMSgv6Zz8idoc4a6mr8BDzTJY47LJhkJ8UB7WEGuduB",
+}
+
+func newMainnetService() *WalletService {
+	service := NewWalletService(newMemRepo(), &chaincfg.MainNetParams)
+	service.SetBackend(newFakeBackend())
+	return service
+}
+
+func TestMultisigAddresses(t *testing.T) {
+	// 2-of-2 sortedmulti of the child keys at chain/index, derived independently
+	for _, tc := range []struct {
+		addressType  domain.AddressType
+		chain, index uint32
+		want         string
+	}{
+		{domain.AddressP2WSH, 0, 0, "bc1qpsnmhwhv2cvnyp25efl8xvpyyenc8qr7zt0fj47dklj0txpc4e0q7vlzgy"},
+		{domain.AddressP2WSH, 1, 3, "bc1q7ffq2dcl5s40fj7vmqzfh30fwgvrlytgqfqhx2ar3gluud2pc8rsvwjwxg"},
+		{domain.AddressP2SHP2WSH, 0, 0, "35MLEwGPsDu4gssZeuQjyah7GkBWccsB9o"},
+		{domain.AddressP2SHP2WSH, 1, 3, "38F1CqyUGUmseS3LQ15yNWLub4vmHS1svB"},
+	} {
+		service := newMainnetService()
+		wallet, err := service.CreateMultisig("vault", MultisigOptions{Threshold: 2, AddressType: tc.addressType, Cosigners: testCosigners})
+		if err != nil {
+			t.Fatalf("%s: CreateMultisig: %v", tc.addressType, err)
+		}
+
+		derived, err := service.deriveMultisigAddress(wallet, tc.chain, tc.index)
+		if err != nil {
+			t.Errorf("%s %d/%d: deriveMultisigAddress: %v", tc.addressType, tc.chain, tc.index, err)
+			continue
+		}
+		if derived.Address != tc.want {
+			t.Errorf("%s %d/%d address = %s, want %s", tc.addressType, tc.chain, tc.index, derived.Address, tc.want)
+		}
+		if tc.chain == 0 && tc.index == 0 && wallet.Address != tc.want {
+			t.Errorf("%s primary address = %s, want %s", tc.addressType, wallet.Address, tc.want)
+		}
+
+		// Listing the cosigners in the other order gives the same addresses
+		reversed := []string{testCosigners[1], testCosigners[0]}
+		other, err := newMainnetService().CreateMultisig("vault", MultisigOptions{Threshold: 2, AddressType: tc.addressType, Cosigners: reversed})
+		if err != nil {
+			t.Fatalf("%s: CreateMultisig with reversed cosigners: %v", tc.addressType, err)
+		}
+		if other.Address != wallet.Address {
+			t.Errorf("%s: address depends on cosigner order: %s vs %s", tc.addressType, other.Address, wallet.Address)
+		}
+	}
+}
+
+func TestMultisigConfigRoundTrip(t *testing.T) {
+	for _, addressType := range []domain.AddressType{domain.AddressP2WSH, domain.AddressP2SHP2WSH} {
+		service := newMainnetService()
+		wallet, err := service.CreateMultisig("vault", MultisigOptions{Threshold: 2, AddressType: addressType, Cosigners: testCosigners})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		config, err := service.ExportMultisigConfig(wallet.ID)
+		if err != nil {
+			t.Fatalf("ExportMultisigConfig: %v", err)
+		}
+		name, opts, err := parseMultisigConfig(config)
+		if err != nil {
+			t.Fatalf("%s: parseMultisigConfig of\n%s: %v", addressType, config, err)
+		}
+		if name != "vault" || opts.Threshold != 2 || opts.AddressType != addressType {
+			t.Errorf("%s: parsed name, threshold, type = %q, %d, %s", addressType, name, opts.Threshold, opts.AddressType)
+		}
+		if !reflect.DeepEqual(opts.Cosigners, testCosigners) {
+			t.Errorf("%s: parsed cosigners = %q, want %q", addressType, opts.Cosigners, testCosigners)
+		}
+
+		// The file sets up the same wallet elsewhere
+		imported, err := newMainnetService().ImportMultisigConfig("", config, "", "")
+		if err != nil {
+			t.Fatalf("%s: ImportMultisigConfig: %v", addressType, err)
+		}
+		if imported.Address != wallet.Address || !reflect.DeepEqual(imported.Multisig, wallet.Multisig) {
+			t.Errorf("%s: imported wallet differs: %s, %+v", addressType, imported.Address, imported.Multisig)
+		}
+	}
+}
+
+func TestParseMultisigConfigColdcard(t *testing.T) {
+	// Layout written by Coldcard, with upper case fingerprints and a shared derivation
+	config := strings.Join([]string{
+		"# Coldcard Multisig setup file (created on 5F2E0A3C)",
+		"#",
+		"Name: CC-2-of-2",
+		"Policy: 2 of 2",
+		"Derivation: m/48'/0'/0'/2'",
+		"Format: P2WSH",
+		"",
+		"3442193E: " + testCosigners[0][strings.Index(testCosigners[0], "]")+1:],
+		"BD16BEE5: " + testCosigners[1][strings.Index(testCosigners[1], "]")+1:],
+	}, "\n")
+
+	name, opts, err := parseMultisigConfig(config)
+	if err != nil {
+		t.Fatalf("parseMultisigConfig: %v", err)
+	}
+	if name != "CC-2-of-2" || opts.Threshold != 2 || opts.AddressType != domain.AddressP2WSH {
+		t.Errorf("parsed name, threshold, type = %q, %d, %s", name, opts.Threshold, opts.AddressType)
+	}
+	if !reflect.DeepEqual(opts.Cosigners, testCosigners) {
+		t.Errorf("parsed cosigners = %q, want %q", opts.Cosigners, testCosigners)
+	}
+
+	for _, bad := range []string{
+		"Policy: 2 of 3\nDerivation: m/48'/0'/0'/2'\n3442193E: xpub",
+		"3442193E: xpub",
+		"Policy: two of three",
+		"not a setting",
+	} {
+		if _, _, err := parseMultisigConfig(bad); err == nil {
+			t.Errorf("parseMultisigConfig(%q) succeeded", bad)
+		}
+	}
+}
@@ -0,0 +1,420 @@
+package service
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/dhfai/go-wallet/internal/domain"
+	"github.com/dhfai/go-wallet/pkg/crypto"
+	"github.com/dhfai/go-wallet/pkg/psbt"
+	"github.com/dhfai/go-wallet/pkg/txbuilder"
+)
+
+// CreatePSBT funds a payment from the wallet like SendBitcoin but returns it
+// unsigned as a PSBT, with the UTXOs, scripts and key origins a signer needs.
+// No private keys are used, so the packet can be signed on another machine.
+// CreatePSBT menyusun pembayaran sebagai PSBT yang belum ditandatangani
+func (s *WalletService) CreatePSBT(ctx context.Context, walletID, toAddress string, amount domain.Amount, opts SendOptions) (*psbt.Packet, error) {
+	if amount <= 0 || opts.FeeRate < 0 {
+		return nil, domain.ErrInvalidAmount
+	}
+
+	wallet, err := s.repo.FindByID(walletID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkNetwork(wallet); err != nil {
+		return nil, err
+	}
+
+	funded, err := s.fundTransaction(ctx, wallet, toAddress, amount, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	packet, err := psbt.New(funded.builder.Transaction())
+	if err != nil {
+		return nil, err
+	}
+
+	packet.XPubs = append(packet.XPubs, s.globalXPubs(wallet)...)
+
+	for i, input := range funded.inputs {
+		if err := packet.SetWitnessUTXO(i, funded.builder.PrevOut(i)); err != nil {
+			return nil, err
+		}
+
+		// Legacy inputs are signed over the full previous transaction
+		if input.ScriptType == domain.AddressP2PKH {
+			rawTx, err := s.backend.GetRawTransaction(ctx, input.TxID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch transaction %s: %w", input.TxID, err)
+			}
+			prevTx, err := txbuilder.ParseTransactionHex(rawTx)
+			if err != nil {
+				return nil, fmt.Errorf("invalid transaction %s: %w", input.TxID, err)
+			}
+			if err := packet.SetNonWitnessUTXO(i, prevTx); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := s.describeInput(packet, i, wallet, input.Address); err != nil {
+			return nil, err
+		}
+	}
+
+	// Let signers recognise the change output as the wallet's own
+	if funded.change > 0 {
+		if err := s.describeOutput(packet, 1, wallet, funded.changeAddress); err != nil {
+			return nil, err
+		}
+	}
+
+	// Keep the derived change address
+	if err := s.repo.Update(wallet); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrStorageOperation, err)
+	}
+
+	return packet, nil
+}
+
+// SignPSBT adds the wallet's signatures to every input it can sign and returns
+// how many inputs were signed. Inputs of other wallets are left untouched.
+// SignPSBT menandatangani input PSBT yang dimiliki wallet
+func (s *WalletService) SignPSBT(walletID string, packet *psbt.Packet) (int, error) {
+	wallet, err := s.repo.FindByID(walletID)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := s.checkNetwork(wallet); err != nil {
+		return 0, err
+	}
+
+	if err := requireSigningKeys(wallet); err != nil {
+		return 0, err
+	}
+
+	if err := s.requireUnlocked(); err != nil {
+		return 0, err
+	}
+
+	signed := 0
+	for i := range packet.Inputs {
+		if packet.Inputs[i].IsFinalized() {
+			continue
+		}
+
+		utxo, err := packet.InputUTXO(i)
+		if err != nil {
+			return signed, err
+		}
+		address, ok := s.addressForScript(wallet, utxo.PkScript)
+		if !ok {
+			continue
+		}
+
+		privKey, err := s.privateKeyForAddress(wallet, address)
+		if err != nil {
+			return signed, fmt.Errorf("%w: %v", domain.ErrInvalidPrivateKey, err)
+		}
+
+		// A packet from another coordinator may lack the scripts of a multisig input
+		if wallet.IsMultisig() && packet.Inputs[i].WitnessScript == nil {
+			if err := s.describeInput(packet, i, wallet, address); err != nil {
+				return signed, err
+			}
+		}
+
+		// A packet from another wallet may lack the redeem script of a nested SegWit input
+		if !wallet.IsMultisig() && packet.Inputs[i].RedeemScript == nil && txbuilder.ClassifyScript(utxo.PkScript) == txbuilder.ScriptP2SH {
+			pubKeyHash := s.crypto.Hash160(s.crypto.SerializeCompressed(&privKey.PublicKey))
+			packet.Inputs[i].RedeemScript = s.crypto.NestedSegWitRedeemScript(pubKeyHash)
+		}
+
+		if err := packet.Sign(i, privKey); err != nil {
+			return signed, fmt.Errorf("failed to sign input %d: %w", i, err)
+		}
+		signed++
+	}
+
+	return signed, nil
+}
+
+// BroadcastPSBT finalizes a fully signed PSBT, broadcasts the extracted
+// transaction and records it in every wallet whose outputs it spends. The
+// returned record carries the txid, fee and size of the whole transaction.
+// BroadcastPSBT memfinalisasi PSBT yang sudah ditandatangani lalu menyiarkannya
+func (s *WalletService) BroadcastPSBT(ctx context.Context, packet *psbt.Packet) (*domain.Transaction, error) {
+	if err := packet.Finalize(); err != nil {
+		return nil, err
+	}
+
+	tx, err := packet.Extract()
+	if err != nil {
+		return nil, err
+	}
+
+	// Every input carries its UTXO once finalized, so the fee is known
+	fee, err := packet.Fee()
+	if err != nil {
+		return nil, err
+	}
+
+	reported, err := s.backend.BroadcastTransaction(ctx, tx.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("failed to broadcast transaction: %w", err)
+	}
+	txID := tx.TxID()
+
+	broadcast := domain.Transaction{
+		ID:        txID,
+		Fee:       domain.Amount(fee),
+		FeeRate:   float64(fee) / float64(tx.VSize()),
+		VSize:     tx.VSize(),
+		Type:      "send",
+		Status:    "pending",
+		Timestamp: time.Now(),
+		RBF:       tx.SignalsRBF(),
+		Hex:       tx.Hex(),
+
+		ReportedTxID: reportedTxID(reported, txID),
+	}
+
+	// The transaction is on the network now; keep the spent outputs out of coin selection
+	wallets, err := s.repo.FindAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrStorageOperation, err)
+	}
+	for _, wallet := range wallets {
+		if s.checkNetwork(wallet) != nil || !s.recordPSBTSpend(wallet, tx, broadcast) {
+			continue
+		}
+		if err := s.repo.Update(wallet); err != nil {
+			return nil, fmt.Errorf("%w: %v", domain.ErrStorageOperation, err)
+		}
+	}
+
+	return &broadcast, nil
+}
+
+// recordPSBTSpend records tx in wallet when it spends any of the wallet's
+// UTXOs: the spent outputs are removed, outputs paying back to the wallet are
+// added, and the wallet pays the fee only when all inputs are its own.
+func (s *WalletService) recordPSBTSpend(wallet *domain.Wallet, tx *txbuilder.Transaction, record domain.Transaction) bool {
+	if _, ok := wallet.FindTransaction(record.ID); ok {
+		return false
+	}
+
+	utxoByOutpoint := make(map[string]domain.UTXO, len(wallet.UTXOs))
+	for _, utxo := range wallet.UTXOs {
+		utxoByOutpoint[utxo.Outpoint()] = utxo
+	}
+
+	var spent []string
+	var spentValue int64
+	for _, in := range tx.Inputs {
+		utxo, ok := utxoByOutpoint[fmt.Sprintf("%s:%d", txbuilder.FormatTxID(in.PrevTxID), in.PrevIndex)]
+		if !ok {
+			continue
+		}
+		record.Inputs = append(record.Inputs, utxo)
+		spent = append(spent, utxo.Outpoint())
+		spentValue += utxo.Value.Satoshis()
+	}
+	if len(spent) == 0 {
+		return false
+	}
+
+	var returned int64
+	var change []domain.UTXO
+	for i, out := range tx.Outputs {
+		address, ok := s.addressForScript(wallet, out.PkScript)
+		if !ok {
+			if record.To == "" {
+				record.To, _ = txbuilder.ScriptAddress(out.PkScript, s.params)
+			}
+			continue
+		}
+		returned += out.Value
+		change = append(change, domain.UTXO{
+			TxID:       record.ID,
+			Vout:       uint32(i),
+			Value:      domain.Amount(out.Value),
+			ScriptType: wallet.ScriptType(),
+			Address:    address,
+			Change:     true,
+		})
+	}
+
+	// Other participants of a jointly funded transaction pay their share of the fee
+	if len(spent) < len(tx.Inputs) {
+		record.Fee = 0
+	}
+	record.From = record.Inputs[0].Address
+	record.Amount = domain.Amount(spentValue-returned) - record.Fee
+	if record.To == "" {
+		record.Type = "self"
+		record.Amount = 0
+		record.To = record.From
+	}
+
+	wallet.AddTransaction(record)
+	wallet.SpendUTXOs(spent)
+	wallet.UTXOs = append(wallet.UTXOs, change...)
+	return true
+}
+
+// describeInput adds the redeem script and key origin of the wallet address spent by input i
+func (s *WalletService) describeInput(packet *psbt.Packet, i int, wallet *domain.Wallet, address string) error {
+	if wallet.IsMultisig() {
+		redeemScript, witnessScript, origins, ok := s.multisigScripts(wallet, address)
+		if !ok {
+			return nil
+		}
+		packet.Inputs[i].RedeemScript = redeemScript
+		packet.Inputs[i].WitnessScript = witnessScript
+		for _, origin := range origins {
+			if err := packet.AddInputDerivation(i, origin); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	pubKey, origin, ok := s.keyOrigin(wallet, address)
+	if !ok {
+		return nil
+	}
+
+	in := &packet.Inputs[i]
+	switch wallet.ScriptType() {
+	case domain.AddressP2SHP2WPKH:
+		in.RedeemScript = s.crypto.NestedSegWitRedeemScript(s.crypto.Hash160(pubKey))
+	case domain.AddressP2TR:
+		in.TaprootInternalKey = pubKey[1:]
+		if origin != nil {
+			in.TaprootBip32Derivation = append(in.TaprootBip32Derivation, psbt.TaprootBip32Derivation{
+				XOnlyPubKey: pubKey[1:],
+				Fingerprint: origin.Fingerprint,
+				Path:        origin.Path,
+			})
+		}
+		return nil
+	}
+
+	if origin == nil {
+		return nil
+	}
+	return packet.AddInputDerivation(i, *origin)
+}
+
+// describeOutput adds the redeem script and key origin of the wallet address paid by output i
+func (s *WalletService) describeOutput(packet *psbt.Packet, i int, wallet *domain.Wallet, address string) error {
+	if wallet.IsMultisig() {
+		redeemScript, witnessScript, origins, ok := s.multisigScripts(wallet, address)
+		if !ok {
+			return nil
+		}
+		packet.Outputs[i].RedeemScript = redeemScript
+		packet.Outputs[i].WitnessScript = witnessScript
+		for _, origin := range origins {
+			if err := packet.AddOutputDerivation(i, origin); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	pubKey, origin, ok := s.keyOrigin(wallet, address)
+	if !ok {
+		return nil
+	}
+
+	out := &packet.Outputs[i]
+	switch wallet.ScriptType() {
+	case domain.AddressP2SHP2WPKH:
+		out.RedeemScript = s.crypto.NestedSegWitRedeemScript(s.crypto.Hash160(pubKey))
+	case domain.AddressP2TR:
+		out.TaprootInternalKey = pubKey[1:]
+		if origin != nil {
+			out.TaprootBip32Derivation = append(out.TaprootBip32Derivation, psbt.TaprootBip32Derivation{
+				XOnlyPubKey: pubKey[1:],
+				Fingerprint: origin.Fingerprint,
+				Path:        origin.Path,
+			})
+		}
+		return nil
+	}
+
+	if origin == nil {
+		return nil
+	}
+	return packet.AddOutputDerivation(i, *origin)
+}
+
+// keyOrigin returns the public key of a wallet address and, for HD wallets, its BIP32 origin
+func (s *WalletService) keyOrigin(wallet *domain.Wallet, address string) ([]byte, *psbt.Bip32Derivation, bool) {
+	derived, ok := wallet.FindAddress(address)
+	if !ok {
+		if address != wallet.Address {
+			return nil, nil, false
+		}
+		pubKey, err := hex.DecodeString(wallet.PublicKey)
+		return pubKey, nil, err == nil && len(pubKey) == 33
+	}
+
+	pubKey, err := hex.DecodeString(derived.PublicKey)
+	if err != nil || len(pubKey) != 33 {
+		return nil, nil, false
+	}
+
+	fingerprint, err := hex.DecodeString(wallet.MasterFingerprint)
+	accountPath, pathErr := crypto.ParseDerivationPath(wallet.DerivationPath)
+	if err != nil || len(fingerprint) != 4 || pathErr != nil {
+		return pubKey, nil, true
+	}
+
+	origin := &psbt.Bip32Derivation{
+		PubKey: pubKey,
+		Path:   append(accountPath, derived.Chain, derived.Index),
+	}
+	copy(origin.Fingerprint[:], fingerprint)
+	return pubKey, origin, true
+}
+
+// globalXPubs returns the account xpubs of the wallet (every cosigner of a
+// multisig wallet) with their origins for the PSBT global map
+func (s *WalletService) globalXPubs(wallet *domain.Wallet) []psbt.XPub {
+	var xpubs []psbt.XPub
+	if wallet.IsMultisig() {
+		for _, cosigner := range wallet.Multisig.Cosigners {
+			if xpub, ok := s.globalXPub(cosigner.Xpub, cosigner.Fingerprint, cosigner.DerivationPath); ok {
+				xpubs = append(xpubs, xpub)
+			}
+		}
+	} else if wallet.IsHD() {
+		if xpub, ok := s.globalXPub(wallet.AccountXpub, wallet.MasterFingerprint, wallet.DerivationPath); ok {
+			xpubs = append(xpubs, xpub)
+		}
+	}
+	return xpubs
+}
+
+// globalXPub encodes an account xpub with its origin; keys of unknown origin are skipped
+func (s *WalletService) globalXPub(accountXpub, masterFingerprint, derivationPath string) (psbt.XPub, bool) {
+	version, payload, err := s.crypto.DecodeBase58Check(accountXpub)
+	fingerprint, fpErr := hex.DecodeString(masterFingerprint)
+	path, pathErr := crypto.ParseDerivationPath(derivationPath)
+	if err != nil || fpErr != nil || pathErr != nil || len(payload) != 77 || len(fingerprint) != 4 {
+		return psbt.XPub{}, false
+	}
+
+	xpub := psbt.XPub{ExtendedKey: append([]byte{version}, payload...), Path: path}
+	copy(xpub.Fingerprint[:], fingerprint)
+	return xpub, true
+}
@@ -0,0 +1,259 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dhfai/go-wallet/internal/domain"
+	"github.com/dhfai/go-wallet/pkg/txbuilder"
+)
+
+// BumpFeeResult holds the replacement transaction and the change it gave up
+// BumpFeeResult berisi transaksi pengganti dan kembalian yang dilepas
+type BumpFeeResult struct {
+	Replacement   domain.Transaction
+	DroppedChange domain.Amount // Change below the dust limit that went to the fee; 0 if kept
+}
+
+// BumpFee replaces a pending outgoing transaction (BIP125) with one spending the
+// same inputs at feeRate sat/vB, paying the extra fee from its change output.
+// The original is marked replaced and linked to the replacement. Transactions
+// still spending outputs of the original must be bumped instead.
+// BumpFee mengganti transaksi pending dengan fee yang lebih tinggi (RBF)
+func (s *WalletService) BumpFee(ctx context.Context, walletID, txID string, feeRate float64) (*BumpFeeResult, error) {
+	wallet, err := s.repo.FindByID(walletID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkNetwork(wallet); err != nil {
+		return nil, err
+	}
+
+	if err := requireSingleSigner(wallet); err != nil {
+		return nil, err
+	}
+
+	if err := s.requireUnlocked(); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkMaxFeeRate(feeRate); err != nil {
+		return nil, err
+	}
+
+	original, ok := wallet.FindTransaction(txID)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", domain.ErrTransactionNotFound, txID)
+	}
+	if err := checkReplaceable(original); err != nil {
+		return nil, err
+	}
+
+	// The network would evict the wallet's transactions spending the original
+	// along with it, and their fees would count towards rule 3
+	if spender := pendingSpender(wallet, txID); spender != "" {
+		return nil, fmt.Errorf("%w: %s is spent by pending transaction %s, bump that one instead",
+			domain.ErrNotReplaceable, txID, spender)
+	}
+
+	prevTx, err := txbuilder.ParseTransactionHex(original.Hex)
+	if err != nil {
+		return nil, fmt.Errorf("%w: stored transaction is invalid: %v", domain.ErrNotReplaceable, err)
+	}
+	if len(prevTx.Inputs) != len(original.Inputs) {
+		return nil, fmt.Errorf("%w: stored inputs do not match the transaction", domain.ErrNotReplaceable)
+	}
+
+	// The change output is the one paying back to the wallet, other than the payment itself
+	paymentScript, err := txbuilder.PayToAddrScript(original.To, s.params)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidAddress, err)
+	}
+
+	changeIndex := -1
+	outputTypes := make([]txbuilder.ScriptType, 0, len(prevTx.Outputs))
+	for i, out := range prevTx.Outputs {
+		outputTypes = append(outputTypes, txbuilder.ClassifyScript(out.PkScript))
+		if changeIndex < 0 && !bytes.Equal(out.PkScript, paymentScript) && s.ownsScript(wallet, out.PkScript) {
+			changeIndex = i
+		}
+	}
+	if changeIndex < 0 {
+		return nil, fmt.Errorf("%w: transaction has no change output to pay the higher fee", domain.ErrNotReplaceable)
+	}
+
+	inputTypes := make([]txbuilder.ScriptType, 0, len(original.Inputs))
+	for _, input := range original.Inputs {
+		inputTypes = append(inputTypes, scriptTypeForAddress(input.ScriptType))
+	}
+
+	// BIP125 rules 3 and 4: the replacement pays a higher absolute fee, and the
+	// increase covers its own size at the minimum relay fee rate
+	vsize := txbuilder.EstimateVSize(inputTypes, outputTypes)
+	oldFee := original.Fee.Satoshis()
+	newFee := txbuilder.FeeForVSize(vsize, feeRate)
+	if minFee := oldFee + txbuilder.FeeForVSize(vsize, txbuilder.MinRelayFeeRate); newFee < minFee {
+		return nil, fmt.Errorf("%w: %.2f sat/vB pays %d sats, a replacement must pay at least %d sats (%.2f sat/vB)",
+			domain.ErrFeeTooLow, feeRate, newFee, minFee, float64(minFee)/float64(vsize))
+	}
+
+	change := prevTx.Outputs[changeIndex].Value - (newFee - oldFee)
+	if change < 0 {
+		return nil, fmt.Errorf("%w: change of %d sats cannot pay the %d sats fee increase",
+			domain.ErrInsufficientBalance, prevTx.Outputs[changeIndex].Value, newFee-oldFee)
+	}
+
+	// Rebuild with the same inputs; change that would become dust goes to the fee
+	builder := txbuilder.NewBuilder(s.params)
+	for _, input := range original.Inputs {
+		script, err := txbuilder.PayToAddrScript(input.Address, s.params)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", domain.ErrInvalidAddress, err)
+		}
+		if err := builder.AddInput(input.TxID, input.Vout, input.Value.Satoshis(), script); err != nil {
+			return nil, fmt.Errorf("failed to add input: %w", err)
+		}
+	}
+
+	replacement := builder.Transaction()
+	replacement.LockTime = prevTx.LockTime
+	for i, out := range prevTx.Outputs {
+		value := out.Value
+		if i == changeIndex {
+			if change < txbuilder.DustLimit {
+				continue
+			}
+			value = change
+		}
+		replacement.Outputs = append(replacement.Outputs, &txbuilder.TxOut{Value: value, PkScript: out.PkScript})
+	}
+
+	for i, input := range original.Inputs {
+		privKey, err := s.privateKeyForAddress(wallet, input.Address)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", domain.ErrInvalidPrivateKey, err)
+		}
+		if err := builder.SignInput(i, privKey); err != nil {
+			return nil, fmt.Errorf("failed to sign transaction: %w", err)
+		}
+	}
+
+	reported, err := s.backend.BroadcastTransaction(ctx, replacement.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("failed to broadcast transaction: %w", err)
+	}
+	newTxID := replacement.TxID()
+
+	bumped := domain.Transaction{
+		ID:        newTxID,
+		From:      original.From,
+		To:        original.To,
+		Amount:    original.Amount,
+		Fee:       domain.Amount(builder.Fee()),
+		FeeRate:   float64(builder.Fee()) / float64(replacement.VSize()),
+		VSize:     replacement.VSize(),
+		Type:      "send",
+		Status:    "pending",
+		Timestamp: time.Now(),
+		Note:      original.Note,
+		RBF:       replacement.SignalsRBF(),
+		Hex:       replacement.Hex(),
+		Inputs:    original.Inputs,
+		Replaces:  txID,
+
+		ReportedTxID: reportedTxID(reported, newTxID),
+	}
+
+	// Swap the change of the original for the change of the replacement
+	wallet.SpendUTXOs([]string{fmt.Sprintf("%s:%d", txID, changeIndex)})
+	if change >= txbuilder.DustLimit {
+		changeAddress, _ := s.addressForScript(wallet, prevTx.Outputs[changeIndex].PkScript)
+		wallet.UTXOs = append(wallet.UTXOs, domain.UTXO{
+			TxID:       newTxID,
+			Vout:       uint32(changeIndex),
+			Value:      domain.Amount(change),
+			ScriptType: addressTypeForScript(outputTypes[changeIndex]),
+			Address:    changeAddress,
+			Change:     true,
+		})
+	}
+
+	result := &BumpFeeResult{Replacement: bumped}
+	if change < txbuilder.DustLimit {
+		result.DroppedChange = domain.Amount(change)
+	}
+
+	if err := wallet.ReplaceTransaction(txID, bumped); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(wallet); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrStorageOperation, err)
+	}
+
+	// Keep a receiving wallet in our system in step with the replacement
+	if receiver, err := s.repo.FindByAddress(original.To); err == nil && receiver != nil && receiver.ID != wallet.ID {
+		if received, ok := receiver.FindTransaction(txID); ok {
+			receipt := *received
+			receipt.ID = newTxID
+			receipt.Timestamp = time.Now()
+			if err := receiver.ReplaceTransaction(txID, receipt); err == nil {
+				_ = s.repo.Update(receiver) // Ignore error for receiver
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// checkReplaceable verifies that tx is an outgoing transaction that may still be replaced
+func checkReplaceable(tx *domain.Transaction) error {
+	switch {
+	case tx.Type != "send":
+		return fmt.Errorf("%w: %s is not an outgoing transaction", domain.ErrNotReplaceable, tx.ID)
+	case tx.ReplacedBy != "":
+		return fmt.Errorf("%w: %s was already replaced by %s", domain.ErrNotReplaceable, tx.ID, tx.ReplacedBy)
+	case tx.Status != "pending":
+		return fmt.Errorf("%w: %s is %s", domain.ErrNotReplaceable, tx.ID, tx.Status)
+	case !tx.RBF:
+		return fmt.Errorf("%w: %s does not signal replace-by-fee", domain.ErrNotReplaceable, tx.ID)
+	case tx.Hex == "" || len(tx.Inputs) == 0:
+		return fmt.Errorf("%w: %s was sent before inputs were recorded", domain.ErrNotReplaceable, tx.ID)
+	}
+	return nil
+}
+
+// pendingSpender returns the ID of a pending wallet transaction spending an
+// output of txID, or "" when there is none
+func pendingSpender(wallet *domain.Wallet, txID string) string {
+	for _, tx := range wallet.Transactions {
+		if tx.Status != "pending" {
+			continue
+		}
+		for _, input := range tx.Inputs {
+			if input.TxID == txID {
+				return tx.ID
+			}
+		}
+	}
+	return ""
+}
+
+// ownsScript reports whether an output script pays to one of the wallet's addresses
+func (s *WalletService) ownsScript(wallet *domain.Wallet, script []byte) bool {
+	_, ok := s.addressForScript(wallet, script)
+	return ok
+}
+
+// addressForScript returns the wallet address whose output script is script
+func (s *WalletService) addressForScript(wallet *domain.Wallet, script []byte) (string, bool) {
+	for _, address := range wallet.AllAddresses() {
+		addressScript, err := txbuilder.PayToAddrScript(address, s.params)
+		if err == nil && bytes.Equal(addressScript, script) {
+			return address, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dhfai/go-wallet/internal/domain"
+	"github.com/dhfai/go-wallet/pkg/network"
+	"github.com/dhfai/go-wallet/pkg/txbuilder"
+)
+
+// sendPending sends amount sats from wallet to testRecipient at feeRate and
+// returns the recorded transaction with its broadcast form
+func sendPending(t *testing.T, service *WalletService, backend *fakeBackend, wallet *domain.Wallet, amount int64, feeRate float64) (*domain.Transaction, *txbuilder.Transaction) {
+	t.Helper()
+	tx, err := service.SendBitcoin(context.Background(), wallet.ID, testRecipient, domain.Amount(amount), SendOptions{FeeRate: feeRate})
+	if err != nil {
+		t.Fatalf("SendBitcoin: %v", err)
+	}
+	return tx, backend.lastBroadcast(t)
+}
+
+func TestBumpFee(t *testing.T) {
+	ctx := context.Background()
+	service, _, backend := newTestService(t)
+	wallet := newFundedWallet(t, service, backend, domain.AddressP2WPKH, 100000)
+
+	original, sent := sendPending(t, service, backend, wallet, 30000, 2)
+	if len(sent.Outputs) != 2 {
+		t.Fatalf("original has %d outputs, want payment and change", len(sent.Outputs))
+	}
+	oldChange := sent.Outputs[1].Value
+
+	result, err := service.BumpFee(ctx, wallet.ID, original.ID, 10)
+	if err != nil {
+		t.Fatalf("BumpFee: %v", err)
+	}
+	bumped := result.Replacement
+	replacement := backend.lastBroadcast(t)
+	if bumped.ID != replacement.TxID() {
+		t.Errorf("replacement recorded as %s, broadcast as %s", bumped.ID, replacement.TxID())
+	}
+
+	// Same inputs, same payment; the fee increase comes out of the change
+	if len(replacement.Inputs) != len(sent.Inputs) {
+		t.Fatalf("replacement has %d inputs, want %d", len(replacement.Inputs), len(sent.Inputs))
+	}
+	for i, in := range replacement.Inputs {
+		if in.PrevTxID != sent.Inputs[i].PrevTxID || in.PrevIndex != sent.Inputs[i].PrevIndex {
+			t.Errorf("replacement input %d spends another output than the original", i)
+		}
+	}
+	if len(replacement.Outputs) != 2 {
+		t.Fatalf("replacement has %d outputs, want 2", len(replacement.Outputs))
+	}
+	if out := replacement.Outputs[0]; out.Value != 30000 || !bytes.Equal(out.PkScript, sent.Outputs[0].PkScript) {
+		t.Errorf("replacement payment = %d sats, want the original 30000", out.Value)
+	}
+	increase := bumped.Fee.Satoshis() - original.Fee.Satoshis()
+	if got := replacement.Outputs[1].Value; got != oldChange-increase {
+		t.Errorf("replacement change = %d, want %d - %d", got, oldChange, increase)
+	}
+	if result.DroppedChange != 0 {
+		t.Errorf("DroppedChange = %d, want 0", result.DroppedChange)
+	}
+
+	// BIP125 rules 3 and 4
+	if increase < txbuilder.FeeForVSize(replacement.VSize(), txbuilder.MinRelayFeeRate) {
+		t.Errorf("fee increase %d does not pay for the replacement's %d vB", increase, replacement.VSize())
+	}
+	if bumped.FeeRate < 10 {
+		t.Errorf("replacement fee rate = %.2f, want at least 10", bumped.FeeRate)
+	}
+
+	// The original is linked to its successor, and the change UTXO swapped
+	stored, ok := wallet.FindTransaction(original.ID)
+	if !ok {
+		t.Fatal("original transaction missing")
+	}
+	if stored.Status != "replaced" || stored.ReplacedBy != bumped.ID {
+		t.Errorf("original status, replaced by = %s, %s, want replaced, %s", stored.Status, stored.ReplacedBy, bumped.ID)
+	}
+	if successor, ok := wallet.FindTransaction(bumped.ID); !ok || successor.Replaces != original.ID {
+		t.Errorf("replacement missing or not linked to %s", original.ID)
+	}
+	for _, utxo := range wallet.UTXOs {
+		if utxo.TxID == original.ID {
+			t.Errorf("change of the replaced transaction still listed: %s", utxo.Outpoint())
+		}
+	}
+	if len(wallet.UTXOs) != 1 || wallet.UTXOs[0].TxID != bumped.ID || wallet.UTXOs[0].Value.Satoshis() != oldChange-increase {
+		t.Errorf("UTXOs after bump = %+v, want the replacement's change", wallet.UTXOs)
+	}
+
+	// The original can no longer be bumped
+	if _, err := service.BumpFee(ctx, wallet.ID, original.ID, 20); !errors.Is(err, domain.ErrNotReplaceable) {
+		t.Errorf("second bump of the original error = %v, want ErrNotReplaceable", err)
+	}
+}
+
+func TestBumpFeeRequiresHigherFee(t *testing.T) {
+	ctx := context.Background()
+	service, _, backend := newTestService(t)
+	wallet := newFundedWallet(t, service, backend, domain.AddressP2WPKH, 100000)
+	original, _ := sendPending(t, service, backend, wallet, 30000, 4)
+
+	// Rule 4: the increase must pay for the replacement at 1 sat/vB, so
+	// 4.5 sat/vB is too little even though it beats the original
+	for _, rate := range []float64{2, 4, 4.5} {
+		if _, err := service.BumpFee(ctx, wallet.ID, original.ID, rate); !errors.Is(err, domain.ErrFeeTooLow) {
+			t.Errorf("BumpFee at %.1f sat/vB error = %v, want ErrFeeTooLow", rate, err)
+		}
+	}
+	if len(backend.broadcast) != 1 {
+		t.Errorf("%d transactions broadcast, want only the original", len(backend.broadcast))
+	}
+	if _, err := service.BumpFee(ctx, wallet.ID, original.ID, 5.5); err != nil {
+		t.Errorf("BumpFee at 5.5 sat/vB: %v", err)
+	}
+}
+
+func TestBumpFeeDropsDustChange(t *testing.T) {
+	ctx := context.Background()
+	service, _, backend := newTestService(t)
+	wallet := newFundedWallet(t, service, backend, domain.AddressP2WPKH, 100000)
+
+	// About 1500 sats of change at 1 sat/vB, less than the increase to 10 sat/vB
+	original, sent := sendPending(t, service, backend, wallet, 98350, 1)
+	if len(sent.Outputs) != 2 {
+		t.Fatalf("original has %d outputs, want payment and change", len(sent.Outputs))
+	}
+	oldChange := sent.Outputs[1].Value
+
+	result, err := service.BumpFee(ctx, wallet.ID, original.ID, 10)
+	if err != nil {
+		t.Fatalf("BumpFee: %v", err)
+	}
+	replacement := backend.lastBroadcast(t)
+	if len(replacement.Outputs) != 1 || replacement.Outputs[0].Value != 98350 {
+		t.Fatalf("replacement outputs = %d, want only the payment", len(replacement.Outputs))
+	}
+
+	// Everything beyond the payment goes to the fee, and the caller is told how much change that was
+	if fee := result.Replacement.Fee.Satoshis(); fee != 100000-98350 {
+		t.Errorf("replacement fee = %d, want %d", fee, 100000-98350)
+	}
+	if dropped := result.DroppedChange.Satoshis(); dropped <= 0 || dropped >= txbuilder.DustLimit || dropped >= oldChange {
+		t.Errorf("DroppedChange = %d, want the dust left of the %d sats change", dropped, oldChange)
+	}
+	if len(wallet.UTXOs) != 0 {
+		t.Errorf("UTXOs after dropping the change = %+v, want none", wallet.UTXOs)
+	}
+}
+
+func TestBumpFeeRefusesSpentChange(t *testing.T) {
+	ctx := context.Background()
+	service, _, backend := newTestService(t)
+	wallet := newFundedWallet(t, service, backend, domain.AddressP2WPKH, 100000)
+	original, sent := sendPending(t, service, backend, wallet, 30000, 2)
+
+	// The next payment spends the unconfirmed change of the first
+	change := wallet.UTXOs[0]
+	backend.utxos[wallet.Address] = nil
+	backend.utxos[change.Address] = []network.UTXOInfo{{TxID: original.ID, Vout: 1, Value: sent.Outputs[1].Value}}
+	child, _ := sendPending(t, service, backend, wallet, 20000, 2)
+
+	_, err := service.BumpFee(ctx, wallet.ID, original.ID, 10)
+	if !errors.Is(err, domain.ErrNotReplaceable) || !strings.Contains(err.Error(), child.ID) {
+		t.Fatalf("BumpFee of a transaction with a pending child error = %v, want ErrNotReplaceable naming %s", err, child.ID)
+	}
+	if len(backend.broadcast) != 2 {
+		t.Errorf("%d transactions broadcast, want 2", len(backend.broadcast))
+	}
+
+	// The child itself can be bumped
+	if _, err := service.BumpFee(ctx, wallet.ID, child.ID, 10); err != nil {
+		t.Errorf("BumpFee of the child: %v", err)
+	}
+}
@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dhfai/go-wallet/internal/domain"
+	"github.com/dhfai/go-wallet/pkg/coinselect"
+	"github.com/dhfai/go-wallet/pkg/txbuilder"
+)
+
+// defaultCoinSelection tries a changeless branch-and-bound match first and
+// falls back to a random draw, like Bitcoin Core
+func defaultCoinSelection() []coinselect.Selector {
+	return []coinselect.Selector{
+		coinselect.BranchAndBound{},
+		coinselect.NewSingleRandomDraw(),
+	}
+}
+
+// SetCoinSelection replaces the strategies SendBitcoin uses to pick inputs; they are tried in order
+// SetCoinSelection mengganti strategi pemilihan input yang digunakan SendBitcoin
+func (s *WalletService) SetCoinSelection(strategies ...coinselect.Selector) {
+	if len(strategies) == 0 {
+		strategies = defaultCoinSelection()
+	}
+	s.selectors = strategies
+}
+
+// GetUTXOs returns the wallet's unspent outputs as of the last sync
+// GetUTXOs mengembalikan UTXO wallet dari sinkronisasi terakhir
+func (s *WalletService) GetUTXOs(walletID string) ([]domain.UTXO, error) {
+	wallet, err := s.repo.FindByID(walletID)
+	if err != nil {
+		return nil, err
+	}
+	return wallet.UTXOs, nil
+}
+
+// refreshUTXOs replaces the wallet's UTXO set with the outputs the backend reports for its addresses
+func (s *WalletService) refreshUTXOs(ctx context.Context, wallet *domain.Wallet) error {
+	var utxos []domain.UTXO
+	for _, address := range wallet.AllAddresses() {
+		script, err := txbuilder.PayToAddrScript(address, s.params)
+		if err != nil {
+			return fmt.Errorf("%w: %v", domain.ErrInvalidAddress, err)
+		}
+		scriptType := addressTypeForScript(txbuilder.ClassifyScript(script))
+		if wallet.IsMultisig() {
+			scriptType = wallet.ScriptType()
+		}
+
+		derived, _ := wallet.FindAddress(address)
+		change := derived.Address != "" && derived.Chain == domain.ChainChange
+
+		outputs, err := s.backend.GetUTXOs(ctx, address)
+		if err != nil {
+			return fmt.Errorf("failed to fetch UTXOs from blockchain: %w", err)
+		}
+
+		for _, output := range outputs {
+			utxo := domain.UTXO{
+				TxID:       output.TxID,
+				Vout:       uint32(output.Vout),
+				Value:      domain.Amount(output.Value),
+				ScriptType: scriptType,
+				Address:    address,
+				Change:     change,
+			}
+			if output.Status.Confirmed {
+				utxo.Height = output.Status.BlockHeight
+			}
+			utxos = append(utxos, utxo)
+		}
+	}
+
+	wallet.UTXOs = utxos
+	markCoinbaseOutputs(wallet)
+	return nil
+}
+
+// selectCoins picks wallet UTXOs paying amount to a script of outputType at
+// feeRate sat/vB, with change to an output of the wallet's own type
+func (s *WalletService) selectCoins(wallet *domain.Wallet, amount domain.Amount, feeRate float64, outputType txbuilder.ScriptType) (*coinselect.Result, error) {
+	coins := make([]coinselect.Coin, 0, len(wallet.UTXOs))
+	witness := false
+	for _, utxo := range wallet.UTXOs {
+		if !utxo.IsMature(wallet.SyncHeight) {
+			continue
+		}
+		witness = witness || txbuilder.IsWitnessSpend(scriptTypeForAddress(utxo.ScriptType))
+		coins = append(coins, coinselect.Coin{
+			TxID:   utxo.TxID,
+			Vout:   utxo.Vout,
+			Value:  utxo.Value.Satoshis(),
+			Weight: spendWeight(wallet, utxo.ScriptType),
+		})
+	}
+	changeType := scriptTypeForAddress(wallet.ScriptType())
+
+	baseWeight := txbuilder.EstimateWeight(nil, []txbuilder.ScriptType{outputType})
+	if witness {
+		baseWeight += txbuilder.SegWitOverheadWeight
+	}
+
+	req := coinselect.Request{
+		Target:            amount.Satoshis(),
+		FeeRate:           feeRate,
+		BaseWeight:        baseWeight,
+		ChangeWeight:      txbuilder.OutputWeight(changeType),
+		ChangeSpendWeight: spendWeight(wallet, wallet.ScriptType()),
+		DustLimit:         txbuilder.DustLimit,
+	}
+
+	result, err := coinselect.Select(coins, req, s.selectors...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInsufficientBalance, err)
+	}
+	return result, nil
+}
+
+// fundedTransaction is an unsigned payment whose inputs and change have been chosen
+type fundedTransaction struct {
+	builder       *txbuilder.Builder
+	inputs        []domain.UTXO
+	change        int64  // Change value in satoshis, zero when there is no change output
+	changeAddress string // Address of the change output at index 1
+}
+
+// fundTransaction selects inputs paying amount to toAddress at the fee rate of
+// opts and builds the unsigned transaction with its change output. A fresh
+// change address is derived for HD wallets; the caller persists the wallet.
+func (s *WalletService) fundTransaction(ctx context.Context, wallet *domain.Wallet, toAddress string, amount domain.Amount, opts SendOptions) (*fundedTransaction, error) {
+	feeRate, err := s.feeRateFor(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Refresh the UTXO set so only outputs that are still unspent are selected
+	if err := s.refreshUTXOs(ctx, wallet); err != nil {
+		return nil, err
+	}
+
+	recipientScript, err := txbuilder.PayToAddrScript(toAddress, s.params)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidAddress, err)
+	}
+
+	selection, err := s.selectCoins(wallet, amount, feeRate, txbuilder.ClassifyScript(recipientScript))
+	if err != nil {
+		return nil, err
+	}
+
+	builder := txbuilder.NewBuilder(s.params)
+	if err := builder.AddOutput(toAddress, amount.Satoshis()); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidAddress, err)
+	}
+	builder.SetRBF(!opts.DisableRBF)
+
+	utxoByOutpoint := make(map[string]domain.UTXO, len(wallet.UTXOs))
+	for _, utxo := range wallet.UTXOs {
+		utxoByOutpoint[utxo.Outpoint()] = utxo
+	}
+
+	funded := &fundedTransaction{builder: builder, change: selection.Change}
+	for _, coin := range selection.Coins {
+		utxo := utxoByOutpoint[fmt.Sprintf("%s:%d", coin.TxID, coin.Vout)]
+
+		script, err := txbuilder.PayToAddrScript(utxo.Address, s.params)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", domain.ErrInvalidAddress, err)
+		}
+		if err := builder.AddInput(utxo.TxID, utxo.Vout, utxo.Value.Satoshis(), script); err != nil {
+			return nil, fmt.Errorf("failed to add input: %w", err)
+		}
+		funded.inputs = append(funded.inputs, utxo)
+	}
+
+	// Return change to a fresh change address (HD) or the sender
+	if selection.Change > 0 {
+		funded.changeAddress = wallet.Address
+		if wallet.IsHD() {
+			derived, err := s.nextAddress(wallet, domain.ChainChange)
+			if err != nil {
+				return nil, fmt.Errorf("failed to derive change address: %w", err)
+			}
+			funded.changeAddress = derived.Address
+		}
+
+		if err := builder.AddOutput(funded.changeAddress, selection.Change); err != nil {
+			return nil, fmt.Errorf("failed to add change output: %w", err)
+		}
+	}
+
+	return funded, nil
+}
+
+// applyTo replaces the spent outputs of wallet with the change of transaction txID
+func (f *fundedTransaction) applyTo(wallet *domain.Wallet, txID string) {
+	spent := make([]string, 0, len(f.inputs))
+	for _, input := range f.inputs {
+		spent = append(spent, input.Outpoint())
+	}
+	wallet.SpendUTXOs(spent)
+
+	if f.change > 0 {
+		wallet.UTXOs = append(wallet.UTXOs, domain.UTXO{
+			TxID:       txID,
+			Vout:       1,
+			Value:      domain.Amount(f.change),
+			ScriptType: wallet.ScriptType(),
+			Address:    f.changeAddress,
+			Change:     true,
+		})
+	}
+}
+
+// addressTypeForScript maps an output script template to the wallet address type that produces it
+func addressTypeForScript(scriptType txbuilder.ScriptType) domain.AddressType {
+	switch scriptType {
+	case txbuilder.ScriptP2PKH:
+		return domain.AddressP2PKH
+	case txbuilder.ScriptP2SH:
+		return domain.AddressP2SHP2WPKH
+	case txbuilder.ScriptP2TR:
+		return domain.AddressP2TR
+	case txbuilder.ScriptP2WSH:
+		return domain.AddressP2WSH
+	default:
+		return domain.AddressP2WPKH
+	}
+}
+
+// scriptTypeForAddress maps a wallet address type to its output script template
+func scriptTypeForAddress(addressType domain.AddressType) txbuilder.ScriptType {
+	switch addressType {
+	case domain.AddressP2PKH:
+		return txbuilder.ScriptP2PKH
+	case domain.AddressP2SHP2WPKH, domain.AddressP2SHP2WSH:
+		return txbuilder.ScriptP2SH
+	case domain.AddressP2TR:
+		return txbuilder.ScriptP2TR
+	case domain.AddressP2WSH:
+		return txbuilder.ScriptP2WSH
+	default:
+		return txbuilder.ScriptP2WPKH
+	}
+}
+
+// spendWeight returns the weight of an input spending a wallet output of addressType
+func spendWeight(wallet *domain.Wallet, addressType domain.AddressType) int {
+	if wallet.IsMultisig() {
+		return txbuilder.MultisigInputWeight(wallet.Multisig.Threshold, len(wallet.Multisig.Cosigners), addressType == domain.AddressP2SHP2WSH)
+	}
+	return txbuilder.InputWeight(scriptTypeForAddress(addressType))
+}
@@ -1,10 +1,16 @@
 package service
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/dhfai/go-wallet/internal/domain"
+	"github.com/dhfai/go-wallet/pkg/chaincfg"
+	"github.com/dhfai/go-wallet/pkg/coinselect"
 	"github.com/dhfai/go-wallet/pkg/crypto"
 	"github.com/dhfai/go-wallet/pkg/network"
 	"github.com/google/uuid"
@@ -21,53 +27,257 @@ type WalletRepository interface {
 	Delete(id string) error
 }
 
+// KeyStore is implemented by repositories that encrypt private keys at rest
+// KeyStore diimplementasikan oleh repository yang mengenkripsi private key
+type KeyStore interface {
+	IsEncrypted() bool
+	IsLocked() bool
+	Unlock(passphrase string) error
+	Lock()
+	ChangePassphrase(oldPassphrase, newPassphrase string) error
+}
+
 // WalletService handles all wallet business logic
 // WalletService menangani semua logika bisnis wallet
 type WalletService struct {
-	repo   WalletRepository
-	crypto *crypto.BitcoinCrypto
+	repo      WalletRepository
+	keys      KeyStore // nil if the repository does not support encryption
+	params    *chaincfg.Params
+	crypto    *crypto.BitcoinCrypto
+	backend   network.ChainBackend
+	selectors []coinselect.Selector // Coin selection strategies, tried in order
+	gapLimit  int                   // Unused addresses in a row after which discovery stops
+	finality  int64                 // Confirmations after which a transaction is final
+
+	feePriority domain.FeePriority // Priority of sends that name neither a priority nor a fee rate
+	maxFeeRate  float64            // Highest fee rate in sat/vB the wallet pays; 0 for no limit
 }
 
-// NewWalletService creates a new WalletService instance
-// NewWalletService membuat instance baru WalletService
-func NewWalletService(repo WalletRepository) *WalletService {
+// NewWalletService creates a new WalletService instance for the given network (nil selects mainnet)
+// NewWalletService membuat instance baru WalletService untuk jaringan tertentu
+func NewWalletService(repo WalletRepository, params *chaincfg.Params) *WalletService {
+	if params == nil {
+		params = &chaincfg.MainNetParams
+	}
+
+	keys, _ := repo.(KeyStore)
+
 	return &WalletService{
-		repo:   repo,
-		crypto: crypto.NewBitcoinCrypto(),
+		repo:      repo,
+		keys:      keys,
+		params:    params,
+		crypto:    crypto.NewBitcoinCryptoForNetwork(params),
+		backend:   network.NewBlockchainExplorerForNetwork(params),
+		selectors: defaultCoinSelection(),
+		gapLimit:  DefaultGapLimit,
+		finality:  DefaultFinalConfirmations,
+
+		feePriority: domain.DefaultFeePriority,
 	}
 }
 
-// CreateWallet creates a new wallet with generated keys
-// CreateWallet membuat wallet baru dengan kunci yang di-generate
-func (s *WalletService) CreateWallet(name string) (*domain.Wallet, error) {
+// Network returns the parameters of the network the service operates on
+// Network mengembalikan parameter jaringan yang digunakan service
+func (s *WalletService) Network() *chaincfg.Params {
+	return s.params
+}
+
+// SetBackend replaces the source of blockchain data, by default the network's Esplora explorer
+// SetBackend mengganti sumber data blockchain, bawaan explorer Esplora jaringan
+func (s *WalletService) SetBackend(backend network.ChainBackend) {
+	s.backend = backend
+}
+
+// checkNetwork rejects wallets that belong to a different network than the service
+func (s *WalletService) checkNetwork(wallet *domain.Wallet) error {
+	if wallet.NetworkName() != s.params.Name {
+		return fmt.Errorf("%w: wallet %s is on %s, not %s", domain.ErrNetworkMismatch, wallet.Name, wallet.NetworkName(), s.params.Name)
+	}
+	return nil
+}
+
+// IsEncrypted reports whether private keys are encrypted at rest
+// IsEncrypted memeriksa apakah private key disimpan terenkripsi
+func (s *WalletService) IsEncrypted() bool {
+	return s.keys != nil && s.keys.IsEncrypted()
+}
+
+// IsLocked reports whether private keys are unavailable until Unlock is called
+// IsLocked memeriksa apakah private key terkunci
+func (s *WalletService) IsLocked() bool {
+	return s.keys != nil && s.keys.IsLocked()
+}
+
+// Unlock decrypts the private keys with the storage passphrase
+// Unlock mendekripsi private key dengan passphrase storage
+func (s *WalletService) Unlock(passphrase string) error {
+	if s.keys == nil {
+		return nil
+	}
+	return s.keys.Unlock(passphrase)
+}
+
+// Lock removes decrypted private keys from memory
+// Lock menghapus private key yang sudah didekripsi dari memori
+func (s *WalletService) Lock() {
+	if s.keys != nil {
+		s.keys.Lock()
+	}
+}
+
+// SetPassphrase encrypts private keys with newPassphrase. Plaintext storage is
+// migrated on first use; otherwise oldPassphrase must match the current one.
+// SetPassphrase mengenkripsi private key dengan passphrase baru
+func (s *WalletService) SetPassphrase(oldPassphrase, newPassphrase string) error {
+	if s.keys == nil {
+		return fmt.Errorf("%w: storage does not support encryption", domain.ErrStorageOperation)
+	}
+
+	if err := s.keys.ChangePassphrase(oldPassphrase, newPassphrase); err != nil {
+		if errors.Is(err, domain.ErrInvalidPassphrase) {
+			return err
+		}
+		return fmt.Errorf("%w: %v", domain.ErrStorageOperation, err)
+	}
+
+	return nil
+}
+
+// requireUnlocked fails with ErrWalletLocked while private keys are unavailable
+func (s *WalletService) requireUnlocked() error {
+	if s.IsLocked() {
+		return domain.ErrWalletLocked
+	}
+	return nil
+}
+
+// CreateWalletOptions configures how a new wallet is generated
+// CreateWalletOptions mengatur cara wallet baru dibuat
+type CreateWalletOptions struct {
+	WordCount   int                // Mnemonic length: 12 or 24 words (default 12)
+	Passphrase  string             // Optional BIP39 passphrase ("25th word")
+	AddressType domain.AddressType // Address type (default p2wpkh)
+}
+
+// CreateWallet creates a new wallet from a freshly generated BIP39 mnemonic.
+// The mnemonic is returned once and is not stored; it is the wallet backup.
+// CreateWallet membuat wallet baru dari mnemonic BIP39 yang baru di-generate
+func (s *WalletService) CreateWallet(name string, opts CreateWalletOptions) (*domain.Wallet, string, error) {
+	// Validate input
+	if name == "" {
+		return nil, "", fmt.Errorf("wallet name cannot be empty")
+	}
+
+	wordCount := opts.WordCount
+	if wordCount == 0 {
+		wordCount = 12
+	}
+	if wordCount != 12 && wordCount != 24 {
+		return nil, "", fmt.Errorf("%w: mnemonic must have 12 or 24 words", domain.ErrInvalidMnemonic)
+	}
+
+	if err := s.requireUnlocked(); err != nil {
+		return nil, "", err
+	}
+
+	// Generate mnemonic
+	mnemonic, err := s.crypto.GenerateMnemonic(wordCount)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", domain.ErrKeyGeneration, err)
+	}
+
+	wallet, err := s.walletFromMnemonic(name, mnemonic, opts.Passphrase, opts.AddressType)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return wallet, mnemonic, nil
+}
+
+// RestoreWallet rebuilds a wallet from its BIP39 mnemonic and optional passphrase.
+// The address type must match the one used at creation to find the same addresses.
+// RestoreWallet memulihkan wallet dari mnemonic BIP39 dan passphrase opsional
+func (s *WalletService) RestoreWallet(name, mnemonic, passphrase string, addressType domain.AddressType) (*domain.Wallet, error) {
 	// Validate input
 	if name == "" {
 		return nil, fmt.Errorf("wallet name cannot be empty")
 	}
 
-	// Generate key pair
-	privateKey, publicKey, err := s.crypto.GenerateKeyPair()
+	if err := s.crypto.ValidateMnemonic(mnemonic); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidMnemonic, err)
+	}
+
+	if err := s.requireUnlocked(); err != nil {
+		return nil, err
+	}
+
+	return s.walletFromMnemonic(name, mnemonic, passphrase, addressType)
+}
+
+// accountPath returns the first account path for an address type (BIP44/49/84/86) on the service network
+func (s *WalletService) accountPath(addressType domain.AddressType) string {
+	return fmt.Sprintf("m/%d'/%d'/0'", addressType.Purpose(), s.params.HDCoinType)
+}
+
+// walletFromMnemonic derives the account key for the address type and its first receive address, then saves the wallet
+func (s *WalletService) walletFromMnemonic(name, mnemonic, passphrase string, addressType domain.AddressType) (*domain.Wallet, error) {
+	if addressType == "" {
+		addressType = domain.DefaultAddressType
+	}
+
+	seed := s.crypto.MnemonicToSeed(mnemonic, passphrase)
+
+	master, err := s.crypto.NewMasterKey(seed)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %v", domain.ErrKeyGeneration, err)
 	}
 
-	// Generate Native SegWit address (bc1...) - Compatible with Phantom & Exchanges
-	address, err := s.crypto.GenerateSegWitAddress(publicKey)
+	path, err := crypto.ParseDerivationPath(s.accountPath(addressType))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrKeyGeneration, err)
+	}
+
+	account, err := master.DerivePath(path...)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %v", domain.ErrKeyGeneration, err)
 	}
 
-	// Create wallet
 	wallet := &domain.Wallet{
-		ID:           uuid.New().String(),
-		Name:         name,
-		PrivateKey:   privateKey,
-		PublicKey:    publicKey,
-		Address:      address,
-		Balance:      0.0,
-		Transactions: []domain.Transaction{},
-		CreatedAt:    time.Now(),
-		UpdatedAt:    time.Now(),
+		ID:                uuid.New().String(),
+		Name:              name,
+		AddressType:       addressType,
+		Network:           s.params.Name,
+		Balance:           0,
+		Transactions:      []domain.Transaction{},
+		MasterFingerprint: hex.EncodeToString(master.Fingerprint()),
+		DerivationPath:    s.accountPath(addressType),
+		AccountXpub:       account.Neuter().String(),
+		AccountXprv:       account.String(),
+		CreatedAt:         time.Now(),
+		UpdatedAt:         time.Now(),
+	}
+
+	// The first receive address (account/0/0) is the wallet's primary address
+	first, err := s.nextAddress(wallet, domain.ChainReceive)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrKeyGeneration, err)
+	}
+
+	firstKey, err := account.DerivePath(first.Chain, first.Index)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrKeyGeneration, err)
+	}
+
+	wallet.PrivateKey, err = firstKey.PrivateKeyHex()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrKeyGeneration, err)
+	}
+	wallet.PublicKey = first.PublicKey
+	wallet.Address = first.Address
+
+	// Refuse to restore the same wallet twice
+	if existing, err := s.repo.FindByAddress(wallet.Address); err == nil && existing != nil {
+		return nil, domain.ErrWalletExists
 	}
 
 	// Save to repository
@@ -78,6 +288,145 @@ func (s *WalletService) CreateWallet(name string) (*domain.Wallet, error) {
 	return wallet, nil
 }
 
+// NewReceiveAddress derives the next unused receive address of an HD wallet
+// NewReceiveAddress menurunkan alamat penerimaan berikutnya dari wallet HD
+func (s *WalletService) NewReceiveAddress(walletID string) (*domain.DerivedAddress, error) {
+	return s.newAddress(walletID, domain.ChainReceive)
+}
+
+// NewChangeAddress derives the next unused change address of an HD wallet
+// NewChangeAddress menurunkan alamat kembalian berikutnya dari wallet HD
+func (s *WalletService) NewChangeAddress(walletID string) (*domain.DerivedAddress, error) {
+	return s.newAddress(walletID, domain.ChainChange)
+}
+
+func (s *WalletService) newAddress(walletID string, chain uint32) (*domain.DerivedAddress, error) {
+	wallet, err := s.repo.FindByID(walletID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !wallet.IsHD() {
+		return nil, domain.ErrNotHDWallet
+	}
+
+	if err := s.checkNetwork(wallet); err != nil {
+		return nil, err
+	}
+
+	derived, err := s.nextAddress(wallet, chain)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrKeyGeneration, err)
+	}
+
+	if err := s.repo.Update(wallet); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrStorageOperation, err)
+	}
+
+	return &derived, nil
+}
+
+// nextAddress derives the next address on chain from the account xpub and records it on the wallet.
+// The caller is responsible for persisting the wallet.
+func (s *WalletService) nextAddress(wallet *domain.Wallet, chain uint32) (domain.DerivedAddress, error) {
+	index := wallet.ReceiveIndex
+	if chain == domain.ChainChange {
+		index = wallet.ChangeIndex
+	}
+
+	derived, err := s.deriveWalletAddress(wallet, chain, index)
+	if err != nil {
+		return domain.DerivedAddress{}, err
+	}
+
+	wallet.Addresses = append(wallet.Addresses, derived)
+	if chain == domain.ChainChange {
+		wallet.ChangeIndex = index + 1
+	} else {
+		wallet.ReceiveIndex = index + 1
+	}
+	wallet.UpdatedAt = time.Now()
+
+	return derived, nil
+}
+
+// deriveWalletAddress derives the address at chain/index of an HD or multisig wallet without recording it
+func (s *WalletService) deriveWalletAddress(wallet *domain.Wallet, chain, index uint32) (domain.DerivedAddress, error) {
+	if wallet.IsMultisig() {
+		return s.deriveMultisigAddress(wallet, chain, index)
+	}
+
+	account, err := s.crypto.ParseExtendedKey(wallet.AccountXpub)
+	if err != nil {
+		return domain.DerivedAddress{}, err
+	}
+	return s.deriveAddress(account, wallet.ScriptType(), chain, index)
+}
+
+// deriveAddress derives the address at chain/index below an account key
+func (s *WalletService) deriveAddress(account *crypto.ExtendedKey, addressType domain.AddressType, chain, index uint32) (domain.DerivedAddress, error) {
+	child, err := account.DerivePath(chain, index)
+	if err != nil {
+		return domain.DerivedAddress{}, err
+	}
+
+	publicKey := child.PublicKeyHex()
+	address, err := s.addressFor(publicKey, addressType)
+	if err != nil {
+		return domain.DerivedAddress{}, err
+	}
+
+	return domain.DerivedAddress{
+		Address:   address,
+		PublicKey: publicKey,
+		Chain:     chain,
+		Index:     index,
+	}, nil
+}
+
+// addressFor encodes a compressed public key as an address of the given type
+func (s *WalletService) addressFor(publicKeyHex string, addressType domain.AddressType) (string, error) {
+	switch addressType {
+	case domain.AddressP2PKH:
+		return s.crypto.GenerateAddress(publicKeyHex)
+	case domain.AddressP2SHP2WPKH:
+		return s.crypto.GenerateNestedSegWitAddress(publicKeyHex)
+	case domain.AddressP2WPKH:
+		return s.crypto.GenerateSegWitAddress(publicKeyHex)
+	case domain.AddressP2TR:
+		return s.crypto.GenerateTaprootAddress(publicKeyHex)
+	default:
+		return "", fmt.Errorf("unsupported address type %q", addressType)
+	}
+}
+
+// privateKeyForAddress returns the signing key controlling one of the wallet's addresses
+func (s *WalletService) privateKeyForAddress(wallet *domain.Wallet, address string) (*ecdsa.PrivateKey, error) {
+	if err := requireSigningKeys(wallet); err != nil {
+		return nil, err
+	}
+
+	if derived, ok := wallet.FindAddress(address); ok && wallet.AccountXprv != "" {
+		account, err := s.crypto.ParseExtendedKey(wallet.AccountXprv)
+		if err != nil {
+			return nil, err
+		}
+
+		child, err := account.DerivePath(derived.Chain, derived.Index)
+		if err != nil {
+			return nil, err
+		}
+
+		return child.ECPrivateKey()
+	}
+
+	if address == wallet.Address {
+		return s.crypto.PrivateKeyFromHex(wallet.PrivateKey)
+	}
+
+	return nil, fmt.Errorf("no key for address %s", address)
+}
+
 // GetWallet retrieves a wallet by ID
 // GetWallet mengambil wallet berdasarkan ID
 func (s *WalletService) GetWallet(id string) (*domain.Wallet, error) {
@@ -108,7 +457,7 @@ func (s *WalletService) GetAllWallets() ([]*domain.Wallet, error) {
 
 // GetBalance returns the current balance of a wallet
 // GetBalance mengembalikan saldo saat ini dari wallet
-func (s *WalletService) GetBalance(walletID string) (float64, error) {
+func (s *WalletService) GetBalance(walletID string) (domain.Amount, error) {
 	wallet, err := s.repo.FindByID(walletID)
 	if err != nil {
 		return 0, err
@@ -117,11 +466,11 @@ func (s *WalletService) GetBalance(walletID string) (float64, error) {
 	return wallet.Balance, nil
 }
 
-// SendBitcoin sends Bitcoin from one wallet to another
-// SendBitcoin mengirim Bitcoin dari satu wallet ke wallet lain
-func (s *WalletService) SendBitcoin(fromWalletID, toAddress string, amount, fee float64, note string) (*domain.Transaction, error) {
+// SendBitcoin builds, signs and broadcasts a transaction spending the wallet's UTXOs
+// SendBitcoin menyusun, menandatangani dan menyiarkan transaksi dari UTXO wallet
+func (s *WalletService) SendBitcoin(ctx context.Context, fromWalletID, toAddress string, amount domain.Amount, opts SendOptions) (*domain.Transaction, error) {
 	// Validate amount
-	if amount <= 0 {
+	if amount <= 0 || opts.FeeRate < 0 {
 		return nil, domain.ErrInvalidAmount
 	}
 
@@ -131,109 +480,69 @@ func (s *WalletService) SendBitcoin(fromWalletID, toAddress string, amount, fee
 		return nil, err
 	}
 
-	// Check balance
-	totalAmount := amount + fee
-	if senderWallet.Balance < totalAmount {
-		return nil, domain.ErrInsufficientBalance
+	if err := s.checkNetwork(senderWallet); err != nil {
+		return nil, err
 	}
 
-	// Create transaction hash
-	txHash := s.crypto.HashTransaction(
-		senderWallet.Address,
-		toAddress,
-		amount,
-		time.Now().Unix(),
-	)
-
-	// Sign transaction
-	signature, err := s.crypto.SignTransaction(txHash, senderWallet.PrivateKey)
-	if err != nil {
-		return nil, fmt.Errorf("failed to sign transaction: %w", err)
+	if err := requireSingleSigner(senderWallet); err != nil {
+		return nil, err
 	}
 
-	// Create transaction
-	transaction := domain.Transaction{
-		ID:        txHash,
-		From:      senderWallet.Address,
-		To:        toAddress,
-		Amount:    amount,
-		Fee:       fee,
-		Type:      "send",
-		Status:    "confirmed",
-		Timestamp: time.Now(),
-		Note:      fmt.Sprintf("%s | Signature: %s", note, signature[:16]+"..."),
+	if err := s.requireUnlocked(); err != nil {
+		return nil, err
 	}
 
-	// Add transaction to sender wallet
-	senderWallet.AddTransaction(transaction)
-
-	// Update sender wallet
-	if err := s.repo.Update(senderWallet); err != nil {
-		return nil, fmt.Errorf("%w: %v", domain.ErrStorageOperation, err)
+	funded, err := s.fundTransaction(ctx, senderWallet, toAddress, amount, opts)
+	if err != nil {
+		return nil, err
 	}
+	builder := funded.builder
 
-	// Try to update receiver wallet if exists in our system
-	receiverWallet, err := s.repo.FindByAddress(toAddress)
-	if err == nil && receiverWallet != nil {
-		receiveTx := domain.Transaction{
-			ID:        txHash,
-			From:      senderWallet.Address,
-			To:        toAddress,
-			Amount:    amount,
-			Fee:       0, // Receiver doesn't pay fee
-			Type:      "receive",
-			Status:    "confirmed",
-			Timestamp: time.Now(),
-			Note:      note,
+	// Sign each input with the key of the address it spends from
+	for i, input := range funded.inputs {
+		privKey, err := s.privateKeyForAddress(senderWallet, input.Address)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", domain.ErrInvalidPrivateKey, err)
+		}
+		if err := builder.SignInput(i, privKey); err != nil {
+			return nil, fmt.Errorf("failed to sign transaction: %w", err)
 		}
-
-		receiverWallet.AddTransaction(receiveTx)
-		_ = s.repo.Update(receiverWallet) // Ignore error for receiver
-	}
-
-	return &transaction, nil
-}
-
-// ReceiveBitcoin records incoming Bitcoin to a wallet
-// ReceiveBitcoin mencatat Bitcoin masuk ke wallet
-func (s *WalletService) ReceiveBitcoin(toWalletID, fromAddress string, amount float64, note string) (*domain.Transaction, error) {
-	// Validate amount
-	if amount <= 0 {
-		return nil, domain.ErrInvalidAmount
 	}
 
-	// Get receiver wallet
-	receiverWallet, err := s.repo.FindByID(toWalletID)
+	// Broadcast transaction
+	tx := builder.Transaction()
+	reported, err := s.backend.BroadcastTransaction(ctx, tx.Hex())
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to broadcast transaction: %w", err)
 	}
-
-	// Create transaction hash
-	txHash := s.crypto.HashTransaction(
-		fromAddress,
-		receiverWallet.Address,
-		amount,
-		time.Now().Unix(),
-	)
+	txID := tx.TxID()
 
 	// Create transaction
 	transaction := domain.Transaction{
-		ID:        txHash,
-		From:      fromAddress,
-		To:        receiverWallet.Address,
+		ID:        txID,
+		From:      senderWallet.Address,
+		To:        toAddress,
 		Amount:    amount,
-		Fee:       0,
-		Type:      "receive",
-		Status:    "confirmed",
+		Fee:       domain.Amount(builder.Fee()),
+		FeeRate:   float64(builder.Fee()) / float64(tx.VSize()),
+		VSize:     tx.VSize(),
+		Type:      "send",
+		Status:    "pending",
 		Timestamp: time.Now(),
-		Note:      note,
+		Note:      opts.Note,
+		RBF:       tx.SignalsRBF(),
+		Hex:       tx.Hex(),
+		Inputs:    funded.inputs,
+
+		ReportedTxID: reportedTxID(reported, txID),
 	}
 
-	// Add transaction to wallet
-	receiverWallet.AddTransaction(transaction)
+	// Add transaction to sender wallet and replace the spent outputs with the change
+	senderWallet.AddTransaction(transaction)
+	funded.applyTo(senderWallet, txID)
 
-	// Update wallet
-	if err := s.repo.Update(receiverWallet); err != nil {
+	// Update sender wallet
+	if err := s.repo.Update(senderWallet); err != nil {
 		return nil, fmt.Errorf("%w: %v", domain.ErrStorageOperation, err)
 	}
 
@@ -265,12 +574,41 @@ func (s *WalletService) ExportPrivateKey(walletID string) (string, error) {
 		return "", err
 	}
 
+	if err := requireSingleSigner(wallet); err != nil {
+		return "", err
+	}
+
+	if err := s.requireUnlocked(); err != nil {
+		return "", err
+	}
+
 	return wallet.PrivateKey, nil
 }
 
-// ImportWallet imports a wallet from private key
+// ExportWIF exports the private key in Wallet Import Format for the wallet's own network
+// ExportWIF mengekspor private key dalam format WIF sesuai jaringan wallet
+func (s *WalletService) ExportWIF(walletID string) (string, error) {
+	privateKey, err := s.ExportPrivateKey(walletID)
+	if err != nil {
+		return "", err
+	}
+
+	wallet, err := s.repo.FindByID(walletID)
+	if err != nil {
+		return "", err
+	}
+
+	params, err := chaincfg.ParamsForName(wallet.NetworkName())
+	if err != nil {
+		return "", err
+	}
+
+	return crypto.NewBitcoinCryptoForNetwork(params).ConvertToWIF(privateKey, true)
+}
+
+// ImportWallet imports a wallet from private key, using addressType (default p2wpkh) for its address
 // ImportWallet mengimpor wallet dari private key
-func (s *WalletService) ImportWallet(name, privateKeyHex string) (*domain.Wallet, error) {
+func (s *WalletService) ImportWallet(name, privateKeyHex string, addressType domain.AddressType) (*domain.Wallet, error) {
 	// Validate input
 	if name == "" {
 		return nil, fmt.Errorf("wallet name cannot be empty")
@@ -280,6 +618,10 @@ func (s *WalletService) ImportWallet(name, privateKeyHex string) (*domain.Wallet
 		return nil, domain.ErrInvalidPrivateKey
 	}
 
+	if err := s.requireUnlocked(); err != nil {
+		return nil, err
+	}
+
 	// Try to derive public key from private key
 	privKey, err := s.crypto.PrivateKeyFromHex(privateKeyHex)
 	if err != nil {
@@ -287,10 +629,13 @@ func (s *WalletService) ImportWallet(name, privateKeyHex string) (*domain.Wallet
 	}
 
 	// Get public key
-	publicKey := fmt.Sprintf("04%064x%064x", privKey.PublicKey.X, privKey.PublicKey.Y)
+	publicKey := s.crypto.CompressPublicKey(&privKey.PublicKey)
 
 	// Generate address
-	address, err := s.crypto.GenerateAddress(publicKey)
+	if addressType == "" {
+		addressType = domain.DefaultAddressType
+	}
+	address, err := s.addressFor(publicKey, addressType)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate address: %w", err)
 	}
@@ -302,7 +647,9 @@ func (s *WalletService) ImportWallet(name, privateKeyHex string) (*domain.Wallet
 		PrivateKey:   privateKeyHex,
 		PublicKey:    publicKey,
 		Address:      address,
-		Balance:      0.0,
+		AddressType:  addressType,
+		Network:      s.params.Name,
+		Balance:      0,
 		Transactions: []domain.Transaction{},
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
@@ -316,22 +663,37 @@ func (s *WalletService) ImportWallet(name, privateKeyHex string) (*domain.Wallet
 	return wallet, nil
 }
 
-// SyncWallet syncs wallet balance and transactions with the Bitcoin blockchain (MAINNET ONLY)
-// SyncWallet sinkronisasi saldo wallet dan transaksi dengan blockchain Bitcoin (MAINNET SAJA)
-func (s *WalletService) SyncWallet(walletID string) (*domain.Wallet, error) {
+// SyncWallet syncs wallet balance and transactions with the blockchain of the service network
+// SyncWallet sinkronisasi saldo wallet dan transaksi dengan blockchain jaringan service
+func (s *WalletService) SyncWallet(ctx context.Context, walletID string) (*domain.Wallet, error) {
 	// Get wallet from repository
 	wallet, err := s.repo.FindByID(walletID)
 	if err != nil {
 		return nil, err
 	}
 
-	// Create blockchain explorer (MAINNET ONLY)
-	explorer := network.NewBlockchainExplorer()
+	if err := s.checkNetwork(wallet); err != nil {
+		return nil, err
+	}
+
+	if err := s.watchWallet(ctx, wallet); err != nil {
+		return nil, err
+	}
 
-	// Get balance from blockchain
-	balance, err := explorer.GetBalance(wallet.Address)
+	// Get balance of every wallet address from blockchain
+	balance, used, err := s.updateAddressStats(ctx, wallet, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch balance from blockchain: %w", err)
+		return nil, err
+	}
+
+	// Refresh the spendable outputs
+	if err := s.refreshUTXOs(ctx, wallet); err != nil {
+		return nil, err
+	}
+
+	// Merge the on-chain history of the used addresses
+	if err := s.syncTransactions(ctx, wallet, used); err != nil {
+		return nil, err
 	}
 
 	// Update wallet balance
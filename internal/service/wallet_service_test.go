@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/dhfai/go-wallet/internal/domain"
+	"github.com/dhfai/go-wallet/pkg/chaincfg"
+	"github.com/dhfai/go-wallet/pkg/network"
+	"github.com/dhfai/go-wallet/pkg/txbuilder"
+)
+
+// testRecipient is a regtest P2WPKH address outside every test wallet
+const testRecipient = "bcrt1qw508d6qejxtdg4y5r3zarvary0c5xw7kygt080"
+
+// memRepo is a WalletRepository kept in memory
+type memRepo struct {
+	wallets map[string]*domain.Wallet
+}
+
+func newMemRepo() *memRepo {
+	return &memRepo{wallets: make(map[string]*domain.Wallet)}
+}
+
+func (r *memRepo) Save(wallet *domain.Wallet) error {
+	if _, exists := r.wallets[wallet.ID]; exists {
+		return domain.ErrWalletExists
+	}
+	r.wallets[wallet.ID] = wallet
+	return nil
+}
+
+func (r *memRepo) FindByID(id string) (*domain.Wallet, error) {
+	wallet, ok := r.wallets[id]
+	if !ok {
+		return nil, domain.ErrWalletNotFound
+	}
+	return wallet, nil
+}
+
+func (r *memRepo) FindByAddress(address string) (*domain.Wallet, error) {
+	for _, wallet := range r.wallets {
+		if wallet.OwnsAddress(address) {
+			return wallet, nil
+		}
+	}
+	return nil, domain.ErrWalletNotFound
+}
+
+func (r *memRepo) FindAll() ([]*domain.Wallet, error) {
+	wallets := make([]*domain.Wallet, 0, len(r.wallets))
+	for _, wallet := range r.wallets {
+		wallets = append(wallets, wallet)
+	}
+	return wallets, nil
+}
+
+func (r *memRepo) Update(wallet *domain.Wallet) error {
+	if _, exists := r.wallets[wallet.ID]; !exists {
+		return domain.ErrWalletNotFound
+	}
+	r.wallets[wallet.ID] = wallet
+	return nil
+}
+
+func (r *memRepo) Delete(id string) error {
+	delete(r.wallets, id)
+	return nil
+}
+
+// fakeBackend is a ChainBackend answering from maps filled by the test
+type fakeBackend struct {
+	utxos     map[string][]network.UTXOInfo // By address
+	history   map[string][]network.TxInfo   // By address, newest first
+	txs       map[string]*network.TxInfo    // By txid
+	outspends map[string]*network.Outspend  // By outpoint
+	tip       int64
+
+	queried   []string // Addresses passed to GetAddressInfo, in order
+	broadcast []*txbuilder.Transaction
+}
+
+func newFakeBackend() *fakeBackend {
+	return &fakeBackend{
+		utxos:     make(map[string][]network.UTXOInfo),
+		history:   make(map[string][]network.TxInfo),
+		txs:       make(map[string]*network.TxInfo),
+		outspends: make(map[string]*network.Outspend),
+		tip:       100,
+	}
+}
+
+func (b *fakeBackend) GetAddressInfo(ctx context.Context, address string) (*network.AddressInfo, error) {
+	b.queried = append(b.queried, address)
+
+	info := &network.AddressInfo{Address: address}
+	info.ChainStats.TxCount = int64(len(b.history[address]))
+	for _, utxo := range b.utxos[address] {
+		info.ChainStats.FundedTxoCount++
+		info.ChainStats.FundedTxoSum += utxo.Value
+	}
+	return info, nil
+}
+
+func (b *fakeBackend) GetBalance(ctx context.Context, address string) (int64, error) {
+	info, err := b.GetAddressInfo(ctx, address)
+	if err != nil {
+		return 0, err
+	}
+	return info.Balance(), nil
+}
+
+func (b *fakeBackend) GetUTXOs(ctx context.Context, address string) ([]network.UTXOInfo, error) {
+	return b.utxos[address], nil
+}
+
+func (b *fakeBackend) GetTransactionHistory(ctx context.Context, address string) ([]network.TxInfo, error) {
+	return b.history[address], nil
+}
+
+func (b *fakeBackend) GetTransaction(ctx context.Context, txID string) (*network.TxInfo, error) {
+	tx, ok := b.txs[txID]
+	if !ok {
+		return nil, fmt.Errorf("%w: transaction %s", network.ErrNotFound, txID)
+	}
+	return tx, nil
+}
+
+func (b *fakeBackend) GetRawTransaction(ctx context.Context, txID string) (string, error) {
+	for _, tx := range b.broadcast {
+		if tx.TxID() == txID {
+			return tx.Hex(), nil
+		}
+	}
+	return "", fmt.Errorf("%w: transaction %s", network.ErrNotFound, txID)
+}
+
+func (b *fakeBackend) GetOutspend(ctx context.Context, txID string, vout uint32) (*network.Outspend, error) {
+	if outspend, ok := b.outspends[fmt.Sprintf("%s:%d", txID, vout)]; ok {
+		return outspend, nil
+	}
+	return &network.Outspend{}, nil
+}
+
+func (b *fakeBackend) BroadcastTransaction(ctx context.Context, txHex string) (string, error) {
+	tx, err := txbuilder.ParseTransactionHex(txHex)
+	if err != nil {
+		return "", err
+	}
+	b.broadcast = append(b.broadcast, tx)
+	return tx.TxID(), nil
+}
+
+func (b *fakeBackend) GetFeeEstimates(ctx context.Context) (network.FeeEstimates, error) {
+	return network.FeeEstimates{1: 20, 6: 10, 144: 2}, nil
+}
+
+func (b *fakeBackend) GetTipHeight(ctx context.Context) (int64, error) {
+	return b.tip, nil
+}
+
+// lastBroadcast returns the transaction broadcast last
+func (b *fakeBackend) lastBroadcast(t *testing.T) *txbuilder.Transaction {
+	t.Helper()
+	if len(b.broadcast) == 0 {
+		t.Fatal("nothing was broadcast")
+	}
+	return b.broadcast[len(b.broadcast)-1]
+}
+
+// newTestService returns a regtest service on an in-memory repository and a fake backend
+func newTestService(t *testing.T) (*WalletService, *memRepo, *fakeBackend) {
+	t.Helper()
+	repo := newMemRepo()
+	backend := newFakeBackend()
+	service := NewWalletService(repo, &chaincfg.RegressionNetParams)
+	service.SetBackend(backend)
+	return service, repo, backend
+}
+
+// newFundedWallet creates an HD wallet of addressType whose primary address
+// holds one confirmed output of value sats
+func newFundedWallet(t *testing.T, service *WalletService, backend *fakeBackend, addressType domain.AddressType, value int64) *domain.Wallet {
+	t.Helper()
+	wallet, _, err := service.CreateWallet("test", CreateWalletOptions{AddressType: addressType})
+	if err != nil {
+		t.Fatalf("CreateWallet: %v", err)
+	}
+	backend.utxos[wallet.Address] = []network.UTXOInfo{{
+		TxID:   fmt.Sprintf("%064x", len(backend.utxos)+1),
+		Vout:   0,
+		Value:  value,
+		Status: network.TxStatus{Confirmed: true, BlockHeight: 90},
+	}}
+	return wallet
+}
+
+func TestReportedTxID(t *testing.T) {
+	for _, tc := range []struct {
+		reported, local, want string
+	}{
+		{"abc", "abc", ""},
+		{" ABC\n", "abc", ""},
+		{"def", "abc", "def"},
+	} {
+		if got := reportedTxID(tc.reported, tc.local); got != tc.want {
+			t.Errorf("reportedTxID(%q, %q) = %q, want %q", tc.reported, tc.local, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dhfai/go-wallet/internal/domain"
+	"github.com/dhfai/go-wallet/pkg/network"
+)
+
+func TestWatchOnlyRefusesToSign(t *testing.T) {
+	ctx := context.Background()
+
+	// The signer and the watch-only copy of its account live in separate stores
+	signer, _, _ := newTestService(t)
+	hot, _, err := signer.CreateWallet("hot", CreateWalletOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	service, _, backend := newTestService(t)
+	watch, err := service.ImportWatchOnly("watch", []string{hot.AccountXpub}, "")
+	if err != nil {
+		t.Fatalf("ImportWatchOnly: %v", err)
+	}
+	if watch.Address != hot.Address {
+		t.Fatalf("watch-only address = %s, want %s", watch.Address, hot.Address)
+	}
+	backend.utxos[watch.Address] = []network.UTXOInfo{{
+		TxID:   strings.Repeat("11", 32),
+		Value:  100000,
+		Status: network.TxStatus{Confirmed: true, BlockHeight: 90},
+	}}
+
+	for _, tc := range []struct {
+		name string
+		call func() error
+	}{
+		{"send", func() error {
+			_, err := service.SendBitcoin(ctx, watch.ID, testRecipient, 10000, SendOptions{FeeRate: 2})
+			return err
+		}},
+		{"bumpfee", func() error {
+			_, err := service.BumpFee(ctx, watch.ID, strings.Repeat("22", 32), 10)
+			return err
+		}},
+		{"cpfp", func() error {
+			_, err := service.ChildPaysForParent(ctx, watch.ID, strings.Repeat("33", 32), 10)
+			return err
+		}},
+	} {
+		if err := tc.call(); !errors.Is(err, domain.ErrWatchOnly) {
+			t.Errorf("%s from a watch-only wallet error = %v, want ErrWatchOnly", tc.name, err)
+		}
+	}
+	if len(backend.broadcast) != 0 {
+		t.Errorf("%d transactions broadcast from a watch-only wallet", len(backend.broadcast))
+	}
+
+	// A watch-only wallet creates the PSBT, but only the key holder signs it
+	packet, err := service.CreatePSBT(ctx, watch.ID, testRecipient, 10000, SendOptions{FeeRate: 2})
+	if err != nil {
+		t.Fatalf("CreatePSBT: %v", err)
+	}
+	if _, err := service.SignPSBT(watch.ID, packet); !errors.Is(err, domain.ErrWatchOnly) {
+		t.Errorf("psbt sign with a watch-only wallet error = %v, want ErrWatchOnly", err)
+	}
+	signed, err := signer.SignPSBT(hot.ID, packet)
+	if err != nil || signed != 1 {
+		t.Errorf("psbt sign with the key holder = %d, %v, want 1 input signed", signed, err)
+	}
+}
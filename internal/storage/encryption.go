@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/dhfai/go-wallet/internal/domain"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	kdfScrypt          = "scrypt"
+	cipherXChaCha20    = "xchacha20-poly1305"
+	encryptionKeySize  = chacha20poly1305.KeySize
+	encryptionSaltSize = 32
+
+	// Default scrypt cost: N=2^15, r=8, p=1 (~100ms, 32 MiB)
+	defaultScryptN = 1 << 15
+	defaultScryptR = 8
+	defaultScryptP = 1
+)
+
+// passphraseCheck is sealed with the derived key so a wrong passphrase is
+// detected even when the file holds no wallets
+var passphraseCheck = []byte("go-wallet passphrase check")
+
+// encryptionParams describes how the key encrypting wallet secrets is derived
+// encryptionParams menjelaskan cara key enkripsi secret wallet diturunkan
+type encryptionParams struct {
+	KDF    string `json:"kdf"`    // Key derivation function ("scrypt")
+	Salt   string `json:"salt"`   // Base64 KDF salt
+	N      int    `json:"n"`      // scrypt CPU/memory cost
+	R      int    `json:"r"`      // scrypt block size
+	P      int    `json:"p"`      // scrypt parallelism
+	Cipher string `json:"cipher"` // AEAD cipher ("xchacha20-poly1305")
+	Check  string `json:"check"`  // Sealed passphraseCheck, verifies the passphrase
+}
+
+// newEncryptionParams creates parameters with a fresh random salt
+func newEncryptionParams() (*encryptionParams, error) {
+	salt := make([]byte, encryptionSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	return &encryptionParams{
+		KDF:    kdfScrypt,
+		Salt:   base64.StdEncoding.EncodeToString(salt),
+		N:      defaultScryptN,
+		R:      defaultScryptR,
+		P:      defaultScryptP,
+		Cipher: cipherXChaCha20,
+	}, nil
+}
+
+// deriveKey derives the encryption key from a passphrase
+// deriveKey menurunkan key enkripsi dari passphrase
+func (p *encryptionParams) deriveKey(passphrase string) ([]byte, error) {
+	if p.KDF != kdfScrypt || p.Cipher != cipherXChaCha20 {
+		return nil, fmt.Errorf("unsupported encryption scheme %s/%s", p.KDF, p.Cipher)
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(p.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid salt: %w", err)
+	}
+
+	key, err := scrypt.Key([]byte(passphrase), salt, p.N, p.R, p.P, encryptionKeySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+
+	return key, nil
+}
+
+// verifyKey checks key against the sealed passphrase check value
+func (p *encryptionParams) verifyKey(key []byte) error {
+	plaintext, err := open(key, p.Check, nil)
+	if err != nil || subtle.ConstantTimeCompare(plaintext, passphraseCheck) != 1 {
+		return domain.ErrInvalidPassphrase
+	}
+	return nil
+}
+
+// seal encrypts plaintext with XChaCha20-Poly1305 and returns base64(nonce || ciphertext).
+// additionalData is authenticated but not encrypted.
+// seal mengenkripsi plaintext dengan XChaCha20-Poly1305
+func seal(key, plaintext, additionalData []byte) (string, error) {
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := aead.Seal(nonce, nonce, plaintext, additionalData)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// open decrypts a value produced by seal
+// open mendekripsi nilai yang dihasilkan oleh seal
+func open(key []byte, encoded string, additionalData []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ciphertext encoding: %w", err)
+	}
+	if len(sealed) < aead.NonceSize()+aead.Overhead() {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additionalData)
+}
+
+// wipe overwrites a key in memory
+func wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
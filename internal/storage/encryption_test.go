@@ -0,0 +1,334 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dhfai/go-wallet/internal/domain"
+)
+
+const (
+	testPrivateKey = "KwDiBf89QgGbjEhKnhXJuH7LrciVrZi3qYjgd9M7rFU73sVHnoWn"
+	testXprv       = "xprv9s21ZrQH143K3QTDL4LXw2F7HEK3wJUD2nW2nRk4stbPy6cq3jPPqjiChkVvvNKmPGJxWUtg6LnF5kejMRNNU3TGtRBeJgk33yuGBxrMPHi"
+)
+
+func testWallet(id, name string, balance domain.Amount) *domain.Wallet {
+	return &domain.Wallet{
+		ID:          id,
+		Name:        name,
+		Address:     "bc1q" + id,
+		Balance:     balance,
+		PrivateKey:  testPrivateKey,
+		AccountXprv: testXprv,
+	}
+}
+
+// readWalletFile returns the wallets file as stored on disk
+func readWalletFile(t *testing.T, path string) walletFile {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte(testPrivateKey)) || bytes.Contains(data, []byte(testXprv)) {
+		t.Fatalf("%s holds plaintext key material", path)
+	}
+	var file walletFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		t.Fatalf("unmarshal %s: %v", path, err)
+	}
+	return file
+}
+
+func TestSealOpenRoundTrip(t *testing.T) {
+	params, err := newEncryptionParams()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := params.deriveKey("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sealed, err := seal(key, []byte("secret"), []byte("w1"))
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	plaintext, err := open(key, sealed, []byte("w1"))
+	if err != nil || string(plaintext) != "secret" {
+		t.Errorf("open = %q, %v, want %q", plaintext, err, "secret")
+	}
+
+	// The wallet ID is authenticated, so a blob moved to another wallet does not open
+	if _, err := open(key, sealed, []byte("w2")); err == nil {
+		t.Error("open with another wallet ID succeeded")
+	}
+
+	otherKey, err := params.deriveKey("wrong horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := open(otherKey, sealed, []byte("w1")); err == nil {
+		t.Error("open with another key succeeded")
+	}
+}
+
+func TestEncryptPlaintextFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wallets.json")
+	wallet := `{"id":"w1","name":"old","address":"1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH","balance":0.5,` +
+		`"private_key":"` + testPrivateKey + `","transactions":null}`
+	if err := os.WriteFile(path, []byte("["+wallet+"]"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := NewJSONWalletRepository(path)
+	if err != nil {
+		t.Fatalf("NewJSONWalletRepository: %v", err)
+	}
+	if repo.IsEncrypted() {
+		t.Fatal("plaintext file reported encrypted")
+	}
+
+	// The old passphrase is ignored on a plaintext file
+	if err := repo.ChangePassphrase("ignored", "passphrase"); err != nil {
+		t.Fatalf("ChangePassphrase: %v", err)
+	}
+	file := readWalletFile(t, path)
+	if file.Encryption == nil || len(file.Wallets) != 1 || file.Wallets[0].EncryptedSecrets == "" {
+		t.Fatalf("file after setting a passphrase = %+v, want encrypted secrets", file)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temporary file left behind: %v", err)
+	}
+
+	reopened, err := NewJSONWalletRepository(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if !reopened.IsLocked() {
+		t.Fatal("reopened repository is not locked")
+	}
+	if err := reopened.Unlock("passphrase"); err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+	loaded, err := reopened.FindByID("w1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.PrivateKey != testPrivateKey || loaded.Balance != 50000000 {
+		t.Errorf("unlocked wallet key, balance = %q, %d, want %q, 50000000", loaded.PrivateKey, loaded.Balance, testPrivateKey)
+	}
+}
+
+func TestUnlockWrongPassphrase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wallets.json")
+	repo, err := NewJSONWalletRepository(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.ChangePassphrase("", "passphrase"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The check value catches a wrong passphrase even without any wallets
+	reopened, err := NewJSONWalletRepository(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reopened.Unlock("wrong"); !errors.Is(err, domain.ErrInvalidPassphrase) {
+		t.Errorf("Unlock with a wrong passphrase error = %v, want ErrInvalidPassphrase", err)
+	}
+	if !reopened.IsLocked() {
+		t.Error("repository unlocked by a wrong passphrase")
+	}
+}
+
+func TestMovedSecretsDoNotOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wallets.json")
+	repo, err := NewJSONWalletRepository(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.ChangePassphrase("", "passphrase"); err != nil {
+		t.Fatal(err)
+	}
+	for _, wallet := range []*domain.Wallet{testWallet("w1", "first", 1000), testWallet("w2", "second", 2000)} {
+		if err := repo.Save(wallet); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Swap the sealed secrets of both wallets in the file
+	file := readWalletFile(t, path)
+	file.Wallets[0].EncryptedSecrets, file.Wallets[1].EncryptedSecrets = file.Wallets[1].EncryptedSecrets, file.Wallets[0].EncryptedSecrets
+	data, err := json.Marshal(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewJSONWalletRepository(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reopened.Unlock("passphrase"); err == nil || !strings.Contains(err.Error(), "failed to decrypt wallet") {
+		t.Errorf("Unlock with swapped secrets error = %v, want a decryption failure", err)
+	}
+	if !reopened.IsLocked() {
+		t.Error("repository unlocked with swapped secrets")
+	}
+}
+
+func TestUpdateWhileLocked(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wallets.json")
+	repo, err := NewJSONWalletRepository(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.ChangePassphrase("", "passphrase"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Save(testWallet("w1", "first", 1000)); err != nil {
+		t.Fatal(err)
+	}
+	repo.Lock()
+
+	// Public data can change while locked and the sealed secrets stay in place
+	wallet, err := repo.FindByID("w1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wallet.PrivateKey != "" || wallet.AccountXprv != "" {
+		t.Fatal("Lock kept key material in memory")
+	}
+	wallet.Name = "renamed"
+	if err := repo.Update(wallet); err != nil {
+		t.Fatalf("Update while locked: %v", err)
+	}
+	if file := readWalletFile(t, path); file.Wallets[0].EncryptedSecrets == "" {
+		t.Error("Update while locked dropped the sealed secrets")
+	}
+
+	// New key material cannot be encrypted without the key
+	wallet.PrivateKey = testPrivateKey
+	if err := repo.Update(wallet); !errors.Is(err, domain.ErrWalletLocked) {
+		t.Errorf("Update with key material while locked error = %v, want ErrWalletLocked", err)
+	}
+	if err := repo.Save(testWallet("w2", "second", 2000)); !errors.Is(err, domain.ErrWalletLocked) {
+		t.Errorf("Save with key material while locked error = %v, want ErrWalletLocked", err)
+	}
+	wallet.PrivateKey = ""
+
+	reopened, err := NewJSONWalletRepository(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reopened.Unlock("passphrase"); err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+	loaded, err := reopened.FindByID("w1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Name != "renamed" || loaded.PrivateKey != testPrivateKey || loaded.AccountXprv != testXprv {
+		t.Errorf("wallet after a locked update = %q with keys %q, %q, want renamed with the original keys", loaded.Name, loaded.PrivateKey, loaded.AccountXprv)
+	}
+}
+
+func TestChangePassphraseReseals(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wallets.json")
+	repo, err := NewJSONWalletRepository(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.ChangePassphrase("", "old"); err != nil {
+		t.Fatal(err)
+	}
+	for _, wallet := range []*domain.Wallet{testWallet("w1", "first", 1000), testWallet("w2", "second", 2000)} {
+		if err := repo.Save(wallet); err != nil {
+			t.Fatal(err)
+		}
+	}
+	before := readWalletFile(t, path)
+
+	if err := repo.ChangePassphrase("wrong", "new"); !errors.Is(err, domain.ErrInvalidPassphrase) {
+		t.Errorf("ChangePassphrase with a wrong old passphrase error = %v, want ErrInvalidPassphrase", err)
+	}
+	if err := repo.ChangePassphrase("old", "new"); err != nil {
+		t.Fatalf("ChangePassphrase: %v", err)
+	}
+
+	after := readWalletFile(t, path)
+	if after.Encryption.Salt == before.Encryption.Salt {
+		t.Error("ChangePassphrase kept the old salt")
+	}
+	sealedBefore := make(map[string]string)
+	for _, record := range before.Wallets {
+		sealedBefore[record.ID] = record.EncryptedSecrets
+	}
+	for _, record := range after.Wallets {
+		if record.EncryptedSecrets == "" || record.EncryptedSecrets == sealedBefore[record.ID] {
+			t.Errorf("wallet %s was not re-sealed", record.ID)
+		}
+	}
+
+	reopened, err := NewJSONWalletRepository(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reopened.Unlock("old"); !errors.Is(err, domain.ErrInvalidPassphrase) {
+		t.Errorf("Unlock with the old passphrase error = %v, want ErrInvalidPassphrase", err)
+	}
+	if err := reopened.Unlock("new"); err != nil {
+		t.Fatalf("Unlock with the new passphrase: %v", err)
+	}
+	for _, id := range []string{"w1", "w2"} {
+		wallet, err := reopened.FindByID(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if wallet.PrivateKey != testPrivateKey || wallet.AccountXprv != testXprv {
+			t.Errorf("wallet %s keys after ChangePassphrase = %q, %q", id, wallet.PrivateKey, wallet.AccountXprv)
+		}
+	}
+}
+
+func TestListWithoutPassphrase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wallets.json")
+	repo, err := NewJSONWalletRepository(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.ChangePassphrase("", "passphrase"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Save(testWallet("w1", "first", 1000)); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewJSONWalletRepository(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wallets, err := reopened.FindAll()
+	if err != nil {
+		t.Fatalf("FindAll: %v", err)
+	}
+	if len(wallets) != 1 {
+		t.Fatalf("FindAll returned %d wallets, want 1", len(wallets))
+	}
+	info := wallets[0].ToInfo()
+	if info.Name != "first" || info.Address != "bc1qw1" || info.Balance != 1000 {
+		t.Errorf("locked wallet info = %+v, want first, bc1qw1, 1000 sats", info)
+	}
+	if wallets[0].PrivateKey != "" || wallets[0].AccountXprv != "" {
+		t.Error("locked repository returned key material")
+	}
+}
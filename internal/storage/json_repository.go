@@ -1,7 +1,9 @@
 package storage
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -10,12 +12,51 @@ import (
 	"github.com/dhfai/go-wallet/internal/domain"
 )
 
-// JSONWalletRepository implements WalletRepository using JSON file storage
+// walletFileVersion is the current version of the storage format:
+//
+//	0: bare JSON array of wallets, amounts in BTC (float)
+//	1: encrypted envelope, amounts in BTC (float)
+//	2: envelope with optional encryption, amounts in satoshis
+const walletFileVersion = 2
+
+// JSONWalletRepository implements WalletRepository using JSON file storage.
+// Once a passphrase is set, private keys are stored encrypted and only
+// available after Unlock; public wallet data stays readable.
 // JSONWalletRepository mengimplementasikan WalletRepository menggunakan penyimpanan file JSON
 type JSONWalletRepository struct {
-	filePath string
-	mu       sync.RWMutex
-	wallets  map[string]*domain.Wallet
+	filePath   string
+	mu         sync.RWMutex
+	wallets    map[string]*domain.Wallet
+	encryption *encryptionParams // nil while the file is stored in plaintext
+	sealed     map[string]string // Encrypted secrets by wallet ID
+	key        []byte            // Derived encryption key, nil while locked
+}
+
+// walletFile is the on-disk layout of the wallets file
+type walletFile struct {
+	Version    int               `json:"version"`
+	Encryption *encryptionParams `json:"encryption,omitempty"`
+	Wallets    []walletRecord    `json:"wallets"`
+}
+
+// walletRecord is a wallet without key material plus its encrypted secrets
+type walletRecord struct {
+	*domain.Wallet
+	EncryptedSecrets string `json:"encrypted_secrets,omitempty"`
+}
+
+// walletSecrets holds the key material that is encrypted at rest
+type walletSecrets struct {
+	PrivateKey  string `json:"private_key,omitempty"`
+	AccountXprv string `json:"account_xprv,omitempty"`
+}
+
+func secretsOf(wallet *domain.Wallet) walletSecrets {
+	return walletSecrets{PrivateKey: wallet.PrivateKey, AccountXprv: wallet.AccountXprv}
+}
+
+func (s walletSecrets) empty() bool {
+	return s.PrivateKey == "" && s.AccountXprv == ""
 }
 
 // NewJSONWalletRepository creates a new JSONWalletRepository
@@ -24,6 +65,7 @@ func NewJSONWalletRepository(filePath string) (*JSONWalletRepository, error) {
 	repo := &JSONWalletRepository{
 		filePath: filePath,
 		wallets:  make(map[string]*domain.Wallet),
+		sealed:   make(map[string]string),
 	}
 
 	// Create directory if not exists
@@ -54,6 +96,10 @@ func (r *JSONWalletRepository) Save(wallet *domain.Wallet) error {
 		return domain.ErrWalletExists
 	}
 
+	if err := r.checkWritable(wallet); err != nil {
+		return err
+	}
+
 	r.wallets[wallet.ID] = wallet
 	return r.persist()
 }
@@ -79,7 +125,7 @@ func (r *JSONWalletRepository) FindByAddress(address string) (*domain.Wallet, er
 	defer r.mu.RUnlock()
 
 	for _, wallet := range r.wallets {
-		if wallet.Address == address {
+		if wallet.OwnsAddress(address) {
 			return wallet, nil
 		}
 	}
@@ -111,6 +157,10 @@ func (r *JSONWalletRepository) Update(wallet *domain.Wallet) error {
 		return domain.ErrWalletNotFound
 	}
 
+	if err := r.checkWritable(wallet); err != nil {
+		return err
+	}
+
 	r.wallets[wallet.ID] = wallet
 	return r.persist()
 }
@@ -126,9 +176,19 @@ func (r *JSONWalletRepository) Delete(id string) error {
 	}
 
 	delete(r.wallets, id)
+	delete(r.sealed, id)
 	return r.persist()
 }
 
+// checkWritable rejects new key material while the repository is locked,
+// since it could not be encrypted
+func (r *JSONWalletRepository) checkWritable(wallet *domain.Wallet) error {
+	if r.encryption != nil && r.key == nil && !secretsOf(wallet).empty() {
+		return domain.ErrWalletLocked
+	}
+	return nil
+}
+
 // persist saves wallets to JSON file
 // persist menyimpan wallets ke file JSON
 func (r *JSONWalletRepository) persist() error {
@@ -138,14 +198,52 @@ func (r *JSONWalletRepository) persist() error {
 		walletSlice = append(walletSlice, wallet)
 	}
 
+	records := make([]walletRecord, 0, len(walletSlice))
+	for _, wallet := range walletSlice {
+		if r.encryption == nil {
+			records = append(records, walletRecord{Wallet: wallet})
+			continue
+		}
+
+		// Encrypted storage keeps key material out of the plaintext records
+		secrets := secretsOf(wallet)
+		if r.key != nil && !secrets.empty() {
+			plaintext, err := json.Marshal(secrets)
+			if err != nil {
+				return fmt.Errorf("failed to marshal secrets: %w", err)
+			}
+			sealed, err := seal(r.key, plaintext, []byte(wallet.ID))
+			if err != nil {
+				return fmt.Errorf("failed to encrypt wallet %s: %w", wallet.ID, err)
+			}
+			r.sealed[wallet.ID] = sealed
+		}
+
+		public := *wallet
+		public.PrivateKey = ""
+		public.AccountXprv = ""
+		records = append(records, walletRecord{Wallet: &public, EncryptedSecrets: r.sealed[wallet.ID]})
+	}
+
+	payload := walletFile{
+		Version:    walletFileVersion,
+		Encryption: r.encryption,
+		Wallets:    records,
+	}
+
 	// Marshal to JSON
-	data, err := json.MarshalIndent(walletSlice, "", "  ")
+	data, err := json.MarshalIndent(payload, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal wallets: %w", err)
 	}
 
-	// Write to file
-	if err := os.WriteFile(r.filePath, data, 0600); err != nil {
+	// Write to a temporary file and rename it so a crash never leaves a truncated file
+	tmpPath := r.filePath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := os.Rename(tmpPath, r.filePath); err != nil {
+		os.Remove(tmpPath)
 		return fmt.Errorf("failed to write file: %w", err)
 	}
 
@@ -161,18 +259,199 @@ func (r *JSONWalletRepository) load() error {
 		return err
 	}
 
-	// Unmarshal JSON
-	var walletSlice []*domain.Wallet
-	if err := json.Unmarshal(data, &walletSlice); err != nil {
+	r.wallets = make(map[string]*domain.Wallet)
+	r.sealed = make(map[string]string)
+
+	var file struct {
+		Version    int               `json:"version"`
+		Encryption *encryptionParams `json:"encryption"`
+		Wallets    []json.RawMessage `json:"wallets"`
+	}
+
+	// Version 0 files are a bare JSON array of wallets
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		err = json.Unmarshal(data, &file.Wallets)
+	} else {
+		err = json.Unmarshal(data, &file)
+	}
+	if err != nil {
 		return fmt.Errorf("failed to unmarshal wallets: %w", err)
 	}
+	if file.Version > walletFileVersion {
+		return fmt.Errorf("unsupported wallet file version %d", file.Version)
+	}
 
-	// Convert slice to map
-	r.wallets = make(map[string]*domain.Wallet)
-	for _, wallet := range walletSlice {
-		r.wallets[wallet.ID] = wallet
+	r.encryption = file.Encryption
+	for _, raw := range file.Wallets {
+		if file.Version < 2 {
+			if raw, err = migrateAmounts(raw); err != nil {
+				return fmt.Errorf("failed to migrate wallet amounts: %w", err)
+			}
+		}
+
+		var record walletRecord
+		if err := json.Unmarshal(raw, &record); err != nil {
+			return fmt.Errorf("failed to unmarshal wallet: %w", err)
+		}
+		if record.Wallet == nil {
+			continue
+		}
+
+		r.wallets[record.ID] = record.Wallet
+		if record.EncryptedSecrets != "" {
+			r.sealed[record.ID] = record.EncryptedSecrets
+		}
+	}
+
+	// Rewrite older files in the current format
+	if file.Version < walletFileVersion {
+		return r.persist()
+	}
+
+	return nil
+}
+
+// IsEncrypted reports whether key material is encrypted at rest
+// IsEncrypted memeriksa apakah key disimpan terenkripsi
+func (r *JSONWalletRepository) IsEncrypted() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.encryption != nil
+}
+
+// IsLocked reports whether private keys are currently unavailable
+// IsLocked memeriksa apakah private key sedang tidak tersedia
+func (r *JSONWalletRepository) IsLocked() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.encryption != nil && r.key == nil
+}
+
+// Unlock derives the key from passphrase and decrypts the key material of every wallet
+// Unlock menurunkan key dari passphrase dan mendekripsi key semua wallet
+func (r *JSONWalletRepository) Unlock(passphrase string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.encryption == nil || r.key != nil {
+		return nil
+	}
+
+	key, err := r.encryption.deriveKey(passphrase)
+	if err != nil {
+		return err
+	}
+	if err := r.encryption.verifyKey(key); err != nil {
+		wipe(key)
+		return err
+	}
+
+	decrypted := make(map[string]walletSecrets, len(r.sealed))
+	for id, sealed := range r.sealed {
+		plaintext, err := open(key, sealed, []byte(id))
+		if err != nil {
+			wipe(key)
+			return fmt.Errorf("failed to decrypt wallet %s: %w", id, err)
+		}
+
+		var secrets walletSecrets
+		err = json.Unmarshal(plaintext, &secrets)
+		wipe(plaintext)
+		if err != nil {
+			wipe(key)
+			return fmt.Errorf("failed to decode wallet %s secrets: %w", id, err)
+		}
+		decrypted[id] = secrets
+	}
+
+	for id, secrets := range decrypted {
+		if wallet, ok := r.wallets[id]; ok {
+			wallet.PrivateKey = secrets.PrivateKey
+			wallet.AccountXprv = secrets.AccountXprv
+		}
+	}
+	r.key = key
+
+	return nil
+}
+
+// Lock forgets the encryption key and clears decrypted key material from memory
+// Lock menghapus key enkripsi dan private key dari memori
+func (r *JSONWalletRepository) Lock() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.encryption == nil {
+		return
+	}
+
+	wipe(r.key)
+	r.key = nil
+
+	for _, wallet := range r.wallets {
+		wallet.PrivateKey = ""
+		wallet.AccountXprv = ""
+	}
+}
+
+// ChangePassphrase sets or changes the storage passphrase. On a plaintext
+// file oldPassphrase is ignored and all key material is encrypted (migration).
+// ChangePassphrase mengatur atau mengganti passphrase storage
+func (r *JSONWalletRepository) ChangePassphrase(oldPassphrase, newPassphrase string) error {
+	if newPassphrase == "" {
+		return errors.New("passphrase cannot be empty")
+	}
+
+	if r.IsEncrypted() {
+		if err := r.Unlock(oldPassphrase); err != nil {
+			return err
+		}
+
+		// Unlock is a no-op when already unlocked, so verify the old passphrase explicitly
+		r.mu.RLock()
+		params := r.encryption
+		r.mu.RUnlock()
+
+		oldKey, err := params.deriveKey(oldPassphrase)
+		if err != nil {
+			return err
+		}
+		err = params.verifyKey(oldKey)
+		wipe(oldKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	params, err := newEncryptionParams()
+	if err != nil {
+		return err
+	}
+	key, err := params.deriveKey(newPassphrase)
+	if err != nil {
+		return err
+	}
+	params.Check, err = seal(key, passphraseCheck, nil)
+	if err != nil {
+		wipe(key)
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	prevParams, prevKey, prevSealed := r.encryption, r.key, r.sealed
+	r.encryption, r.key, r.sealed = params, key, make(map[string]string)
+
+	if err := r.persist(); err != nil {
+		r.encryption, r.key, r.sealed = prevParams, prevKey, prevSealed
+		wipe(key)
+		return err
 	}
 
+	wipe(prevKey)
 	return nil
 }
 
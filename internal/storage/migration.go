@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/dhfai/go-wallet/internal/domain"
+)
+
+// migrateAmounts converts a wallet record written before version 2, whose
+// balance and transaction amounts are BTC floats, to integer satoshis. The
+// numbers are converted from their decimal text, so no float rounding occurs.
+// migrateAmounts mengkonversi jumlah BTC (float) pada wallet lama menjadi satoshi
+func migrateAmounts(raw json.RawMessage) (json.RawMessage, error) {
+	var record map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &record); err != nil {
+		return nil, err
+	}
+
+	if err := convertBTCField(record, "balance"); err != nil {
+		return nil, err
+	}
+
+	if rawTxs, ok := record["transactions"]; ok && string(rawTxs) != "null" {
+		var txs []map[string]json.RawMessage
+		if err := json.Unmarshal(rawTxs, &txs); err != nil {
+			return nil, err
+		}
+
+		for _, tx := range txs {
+			if err := convertBTCField(tx, "amount"); err != nil {
+				return nil, err
+			}
+			if err := convertBTCField(tx, "fee"); err != nil {
+				return nil, err
+			}
+		}
+
+		converted, err := json.Marshal(txs)
+		if err != nil {
+			return nil, err
+		}
+		record["transactions"] = converted
+	}
+
+	return json.Marshal(record)
+}
+
+// convertBTCField replaces a BTC number in object[key] with its value in satoshis
+func convertBTCField(object map[string]json.RawMessage, key string) error {
+	value, ok := object[key]
+	if !ok || string(value) == "null" {
+		return nil
+	}
+
+	amount, err := domain.AmountFromBTCDecimal(string(value))
+	if err != nil {
+		return err
+	}
+
+	object[key] = json.RawMessage(strconv.FormatInt(amount.Satoshis(), 10))
+	return nil
+}
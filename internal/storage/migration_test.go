@@ -0,0 +1,136 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// btcAmounts are BTC floats as older wallet files wrote them, with their value in satoshis
+var btcAmounts = []struct {
+	btc  string
+	sats int64
+}{
+	{"0.1", 10000000},
+	{"0.00000001", 1},
+	{"21000000", 2100000000000000},
+	{"1e-8", 1},
+}
+
+func TestMigrateAmounts(t *testing.T) {
+	for _, tc := range btcAmounts {
+		raw := fmt.Sprintf(`{"id":"w1","balance":%s,"transactions":[{"id":"t1","amount":%s,"fee":%s}]}`, tc.btc, tc.btc, tc.btc)
+
+		migrated, err := migrateAmounts(json.RawMessage(raw))
+		if err != nil {
+			t.Errorf("migrateAmounts(%s): %v", tc.btc, err)
+			continue
+		}
+
+		var record struct {
+			ID           string `json:"id"`
+			Balance      int64  `json:"balance"`
+			Transactions []struct {
+				Amount int64 `json:"amount"`
+				Fee    int64 `json:"fee"`
+			} `json:"transactions"`
+		}
+		if err := json.Unmarshal(migrated, &record); err != nil {
+			t.Errorf("migrated %s is not integer satoshis: %v (%s)", tc.btc, err, migrated)
+			continue
+		}
+		if record.ID != "w1" || len(record.Transactions) != 1 {
+			t.Errorf("migrated record = %s, lost fields", migrated)
+			continue
+		}
+		if record.Balance != tc.sats || record.Transactions[0].Amount != tc.sats || record.Transactions[0].Fee != tc.sats {
+			t.Errorf("migrateAmounts(%s) = balance %d, amount %d, fee %d, want %d",
+				tc.btc, record.Balance, record.Transactions[0].Amount, record.Transactions[0].Fee, tc.sats)
+		}
+	}
+}
+
+func TestMigrateAmountsRejects(t *testing.T) {
+	for _, raw := range []string{
+		`{"balance":"abc"}`,
+		`{"balance":21000001}`,
+		`{"balance":0,"transactions":[{"amount":true}]}`,
+		`[1]`,
+	} {
+		if _, err := migrateAmounts(json.RawMessage(raw)); err == nil {
+			t.Errorf("migrateAmounts(%s) succeeded, want an error", raw)
+		}
+	}
+
+	// Missing and null amounts are left alone
+	migrated, err := migrateAmounts(json.RawMessage(`{"id":"w1","transactions":null}`))
+	if err != nil {
+		t.Fatalf("migrateAmounts: %v", err)
+	}
+	if string(migrated) != `{"id":"w1","transactions":null}` {
+		t.Errorf("migrateAmounts = %s, want the record unchanged", migrated)
+	}
+}
+
+func TestLoadMigratesOldVersions(t *testing.T) {
+	for _, tc := range btcAmounts {
+		wallet := fmt.Sprintf(`{"id":"w1","name":"old","public_key":"","address":"1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH",`+
+			`"balance":%s,"transactions":[{"id":"t1","from":"","to":"","amount":%s,"fee":%s,"type":"send","status":"confirmed"}]}`,
+			tc.btc, tc.btc, tc.btc)
+
+		for version, file := range map[int]string{
+			0: "[" + wallet + "]",
+			1: `{"version":1,"wallets":[` + wallet + `]}`,
+		} {
+			path := filepath.Join(t.TempDir(), "wallets.json")
+			if err := os.WriteFile(path, []byte(file), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			repo, err := NewJSONWalletRepository(path)
+			if err != nil {
+				t.Errorf("v%d %s: NewJSONWalletRepository: %v", version, tc.btc, err)
+				continue
+			}
+			loaded, err := repo.FindByID("w1")
+			if err != nil {
+				t.Errorf("v%d %s: FindByID: %v", version, tc.btc, err)
+				continue
+			}
+			if loaded.Balance.Satoshis() != tc.sats {
+				t.Errorf("v%d %s: balance = %d sats, want %d", version, tc.btc, loaded.Balance.Satoshis(), tc.sats)
+			}
+			if tx := loaded.Transactions[0]; tx.Amount.Satoshis() != tc.sats || tx.Fee.Satoshis() != tc.sats {
+				t.Errorf("v%d %s: amount, fee = %d, %d sats, want %d", version, tc.btc, tx.Amount.Satoshis(), tx.Fee.Satoshis(), tc.sats)
+			}
+
+			// The file is rewritten in the current format and loads again unchanged
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var header struct {
+				Version int `json:"version"`
+			}
+			if err := json.Unmarshal(data, &header); err != nil || header.Version != walletFileVersion {
+				t.Errorf("v%d %s: rewritten file version = %d, %v, want %d", version, tc.btc, header.Version, err, walletFileVersion)
+			}
+
+			reopened, err := NewJSONWalletRepository(path)
+			if err != nil {
+				t.Errorf("v%d %s: reopen: %v", version, tc.btc, err)
+				continue
+			}
+			again, err := reopened.FindByID("w1")
+			if err != nil {
+				t.Errorf("v%d %s: reopened FindByID: %v", version, tc.btc, err)
+				continue
+			}
+			if again.Balance != loaded.Balance {
+				t.Errorf("v%d %s: reopened balance = %v, want %v", version, tc.btc, again.Balance, loaded.Balance)
+			}
+		}
+	}
+}
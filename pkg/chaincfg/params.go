@@ -0,0 +1,190 @@
+package chaincfg
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Params holds the constants that differ between Bitcoin networks
+// Params menyimpan konstanta yang berbeda di setiap jaringan Bitcoin
+type Params struct {
+	Name string // Network name: mainnet, testnet, signet or regtest
+
+	Bech32HRP        string // Human-readable part of SegWit addresses
+	PubKeyHashAddrID byte   // Base58 version byte of P2PKH addresses
+	ScriptHashAddrID byte   // Base58 version byte of P2SH addresses
+	PrivateKeyID     byte   // WIF version byte
+
+	HDPrivateKeyID [4]byte // Extended private key version (xprv/tprv)
+	HDPublicKeyID  [4]byte // Extended public key version (xpub/tpub)
+	HDCoinType     uint32  // BIP44 coin type: 0 for mainnet, 1 for test networks
+
+	// SLIP-132 extended public key versions that also name the account's address type
+	HDPublicKeyIDNestedSegWit [4]byte // ypub/upub: P2SH-P2WPKH accounts (BIP49)
+	HDPublicKeyIDSegWit       [4]byte // zpub/vpub: P2WPKH accounts (BIP84)
+
+	ExplorerURL    string // Esplora API base URL
+	ExplorerWebURL string // Block explorer website for links
+
+	RPCPort    string // Default bitcoind JSON-RPC port
+	DataSubdir string // bitcoind data directory subfolder holding the .cookie file
+}
+
+// MainNetParams are the parameters of the Bitcoin main network
+var MainNetParams = Params{
+	Name:             "mainnet",
+	Bech32HRP:        "bc",
+	PubKeyHashAddrID: 0x00,
+	ScriptHashAddrID: 0x05,
+	PrivateKeyID:     0x80,
+	HDPrivateKeyID:   [4]byte{0x04, 0x88, 0xad, 0xe4},
+	HDPublicKeyID:    [4]byte{0x04, 0x88, 0xb2, 0x1e},
+	HDCoinType:       0,
+
+	HDPublicKeyIDNestedSegWit: [4]byte{0x04, 0x9d, 0x7c, 0xb2},
+	HDPublicKeyIDSegWit:       [4]byte{0x04, 0xb2, 0x47, 0x46},
+
+	ExplorerURL:    "https://blockstream.info/api",
+	ExplorerWebURL: "https://blockstream.info",
+
+	RPCPort:    "8332",
+	DataSubdir: "",
+}
+
+// TestNet3Params are the parameters of the Bitcoin test network (version 3)
+var TestNet3Params = Params{
+	Name:             "testnet",
+	Bech32HRP:        "tb",
+	PubKeyHashAddrID: 0x6f,
+	ScriptHashAddrID: 0xc4,
+	PrivateKeyID:     0xef,
+	HDPrivateKeyID:   [4]byte{0x04, 0x35, 0x83, 0x94},
+	HDPublicKeyID:    [4]byte{0x04, 0x35, 0x87, 0xcf},
+	HDCoinType:       1,
+
+	HDPublicKeyIDNestedSegWit: [4]byte{0x04, 0x4a, 0x52, 0x62},
+	HDPublicKeyIDSegWit:       [4]byte{0x04, 0x5f, 0x1c, 0xf6},
+
+	ExplorerURL:    "https://blockstream.info/testnet/api",
+	ExplorerWebURL: "https://blockstream.info/testnet",
+
+	RPCPort:    "18332",
+	DataSubdir: "testnet3",
+}
+
+// SigNetParams are the parameters of the default signet
+var SigNetParams = Params{
+	Name:             "signet",
+	Bech32HRP:        "tb",
+	PubKeyHashAddrID: 0x6f,
+	ScriptHashAddrID: 0xc4,
+	PrivateKeyID:     0xef,
+	HDPrivateKeyID:   [4]byte{0x04, 0x35, 0x83, 0x94},
+	HDPublicKeyID:    [4]byte{0x04, 0x35, 0x87, 0xcf},
+	HDCoinType:       1,
+
+	HDPublicKeyIDNestedSegWit: [4]byte{0x04, 0x4a, 0x52, 0x62},
+	HDPublicKeyIDSegWit:       [4]byte{0x04, 0x5f, 0x1c, 0xf6},
+
+	ExplorerURL:    "https://mempool.space/signet/api",
+	ExplorerWebURL: "https://mempool.space/signet",
+
+	RPCPort:    "38332",
+	DataSubdir: "signet",
+}
+
+// RegressionNetParams are the parameters of a local regression test network.
+// The explorer URL points at a local Esplora (electrs) instance.
+var RegressionNetParams = Params{
+	Name:             "regtest",
+	Bech32HRP:        "bcrt",
+	PubKeyHashAddrID: 0x6f,
+	ScriptHashAddrID: 0xc4,
+	PrivateKeyID:     0xef,
+	HDPrivateKeyID:   [4]byte{0x04, 0x35, 0x83, 0x94},
+	HDPublicKeyID:    [4]byte{0x04, 0x35, 0x87, 0xcf},
+	HDCoinType:       1,
+
+	HDPublicKeyIDNestedSegWit: [4]byte{0x04, 0x4a, 0x52, 0x62},
+	HDPublicKeyIDSegWit:       [4]byte{0x04, 0x5f, 0x1c, 0xf6},
+
+	ExplorerURL:    "http://127.0.0.1:3002",
+	ExplorerWebURL: "http://127.0.0.1:3002",
+
+	RPCPort:    "18443",
+	DataSubdir: "regtest",
+}
+
+var allParams = []*Params{&MainNetParams, &TestNet3Params, &SigNetParams, &RegressionNetParams}
+
+// ParamsForName returns the parameters of a network by name; "" selects mainnet
+// ParamsForName mengembalikan parameter jaringan berdasarkan nama
+func ParamsForName(name string) (*Params, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "mainnet", "main", "bitcoin":
+		return &MainNetParams, nil
+	case "testnet", "testnet3", "test":
+		return &TestNet3Params, nil
+	case "signet":
+		return &SigNetParams, nil
+	case "regtest":
+		return &RegressionNetParams, nil
+	default:
+		return nil, fmt.Errorf("unknown network %q (use mainnet, testnet, signet or regtest)", name)
+	}
+}
+
+// IsMainNet reports whether the parameters describe the main network
+func (p *Params) IsMainNet() bool {
+	return p.Name == MainNetParams.Name
+}
+
+// AddressURL returns the block explorer page of an address
+func (p *Params) AddressURL(address string) string {
+	return fmt.Sprintf("%s/address/%s", p.ExplorerWebURL, address)
+}
+
+// TxURL returns the block explorer page of a transaction
+func (p *Params) TxURL(txID string) string {
+	return fmt.Sprintf("%s/tx/%s", p.ExplorerWebURL, txID)
+}
+
+// HDPublicKeyIDFor returns the extended public key version matching an
+// extended private key version, or false if the version is unknown
+func HDPublicKeyIDFor(privateKeyID [4]byte) ([4]byte, bool) {
+	for _, params := range allParams {
+		if params.HDPrivateKeyID == privateKeyID {
+			return params.HDPublicKeyID, true
+		}
+	}
+	return [4]byte{}, false
+}
+
+// IsHDPrivateKeyID reports whether version is a known extended private key version
+func IsHDPrivateKeyID(version []byte) bool {
+	for _, params := range allParams {
+		if bytes.Equal(params.HDPrivateKeyID[:], version) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsHDPublicKeyID reports whether version is a known extended public key version,
+// including the SLIP-132 ypub/zpub variants
+func IsHDPublicKeyID(version []byte) bool {
+	for _, params := range allParams {
+		if params.IsHDPublicKeyID(version) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsHDPublicKeyID reports whether version is one of the network's extended public key versions
+func (p *Params) IsHDPublicKeyID(version []byte) bool {
+	return bytes.Equal(p.HDPublicKeyID[:], version) ||
+		bytes.Equal(p.HDPublicKeyIDNestedSegWit[:], version) ||
+		bytes.Equal(p.HDPublicKeyIDSegWit[:], version)
+}
@@ -0,0 +1,136 @@
+package coinselect
+
+import (
+	"math"
+	"sort"
+)
+
+// DefaultBnBMaxTries bounds the depth-first search like Bitcoin Core's TOTAL_TRIES
+const DefaultBnBMaxTries = 100000
+
+// BranchAndBound searches for a selection whose effective value lands between the
+// target and the target plus the cost of a change output, so that no change is
+// needed. Among matches it keeps the one with the least waste. This is the
+// algorithm of Bitcoin Core's SelectCoinsBnB (Murch, "An Evaluation of Coin
+// Selection Strategies").
+// BranchAndBound mencari kombinasi coin tanpa output kembalian
+type BranchAndBound struct {
+	MaxTries int // Search iterations before giving up, DefaultBnBMaxTries when zero
+}
+
+// Select implements Selector
+func (b BranchAndBound) Select(coins []Coin, req Request) (*Result, error) {
+	maxTries := b.MaxTries
+	if maxTries <= 0 {
+		maxTries = DefaultBnBMaxTries
+	}
+
+	// Only coins worth more than the fee to spend them are useful
+	type candidate struct {
+		coin      Coin
+		effective int64
+		waste     int64 // Fee now minus fee at the long-term rate
+	}
+
+	var pool []candidate
+	var available int64
+	for _, coin := range coins {
+		effective := req.effectiveValue(coin)
+		if effective <= 0 {
+			continue
+		}
+		pool = append(pool, candidate{
+			coin:      coin,
+			effective: effective,
+			waste:     req.fee(coin.Weight) - req.longTermFee(coin.Weight),
+		})
+		available += effective
+	}
+
+	target := req.Target + req.fixedFee()
+	if available < target {
+		return nil, ErrInsufficientFunds
+	}
+
+	sort.SliceStable(pool, func(i, j int) bool {
+		return pool[i].effective > pool[j].effective
+	})
+
+	upperBound := target + req.costOfChange()
+
+	var (
+		selection []bool // Inclusion decision for pool[0:len(selection)]
+		best      []bool
+		bestWaste int64 = math.MaxInt64
+		value     int64
+		waste     int64
+	)
+
+	for tries := 0; tries < maxTries; tries++ {
+		backtrack := false
+
+		switch {
+		case value+available < target, // Cannot reach the target on this branch
+			value > upperBound, // Overshot, more coins only make it worse
+			waste > bestWaste && len(pool) > 0 && pool[0].waste > 0: // Waste only grows while fees are above long-term
+			backtrack = true
+		case value >= target:
+			// Match: excess is dropped to the fee and counts as waste
+			if total := waste + value - target; total <= bestWaste {
+				best = append(best[:0], selection...)
+				bestWaste = total
+			}
+			backtrack = true
+		}
+
+		if backtrack {
+			// Walk back to the last included coin, restoring skipped coins to available
+			for len(selection) > 0 && !selection[len(selection)-1] {
+				available += pool[len(selection)-1].effective
+				selection = selection[:len(selection)-1]
+			}
+			if len(selection) == 0 {
+				break // Searched the whole tree
+			}
+
+			// Try the branch without that coin
+			last := len(selection) - 1
+			selection[last] = false
+			value -= pool[last].effective
+			waste -= pool[last].waste
+			continue
+		}
+
+		// Explore the next coin, inclusion branch first
+		next := len(selection)
+		available -= pool[next].effective
+
+		// Including a coin equivalent to one just excluded yields a duplicate branch
+		if next > 0 && !selection[next-1] &&
+			pool[next].effective == pool[next-1].effective && pool[next].waste == pool[next-1].waste {
+			selection = append(selection, false)
+			continue
+		}
+
+		selection = append(selection, true)
+		value += pool[next].effective
+		waste += pool[next].waste
+	}
+
+	if best == nil {
+		return nil, ErrNoSolution
+	}
+
+	var selected []Coin
+	for i, included := range best {
+		if included {
+			selected = append(selected, pool[i].coin)
+		}
+	}
+
+	result, ok := finalize(selected, req, false)
+	if !ok {
+		return nil, ErrNoSolution
+	}
+	return result, nil
+}
@@ -0,0 +1,160 @@
+// Package coinselect chooses which unspent outputs fund a transaction.
+//
+// Amounts are in satoshis and sizes in weight units; fees are charged at
+// Request.FeeRate satoshis per virtual byte.
+package coinselect
+
+import (
+	"errors"
+	"math"
+)
+
+var (
+	// ErrInsufficientFunds is returned when all coins together cannot pay the target and fee
+	ErrInsufficientFunds = errors.New("insufficient funds")
+
+	// ErrNoSolution is returned by strategies that found no acceptable selection even though funds suffice
+	ErrNoSolution = errors.New("no coin selection solution found")
+)
+
+// Coin is a spendable output
+// Coin adalah output yang dapat dibelanjakan
+type Coin struct {
+	TxID   string
+	Vout   uint32
+	Value  int64 // Output value in satoshis
+	Weight int   // Weight of the input spending this coin
+}
+
+// Request describes the payment the selected coins must fund
+// Request menjelaskan pembayaran yang harus didanai oleh coin terpilih
+type Request struct {
+	Target      int64   // Sum of the payment outputs in satoshis
+	FeeRate     float64 // Fee rate in sat/vB
+	BaseWeight  int     // Weight of the transaction without inputs or change
+	AbsoluteFee int64   // Fixed fee to pay instead of FeeRate when positive
+
+	ChangeWeight      int     // Weight of the change output
+	ChangeSpendWeight int     // Weight of the input that later spends the change
+	LongTermFeeRate   float64 // Expected future fee rate in sat/vB, defaults to FeeRate
+	DustLimit         int64   // Change below this value is added to the fee
+}
+
+// Result is a funded selection
+// Result adalah hasil pemilihan coin yang sudah terdanai
+type Result struct {
+	Coins  []Coin
+	Fee    int64 // Fee paid in satoshis
+	Change int64 // Change output value, 0 when the selection has no change
+}
+
+// InputValue returns the total value of the selected coins
+func (r *Result) InputValue() int64 {
+	var total int64
+	for _, coin := range r.Coins {
+		total += coin.Value
+	}
+	return total
+}
+
+// Selector is a coin selection strategy
+// Selector adalah strategi pemilihan coin
+type Selector interface {
+	Select(coins []Coin, req Request) (*Result, error)
+}
+
+// Select tries each strategy in order and returns the first solution.
+// When all strategies fail the error of the last one is returned.
+// Select mencoba setiap strategi secara berurutan
+func Select(coins []Coin, req Request, strategies ...Selector) (*Result, error) {
+	var lastErr error = ErrInsufficientFunds
+	for _, strategy := range strategies {
+		result, err := strategy.Select(coins, req)
+		if err == nil {
+			return result, nil
+		}
+		if !errors.Is(err, ErrNoSolution) && !errors.Is(err, ErrInsufficientFunds) {
+			return nil, err
+		}
+		lastErr = err
+	}
+
+	return nil, lastErr
+}
+
+// fee returns the fee in satoshis for weight at the request's fee rate
+func (req Request) fee(weight int) int64 {
+	if req.AbsoluteFee > 0 {
+		return 0
+	}
+	return feeAt(req.FeeRate, weight)
+}
+
+// fixedFee returns the part of the fee that does not depend on the selection
+func (req Request) fixedFee() int64 {
+	if req.AbsoluteFee > 0 {
+		return req.AbsoluteFee
+	}
+	return feeAt(req.FeeRate, req.BaseWeight)
+}
+
+// longTermFee returns the fee for weight at the long-term fee rate
+func (req Request) longTermFee(weight int) int64 {
+	if req.AbsoluteFee > 0 {
+		return 0
+	}
+	if req.LongTermFeeRate > 0 {
+		return feeAt(req.LongTermFeeRate, weight)
+	}
+	return feeAt(req.FeeRate, weight)
+}
+
+// costOfChange is the fee of creating the change output now and spending it later
+func (req Request) costOfChange() int64 {
+	return req.fee(req.ChangeWeight) + req.longTermFee(req.ChangeSpendWeight)
+}
+
+// effectiveValue is the value a coin contributes after paying for its own input
+func (req Request) effectiveValue(coin Coin) int64 {
+	return coin.Value - req.fee(coin.Weight)
+}
+
+func feeAt(rate float64, weight int) int64 {
+	return int64(math.Ceil(rate * float64(weight) / 4))
+}
+
+func totalEffectiveValue(coins []Coin, req Request) int64 {
+	var total int64
+	for _, coin := range coins {
+		if value := req.effectiveValue(coin); value > 0 {
+			total += value
+		}
+	}
+	return total
+}
+
+// finalize computes the fee and change of a selection; allowChange false sends any excess to the fee.
+// It reports false when the selection does not cover the target and fee.
+func finalize(selected []Coin, req Request, allowChange bool) (*Result, bool) {
+	var value int64
+	weight := req.BaseWeight
+	for _, coin := range selected {
+		value += coin.Value
+		weight += coin.Weight
+	}
+
+	feeNoChange := req.fixedFee() + req.fee(weight-req.BaseWeight)
+	if value < req.Target+feeNoChange {
+		return nil, false
+	}
+
+	coins := append([]Coin(nil), selected...)
+	if allowChange {
+		feeWithChange := req.fixedFee() + req.fee(weight-req.BaseWeight+req.ChangeWeight)
+		if change := value - req.Target - feeWithChange; change >= req.DustLimit && change > 0 {
+			return &Result{Coins: coins, Fee: feeWithChange, Change: change}, true
+		}
+	}
+
+	return &Result{Coins: coins, Fee: value - req.Target}, true
+}
@@ -0,0 +1,293 @@
+package coinselect
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// P2WPKH weights: 68 vB inputs, 31 vB outputs, 10.5 vB overhead
+const (
+	testInputWeight  = 272
+	testOutputWeight = 124
+	testBaseWeight   = 42 + testOutputWeight
+)
+
+func testCoins(values ...int64) []Coin {
+	coins := make([]Coin, len(values))
+	for i, value := range values {
+		coins[i] = Coin{TxID: "tx", Vout: uint32(i), Value: value, Weight: testInputWeight}
+	}
+	return coins
+}
+
+func testRequest(target int64, feeRate float64) Request {
+	return Request{
+		Target:            target,
+		FeeRate:           feeRate,
+		BaseWeight:        testBaseWeight,
+		ChangeWeight:      testOutputWeight,
+		ChangeSpendWeight: testInputWeight,
+		DustLimit:         546,
+	}
+}
+
+func values(coins []Coin) []int64 {
+	out := make([]int64, len(coins))
+	for i, coin := range coins {
+		out[i] = coin.Value
+	}
+	return out
+}
+
+func checkBalanced(t *testing.T, result *Result, req Request) {
+	t.Helper()
+	if got := result.InputValue() - req.Target - result.Change; got != result.Fee {
+		t.Errorf("inputs - target - change = %d, fee = %d", got, result.Fee)
+	}
+	if result.Change != 0 && result.Change < req.DustLimit {
+		t.Errorf("change %d below dust limit", result.Change)
+	}
+}
+
+func TestLargestFirst(t *testing.T) {
+	tests := []struct {
+		name       string
+		coins      []int64
+		target     int64
+		feeRate    float64
+		wantValues []int64
+		wantFee    int64
+		wantChange int64
+	}{
+		{
+			name:       "single largest coin covers target",
+			coins:      []int64{10000, 50000, 20000},
+			target:     30000,
+			feeRate:    1,
+			wantValues: []int64{50000},
+			wantFee:    141, // 42+124+272+124 WU = 562 WU = 140.5 vB
+			wantChange: 19859,
+		},
+		{
+			name:       "adds coins in descending order",
+			coins:      []int64{10000, 50000, 20000},
+			target:     65000,
+			feeRate:    2,
+			wantValues: []int64{50000, 20000},
+			wantFee:    417, // 834 WU
+			wantChange: 4583,
+		},
+		{
+			name:       "small excess goes to fee",
+			coins:      []int64{30000},
+			target:     29700,
+			feeRate:    1,
+			wantValues: []int64{30000},
+			wantFee:    300,
+			wantChange: 0,
+		},
+		{
+			name:       "skips uneconomical coins",
+			coins:      []int64{100, 40000},
+			target:     30000,
+			feeRate:    5,
+			wantValues: []int64{40000},
+			wantFee:    703,
+			wantChange: 9297,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := testRequest(tt.target, tt.feeRate)
+			result, err := LargestFirst{}.Select(testCoins(tt.coins...), req)
+			if err != nil {
+				t.Fatalf("Select() error = %v", err)
+			}
+			if got := values(result.Coins); !reflect.DeepEqual(got, tt.wantValues) {
+				t.Errorf("selected %v, want %v", got, tt.wantValues)
+			}
+			if result.Fee != tt.wantFee || result.Change != tt.wantChange {
+				t.Errorf("fee %d change %d, want fee %d change %d", result.Fee, result.Change, tt.wantFee, tt.wantChange)
+			}
+			checkBalanced(t, result, req)
+		})
+	}
+}
+
+func TestLargestFirstInsufficientFunds(t *testing.T) {
+	_, err := LargestFirst{}.Select(testCoins(10000, 20000), testRequest(30000, 1))
+	if !errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("Select() error = %v, want ErrInsufficientFunds", err)
+	}
+}
+
+func TestBranchAndBoundExactMatch(t *testing.T) {
+	// At 0 sat/vB effective values equal values, so only exact sums match
+	req := testRequest(0, 0)
+	coins := testCoins(1, 2, 3, 4, 5, 6, 7, 8, 9, 10)
+
+	for _, target := range []int64{1, 5, 11, 27, 55} {
+		req.Target = target
+		result, err := BranchAndBound{}.Select(coins, req)
+		if err != nil {
+			t.Fatalf("target %d: Select() error = %v", target, err)
+		}
+		if got := result.InputValue(); got != target {
+			t.Errorf("target %d: selected %v totalling %d", target, values(result.Coins), got)
+		}
+		if result.Change != 0 || result.Fee != 0 {
+			t.Errorf("target %d: fee %d change %d, want no fee or change", target, result.Fee, result.Change)
+		}
+	}
+
+	req.Target = 56
+	if _, err := (BranchAndBound{}).Select(coins, req); !errors.Is(err, ErrInsufficientFunds) {
+		t.Errorf("target 56: error = %v, want ErrInsufficientFunds", err)
+	}
+}
+
+func TestBranchAndBoundPrefersChangeless(t *testing.T) {
+	// Fee rate 1: base fee 42 sats, inputs cost 68 sats, cost of change 31 + 68 = 99 sats
+	req := testRequest(100000, 1)
+	coins := testCoins(150000, 60110, 40068, 30000)
+
+	result, err := BranchAndBound{}.Select(coins, req)
+	if err != nil {
+		t.Fatalf("Select() error = %v", err)
+	}
+	if got, want := values(result.Coins), []int64{60110, 40068}; !reflect.DeepEqual(got, want) {
+		t.Errorf("selected %v, want %v", got, want)
+	}
+	if result.Change != 0 {
+		t.Errorf("change = %d, want 0", result.Change)
+	}
+	if result.Fee != 178 {
+		t.Errorf("fee = %d, want 178", result.Fee)
+	}
+	checkBalanced(t, result, req)
+}
+
+func TestBranchAndBoundMinimizesWaste(t *testing.T) {
+	// Both {60068, 40130} (excess 20) and {100120} (excess 10) land in the window
+	req := testRequest(100000, 1)
+	coins := testCoins(60068, 40130, 100120)
+
+	result, err := BranchAndBound{}.Select(coins, req)
+	if err != nil {
+		t.Fatalf("Select() error = %v", err)
+	}
+	if got, want := values(result.Coins), []int64{100120}; !reflect.DeepEqual(got, want) {
+		t.Errorf("selected %v, want %v", got, want)
+	}
+}
+
+func TestBranchAndBoundNoSolution(t *testing.T) {
+	// Funds suffice, but every combination overshoots the change window
+	_, err := BranchAndBound{}.Select(testCoins(150000, 300000), testRequest(100000, 1))
+	if !errors.Is(err, ErrNoSolution) {
+		t.Fatalf("Select() error = %v, want ErrNoSolution", err)
+	}
+}
+
+func TestBranchAndBoundMaxTries(t *testing.T) {
+	amounts := make([]int64, 40)
+	for i := range amounts {
+		amounts[i] = 3000 * int64(i+1)
+	}
+
+	// The only match is the smallest coin, the last one the search reaches
+	coins := testCoins(amounts...)
+	req := testRequest(3000, 0)
+
+	if _, err := (BranchAndBound{MaxTries: 10}).Select(coins, req); !errors.Is(err, ErrNoSolution) {
+		t.Fatalf("MaxTries 10: error = %v, want ErrNoSolution", err)
+	}
+
+	result, err := BranchAndBound{}.Select(coins, req)
+	if err != nil {
+		t.Fatalf("Select() error = %v", err)
+	}
+	if got, want := values(result.Coins), []int64{3000}; !reflect.DeepEqual(got, want) {
+		t.Errorf("selected %v, want %v", got, want)
+	}
+}
+
+func TestSingleRandomDraw(t *testing.T) {
+	coins := testCoins(10000, 20000, 30000, 40000, 50000)
+	req := testRequest(45000, 1)
+
+	first, err := NewSingleRandomDrawWithSource(rand.NewSource(42)).Select(coins, req)
+	if err != nil {
+		t.Fatalf("Select() error = %v", err)
+	}
+	second, err := NewSingleRandomDrawWithSource(rand.NewSource(42)).Select(coins, req)
+	if err != nil {
+		t.Fatalf("Select() error = %v", err)
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("same seed gave %v and %v", values(first.Coins), values(second.Coins))
+	}
+
+	checkBalanced(t, first, req)
+	if first.Change == 0 {
+		t.Errorf("expected a change output, got fee %d", first.Fee)
+	}
+}
+
+func TestSingleRandomDrawVariesWithSeed(t *testing.T) {
+	coins := testCoins(10000, 20000, 30000, 40000, 50000, 60000, 70000, 80000)
+	req := testRequest(5000, 1)
+
+	seen := make(map[int64]bool)
+	for seed := int64(0); seed < 20; seed++ {
+		result, err := NewSingleRandomDrawWithSource(rand.NewSource(seed)).Select(coins, req)
+		if err != nil {
+			t.Fatalf("seed %d: Select() error = %v", seed, err)
+		}
+		seen[result.Coins[0].Value] = true
+	}
+	if len(seen) < 2 {
+		t.Errorf("20 seeds always drew %v first", seen)
+	}
+}
+
+func TestSingleRandomDrawInsufficientFunds(t *testing.T) {
+	_, err := NewSingleRandomDrawWithSource(rand.NewSource(1)).Select(testCoins(1000, 2000), testRequest(5000, 1))
+	if !errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("Select() error = %v, want ErrInsufficientFunds", err)
+	}
+}
+
+func TestSelectFallsBack(t *testing.T) {
+	coins := testCoins(150000, 300000)
+	req := testRequest(100000, 1)
+
+	result, err := Select(coins, req, BranchAndBound{}, LargestFirst{})
+	if err != nil {
+		t.Fatalf("Select() error = %v", err)
+	}
+	if got, want := values(result.Coins), []int64{300000}; !reflect.DeepEqual(got, want) {
+		t.Errorf("selected %v, want %v", got, want)
+	}
+	checkBalanced(t, result, req)
+
+	if _, err := Select(coins, testRequest(500000, 1), BranchAndBound{}, LargestFirst{}); !errors.Is(err, ErrInsufficientFunds) {
+		t.Errorf("Select() error = %v, want ErrInsufficientFunds", err)
+	}
+}
+
+func TestAbsoluteFee(t *testing.T) {
+	req := testRequest(30000, 0)
+	req.AbsoluteFee = 1000
+
+	result, err := LargestFirst{}.Select(testCoins(20000, 40000), req)
+	if err != nil {
+		t.Fatalf("Select() error = %v", err)
+	}
+	if result.Fee != 1000 || result.Change != 9000 {
+		t.Errorf("fee %d change %d, want fee 1000 change 9000", result.Fee, result.Change)
+	}
+}
@@ -0,0 +1,29 @@
+package coinselect
+
+import "sort"
+
+// LargestFirst adds coins from the largest value down until the request is funded
+// LargestFirst menambahkan coin dari nilai terbesar hingga pembayaran terdanai
+type LargestFirst struct{}
+
+// Select implements Selector
+func (LargestFirst) Select(coins []Coin, req Request) (*Result, error) {
+	sorted := append([]Coin(nil), coins...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Value > sorted[j].Value
+	})
+
+	var selected []Coin
+	for _, coin := range sorted {
+		if req.effectiveValue(coin) <= 0 {
+			continue
+		}
+
+		selected = append(selected, coin)
+		if result, ok := finalize(selected, req, true); ok {
+			return result, nil
+		}
+	}
+
+	return nil, ErrInsufficientFunds
+}
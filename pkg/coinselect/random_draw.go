@@ -0,0 +1,51 @@
+package coinselect
+
+import (
+	"math/rand"
+	"time"
+)
+
+// SingleRandomDraw adds coins in random order until the request is funded with change.
+// Random selection avoids revealing a pattern in which outputs the wallet spends.
+// SingleRandomDraw menambahkan coin secara acak hingga pembayaran terdanai
+type SingleRandomDraw struct {
+	rng *rand.Rand
+}
+
+// NewSingleRandomDraw creates the strategy with a time-seeded random source
+func NewSingleRandomDraw() *SingleRandomDraw {
+	return NewSingleRandomDrawWithSource(rand.NewSource(time.Now().UnixNano()))
+}
+
+// NewSingleRandomDrawWithSource creates the strategy with the given random source,
+// making the selection reproducible
+func NewSingleRandomDrawWithSource(source rand.Source) *SingleRandomDraw {
+	return &SingleRandomDraw{rng: rand.New(source)}
+}
+
+// Select implements Selector
+func (s *SingleRandomDraw) Select(coins []Coin, req Request) (*Result, error) {
+	shuffled := append([]Coin(nil), coins...)
+	s.rng.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+
+	// Keep drawing until there is enough left over for a change output,
+	// falling back to a changeless result once every coin is drawn
+	var selected []Coin
+	for _, coin := range shuffled {
+		if req.effectiveValue(coin) <= 0 {
+			continue
+		}
+
+		selected = append(selected, coin)
+		if result, ok := finalize(selected, req, true); ok && result.Change > 0 {
+			return result, nil
+		}
+	}
+
+	if result, ok := finalize(selected, req, true); ok {
+		return result, nil
+	}
+	return nil, ErrInsufficientFunds
+}
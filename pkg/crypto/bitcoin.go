@@ -2,38 +2,75 @@ package crypto
 
 import (
 	"crypto/ecdsa"
-	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 	"math/big"
 
+	"github.com/dhfai/go-wallet/pkg/chaincfg"
 	"golang.org/x/crypto/ripemd160"
 )
 
-type BitcoinCrypto struct{}
+type BitcoinCrypto struct {
+	params *chaincfg.Params
+}
 
 func NewBitcoinCrypto() *BitcoinCrypto {
-	return &BitcoinCrypto{}
+	return NewBitcoinCryptoForNetwork(&chaincfg.MainNetParams)
+}
+
+// NewBitcoinCryptoForNetwork creates a BitcoinCrypto that encodes addresses and keys for params
+// NewBitcoinCryptoForNetwork membuat BitcoinCrypto untuk jaringan tertentu
+func NewBitcoinCryptoForNetwork(params *chaincfg.Params) *BitcoinCrypto {
+	return &BitcoinCrypto{params: params}
+}
+
+// Params returns the network parameters used for encoding
+func (bc *BitcoinCrypto) Params() *chaincfg.Params {
+	return bc.params
 }
 
 func (bc *BitcoinCrypto) GenerateKeyPair() (privateKey, publicKey string, err error) {
 
-	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	privKey, err := bc.generatePrivateKey()
 	if err != nil {
 		return "", "", fmt.Errorf("failed to generate private key: %w", err)
 	}
 
-	privateKeyBytes := privKey.D.Bytes()
+	privateKeyBytes := make([]byte, 32)
+	privKey.D.FillBytes(privateKeyBytes)
 	privateKeyHex := hex.EncodeToString(privateKeyBytes)
 
-	pubKeyBytes := elliptic.Marshal(privKey.PublicKey.Curve, privKey.PublicKey.X, privKey.PublicKey.Y)
-	publicKeyHex := hex.EncodeToString(pubKeyBytes)
+	publicKeyHex := bc.CompressPublicKey(&privKey.PublicKey)
 
 	return privateKeyHex, publicKeyHex, nil
 }
 
+func (bc *BitcoinCrypto) generatePrivateKey() (*ecdsa.PrivateKey, error) {
+	n := S256().Params().N
+	buf := make([]byte, 32)
+
+	for {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+
+		d := new(big.Int).SetBytes(buf)
+		if d.Sign() > 0 && d.Cmp(n) < 0 {
+			return bc.privateKeyFromScalar(d), nil
+		}
+	}
+}
+
+func (bc *BitcoinCrypto) privateKeyFromScalar(d *big.Int) *ecdsa.PrivateKey {
+	privKey := new(ecdsa.PrivateKey)
+	privKey.PublicKey.Curve = S256()
+	privKey.D = d
+	privKey.PublicKey.X, privKey.PublicKey.Y = S256().ScalarBaseMult(d.Bytes())
+	return privKey
+}
+
 func (bc *BitcoinCrypto) GenerateAddress(publicKeyHex string) (string, error) {
 
 	publicKeyBytes, err := hex.DecodeString(publicKeyHex)
@@ -50,7 +87,7 @@ func (bc *BitcoinCrypto) GenerateAddress(publicKeyHex string) (string, error) {
 	}
 	publicKeyHash := ripemd160Hasher.Sum(nil)
 
-	versionedPayload := append([]byte{0x00}, publicKeyHash...)
+	versionedPayload := append([]byte{bc.params.PubKeyHashAddrID}, publicKeyHash...)
 
 	firstHash := sha256.Sum256(versionedPayload)
 	secondHash := sha256.Sum256(firstHash[:])
@@ -63,18 +100,59 @@ func (bc *BitcoinCrypto) GenerateAddress(publicKeyHex string) (string, error) {
 	return address, nil
 }
 
+// Hash160 returns RIPEMD160(SHA256(data))
+func (bc *BitcoinCrypto) Hash160(data []byte) []byte {
+	sha256Hash := sha256.Sum256(data)
+	ripemd160Hasher := ripemd160.New()
+	ripemd160Hasher.Write(sha256Hash[:])
+	return ripemd160Hasher.Sum(nil)
+}
+
 func (bc *BitcoinCrypto) PrivateKeyFromHex(privateKeyHex string) (*ecdsa.PrivateKey, error) {
 	privateKeyBytes, err := hex.DecodeString(privateKeyHex)
 	if err != nil {
 		return nil, fmt.Errorf("invalid private key hex: %w", err)
 	}
 
-	privKey := new(ecdsa.PrivateKey)
-	privKey.PublicKey.Curve = elliptic.P256()
-	privKey.D = new(big.Int).SetBytes(privateKeyBytes)
-	privKey.PublicKey.X, privKey.PublicKey.Y = privKey.PublicKey.Curve.ScalarBaseMult(privateKeyBytes)
+	if len(privateKeyBytes) != 32 {
+		return nil, fmt.Errorf("private key must be 32 bytes, got %d", len(privateKeyBytes))
+	}
+
+	d := new(big.Int).SetBytes(privateKeyBytes)
+	if d.Sign() == 0 || d.Cmp(S256().Params().N) >= 0 {
+		return nil, fmt.Errorf("private key out of range")
+	}
+
+	return bc.privateKeyFromScalar(d), nil
+}
+
+// CompressPublicKey serializes a public key in 33-byte compressed SEC form
+func (bc *BitcoinCrypto) CompressPublicKey(pubKey *ecdsa.PublicKey) string {
+	return hex.EncodeToString(bc.SerializeCompressed(pubKey))
+}
+
+// SerializeCompressed returns the 33-byte compressed SEC encoding of a public key
+func (bc *BitcoinCrypto) SerializeCompressed(pubKey *ecdsa.PublicKey) []byte {
+	return compressPoint(pubKey.X, pubKey.Y)
+}
 
-	return privKey, nil
+// SerializeUncompressed returns the 65-byte uncompressed SEC encoding of a public key
+func (bc *BitcoinCrypto) SerializeUncompressed(pubKey *ecdsa.PublicKey) []byte {
+	out := make([]byte, 65)
+	out[0] = 0x04
+	pubKey.X.FillBytes(out[1:33])
+	pubKey.Y.FillBytes(out[33:])
+	return out
+}
+
+// ParsePublicKey parses a compressed (33-byte) or uncompressed (65-byte) SEC public key
+func (bc *BitcoinCrypto) ParsePublicKey(publicKeyHex string) (*ecdsa.PublicKey, error) {
+	publicKeyBytes, err := hex.DecodeString(publicKeyHex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key hex: %w", err)
+	}
+
+	return parsePublicKey(publicKeyBytes)
 }
 
 func (bc *BitcoinCrypto) SignTransaction(txHash string, privateKeyHex string) (string, error) {
@@ -88,33 +166,23 @@ func (bc *BitcoinCrypto) SignTransaction(txHash string, privateKeyHex string) (s
 		return "", fmt.Errorf("invalid transaction hash: %w", err)
 	}
 
-	r, s, err := ecdsa.Sign(rand.Reader, privKey, txHashBytes)
-	if err != nil {
-		return "", fmt.Errorf("signing failed: %w", err)
-	}
-
-	signature := append(r.Bytes(), s.Bytes()...)
+	signature := bc.SignHash(txHashBytes, privKey)
 	signatureHex := hex.EncodeToString(signature)
 
 	return signatureHex, nil
 }
 
+// SignHash signs a 32-byte digest and returns the DER-encoded signature
+func (bc *BitcoinCrypto) SignHash(hash []byte, privKey *ecdsa.PrivateKey) []byte {
+	r, s := signECDSA(privKey.D, hash)
+	return encodeDER(r, s)
+}
+
 func (bc *BitcoinCrypto) VerifySignature(txHash, signatureHex, publicKeyHex string) (bool, error) {
 
-	publicKeyBytes, err := hex.DecodeString(publicKeyHex)
+	pubKey, err := bc.ParsePublicKey(publicKeyHex)
 	if err != nil {
-		return false, fmt.Errorf("invalid public key hex: %w", err)
-	}
-
-	x, y := elliptic.Unmarshal(elliptic.P256(), publicKeyBytes)
-	if x == nil {
-		return false, fmt.Errorf("invalid public key")
-	}
-
-	pubKey := &ecdsa.PublicKey{
-		Curve: elliptic.P256(),
-		X:     x,
-		Y:     y,
+		return false, err
 	}
 
 	signatureBytes, err := hex.DecodeString(signatureHex)
@@ -122,15 +190,17 @@ func (bc *BitcoinCrypto) VerifySignature(txHash, signatureHex, publicKeyHex stri
 		return false, fmt.Errorf("invalid signature hex: %w", err)
 	}
 
-	r := new(big.Int).SetBytes(signatureBytes[:len(signatureBytes)/2])
-	s := new(big.Int).SetBytes(signatureBytes[len(signatureBytes)/2:])
+	r, s, err := parseDER(signatureBytes)
+	if err != nil {
+		return false, fmt.Errorf("invalid signature: %w", err)
+	}
 
 	txHashBytes, err := hex.DecodeString(txHash)
 	if err != nil {
 		return false, fmt.Errorf("invalid transaction hash: %w", err)
 	}
 
-	valid := ecdsa.Verify(pubKey, txHashBytes, r, s)
+	valid := verifyECDSA(pubKey, txHashBytes, r, s)
 
 	return valid, nil
 }
@@ -164,8 +234,41 @@ func (bc *BitcoinCrypto) base58Encode(input []byte) string {
 	return string(result)
 }
 
-func (bc *BitcoinCrypto) HashTransaction(from, to string, amount float64, timestamp int64) string {
-	data := fmt.Sprintf("%s%s%f%d", from, to, amount, timestamp)
+func (bc *BitcoinCrypto) HashTransaction(from, to string, amountSats int64, timestamp int64) string {
+	data := fmt.Sprintf("%s%s%d%d", from, to, amountSats, timestamp)
 	hash := sha256.Sum256([]byte(data))
 	return hex.EncodeToString(hash[:])
 }
+
+func compressPoint(x, y *big.Int) []byte {
+	out := make([]byte, 33)
+	out[0] = 0x02 + byte(y.Bit(0))
+	x.FillBytes(out[1:])
+	return out
+}
+
+func parsePublicKey(data []byte) (*ecdsa.PublicKey, error) {
+	curve := S256()
+
+	var x, y *big.Int
+	switch {
+	case len(data) == 33 && (data[0] == 0x02 || data[0] == 0x03):
+		x = new(big.Int).SetBytes(data[1:])
+		var ok bool
+		y, ok = secp256k1.decompressY(x, data[0] == 0x03)
+		if !ok {
+			return nil, fmt.Errorf("invalid public key")
+		}
+	case len(data) == 65 && data[0] == 0x04:
+		x = new(big.Int).SetBytes(data[1:33])
+		y = new(big.Int).SetBytes(data[33:])
+	default:
+		return nil, fmt.Errorf("invalid public key")
+	}
+
+	if !curve.IsOnCurve(x, y) {
+		return nil, fmt.Errorf("public key is not on secp256k1")
+	}
+
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
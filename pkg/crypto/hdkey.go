@@ -0,0 +1,369 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/dhfai/go-wallet/pkg/chaincfg"
+)
+
+// HardenedKeyStart is the first hardened child index (2^31)
+const HardenedKeyStart uint32 = 0x80000000
+
+// serializedKeyLength is the length of a serialized extended key before the checksum
+const serializedKeyLength = 78
+
+// ErrDerivationInvalid is returned when a derived child key is invalid (probability < 2^-127)
+var ErrDerivationInvalid = errors.New("derived key is invalid, use the next index")
+
+// ExtendedKey is a BIP32 extended private or public key
+// ExtendedKey adalah extended private key atau public key BIP32
+type ExtendedKey struct {
+	key        []byte // 32-byte private scalar or 33-byte compressed public key
+	chainCode  []byte
+	depth      uint8
+	parentFP   []byte
+	childIndex uint32
+	private    bool
+	version    []byte // Serialization version bytes (xprv/xpub, tprv/tpub)
+}
+
+// NewMasterKey derives the BIP32 master key from a seed, versioned for the crypto network
+// NewMasterKey menurunkan master key BIP32 dari seed
+func (bc *BitcoinCrypto) NewMasterKey(seed []byte) (*ExtendedKey, error) {
+	if len(seed) < 16 || len(seed) > 64 {
+		return nil, fmt.Errorf("invalid seed length %d bytes", len(seed))
+	}
+
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+
+	d := new(big.Int).SetBytes(sum[:32])
+	if d.Sign() == 0 || d.Cmp(S256().Params().N) >= 0 {
+		return nil, ErrDerivationInvalid
+	}
+
+	return &ExtendedKey{
+		key:       sum[:32],
+		chainCode: sum[32:],
+		parentFP:  []byte{0, 0, 0, 0},
+		private:   true,
+		version:   append([]byte(nil), bc.params.HDPrivateKeyID[:]...),
+	}, nil
+}
+
+// ParseExtendedKey decodes a Base58Check-serialized extended key (xprv/xpub or tprv/tpub)
+// ParseExtendedKey mendekode extended key yang diserialisasi dalam Base58Check
+func (bc *BitcoinCrypto) ParseExtendedKey(encoded string) (*ExtendedKey, error) {
+	decoded := bc.base58Decode(encoded)
+	if len(decoded) != serializedKeyLength+4 {
+		return nil, fmt.Errorf("invalid extended key length")
+	}
+
+	payload := decoded[:serializedKeyLength]
+	checksum := doubleSHA256(payload)[:4]
+	if !bytes.Equal(checksum, decoded[serializedKeyLength:]) {
+		return nil, fmt.Errorf("invalid extended key checksum")
+	}
+
+	key := &ExtendedKey{
+		version:    append([]byte(nil), payload[0:4]...),
+		depth:      payload[4],
+		parentFP:   append([]byte(nil), payload[5:9]...),
+		childIndex: binary.BigEndian.Uint32(payload[9:13]),
+		chainCode:  append([]byte(nil), payload[13:45]...),
+	}
+	keyData := payload[45:78]
+
+	switch {
+	case chaincfg.IsHDPrivateKeyID(key.version):
+		if keyData[0] != 0x00 {
+			return nil, fmt.Errorf("invalid private key prefix")
+		}
+		d := new(big.Int).SetBytes(keyData[1:])
+		if d.Sign() == 0 || d.Cmp(S256().Params().N) >= 0 {
+			return nil, fmt.Errorf("private key out of range")
+		}
+		key.key = append([]byte(nil), keyData[1:]...)
+		key.private = true
+	case chaincfg.IsHDPublicKeyID(key.version):
+		if _, err := parsePublicKey(keyData); err != nil {
+			return nil, err
+		}
+		key.key = append([]byte(nil), keyData...)
+	default:
+		return nil, fmt.Errorf("unknown extended key version %x", key.version)
+	}
+
+	if key.depth == 0 && (!bytes.Equal(key.parentFP, []byte{0, 0, 0, 0}) || key.childIndex != 0) {
+		return nil, fmt.Errorf("invalid master key metadata")
+	}
+
+	return key, nil
+}
+
+// String serializes the extended key in Base58Check form (xprv... or xpub...)
+// String menserialisasi extended key dalam format Base58Check
+func (k *ExtendedKey) String() string {
+	payload := make([]byte, 0, serializedKeyLength+4)
+	payload = append(payload, k.version...)
+	payload = append(payload, k.depth)
+	payload = append(payload, k.parentFP...)
+	payload = binary.BigEndian.AppendUint32(payload, k.childIndex)
+	payload = append(payload, k.chainCode...)
+	if k.private {
+		payload = append(payload, 0x00)
+	}
+	payload = append(payload, k.key...)
+	payload = append(payload, doubleSHA256(payload)[:4]...)
+
+	return NewBitcoinCrypto().base58Encode(payload)
+}
+
+// Neuter returns the extended public key corresponding to k
+// Neuter mengembalikan extended public key dari k
+func (k *ExtendedKey) Neuter() *ExtendedKey {
+	if !k.private {
+		return k
+	}
+
+	var privateVersion [4]byte
+	copy(privateVersion[:], k.version)
+	publicVersion, _ := chaincfg.HDPublicKeyIDFor(privateVersion)
+
+	return &ExtendedKey{
+		key:        k.pubKeyBytes(),
+		chainCode:  k.chainCode,
+		depth:      k.depth,
+		parentFP:   k.parentFP,
+		childIndex: k.childIndex,
+		version:    publicVersion[:],
+	}
+}
+
+// IsForNetwork reports whether the key's version bytes belong to params
+func (k *ExtendedKey) IsForNetwork(params *chaincfg.Params) bool {
+	return bytes.Equal(k.version, params.HDPrivateKeyID[:]) || params.IsHDPublicKeyID(k.version)
+}
+
+// Version returns the version bytes the key is serialized with
+func (k *ExtendedKey) Version() [4]byte {
+	var version [4]byte
+	copy(version[:], k.version)
+	return version
+}
+
+// WithVersion returns a copy of k serialized with other version bytes, e.g. to turn a zpub into an xpub
+// WithVersion mengembalikan salinan k dengan version bytes lain
+func (k *ExtendedKey) WithVersion(version [4]byte) *ExtendedKey {
+	cp := *k
+	cp.version = append([]byte(nil), version[:]...)
+	return &cp
+}
+
+// IsPrivate reports whether the key can derive private children
+func (k *ExtendedKey) IsPrivate() bool {
+	return k.private
+}
+
+// Depth returns the number of derivation steps from the master key
+func (k *ExtendedKey) Depth() uint8 {
+	return k.depth
+}
+
+// ChildIndex returns the index this key was derived at
+func (k *ExtendedKey) ChildIndex() uint32 {
+	return k.childIndex
+}
+
+// ParentFingerprint returns the fingerprint of the parent key
+func (k *ExtendedKey) ParentFingerprint() []byte {
+	return k.parentFP
+}
+
+// PublicKeyBytes returns the 33-byte compressed public key
+func (k *ExtendedKey) PublicKeyBytes() []byte {
+	return k.pubKeyBytes()
+}
+
+// Child derives the child key at index; indices >= HardenedKeyStart are hardened
+// Child menurunkan child key pada index tertentu
+func (k *ExtendedKey) Child(index uint32) (*ExtendedKey, error) {
+	hardened := index >= HardenedKeyStart
+	if hardened && !k.private {
+		return nil, errors.New("cannot derive a hardened child from a public key")
+	}
+
+	curve := S256()
+	n := curve.Params().N
+
+	data := make([]byte, 0, 37)
+	if hardened {
+		data = append(data, 0x00)
+		data = append(data, k.key...)
+	} else {
+		data = append(data, k.pubKeyBytes()...)
+	}
+	data = binary.BigEndian.AppendUint32(data, index)
+
+	mac := hmac.New(sha512.New, k.chainCode)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+
+	il := new(big.Int).SetBytes(sum[:32])
+	if il.Cmp(n) >= 0 {
+		return nil, ErrDerivationInvalid
+	}
+
+	child := &ExtendedKey{
+		chainCode:  sum[32:],
+		depth:      k.depth + 1,
+		parentFP:   k.Fingerprint(),
+		childIndex: index,
+		private:    k.private,
+		version:    k.version,
+	}
+
+	if k.private {
+		d := new(big.Int).Add(il, new(big.Int).SetBytes(k.key))
+		d.Mod(d, n)
+		if d.Sign() == 0 {
+			return nil, ErrDerivationInvalid
+		}
+		child.key = make([]byte, 32)
+		d.FillBytes(child.key)
+		return child, nil
+	}
+
+	px, py := curve.ScalarBaseMult(sum[:32])
+	parent, err := parsePublicKey(k.key)
+	if err != nil {
+		return nil, err
+	}
+	x, y := curve.Add(px, py, parent.X, parent.Y)
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return nil, ErrDerivationInvalid
+	}
+	child.key = compressPoint(x, y)
+
+	return child, nil
+}
+
+// DerivePath derives a descendant key by applying each index in order
+// DerivePath menurunkan key turunan dengan menerapkan setiap index secara berurutan
+func (k *ExtendedKey) DerivePath(indices ...uint32) (*ExtendedKey, error) {
+	key := k
+	for _, index := range indices {
+		child, err := key.Child(index)
+		if err != nil {
+			return nil, err
+		}
+		key = child
+	}
+	return key, nil
+}
+
+// ECPrivateKey returns the private key; it fails for public extended keys
+// ECPrivateKey mengembalikan private key
+func (k *ExtendedKey) ECPrivateKey() (*ecdsa.PrivateKey, error) {
+	if !k.private {
+		return nil, errors.New("extended key is not private")
+	}
+	return NewBitcoinCrypto().privateKeyFromScalar(new(big.Int).SetBytes(k.key)), nil
+}
+
+// PrivateKeyHex returns the private key as 32-byte hex
+func (k *ExtendedKey) PrivateKeyHex() (string, error) {
+	if !k.private {
+		return "", errors.New("extended key is not private")
+	}
+	return fmt.Sprintf("%x", k.key), nil
+}
+
+// PublicKeyHex returns the compressed public key as hex
+func (k *ExtendedKey) PublicKeyHex() string {
+	return fmt.Sprintf("%x", k.pubKeyBytes())
+}
+
+// Fingerprint returns the first 4 bytes of HASH160 of the public key
+// Fingerprint mengembalikan 4 byte pertama HASH160 dari public key
+func (k *ExtendedKey) Fingerprint() []byte {
+	return NewBitcoinCrypto().Hash160(k.pubKeyBytes())[:4]
+}
+
+func (k *ExtendedKey) pubKeyBytes() []byte {
+	if !k.private {
+		return k.key
+	}
+	x, y := S256().ScalarBaseMult(k.key)
+	return compressPoint(x, y)
+}
+
+// ParseDerivationPath parses a path such as m/84'/0'/0'/0/5; hardened steps use ' h or H
+// ParseDerivationPath mem-parsing path derivasi seperti m/84'/0'/0'/0/5
+func ParseDerivationPath(path string) ([]uint32, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil, fmt.Errorf("empty derivation path")
+	}
+
+	parts := strings.Split(path, "/")
+	if parts[0] == "m" || parts[0] == "M" {
+		parts = parts[1:]
+	}
+
+	indices := make([]uint32, 0, len(parts))
+	for _, part := range parts {
+		hardened := false
+		if strings.HasSuffix(part, "'") || strings.HasSuffix(part, "h") || strings.HasSuffix(part, "H") {
+			hardened = true
+			part = part[:len(part)-1]
+		}
+
+		value, err := strconv.ParseUint(part, 10, 32)
+		if err != nil || value >= uint64(HardenedKeyStart) {
+			return nil, fmt.Errorf("invalid derivation path component %q", part)
+		}
+
+		index := uint32(value)
+		if hardened {
+			index += HardenedKeyStart
+		}
+		indices = append(indices, index)
+	}
+
+	return indices, nil
+}
+
+// FormatDerivationPath renders indices as a path string using ' for hardened steps
+// FormatDerivationPath menampilkan indeks sebagai string path
+func FormatDerivationPath(indices []uint32) string {
+	var sb strings.Builder
+	sb.WriteString("m")
+	for _, index := range indices {
+		sb.WriteString("/")
+		if index >= HardenedKeyStart {
+			sb.WriteString(strconv.FormatUint(uint64(index-HardenedKeyStart), 10))
+			sb.WriteString("'")
+		} else {
+			sb.WriteString(strconv.FormatUint(uint64(index), 10))
+		}
+	}
+	return sb.String()
+}
+
+func doubleSHA256(data []byte) []byte {
+	first := sha256.Sum256(data)
+	second := sha256.Sum256(first[:])
+	return second[:]
+}
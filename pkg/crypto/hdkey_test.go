@@ -0,0 +1,92 @@
+package crypto
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestBIP32Vector1(t *testing.T) {
+	bc := NewBitcoinCrypto()
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+
+	master, err := bc.NewMasterKey(seed)
+	if err != nil {
+		t.Fatalf("NewMasterKey: %v", err)
+	}
+
+	for _, tc := range []struct {
+		path string
+		xpub string
+		xprv string
+	}{
+		{
+			"m",
+			"xpub661MyMwAqRbcFtXgS5sYJABqqG9YLmC4Q1Rdap9gSE8NqtwybGhePY2gZ29ESFjqJoCu1Rupje8YtGqsefD265TMg7usUDFdp6W1EGMcet8",
+			"xprv9s21ZrQH143K3QTDL4LXw2F7HEK3wJUD2nW2nRk4stbPy6cq3jPPqjiChkVvvNKmPGJxWUtg6LnF5kejMRNNU3TGtRBeJgk33yuGBxrMPHi",
+		},
+		{
+			"m/0'",
+			"xpub68Gmy5EdvgibQVfPdqkBBCHxA5htiqg55crXYuXoQRKfDBFA1WEjWgP6LHhwBZeNK1VTsfTFUHCdrfp1bgwQ9xv5ski8PX9rL2dZXvgGDnw",
+			"xprv9uHRZZhk6KAJC1avXpDAp4MDc3sQKNxDiPvvkX8Br5ngLNv1TxvUxt4cV1rGL5hj6KCesnDYUhd7oWgT11eZG7XnxHrnYeSvkzY7d2bhkJ7",
+		},
+		{
+			"m/0'/1",
+			"xpub6ASuArnXKPbfEwhqN6e3mwBcDTgzisQN1wXN9BJcM47sSikHjJf3UFHKkNAWbWMiGj7Wf5uMash7SyYq527Hqck2AxYysAA7xmALppuCkwQ",
+			"xprv9wTYmMFdV23N2TdNG573QoEsfRrWKQgWeibmLntzniatZvR9BmLnvSxqu53Kw1UmYPxLgboyZQaXwTCg8MSY3H2EU4pWcQDnRnrVA1xe8fs",
+		},
+		{
+			"m/0'/1/2'",
+			"xpub6D4BDPcP2GT577Vvch3R8wDkScZWzQzMMUm3PWbmWvVJrZwQY4VUNgqFJPMM3No2dFDFGTsxxpG5uJh7n7epu4trkrX7x7DogT5Uv6fcLW5",
+			"xprv9z4pot5VBttmtdRTWfWQmoH1taj2axGVzFqSb8C9xaxKymcFzXBDptWmT7FwuEzG3ryjH4ktypQSAewRiNMjANTtpgP4mLTj34bhnZX7UiM",
+		},
+		{
+			"m/0'/1/2'/2",
+			"xpub6FHa3pjLCk84BayeJxFW2SP4XRrFd1JYnxeLeU8EqN3vDfZmbqBqaGJAyiLjTAwm6ZLRQUMv1ZACTj37sR62cfN7fe5JnJ7dh8zL4fiyLHV",
+			"xprvA2JDeKCSNNZky6uBCviVfJSKyQ1mDYahRjijr5idH2WwLsEd4Hsb2Tyh8RfQMuPh7f7RtyzTtdrbdqqsunu5Mm3wDvUAKRHSC34sJ7in334",
+		},
+		{
+			"m/0'/1/2'/2/1000000000",
+			"xpub6H1LXWLaKsWFhvm6RVpEL9P4KfRZSW7abD2ttkWP3SSQvnyA8FSVqNTEcYFgJS2UaFcxupHiYkro49S8yGasTvXEYBVPamhGW6cFJodrTHy",
+			"xprvA41z7zogVVwxVSgdKUHDy1SKmdb533PjDz7J6N6mV6uS3ze1ai8FHa8kmHScGpWmj4WggLyQjgPie1rFSruoUihUZREPSL39UNdE3BBDu76",
+		},
+	} {
+		indices, err := ParseDerivationPath(tc.path)
+		if err != nil {
+			t.Fatalf("ParseDerivationPath(%s): %v", tc.path, err)
+		}
+
+		key, err := master.DerivePath(indices...)
+		if err != nil {
+			t.Fatalf("DerivePath(%s): %v", tc.path, err)
+		}
+		if got := key.String(); got != tc.xprv {
+			t.Errorf("%s xprv = %s, want %s", tc.path, got, tc.xprv)
+		}
+		if got := key.Neuter().String(); got != tc.xpub {
+			t.Errorf("%s xpub = %s, want %s", tc.path, got, tc.xpub)
+		}
+
+		// The serialized keys parse back to themselves
+		for _, encoded := range []string{tc.xprv, tc.xpub} {
+			parsed, err := bc.ParseExtendedKey(encoded)
+			if err != nil {
+				t.Errorf("ParseExtendedKey(%s): %v", encoded, err)
+				continue
+			}
+			if parsed.String() != encoded {
+				t.Errorf("ParseExtendedKey(%s) re-encodes as %s", encoded, parsed.String())
+			}
+		}
+	}
+
+	// Public derivation of a normal child matches private derivation
+	parent, _ := master.DerivePath(HardenedKeyStart)
+	private, _ := parent.Child(1)
+	public, err := parent.Neuter().Child(1)
+	if err != nil {
+		t.Fatalf("public Child(1): %v", err)
+	}
+	if public.String() != private.Neuter().String() {
+		t.Errorf("public derivation = %s, want %s", public.String(), private.Neuter().String())
+	}
+}
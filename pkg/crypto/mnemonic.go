@@ -0,0 +1,137 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	// mnemonicIterations is the PBKDF2 iteration count defined by BIP39
+	mnemonicIterations = 2048
+	// mnemonicSeedLength is the length of the derived seed in bytes
+	mnemonicSeedLength = 64
+)
+
+var (
+	bip39IndexOnce sync.Once
+	bip39Index     map[string]int
+)
+
+func wordIndex(word string) (int, bool) {
+	bip39IndexOnce.Do(func() {
+		bip39Index = make(map[string]int, len(bip39English))
+		for i, w := range bip39English {
+			bip39Index[w] = i
+		}
+	})
+
+	idx, ok := bip39Index[word]
+	return idx, ok
+}
+
+// GenerateMnemonic generates a new BIP39 mnemonic with 12, 15, 18, 21 or 24 words
+// GenerateMnemonic menghasilkan mnemonic BIP39 baru dengan 12, 15, 18, 21 atau 24 kata
+func (bc *BitcoinCrypto) GenerateMnemonic(wordCount int) (string, error) {
+	if wordCount < 12 || wordCount > 24 || wordCount%3 != 0 {
+		return "", fmt.Errorf("invalid word count %d: must be 12, 15, 18, 21 or 24", wordCount)
+	}
+
+	// Each 3 words encode 32 bits of entropy plus 1 checksum bit
+	entropy := make([]byte, wordCount/3*4)
+	if _, err := rand.Read(entropy); err != nil {
+		return "", fmt.Errorf("failed to read entropy: %w", err)
+	}
+
+	return bc.EntropyToMnemonic(entropy)
+}
+
+// EntropyToMnemonic encodes 128-256 bits of entropy as a BIP39 mnemonic
+// EntropyToMnemonic mengenkode entropi 128-256 bit menjadi mnemonic BIP39
+func (bc *BitcoinCrypto) EntropyToMnemonic(entropy []byte) (string, error) {
+	if len(entropy) < 16 || len(entropy) > 32 || len(entropy)%4 != 0 {
+		return "", fmt.Errorf("invalid entropy length %d bytes", len(entropy))
+	}
+
+	checksumBits := len(entropy) * 8 / 32
+	hash := sha256.Sum256(entropy)
+
+	// Append the checksum byte; only the first checksumBits of it are used
+	data := append(append([]byte(nil), entropy...), hash[0])
+	totalBits := len(entropy)*8 + checksumBits
+
+	words := make([]string, 0, totalBits/11)
+	for i := 0; i < totalBits; i += 11 {
+		idx := 0
+		for j := 0; j < 11; j++ {
+			bit := i + j
+			idx <<= 1
+			if data[bit/8]&(0x80>>uint(bit%8)) != 0 {
+				idx |= 1
+			}
+		}
+		words = append(words, bip39English[idx])
+	}
+
+	return strings.Join(words, " "), nil
+}
+
+// MnemonicToEntropy decodes a mnemonic and verifies its checksum
+// MnemonicToEntropy mendekode mnemonic dan memverifikasi checksum-nya
+func (bc *BitcoinCrypto) MnemonicToEntropy(mnemonic string) ([]byte, error) {
+	words := strings.Fields(mnemonic)
+	if len(words) < 12 || len(words) > 24 || len(words)%3 != 0 {
+		return nil, fmt.Errorf("invalid mnemonic: %d words", len(words))
+	}
+
+	totalBits := len(words) * 11
+	checksumBits := totalBits / 33
+	entropyBits := totalBits - checksumBits
+
+	data := make([]byte, (totalBits+7)/8)
+	for i, word := range words {
+		idx, ok := wordIndex(strings.ToLower(word))
+		if !ok {
+			return nil, fmt.Errorf("invalid mnemonic: unknown word %q", word)
+		}
+		for j := 0; j < 11; j++ {
+			if idx&(1<<uint(10-j)) != 0 {
+				bit := i*11 + j
+				data[bit/8] |= 0x80 >> uint(bit%8)
+			}
+		}
+	}
+
+	entropy := data[:entropyBits/8]
+	hash := sha256.Sum256(entropy)
+
+	mask := byte(0xff << uint(8-checksumBits))
+	if data[entropyBits/8]&mask != hash[0]&mask {
+		return nil, fmt.Errorf("invalid mnemonic: checksum mismatch")
+	}
+
+	return entropy, nil
+}
+
+// ValidateMnemonic reports whether a mnemonic uses known words and has a valid checksum
+// ValidateMnemonic memeriksa apakah mnemonic valid
+func (bc *BitcoinCrypto) ValidateMnemonic(mnemonic string) error {
+	_, err := bc.MnemonicToEntropy(mnemonic)
+	return err
+}
+
+// MnemonicToSeed derives the 64-byte BIP39 seed using PBKDF2-HMAC-SHA512.
+// Mnemonic and passphrase are NFKD-normalized first, as BIP39 requires.
+// MnemonicToSeed menurunkan seed BIP39 64-byte menggunakan PBKDF2-HMAC-SHA512
+func (bc *BitcoinCrypto) MnemonicToSeed(mnemonic, passphrase string) []byte {
+	normalized := strings.Join(strings.Fields(norm.NFKD.String(mnemonic)), " ")
+	salt := "mnemonic" + norm.NFKD.String(passphrase)
+
+	return pbkdf2.Key([]byte(normalized), []byte(salt), mnemonicIterations, mnemonicSeedLength, sha512.New)
+}
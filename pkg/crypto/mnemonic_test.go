@@ -0,0 +1,101 @@
+package crypto
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// BIP39 test vectors from the reference implementation, all with passphrase "TREZOR"
+var bip39Vectors = []struct {
+	entropy  string
+	mnemonic string
+	seed     string
+	xprv     string
+}{
+	{
+		"00000000000000000000000000000000",
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+		"c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+		"xprv9s21ZrQH143K3h3fDYiay8mocZ3afhfULfb5GX8kCBdno77K4HiA15Tg23wpbeF1pLfs1c5SPmYHrEpTuuRhxMwvKDwqdKiGJS9XFKzUsAF",
+	},
+	{
+		"7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f",
+		"legal winner thank year wave sausage worth useful legal winner thank yellow",
+		"2e8905819b8723fe2c1d161860e5ee1830318dbf49a83bd451cfb8440c28bd6fa457fe1296106559a3c80937a1c1069be3a3a5bd381ee6260e8d9739fce1f607",
+		"xprv9s21ZrQH143K2gA81bYFHqU68xz1cX2APaSq5tt6MFSLeXnCKV1RVUJt9FWNTbrrryem4ZckN8k4Ls1H6nwdvDTvnV7zEXs2HgPezuVccsq",
+	},
+	{
+		"80808080808080808080808080808080",
+		"letter advice cage absurd amount doctor acoustic avoid letter advice cage above",
+		"d71de856f81a8acc65e6fc851a38d4d7ec216fd0796d0a6827a3ad6ed5511a30fa280f12eb2e47ed2ac03b5c462a0358d18d69fe4f985ec81778c1b370b652a8",
+		"xprv9s21ZrQH143K2shfP28KM3nr5Ap1SXjz8gc2rAqqMEynmjt6o1qboCDpxckqXavCwdnYds6yBHZGKHv7ef2eTXy461PXUjBFQg6PrwY4Gzq",
+	},
+	{
+		"ffffffffffffffffffffffffffffffff",
+		"zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo wrong",
+		"ac27495480225222079d7be181583751e86f571027b0497b5b5d11218e0a8a13332572917f0f8e5a589620c6f15b11c61dee327651a14c34e18231052e48c069",
+		"xprv9s21ZrQH143K2V4oox4M8Zmhi2Fjx5XK4Lf7GKRvPSgydU3mjZuKGCTg7UPiBUD7ydVPvSLtg9hjp7MQTYsW67rZHAXeccqYqrsx8LcXnyd",
+	},
+	{
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon art",
+		"bda85446c68413707090a52022edd26a1c9462295029f2e60cd7c4f2bbd3097170af7a4d73245cafa9c3cca8d561a7c3de6f5d4a10be8ed2a5e608d68f92fcc8",
+		"xprv9s21ZrQH143K32qBagUJAMU2LsHg3ka7jqMcV98Y7gVeVyNStwYS3U7yVVoDZ4btbRNf4h6ibWpY22iRmXq35qgLs79f312g2kj5539ebPM",
+	},
+}
+
+func TestBIP39Vectors(t *testing.T) {
+	bc := NewBitcoinCrypto()
+
+	for _, tc := range bip39Vectors {
+		entropy, _ := hex.DecodeString(tc.entropy)
+
+		mnemonic, err := bc.EntropyToMnemonic(entropy)
+		if err != nil {
+			t.Fatalf("EntropyToMnemonic(%s): %v", tc.entropy, err)
+		}
+		if mnemonic != tc.mnemonic {
+			t.Errorf("EntropyToMnemonic(%s) = %q, want %q", tc.entropy, mnemonic, tc.mnemonic)
+		}
+
+		decoded, err := bc.MnemonicToEntropy(tc.mnemonic)
+		if err != nil || hex.EncodeToString(decoded) != tc.entropy {
+			t.Errorf("MnemonicToEntropy(%q) = %x, %v, want %s", tc.mnemonic, decoded, err, tc.entropy)
+		}
+
+		seed := bc.MnemonicToSeed(tc.mnemonic, "TREZOR")
+		if got := hex.EncodeToString(seed); got != tc.seed {
+			t.Errorf("MnemonicToSeed(%q) = %s, want %s", tc.mnemonic, got, tc.seed)
+		}
+
+		master, err := bc.NewMasterKey(seed)
+		if err != nil {
+			t.Fatalf("NewMasterKey: %v", err)
+		}
+		if got := master.String(); got != tc.xprv {
+			t.Errorf("master key of %q = %s, want %s", tc.mnemonic, got, tc.xprv)
+		}
+	}
+}
+
+func TestMnemonicToSeedNormalizesNFKD(t *testing.T) {
+	bc := NewBitcoinCrypto()
+	mnemonic := bip39Vectors[0].mnemonic
+
+	// "é" composed (U+00E9) and decomposed (e + U+0301) are the same passphrase
+	composed := bc.MnemonicToSeed(mnemonic, "caf\u00e9")
+	decomposed := bc.MnemonicToSeed(mnemonic, "cafe\u0301")
+	if hex.EncodeToString(composed) != hex.EncodeToString(decomposed) {
+		t.Error("composed and decomposed passphrases derive different seeds")
+	}
+
+	// A fullwidth letter in the mnemonic normalizes to its ASCII form
+	fullwidth := bc.MnemonicToSeed("\uff41bandon"+mnemonic[len("abandon"):], "TREZOR")
+	if got := hex.EncodeToString(fullwidth); got != bip39Vectors[0].seed {
+		t.Errorf("seed with a fullwidth letter = %s, want %s", got, bip39Vectors[0].seed)
+	}
+
+	if err := bc.ValidateMnemonic("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon"); err == nil {
+		t.Error("mnemonic with a bad checksum was accepted")
+	}
+}
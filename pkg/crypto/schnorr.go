@@ -0,0 +1,193 @@
+package crypto
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+)
+
+// taggedHash computes SHA256(SHA256(tag) || SHA256(tag) || msg...) as defined in BIP340
+func taggedHash(tag string, msgs ...[]byte) []byte {
+	tagHash := sha256.Sum256([]byte(tag))
+
+	h := sha256.New()
+	h.Write(tagHash[:])
+	h.Write(tagHash[:])
+	for _, msg := range msgs {
+		h.Write(msg)
+	}
+	return h.Sum(nil)
+}
+
+// TaggedHash exposes BIP340 tagged hashing for Taproot signature hashes
+func (bc *BitcoinCrypto) TaggedHash(tag string, msgs ...[]byte) []byte {
+	return taggedHash(tag, msgs...)
+}
+
+// liftX returns the point with the given x coordinate and an even y coordinate
+func liftX(x []byte) (*big.Int, *big.Int, error) {
+	S256()
+	px := new(big.Int).SetBytes(x)
+	py, ok := secp256k1.decompressY(px, false)
+	if !ok {
+		return nil, nil, fmt.Errorf("invalid x-only public key")
+	}
+	return px, py, nil
+}
+
+// TaprootOutputKey returns the x-only output key Q = P + H_TapTweak(P)·G for a
+// key-path-only output (BIP86). pubKey may be compressed (33 bytes) or x-only (32 bytes).
+// TaprootOutputKey mengembalikan x-only output key untuk output Taproot key-path
+func (bc *BitcoinCrypto) TaprootOutputKey(pubKey []byte) ([]byte, error) {
+	var xOnly []byte
+	switch len(pubKey) {
+	case 32:
+		xOnly = pubKey
+	case 33:
+		xOnly = pubKey[1:]
+	default:
+		return nil, fmt.Errorf("invalid Taproot internal key length %d", len(pubKey))
+	}
+
+	px, py, err := liftX(xOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	tweak := taggedHash("TapTweak", xOnly)
+	if new(big.Int).SetBytes(tweak).Cmp(S256().Params().N) >= 0 {
+		return nil, fmt.Errorf("invalid Taproot tweak")
+	}
+
+	tx, ty := S256().ScalarBaseMult(tweak)
+	qx, qy := S256().Add(px, py, tx, ty)
+	if qx.Sign() == 0 && qy.Sign() == 0 {
+		return nil, fmt.Errorf("invalid Taproot output key")
+	}
+
+	out := make([]byte, 32)
+	qx.FillBytes(out)
+	return out, nil
+}
+
+// taprootTweakedScalar returns the private scalar for the BIP86 output key of privKey
+func taprootTweakedScalar(privKey *ecdsa.PrivateKey) *big.Int {
+	n := S256().Params().N
+
+	d := new(big.Int).Set(privKey.D)
+	if privKey.PublicKey.Y.Bit(0) == 1 {
+		d.Sub(n, d)
+	}
+
+	xOnly := make([]byte, 32)
+	privKey.PublicKey.X.FillBytes(xOnly)
+
+	tweak := new(big.Int).SetBytes(taggedHash("TapTweak", xOnly))
+	d.Add(d, tweak)
+	return d.Mod(d, n)
+}
+
+// SignTaprootKeyPath signs a BIP341 signature hash for a BIP86 key-path spend
+// SignTaprootKeyPath menandatangani hash BIP341 untuk pembelanjaan Taproot key-path
+func (bc *BitcoinCrypto) SignTaprootKeyPath(hash []byte, privKey *ecdsa.PrivateKey) ([]byte, error) {
+	aux := make([]byte, 32)
+	if _, err := rand.Read(aux); err != nil {
+		return nil, fmt.Errorf("failed to read auxiliary randomness: %w", err)
+	}
+
+	return signSchnorr(taprootTweakedScalar(privKey), hash, aux)
+}
+
+// VerifySchnorr checks a 64-byte BIP340 signature against an x-only public key
+// VerifySchnorr memeriksa tanda tangan BIP340 terhadap x-only public key
+func (bc *BitcoinCrypto) VerifySchnorr(hash, signature, xOnlyPubKey []byte) bool {
+	if len(signature) != 64 || len(xOnlyPubKey) != 32 || len(hash) != 32 {
+		return false
+	}
+
+	curve := S256()
+	params := curve.Params()
+
+	px, py, err := liftX(xOnlyPubKey)
+	if err != nil {
+		return false
+	}
+
+	r := new(big.Int).SetBytes(signature[:32])
+	s := new(big.Int).SetBytes(signature[32:])
+	if r.Cmp(params.P) >= 0 || s.Cmp(params.N) >= 0 {
+		return false
+	}
+
+	e := new(big.Int).SetBytes(taggedHash("BIP0340/challenge", signature[:32], xOnlyPubKey, hash))
+	e.Mod(e, params.N)
+
+	// R = s·G - e·P
+	sx, sy := curve.ScalarBaseMult(s.Bytes())
+	ex, ey := curve.ScalarMult(px, py, e.Bytes())
+	ey.Sub(params.P, ey)
+	rx, ry := curve.Add(sx, sy, ex, ey)
+
+	if (rx.Sign() == 0 && ry.Sign() == 0) || ry.Bit(0) == 1 {
+		return false
+	}
+	return rx.Cmp(r) == 0
+}
+
+// signSchnorr produces a BIP340 signature of a 32-byte message
+func signSchnorr(secret *big.Int, msg, aux []byte) ([]byte, error) {
+	curve := S256()
+	n := curve.Params().N
+
+	if secret.Sign() == 0 || secret.Cmp(n) >= 0 {
+		return nil, fmt.Errorf("invalid secret key")
+	}
+	if len(msg) != 32 || len(aux) != 32 {
+		return nil, fmt.Errorf("message and auxiliary data must be 32 bytes")
+	}
+
+	px, py := curve.ScalarBaseMult(secret.Bytes())
+	d := new(big.Int).Set(secret)
+	if py.Bit(0) == 1 {
+		d.Sub(n, d)
+	}
+
+	pBytes := make([]byte, 32)
+	px.FillBytes(pBytes)
+	dBytes := make([]byte, 32)
+	d.FillBytes(dBytes)
+
+	t := taggedHash("BIP0340/aux", aux)
+	for i := range t {
+		t[i] ^= dBytes[i]
+	}
+
+	k := new(big.Int).SetBytes(taggedHash("BIP0340/nonce", t, pBytes, msg))
+	k.Mod(k, n)
+	if k.Sign() == 0 {
+		return nil, fmt.Errorf("invalid nonce")
+	}
+
+	rx, ry := curve.ScalarBaseMult(k.Bytes())
+	if ry.Bit(0) == 1 {
+		k.Sub(n, k)
+	}
+
+	rBytes := make([]byte, 32)
+	rx.FillBytes(rBytes)
+
+	e := new(big.Int).SetBytes(taggedHash("BIP0340/challenge", rBytes, pBytes, msg))
+	e.Mod(e, n)
+
+	s := new(big.Int).Mul(e, d)
+	s.Add(s, k)
+	s.Mod(s, n)
+
+	sig := make([]byte, 64)
+	copy(sig, rBytes)
+	s.FillBytes(sig[32:])
+
+	return sig, nil
+}
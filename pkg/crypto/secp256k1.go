@@ -0,0 +1,259 @@
+package crypto
+
+import (
+	"crypto/elliptic"
+	"math/big"
+	"sync"
+)
+
+// secp256k1 is the Koblitz curve y² = x³ + 7 used by Bitcoin (SEC 2, section 2.4.1).
+// The standard library only ships NIST curves whose arithmetic assumes a = -3,
+// so point operations are implemented here with Jacobian coordinates for a = 0.
+// secp256k1 adalah kurva Koblitz y² = x³ + 7 yang digunakan oleh Bitcoin.
+type secp256k1Curve struct {
+	params *elliptic.CurveParams
+}
+
+var (
+	s256Once  sync.Once
+	secp256k1 *secp256k1Curve
+)
+
+func initS256() {
+	params := &elliptic.CurveParams{Name: "secp256k1", BitSize: 256}
+	params.P, _ = new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEFFFFFC2F", 16)
+	params.N, _ = new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141", 16)
+	params.B = big.NewInt(7)
+	params.Gx, _ = new(big.Int).SetString("79BE667EF9DCBBAC55A06295CE870B07029BFCDB2DCE28D959F2815B16F81798", 16)
+	params.Gy, _ = new(big.Int).SetString("483ADA7726A3C4655DA4FBFC0E1108A8FD17B448A68554199C47D08FFB10D4B8", 16)
+	secp256k1 = &secp256k1Curve{params: params}
+}
+
+// S256 returns the secp256k1 curve
+// S256 mengembalikan kurva secp256k1
+func S256() elliptic.Curve {
+	s256Once.Do(initS256)
+	return secp256k1
+}
+
+func (c *secp256k1Curve) Params() *elliptic.CurveParams {
+	return c.params
+}
+
+// IsOnCurve reports whether (x, y) satisfies y² = x³ + 7 mod p
+func (c *secp256k1Curve) IsOnCurve(x, y *big.Int) bool {
+	p := c.params.P
+	if x.Sign() < 0 || x.Cmp(p) >= 0 || y.Sign() < 0 || y.Cmp(p) >= 0 {
+		return false
+	}
+
+	y2 := new(big.Int).Mul(y, y)
+	y2.Mod(y2, p)
+
+	return y2.Cmp(c.polynomial(x)) == 0
+}
+
+// polynomial returns x³ + 7 mod p
+func (c *secp256k1Curve) polynomial(x *big.Int) *big.Int {
+	x3 := new(big.Int).Mul(x, x)
+	x3.Mul(x3, x)
+	x3.Add(x3, c.params.B)
+	return x3.Mod(x3, c.params.P)
+}
+
+func (c *secp256k1Curve) Add(x1, y1, x2, y2 *big.Int) (*big.Int, *big.Int) {
+	return c.toAffine(c.addJacobian(c.toJacobian(x1, y1), c.toJacobian(x2, y2)))
+}
+
+func (c *secp256k1Curve) Double(x1, y1 *big.Int) (*big.Int, *big.Int) {
+	return c.toAffine(c.doubleJacobian(c.toJacobian(x1, y1)))
+}
+
+func (c *secp256k1Curve) ScalarMult(x1, y1 *big.Int, k []byte) (*big.Int, *big.Int) {
+	base := c.toJacobian(x1, y1)
+	result := &jacobianPoint{x: new(big.Int), y: new(big.Int), z: new(big.Int)}
+
+	for _, b := range k {
+		for bit := 7; bit >= 0; bit-- {
+			result = c.doubleJacobian(result)
+			if (b>>uint(bit))&1 == 1 {
+				result = c.addJacobian(result, base)
+			}
+		}
+	}
+
+	return c.toAffine(result)
+}
+
+func (c *secp256k1Curve) ScalarBaseMult(k []byte) (*big.Int, *big.Int) {
+	return c.ScalarMult(c.params.Gx, c.params.Gy, k)
+}
+
+// jacobianPoint represents (X/Z², Y/Z³); Z = 0 is the point at infinity
+type jacobianPoint struct {
+	x, y, z *big.Int
+}
+
+func (c *secp256k1Curve) toJacobian(x, y *big.Int) *jacobianPoint {
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return &jacobianPoint{x: new(big.Int), y: new(big.Int), z: new(big.Int)}
+	}
+	return &jacobianPoint{x: new(big.Int).Set(x), y: new(big.Int).Set(y), z: big.NewInt(1)}
+}
+
+func (c *secp256k1Curve) toAffine(pt *jacobianPoint) (*big.Int, *big.Int) {
+	if pt.z.Sign() == 0 {
+		return new(big.Int), new(big.Int)
+	}
+
+	p := c.params.P
+	zInv := new(big.Int).ModInverse(pt.z, p)
+	zInv2 := new(big.Int).Mul(zInv, zInv)
+
+	x := new(big.Int).Mul(pt.x, zInv2)
+	x.Mod(x, p)
+
+	zInv3 := zInv2.Mul(zInv2, zInv)
+	y := new(big.Int).Mul(pt.y, zInv3)
+	y.Mod(y, p)
+
+	return x, y
+}
+
+// doubleJacobian uses the dbl-2009-l formulas for a = 0
+func (c *secp256k1Curve) doubleJacobian(pt *jacobianPoint) *jacobianPoint {
+	if pt.z.Sign() == 0 || pt.y.Sign() == 0 {
+		return &jacobianPoint{x: new(big.Int), y: new(big.Int), z: new(big.Int)}
+	}
+
+	p := c.params.P
+
+	a := new(big.Int).Mul(pt.x, pt.x)
+	a.Mod(a, p)
+	b := new(big.Int).Mul(pt.y, pt.y)
+	b.Mod(b, p)
+	cc := new(big.Int).Mul(b, b)
+	cc.Mod(cc, p)
+
+	d := new(big.Int).Add(pt.x, b)
+	d.Mul(d, d)
+	d.Sub(d, a)
+	d.Sub(d, cc)
+	d.Lsh(d, 1)
+	d.Mod(d, p)
+
+	e := new(big.Int).Mul(a, big.NewInt(3))
+	f := new(big.Int).Mul(e, e)
+
+	x3 := new(big.Int).Sub(f, new(big.Int).Lsh(d, 1))
+	x3.Mod(x3, p)
+
+	y3 := new(big.Int).Sub(d, x3)
+	y3.Mul(y3, e)
+	y3.Sub(y3, new(big.Int).Lsh(cc, 3))
+	y3.Mod(y3, p)
+
+	z3 := new(big.Int).Mul(pt.y, pt.z)
+	z3.Lsh(z3, 1)
+	z3.Mod(z3, p)
+
+	return &jacobianPoint{x: x3, y: y3, z: z3}
+}
+
+// addJacobian uses the add-2007-bl formulas
+func (c *secp256k1Curve) addJacobian(p1, p2 *jacobianPoint) *jacobianPoint {
+	if p1.z.Sign() == 0 {
+		return p2
+	}
+	if p2.z.Sign() == 0 {
+		return p1
+	}
+
+	p := c.params.P
+
+	z1z1 := new(big.Int).Mul(p1.z, p1.z)
+	z1z1.Mod(z1z1, p)
+	z2z2 := new(big.Int).Mul(p2.z, p2.z)
+	z2z2.Mod(z2z2, p)
+
+	u1 := new(big.Int).Mul(p1.x, z2z2)
+	u1.Mod(u1, p)
+	u2 := new(big.Int).Mul(p2.x, z1z1)
+	u2.Mod(u2, p)
+
+	s1 := new(big.Int).Mul(p1.y, p2.z)
+	s1.Mul(s1, z2z2)
+	s1.Mod(s1, p)
+	s2 := new(big.Int).Mul(p2.y, p1.z)
+	s2.Mul(s2, z1z1)
+	s2.Mod(s2, p)
+
+	h := new(big.Int).Sub(u2, u1)
+	h.Mod(h, p)
+	r := new(big.Int).Sub(s2, s1)
+	r.Mod(r, p)
+
+	if h.Sign() == 0 {
+		if r.Sign() == 0 {
+			return c.doubleJacobian(p1)
+		}
+		return &jacobianPoint{x: new(big.Int), y: new(big.Int), z: new(big.Int)}
+	}
+
+	r.Lsh(r, 1)
+
+	i := new(big.Int).Lsh(h, 1)
+	i.Mul(i, i)
+	i.Mod(i, p)
+	j := new(big.Int).Mul(h, i)
+	j.Mod(j, p)
+	v := new(big.Int).Mul(u1, i)
+	v.Mod(v, p)
+
+	x3 := new(big.Int).Mul(r, r)
+	x3.Sub(x3, j)
+	x3.Sub(x3, new(big.Int).Lsh(v, 1))
+	x3.Mod(x3, p)
+
+	y3 := new(big.Int).Sub(v, x3)
+	y3.Mul(y3, r)
+	s1j := new(big.Int).Mul(s1, j)
+	y3.Sub(y3, s1j.Lsh(s1j, 1))
+	y3.Mod(y3, p)
+
+	z3 := new(big.Int).Add(p1.z, p2.z)
+	z3.Mul(z3, z3)
+	z3.Sub(z3, z1z1)
+	z3.Sub(z3, z2z2)
+	z3.Mul(z3, h)
+	z3.Mod(z3, p)
+
+	return &jacobianPoint{x: x3, y: y3, z: z3}
+}
+
+// decompressY recovers the y coordinate for x with the requested parity
+func (c *secp256k1Curve) decompressY(x *big.Int, odd bool) (*big.Int, bool) {
+	p := c.params.P
+	if x.Cmp(p) >= 0 {
+		return nil, false
+	}
+
+	// p ≡ 3 (mod 4), so sqrt(a) = a^((p+1)/4)
+	exp := new(big.Int).Add(p, big.NewInt(1))
+	exp.Rsh(exp, 2)
+
+	y2 := c.polynomial(x)
+	y := new(big.Int).Exp(y2, exp, p)
+
+	check := new(big.Int).Mul(y, y)
+	check.Mod(check, p)
+	if check.Cmp(y2) != 0 {
+		return nil, false
+	}
+
+	if (y.Bit(0) == 1) != odd {
+		y.Sub(p, y)
+	}
+
+	return y, true
+}
@@ -0,0 +1,134 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+// keyOne is the private key 1, whose public key is the generator point
+const keyOne = "0000000000000000000000000000000000000000000000000000000000000001"
+
+func TestKeyOneAddresses(t *testing.T) {
+	bc := NewBitcoinCrypto()
+
+	privKey, err := bc.PrivateKeyFromHex(keyOne)
+	if err != nil {
+		t.Fatalf("PrivateKeyFromHex: %v", err)
+	}
+
+	pubKey := bc.CompressPublicKey(&privKey.PublicKey)
+	if want := "0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"; pubKey != want {
+		t.Fatalf("public key = %s, want %s", pubKey, want)
+	}
+
+	address, err := bc.GenerateAddress(pubKey)
+	if err != nil {
+		t.Fatalf("GenerateAddress: %v", err)
+	}
+	if want := "1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH"; address != want {
+		t.Errorf("P2PKH address = %s, want %s", address, want)
+	}
+
+	segwit, err := bc.GenerateSegWitAddress(pubKey)
+	if err != nil {
+		t.Fatalf("GenerateSegWitAddress: %v", err)
+	}
+	if want := "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"; segwit != want {
+		t.Errorf("P2WPKH address = %s, want %s", segwit, want)
+	}
+}
+
+func TestSignRFC6979(t *testing.T) {
+	bc := NewBitcoinCrypto()
+
+	privKey, err := bc.PrivateKeyFromHex(keyOne)
+	if err != nil {
+		t.Fatalf("PrivateKeyFromHex: %v", err)
+	}
+
+	hash := sha256.Sum256([]byte("Satoshi Nakamoto"))
+	sig := bc.SignHash(hash[:], privKey)
+
+	want := "3045022100934b1ea10a4b3c1757e2b0c017d0b6143ce3c9a7e6a4a49860d7a6ab210ee3d8" +
+		"02202442ce9d2b916064108014783e923ec36b49743e2ffa1c4496f01a512aafd9e5"
+	if got := hex.EncodeToString(sig); got != want {
+		t.Errorf("signature = %s, want %s", got, want)
+	}
+
+	ok, err := bc.VerifySignature(hex.EncodeToString(hash[:]), want, bc.CompressPublicKey(&privKey.PublicKey))
+	if err != nil || !ok {
+		t.Errorf("VerifySignature = %v, %v, want true", ok, err)
+	}
+}
+
+func TestSignLowS(t *testing.T) {
+	bc := NewBitcoinCrypto()
+	n := S256().Params().N
+	halfN := new(big.Int).Rsh(n, 1)
+
+	privKey, err := bc.PrivateKeyFromHex(keyOne)
+	if err != nil {
+		t.Fatalf("PrivateKeyFromHex: %v", err)
+	}
+
+	for i := 0; i < 64; i++ {
+		hash := sha256.Sum256([]byte(fmt.Sprintf("message %d", i)))
+		r, s, err := parseDER(bc.SignHash(hash[:], privKey))
+		if err != nil {
+			t.Fatalf("message %d: parseDER: %v", i, err)
+		}
+		if s.Cmp(halfN) > 0 {
+			t.Errorf("message %d: s = %x is above n/2", i, s)
+		}
+		if !verifyECDSA(&privKey.PublicKey, hash[:], r, s) {
+			t.Errorf("message %d: normalised signature does not verify", i)
+		}
+	}
+}
+
+func TestDERRoundTrip(t *testing.T) {
+	n := S256().Params().N
+	for _, tc := range []struct {
+		r, s string
+	}{
+		{"934b1ea10a4b3c1757e2b0c017d0b6143ce3c9a7e6a4a49860d7a6ab210ee3d8", "2442ce9d2b916064108014783e923ec36b49743e2ffa1c4496f01a512aafd9e5"},
+		{"01", "01"},
+		{"7f", "80"},
+		{"00ff", "0100"},
+		{new(big.Int).Sub(n, big.NewInt(1)).Text(16), new(big.Int).Rsh(n, 1).Text(16)},
+	} {
+		r, _ := new(big.Int).SetString(tc.r, 16)
+		s, _ := new(big.Int).SetString(tc.s, 16)
+
+		der := encodeDER(r, s)
+		gotR, gotS, err := parseDER(der)
+		if err != nil {
+			t.Errorf("parseDER(%x): %v", der, err)
+			continue
+		}
+		if gotR.Cmp(r) != 0 || gotS.Cmp(s) != 0 {
+			t.Errorf("parseDER(%x) = (%x, %x), want (%x, %x)", der, gotR, gotS, r, s)
+		}
+		if again := encodeDER(gotR, gotS); !bytes.Equal(again, der) {
+			t.Errorf("re-encoded %x, want %x", again, der)
+		}
+	}
+
+	for _, invalid := range []string{
+		"",
+		"3007020101020101ff", // trailing byte
+		"300702020001020101", // non-minimal r
+		"3006020181020101",   // negative r
+		"3007020101020101",   // wrong sequence length
+		"3006030101020101",   // not an integer
+	} {
+		sig, _ := hex.DecodeString(invalid)
+		if _, _, err := parseDER(sig); err == nil {
+			t.Errorf("parseDER(%s) accepted an invalid signature", invalid)
+		}
+	}
+}
@@ -1,10 +1,12 @@
 package crypto
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 	"math/big"
+	"strings"
 
 	"golang.org/x/crypto/ripemd160"
 )
@@ -29,8 +31,8 @@ func (bc *BitcoinCrypto) GenerateSegWitAddress(publicKeyHex string) (string, err
 	}
 	pubKeyHash := ripemd160Hasher.Sum(nil)
 
-	// Encode to bech32 (witness version 0, mainnet "bc")
-	address, err := bc.encodeBech32("bc", 0, pubKeyHash)
+	// Encode to bech32 (witness version 0, "bc" on mainnet)
+	address, err := bc.EncodeSegWitAddress(bc.params.Bech32HRP, 0, pubKeyHash)
 	if err != nil {
 		return "", fmt.Errorf("failed to encode bech32: %w", err)
 	}
@@ -56,7 +58,7 @@ func (bc *BitcoinCrypto) GenerateSegWitTestnetAddress(publicKeyHex string) (stri
 	pubKeyHash := ripemd160Hasher.Sum(nil)
 
 	// Use "tb" for testnet bech32 addresses
-	address, err := bc.encodeBech32("tb", 0, pubKeyHash)
+	address, err := bc.EncodeSegWitAddress("tb", 0, pubKeyHash)
 	if err != nil {
 		return "", fmt.Errorf("failed to encode bech32: %w", err)
 	}
@@ -64,8 +66,23 @@ func (bc *BitcoinCrypto) GenerateSegWitTestnetAddress(publicKeyHex string) (stri
 	return address, nil
 }
 
-// encodeBech32 encodes data to bech32 format
-func (bc *BitcoinCrypto) encodeBech32(hrp string, version byte, program []byte) (string, error) {
+// Checksum constants: bech32 (BIP173) for witness v0, bech32m (BIP350) for v1+
+const (
+	bech32Const  = 1
+	bech32mConst = 0x2bc830a3
+)
+
+// witnessChecksumConst returns the checksum constant required for a witness version
+func witnessChecksumConst(version byte) int {
+	if version == 0 {
+		return bech32Const
+	}
+	return bech32mConst
+}
+
+// EncodeSegWitAddress encodes a witness program as a bech32 (v0) or bech32m (v1+) address
+// EncodeSegWitAddress mengenkode witness program sebagai alamat bech32 atau bech32m
+func (bc *BitcoinCrypto) EncodeSegWitAddress(hrp string, version byte, program []byte) (string, error) {
 	// Convert 8-bit to 5-bit
 	converted := bc.convertBits(program, 8, 5, true)
 	if converted == nil {
@@ -76,7 +93,7 @@ func (bc *BitcoinCrypto) encodeBech32(hrp string, version byte, program []byte)
 	data := append([]byte{version}, converted...)
 
 	// Create checksum
-	checksum := bc.bech32Checksum(hrp, data)
+	checksum := bc.bech32Checksum(hrp, data, witnessChecksumConst(version))
 	combined := append(data, checksum...)
 
 	// Encode with bech32 charset
@@ -92,6 +109,98 @@ func (bc *BitcoinCrypto) encodeBech32(hrp string, version byte, program []byte)
 	return result, nil
 }
 
+// GenerateNestedSegWitAddress generates a P2SH-wrapped SegWit (P2SH-P2WPKH) address (3...)
+// GenerateNestedSegWitAddress menghasilkan alamat SegWit yang dibungkus P2SH (3...)
+func (bc *BitcoinCrypto) GenerateNestedSegWitAddress(publicKeyHex string) (string, error) {
+	publicKeyBytes, err := hex.DecodeString(publicKeyHex)
+	if err != nil {
+		return "", fmt.Errorf("invalid public key: %w", err)
+	}
+	if len(publicKeyBytes) != 33 {
+		return "", fmt.Errorf("nested SegWit requires a compressed public key")
+	}
+
+	redeemScript := bc.NestedSegWitRedeemScript(bc.Hash160(publicKeyBytes))
+
+	return bc.EncodeBase58Check(bc.params.ScriptHashAddrID, bc.Hash160(redeemScript)), nil
+}
+
+// NestedSegWitRedeemScript returns the P2SH redeem script (OP_0 <20-byte hash>) for a P2SH-P2WPKH output
+func (bc *BitcoinCrypto) NestedSegWitRedeemScript(pubKeyHash []byte) []byte {
+	return append([]byte{0x00, 0x14}, pubKeyHash...)
+}
+
+// GenerateTaprootAddress generates a BIP86 key-path-only Taproot (bech32m bc1p...) address
+// GenerateTaprootAddress menghasilkan alamat Taproot (bech32m bc1p...) sesuai BIP86
+func (bc *BitcoinCrypto) GenerateTaprootAddress(publicKeyHex string) (string, error) {
+	publicKeyBytes, err := hex.DecodeString(publicKeyHex)
+	if err != nil {
+		return "", fmt.Errorf("invalid public key: %w", err)
+	}
+
+	outputKey, err := bc.TaprootOutputKey(publicKeyBytes)
+	if err != nil {
+		return "", err
+	}
+
+	address, err := bc.EncodeSegWitAddress(bc.params.Bech32HRP, 1, outputKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode bech32m: %w", err)
+	}
+
+	return address, nil
+}
+
+// DecodeSegWitAddress decodes a bech32 SegWit address and returns its witness version and program
+// DecodeSegWitAddress mendekode alamat SegWit bech32 dan mengembalikan versi witness serta program
+func (bc *BitcoinCrypto) DecodeSegWitAddress(hrp, address string) (byte, []byte, error) {
+	if strings.ToLower(address) != address && strings.ToUpper(address) != address {
+		return 0, nil, fmt.Errorf("mixed case bech32 address")
+	}
+	address = strings.ToLower(address)
+
+	sep := strings.LastIndex(address, "1")
+	if sep < 1 || sep+7 > len(address) || len(address) > 90 {
+		return 0, nil, fmt.Errorf("invalid bech32 address length")
+	}
+	if address[:sep] != hrp {
+		return 0, nil, fmt.Errorf("unexpected address prefix %q", address[:sep])
+	}
+
+	const charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+	data := make([]byte, 0, len(address)-sep-1)
+	for _, c := range address[sep+1:] {
+		idx := strings.IndexRune(charset, c)
+		if idx < 0 {
+			return 0, nil, fmt.Errorf("invalid bech32 character %q", c)
+		}
+		data = append(data, byte(idx))
+	}
+
+	values := append(bc.bech32HrpExpand(hrp), data...)
+	checksum := bc.bech32Polymod(values)
+
+	data = data[:len(data)-6]
+	if len(data) < 1 {
+		return 0, nil, fmt.Errorf("missing witness version")
+	}
+
+	version := data[0]
+	if checksum != witnessChecksumConst(version) {
+		return 0, nil, fmt.Errorf("invalid bech32 checksum for witness version %d", version)
+	}
+
+	program := bc.convertBits(data[1:], 5, 8, false)
+	if program == nil || len(program) < 2 || len(program) > 40 || version > 16 {
+		return 0, nil, fmt.Errorf("invalid witness program")
+	}
+	if version == 0 && len(program) != 20 && len(program) != 32 {
+		return 0, nil, fmt.Errorf("invalid witness v0 program length %d", len(program))
+	}
+
+	return version, program, nil
+}
+
 // convertBits converts between bit groups
 func (bc *BitcoinCrypto) convertBits(data []byte, fromBits, toBits int, pad bool) []byte {
 	acc := 0
@@ -120,13 +229,13 @@ func (bc *BitcoinCrypto) convertBits(data []byte, fromBits, toBits int, pad bool
 	return result
 }
 
-// bech32Checksum creates bech32 checksum
-func (bc *BitcoinCrypto) bech32Checksum(hrp string, data []byte) []byte {
+// bech32Checksum creates a bech32 or bech32m checksum
+func (bc *BitcoinCrypto) bech32Checksum(hrp string, data []byte, constant int) []byte {
 	values := bc.bech32HrpExpand(hrp)
 	values = append(values, data...)
 	values = append(values, []byte{0, 0, 0, 0, 0, 0}...)
 
-	polymod := bc.bech32Polymod(values) ^ 1
+	polymod := bc.bech32Polymod(values) ^ constant
 	var checksum []byte
 
 	for i := 0; i < 6; i++ {
@@ -170,19 +279,14 @@ func (bc *BitcoinCrypto) bech32Polymod(values []byte) int {
 
 // ConvertToWIF converts hex private key to WIF format for import
 // ConvertToWIF mengkonversi private key hex ke format WIF untuk import
-func (bc *BitcoinCrypto) ConvertToWIF(privateKeyHex string, compressed bool, testnet bool) (string, error) {
+func (bc *BitcoinCrypto) ConvertToWIF(privateKeyHex string, compressed bool) (string, error) {
 	privateKeyBytes, err := hex.DecodeString(privateKeyHex)
 	if err != nil {
 		return "", fmt.Errorf("invalid hex: %w", err)
 	}
 
-	// Version byte: 0x80 for mainnet, 0xef for testnet
-	versionByte := byte(0x80)
-	if testnet {
-		versionByte = 0xef
-	}
-
-	payload := append([]byte{versionByte}, privateKeyBytes...)
+	// Version byte: 0x80 for mainnet, 0xef for test networks
+	payload := append([]byte{bc.params.PrivateKeyID}, privateKeyBytes...)
 
 	if compressed {
 		payload = append(payload, 0x01)
@@ -199,6 +303,27 @@ func (bc *BitcoinCrypto) ConvertToWIF(privateKeyHex string, compressed bool, tes
 	return wif, nil
 }
 
+// DecodeWIF decodes a WIF private key for the crypto network into hex and its compression flag
+// DecodeWIF mendekode private key WIF menjadi hex beserta flag kompresinya
+func (bc *BitcoinCrypto) DecodeWIF(wif string) (string, bool, error) {
+	version, payload, err := bc.DecodeBase58Check(wif)
+	if err != nil {
+		return "", false, fmt.Errorf("invalid WIF: %w", err)
+	}
+	if version != bc.params.PrivateKeyID {
+		return "", false, fmt.Errorf("WIF key is not for %s", bc.params.Name)
+	}
+
+	switch {
+	case len(payload) == 33 && payload[32] == 0x01:
+		return hex.EncodeToString(payload[:32]), true, nil
+	case len(payload) == 32:
+		return hex.EncodeToString(payload), false, nil
+	default:
+		return "", false, fmt.Errorf("invalid WIF payload length %d", len(payload))
+	}
+}
+
 // base58Decode decodes Base58 string
 func (bc *BitcoinCrypto) base58Decode(input string) []byte {
 	const alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
@@ -232,3 +357,28 @@ func (bc *BitcoinCrypto) base58Decode(input string) []byte {
 
 	return decoded
 }
+
+// EncodeBase58Check encodes version || payload with a 4-byte double SHA-256 checksum
+// EncodeBase58Check mengenkode version || payload dengan checksum 4 byte
+func (bc *BitcoinCrypto) EncodeBase58Check(version byte, payload []byte) string {
+	versioned := append([]byte{version}, payload...)
+	return bc.base58Encode(append(versioned, doubleSHA256(versioned)[:4]...))
+}
+
+// DecodeBase58Check decodes a Base58Check string and returns its version byte and payload
+// DecodeBase58Check mendekode string Base58Check dan mengembalikan version byte serta payload
+func (bc *BitcoinCrypto) DecodeBase58Check(input string) (byte, []byte, error) {
+	decoded := bc.base58Decode(input)
+	if len(decoded) < 5 {
+		return 0, nil, fmt.Errorf("invalid base58 string")
+	}
+
+	payload := decoded[:len(decoded)-4]
+	firstHash := sha256.Sum256(payload)
+	secondHash := sha256.Sum256(firstHash[:])
+	if !bytes.Equal(secondHash[:4], decoded[len(decoded)-4:]) {
+		return 0, nil, fmt.Errorf("invalid base58 checksum")
+	}
+
+	return payload[0], payload[1:], nil
+}
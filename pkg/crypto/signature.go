@@ -0,0 +1,203 @@
+package crypto
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// signECDSA signs a 32-byte hash with a deterministic RFC 6979 nonce and
+// normalises s to the lower half of the group order as required by BIP146
+// signECDSA menandatangani hash 32-byte dengan nonce deterministik RFC 6979
+func signECDSA(d *big.Int, hash []byte) (r, s *big.Int) {
+	curve := S256()
+	n := curve.Params().N
+	halfN := new(big.Int).Rsh(n, 1)
+
+	z := hashToInt(hash, n)
+	nonces := newRFC6979(d, hash, n)
+
+	for {
+		k := nonces()
+
+		rx, _ := curve.ScalarBaseMult(k.Bytes())
+		r = new(big.Int).Mod(rx, n)
+		if r.Sign() == 0 {
+			continue
+		}
+
+		s = new(big.Int).Mul(r, d)
+		s.Add(s, z)
+		s.Mul(s, new(big.Int).ModInverse(k, n))
+		s.Mod(s, n)
+		if s.Sign() == 0 {
+			continue
+		}
+
+		if s.Cmp(halfN) > 0 {
+			s.Sub(n, s)
+		}
+
+		return r, s
+	}
+}
+
+// verifyECDSA checks an ECDSA signature against a public key
+// verifyECDSA memeriksa tanda tangan ECDSA terhadap public key
+func verifyECDSA(pub *ecdsa.PublicKey, hash []byte, r, s *big.Int) bool {
+	curve := S256()
+	n := curve.Params().N
+
+	if r.Sign() <= 0 || s.Sign() <= 0 || r.Cmp(n) >= 0 || s.Cmp(n) >= 0 {
+		return false
+	}
+
+	z := hashToInt(hash, n)
+	w := new(big.Int).ModInverse(s, n)
+
+	u1 := new(big.Int).Mul(z, w)
+	u1.Mod(u1, n)
+	u2 := new(big.Int).Mul(r, w)
+	u2.Mod(u2, n)
+
+	x1, y1 := curve.ScalarBaseMult(u1.Bytes())
+	x2, y2 := curve.ScalarMult(pub.X, pub.Y, u2.Bytes())
+	x, y := curve.Add(x1, y1, x2, y2)
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return false
+	}
+
+	x.Mod(x, n)
+	return x.Cmp(r) == 0
+}
+
+// hashToInt converts a hash to an integer modulo n (bits2int in RFC 6979)
+func hashToInt(hash []byte, n *big.Int) *big.Int {
+	orderBytes := (n.BitLen() + 7) / 8
+	if len(hash) > orderBytes {
+		hash = hash[:orderBytes]
+	}
+
+	z := new(big.Int).SetBytes(hash)
+	excess := len(hash)*8 - n.BitLen()
+	if excess > 0 {
+		z.Rsh(z, uint(excess))
+	}
+
+	return z
+}
+
+// newRFC6979 returns a generator of deterministic nonces (RFC 6979, section 3.2)
+func newRFC6979(d *big.Int, hash []byte, n *big.Int) func() *big.Int {
+	x := make([]byte, 32)
+	d.FillBytes(x)
+
+	h1 := make([]byte, 32)
+	new(big.Int).Mod(hashToInt(hash, n), n).FillBytes(h1)
+
+	v := make([]byte, 32)
+	for i := range v {
+		v[i] = 0x01
+	}
+	k := make([]byte, 32)
+
+	mac := func(key []byte, parts ...[]byte) []byte {
+		h := hmac.New(sha256.New, key)
+		for _, p := range parts {
+			h.Write(p)
+		}
+		return h.Sum(nil)
+	}
+
+	k = mac(k, v, []byte{0x00}, x, h1)
+	v = mac(k, v)
+	k = mac(k, v, []byte{0x01}, x, h1)
+	v = mac(k, v)
+
+	first := true
+	return func() *big.Int {
+		if !first {
+			k = mac(k, v, []byte{0x00})
+			v = mac(k, v)
+		}
+		first = false
+
+		for {
+			v = mac(k, v)
+			candidate := new(big.Int).SetBytes(v)
+			if candidate.Sign() > 0 && candidate.Cmp(n) < 0 {
+				return candidate
+			}
+			k = mac(k, v, []byte{0x00})
+			v = mac(k, v)
+		}
+	}
+}
+
+// encodeDER serialises (r, s) as a strict DER signature (BIP66)
+// encodeDER menserialisasi (r, s) sebagai tanda tangan DER
+func encodeDER(r, s *big.Int) []byte {
+	encodeInt := func(v *big.Int) []byte {
+		b := v.Bytes()
+		if len(b) == 0 {
+			b = []byte{0x00}
+		}
+		if b[0]&0x80 != 0 {
+			b = append([]byte{0x00}, b...)
+		}
+		return append([]byte{0x02, byte(len(b))}, b...)
+	}
+
+	rb := encodeInt(r)
+	sb := encodeInt(s)
+
+	sig := []byte{0x30, byte(len(rb) + len(sb))}
+	sig = append(sig, rb...)
+	return append(sig, sb...)
+}
+
+// parseDER decodes a strict DER signature into (r, s)
+// parseDER mendekode tanda tangan DER menjadi (r, s)
+func parseDER(sig []byte) (r, s *big.Int, err error) {
+	if len(sig) < 8 || len(sig) > 72 {
+		return nil, nil, errors.New("invalid DER signature length")
+	}
+	if sig[0] != 0x30 || int(sig[1]) != len(sig)-2 {
+		return nil, nil, errors.New("invalid DER sequence header")
+	}
+
+	readInt := func(data []byte) (*big.Int, []byte, error) {
+		if len(data) < 2 || data[0] != 0x02 {
+			return nil, nil, errors.New("invalid DER integer marker")
+		}
+		length := int(data[1])
+		if length == 0 || len(data) < 2+length {
+			return nil, nil, errors.New("invalid DER integer length")
+		}
+		value := data[2 : 2+length]
+		if value[0]&0x80 != 0 {
+			return nil, nil, errors.New("negative DER integer")
+		}
+		if length > 1 && value[0] == 0x00 && value[1]&0x80 == 0 {
+			return nil, nil, errors.New("non-minimal DER integer")
+		}
+		return new(big.Int).SetBytes(value), data[2+length:], nil
+	}
+
+	r, rest, err := readInt(sig[2:])
+	if err != nil {
+		return nil, nil, err
+	}
+	s, rest, err = readInt(rest)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(rest) != 0 {
+		return nil, nil, fmt.Errorf("%d trailing bytes after DER signature", len(rest))
+	}
+
+	return r, s, nil
+}
@@ -0,0 +1,103 @@
+package descriptor
+
+import (
+	"fmt"
+	"strings"
+)
+
+// inputCharset orders the characters a descriptor may contain so that the
+// checksum catches the common character swaps of each group (BIP380)
+const inputCharset = "0123456789()[],'/*abcdefgh@:$%{}" +
+	"IJKLMNOPQRSTUVWXYZ&+-.;<=>?!^_|~" +
+	"ijklmnopqrstuvwxyzABCDEFGH`#\"\\ "
+
+// checksumCharset is the bech32 alphabet used to render the checksum
+const checksumCharset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// ChecksumLength is the number of characters after the '#' separator
+const ChecksumLength = 8
+
+// descPolymod steps the BCH code over one 5-bit value
+func descPolymod(c uint64, value int) uint64 {
+	c0 := c >> 35
+	c = ((c & 0x7ffffffff) << 5) ^ uint64(value)
+	if c0&1 != 0 {
+		c ^= 0xf5dee51989
+	}
+	if c0&2 != 0 {
+		c ^= 0xa9fdca3312
+	}
+	if c0&4 != 0 {
+		c ^= 0x1bab10e32d
+	}
+	if c0&8 != 0 {
+		c ^= 0x3706b1677a
+	}
+	if c0&16 != 0 {
+		c ^= 0x644d626ffd
+	}
+	return c
+}
+
+// Checksum returns the 8-character BIP380 checksum of a descriptor without its '#' suffix
+// Checksum mengembalikan checksum BIP380 8 karakter dari sebuah descriptor
+func Checksum(desc string) (string, error) {
+	c := uint64(1)
+	class, classCount := 0, 0
+	for _, ch := range desc {
+		pos := strings.IndexRune(inputCharset, ch)
+		if pos < 0 {
+			return "", fmt.Errorf("%w: invalid character %q", ErrInvalidDescriptor, ch)
+		}
+		c = descPolymod(c, pos&31)
+		class = class*3 + pos>>5
+		classCount++
+		if classCount == 3 {
+			c = descPolymod(c, class)
+			class, classCount = 0, 0
+		}
+	}
+	if classCount > 0 {
+		c = descPolymod(c, class)
+	}
+	for i := 0; i < ChecksumLength; i++ {
+		c = descPolymod(c, 0)
+	}
+	c ^= 1
+
+	var sb strings.Builder
+	for i := 0; i < ChecksumLength; i++ {
+		sb.WriteByte(checksumCharset[(c>>(5*(ChecksumLength-1-i)))&31])
+	}
+	return sb.String(), nil
+}
+
+// AddChecksum returns desc followed by '#' and its checksum
+// AddChecksum mengembalikan descriptor beserta checksum-nya
+func AddChecksum(desc string) (string, error) {
+	checksum, err := Checksum(desc)
+	if err != nil {
+		return "", err
+	}
+	return desc + "#" + checksum, nil
+}
+
+// splitChecksum strips and verifies an optional '#checksum' suffix
+func splitChecksum(text string) (string, error) {
+	desc, checksum, found := strings.Cut(text, "#")
+	if !found {
+		return desc, nil
+	}
+	if len(checksum) != ChecksumLength {
+		return "", fmt.Errorf("%w: checksum must be %d characters", ErrInvalidChecksum, ChecksumLength)
+	}
+
+	expected, err := Checksum(desc)
+	if err != nil {
+		return "", err
+	}
+	if checksum != expected {
+		return "", fmt.Errorf("%w: got %s, expected %s", ErrInvalidChecksum, checksum, expected)
+	}
+	return desc, nil
+}
@@ -0,0 +1,55 @@
+package descriptor
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestChecksumVectors(t *testing.T) {
+	for _, tc := range []struct {
+		desc, checksum string
+	}{
+		// BIP380
+		{"raw(deadbeef)", "89f8spxm"},
+		// Bitcoin Core doc/descriptors.md
+		{"pkh([d34db33f/44'/0'/0']xpub6ERApfZwUNrhLCkDtcHTcxd75RbzS1ed54G1LkBUHQVHQKqhMkhgbmJbZRkrgZw4koxb5JaHWkY4ALHY2grBGRjaDMzQLcgJvLJuZZvRcEL/1/*)", "ml40v0wf"},
+		{"wpkh(02f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f9)", "8zl0zxma"},
+	} {
+		got, err := Checksum(tc.desc)
+		if err != nil {
+			t.Errorf("Checksum(%s): %v", tc.desc, err)
+			continue
+		}
+		if got != tc.checksum {
+			t.Errorf("Checksum(%s) = %s, want %s", tc.desc, got, tc.checksum)
+		}
+
+		withChecksum, err := AddChecksum(tc.desc)
+		if err != nil || withChecksum != tc.desc+"#"+tc.checksum {
+			t.Errorf("AddChecksum(%s) = %s, %v", tc.desc, withChecksum, err)
+		}
+		if body, err := splitChecksum(withChecksum); err != nil || body != tc.desc {
+			t.Errorf("splitChecksum(%s) = %s, %v, want %s", withChecksum, body, err, tc.desc)
+		}
+	}
+}
+
+func TestSplitChecksumRejects(t *testing.T) {
+	// BIP380 invalid checksums
+	for _, text := range []string{
+		"raw(deadbeef)#",
+		"raw(deadbeef)#89f8spxmx",
+		"raw(deadbeef)#89f8spx",
+		"raw(deedbeef)#89f8spxm",
+		"raw(deadbeef)##9f8spxm",
+		"raw(deadbeef)#89f8spxm#",
+	} {
+		if _, err := splitChecksum(text); !errors.Is(err, ErrInvalidChecksum) {
+			t.Errorf("splitChecksum(%s) error = %v, want %v", text, err, ErrInvalidChecksum)
+		}
+	}
+
+	if _, err := Checksum("raw(Ü)"); !errors.Is(err, ErrInvalidDescriptor) {
+		t.Errorf("Checksum with a non-charset character error = %v, want %v", err, ErrInvalidDescriptor)
+	}
+}
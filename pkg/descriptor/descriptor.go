@@ -0,0 +1,331 @@
+// Package descriptor parses and serializes output script descriptors
+// (BIP380-386), the text format Bitcoin Core, Sparrow and Specter use to
+// describe which scripts a wallet watches, for example
+//
+//	wpkh([d34db33f/84'/0'/0']xpub.../0/*)#checksum
+//
+// Supported are pkh(), wpkh(), sh(wpkh()), sh(multi()), wsh(multi()),
+// wsh(sortedmulti()), sh(wsh(...)), key-path-only tr() and addr(), with key origins, ranged
+// derivation, <a;b> multipath steps (BIP389) and the descriptor checksum.
+//
+// Package descriptor mem-parsing dan menserialisasi output script descriptor (BIP380-386).
+package descriptor
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dhfai/go-wallet/pkg/chaincfg"
+	"github.com/dhfai/go-wallet/pkg/crypto"
+	"github.com/dhfai/go-wallet/pkg/txbuilder"
+)
+
+var (
+	ErrInvalidDescriptor = errors.New("descriptor: invalid descriptor")
+	ErrInvalidChecksum   = errors.New("descriptor: invalid checksum")
+	ErrUnsupported       = errors.New("descriptor: unsupported descriptor")
+)
+
+// Type identifies the script template a descriptor expands to
+// Type mengidentifikasi template script dari sebuah descriptor
+type Type string
+
+const (
+	TypePKH    Type = "pkh"      // pkh(KEY)
+	TypeWPKH   Type = "wpkh"     // wpkh(KEY)
+	TypeSHWPKH Type = "sh(wpkh)" // sh(wpkh(KEY))
+	TypeSH     Type = "sh"       // sh(multi(...)) or sh(sortedmulti(...))
+	TypeWSH    Type = "wsh"      // wsh(multi(...)) or wsh(sortedmulti(...))
+	TypeSHWSH  Type = "sh(wsh)"  // sh(wsh(multi(...))) or sh(wsh(sortedmulti(...)))
+	TypeTR     Type = "tr"       // tr(KEY), key path only
+	TypeAddr   Type = "addr"     // addr(ADDRESS)
+)
+
+// Descriptor is a parsed output script descriptor
+// Descriptor adalah output script descriptor yang sudah di-parse
+type Descriptor struct {
+	Type      Type
+	Keys      []Key  // One key, or the multisig keys in script order
+	Threshold int    // Required signatures, multisig only
+	Sorted    bool   // sortedmulti() rather than multi()
+	Address   string // addr() only
+
+	params *chaincfg.Params
+}
+
+// Parse parses a descriptor for params. A trailing '#checksum' is optional
+// but verified when present.
+// Parse mem-parsing descriptor untuk jaringan params
+func Parse(text string, params *chaincfg.Params) (*Descriptor, error) {
+	body, err := splitChecksum(strings.TrimSpace(text))
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{bc: crypto.NewBitcoinCryptoForNetwork(params), params: params}
+	desc, err := p.parseTop(body)
+	if err != nil {
+		return nil, err
+	}
+	desc.params = params
+
+	if err := desc.checkMultipath(); err != nil {
+		return nil, err
+	}
+	return desc, nil
+}
+
+// IsMultisig reports whether the descriptor is a multi() or sortedmulti() descriptor
+func (d *Descriptor) IsMultisig() bool {
+	return d.Type == TypeSH || d.Type == TypeWSH || d.Type == TypeSHWSH
+}
+
+// IsRange reports whether the descriptor expands to one script per index
+func (d *Descriptor) IsRange() bool {
+	for _, key := range d.Keys {
+		if key.Wildcard != WildcardNone {
+			return true
+		}
+	}
+	return false
+}
+
+// IsMultipath reports whether the descriptor contains <a;b> steps
+func (d *Descriptor) IsMultipath() bool {
+	for _, key := range d.Keys {
+		if len(key.Multipath) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPrivateKeys reports whether any key is a WIF or extended private key
+func (d *Descriptor) HasPrivateKeys() bool {
+	for _, key := range d.Keys {
+		if key.IsPrivate() {
+			return true
+		}
+	}
+	return false
+}
+
+// Expand splits a multipath descriptor into one descriptor per alternative,
+// e.g. .../<0;1>/* into the receive .../0/* and change .../1/* descriptors.
+// Descriptors without multipath steps are returned unchanged.
+// Expand memecah descriptor multipath menjadi satu descriptor per alternatif
+func (d *Descriptor) Expand() []*Descriptor {
+	count := 0
+	for _, key := range d.Keys {
+		if len(key.Multipath) > count {
+			count = len(key.Multipath)
+		}
+	}
+	if count == 0 {
+		return []*Descriptor{d}
+	}
+
+	expanded := make([]*Descriptor, count)
+	for i := range expanded {
+		single := *d
+		single.Keys = make([]Key, len(d.Keys))
+		for j, key := range d.Keys {
+			if len(key.Multipath) > 0 {
+				key.Path = append(append([]uint32(nil), key.Path...), key.Multipath[i])
+				key.Multipath = nil
+			}
+			single.Keys[j] = key
+		}
+		expanded[i] = &single
+	}
+	return expanded
+}
+
+// checkMultipath requires all multipath steps of a descriptor to have the same number of alternatives
+func (d *Descriptor) checkMultipath() error {
+	count := 0
+	for _, key := range d.Keys {
+		if len(key.Multipath) == 0 {
+			continue
+		}
+		if count != 0 && len(key.Multipath) != count {
+			return fmt.Errorf("%w: multipath steps have different lengths", ErrInvalidDescriptor)
+		}
+		count = len(key.Multipath)
+	}
+	return nil
+}
+
+// String returns the descriptor with its checksum
+// String mengembalikan descriptor beserta checksum-nya
+func (d *Descriptor) String() string {
+	desc, err := AddChecksum(d.body())
+	if err != nil {
+		// Keys and addresses only ever serialize to charset characters
+		return d.body()
+	}
+	return desc
+}
+
+// body returns the descriptor without its checksum
+func (d *Descriptor) body() string {
+	switch d.Type {
+	case TypePKH, TypeWPKH, TypeTR:
+		return string(d.Type) + "(" + d.Keys[0].String() + ")"
+	case TypeSHWPKH:
+		return "sh(wpkh(" + d.Keys[0].String() + "))"
+	case TypeSH:
+		return "sh(" + d.multiBody() + ")"
+	case TypeWSH:
+		return "wsh(" + d.multiBody() + ")"
+	case TypeSHWSH:
+		return "sh(wsh(" + d.multiBody() + "))"
+	case TypeAddr:
+		return "addr(" + d.Address + ")"
+	default:
+		return ""
+	}
+}
+
+// multiBody returns the multi(...) or sortedmulti(...) fragment
+func (d *Descriptor) multiBody() string {
+	name := "multi"
+	if d.Sorted {
+		name = "sortedmulti"
+	}
+
+	parts := []string{strconv.Itoa(d.Threshold)}
+	for _, key := range d.Keys {
+		parts = append(parts, key.String())
+	}
+	return name + "(" + strings.Join(parts, ",") + ")"
+}
+
+// parser holds what descriptor parsing needs to validate keys and addresses
+type parser struct {
+	bc     *crypto.BitcoinCrypto
+	params *chaincfg.Params
+}
+
+// parseTop parses a top-level descriptor expression
+func (p *parser) parseTop(s string) (*Descriptor, error) {
+	name, args, err := splitCall(s)
+	if err != nil {
+		return nil, err
+	}
+
+	switch name {
+	case "pkh":
+		key, err := p.parseKey(args, contextLegacy)
+		if err != nil {
+			return nil, err
+		}
+		return &Descriptor{Type: TypePKH, Keys: []Key{key}}, nil
+
+	case "wpkh":
+		key, err := p.parseKey(args, contextSegwit)
+		if err != nil {
+			return nil, err
+		}
+		return &Descriptor{Type: TypeWPKH, Keys: []Key{key}}, nil
+
+	case "tr":
+		if strings.Contains(args, ",") {
+			return nil, fmt.Errorf("%w: tr() script trees are not supported", ErrUnsupported)
+		}
+		key, err := p.parseKey(args, contextTaproot)
+		if err != nil {
+			return nil, err
+		}
+		return &Descriptor{Type: TypeTR, Keys: []Key{key}}, nil
+
+	case "sh":
+		inner, innerArgs, err := splitCall(args)
+		if err != nil {
+			return nil, err
+		}
+		switch inner {
+		case "wpkh":
+			key, err := p.parseKey(innerArgs, contextSegwit)
+			if err != nil {
+				return nil, err
+			}
+			return &Descriptor{Type: TypeSHWPKH, Keys: []Key{key}}, nil
+		case "multi", "sortedmulti":
+			desc, err := p.parseMulti("sh", args, contextLegacy)
+			if err != nil {
+				return nil, err
+			}
+			desc.Type = TypeSH
+			return desc, nil
+		case "wsh":
+			desc, err := p.parseMulti("wsh", innerArgs, contextSegwit)
+			if err != nil {
+				return nil, err
+			}
+			desc.Type = TypeSHWSH
+			return desc, nil
+		default:
+			return nil, fmt.Errorf("%w: sh(%s())", ErrUnsupported, inner)
+		}
+
+	case "wsh":
+		desc, err := p.parseMulti("wsh", args, contextSegwit)
+		if err != nil {
+			return nil, err
+		}
+		desc.Type = TypeWSH
+		return desc, nil
+
+	case "addr":
+		if _, err := txbuilder.PayToAddrScript(args, p.params); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidDescriptor, err)
+		}
+		return &Descriptor{Type: TypeAddr, Address: args}, nil
+
+	default:
+		return nil, fmt.Errorf("%w: %s()", ErrUnsupported, name)
+	}
+}
+
+// parseMulti parses multi(k,KEY,...) or sortedmulti(k,KEY,...) inside the wrapper script
+func (p *parser) parseMulti(wrapper, s string, ctx keyContext) (*Descriptor, error) {
+	name, args, err := splitCall(s)
+	if err != nil {
+		return nil, err
+	}
+	if name != "multi" && name != "sortedmulti" {
+		return nil, fmt.Errorf("%w: %s(%s())", ErrUnsupported, wrapper, name)
+	}
+
+	parts := strings.Split(args, ",")
+	threshold, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid multisig threshold %q", ErrInvalidDescriptor, parts[0])
+	}
+	keys := parts[1:]
+	if len(keys) == 0 || len(keys) > txbuilder.MaxMultisigKeys || threshold < 1 || threshold > len(keys) {
+		return nil, fmt.Errorf("%w: invalid multisig policy %d of %d", ErrInvalidDescriptor, threshold, len(keys))
+	}
+
+	desc := &Descriptor{Threshold: threshold, Sorted: name == "sortedmulti"}
+	for _, part := range keys {
+		key, err := p.parseKey(part, ctx)
+		if err != nil {
+			return nil, err
+		}
+		desc.Keys = append(desc.Keys, key)
+	}
+	return desc, nil
+}
+
+// splitCall splits "name(args)" into its name and argument text
+func splitCall(s string) (string, string, error) {
+	open := strings.IndexByte(s, '(')
+	if open <= 0 || !strings.HasSuffix(s, ")") {
+		return "", "", fmt.Errorf("%w: expected name(...) in %q", ErrInvalidDescriptor, s)
+	}
+	return s[:open], s[open+1 : len(s)-1], nil
+}
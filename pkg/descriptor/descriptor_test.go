@@ -0,0 +1,137 @@
+package descriptor
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/dhfai/go-wallet/pkg/chaincfg"
+	"github.com/dhfai/go-wallet/pkg/crypto"
+	"github.com/dhfai/go-wallet/pkg/txbuilder"
+)
+
+// Master keys of the "abandon ... about" BIP39 test mnemonic
+const (
+	abandonXprv = "xprv9s21ZrQH143K3GJpoapnV8SFfukcVBSfeCficPSGfubmSFDxo1kuHnLisriDvSnRRuL2Qrg5ggqHKNVpxR86QEC8w35uxmGoggxtQTPvfUu"
+	abandonTprv = "tprv8ZgxMBicQKsPe5YMU9gHen4Ez3ApihUfykaqUorj9t6FDqy3nP6eoXiAo2ssvpAjoLroQxHqr3R5nE3a5dU3DHTjTgJDd7zrbniJr6nrCzd"
+)
+
+// Key 1, whose public key is the generator point, compressed, uncompressed and as WIF
+const (
+	keyOnePub             = "0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"
+	keyOneUncompressed    = "0479be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798483ada7726a3c4655da4fbfc0e1108a8fd17b448a68554199c47d08ffb10d4b8"
+	keyOneUncompressedWIF = "5HpHagT65TZzG1PH3CSu63k8DbpvD8s5ip4nEB3kEsreAnchuDf"
+)
+
+func TestAddressRoundTrip(t *testing.T) {
+	mainnet, testnet := &chaincfg.MainNetParams, &chaincfg.TestNet3Params
+
+	for _, tc := range []struct {
+		desc    string
+		params  *chaincfg.Params
+		script  string // Expected output script, empty when only the address is known
+		address string // Expected address, empty when only the script is known
+	}{
+		// BIP44, BIP84, BIP49 and BIP86 first receive addresses
+		{"pkh(" + abandonXprv + "/44'/0'/0'/0/*)", mainnet, "", "1LqBGSKuX5yYUonjxT5qGfpUsXKYYWeabA"},
+		{"wpkh(" + abandonXprv + "/84'/0'/0'/0/*)", mainnet, "", "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu"},
+		{"sh(wpkh(" + abandonTprv + "/49'/1'/0'/0/*))", testnet, "", "2Mww8dCYPUpKHofjgcXcBCEGmniw9CoaiD2"},
+		{"tr(" + abandonXprv + "/86'/0'/0'/0/*)", mainnet, "", "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr"},
+
+		// Single keys
+		{"pkh(" + keyOnePub + ")", mainnet, "76a914751e76e8199196d454941c45d1b3a323f1433bd688ac", "1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH"},
+		{"wpkh(" + keyOnePub + ")", mainnet, "0014751e76e8199196d454941c45d1b3a323f1433bd6", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"},
+		{"sh(wpkh(" + keyOnePub + "))", mainnet, "", "3JvL6Ymt8MVWiCNHC7oWU6nLeHNJKLZGLN"},
+		{"pkh(" + keyOneUncompressed + ")", mainnet, "", "1EHNa6Q4Jz2uvNExL497mE43ikXhwF6kZm"},
+		{"pkh(" + keyOneUncompressedWIF + ")", mainnet, "", "1EHNa6Q4Jz2uvNExL497mE43ikXhwF6kZm"},
+
+		// BIP174 multisig inputs
+		{"sh(multi(2,029583bf39ae0a609747ad199addd634fa6108559d6c5cd39b4c2183f1ab96e07f,02dab61ff49a14db6a7d02b0cd1fbb78fc4b18312b5b4e54dae4dba2fbfef536d7))", testnet, "a9140fb9463421696b82c833af241c78c17ddbde493487", ""},
+		{"wsh(multi(2,03089dc10c7ac6db54f91329af617333db388cead0c231f723379d1b99030b02dc,023add904f3d6dcf59ddb906b0dee23529b7ffb9ed50e5e86151926860221f0e73))", testnet, "00208c2353173743b595dfb4a07b72ba8e42e3797da74e87fe7d9d7497e3b2028903", ""},
+		{"sh(wsh(multi(2,03089dc10c7ac6db54f91329af617333db388cead0c231f723379d1b99030b02dc,023add904f3d6dcf59ddb906b0dee23529b7ffb9ed50e5e86151926860221f0e73)))", testnet, "a914b7f5faf40e3d40a5a459b1db3535f2b72fa921e887", ""},
+	} {
+		desc, err := Parse(tc.desc, tc.params)
+		if err != nil {
+			t.Errorf("Parse(%s): %v", tc.desc, err)
+			continue
+		}
+
+		script, err := desc.ScriptAt(0)
+		if err != nil {
+			t.Errorf("%s: ScriptAt: %v", tc.desc, err)
+			continue
+		}
+		if tc.script != "" && hex.EncodeToString(script) != tc.script {
+			t.Errorf("%s: script = %x, want %s", tc.desc, script, tc.script)
+		}
+
+		address, err := desc.AddressAt(0)
+		if err != nil {
+			t.Errorf("%s: AddressAt: %v", tc.desc, err)
+			continue
+		}
+		if tc.address != "" && address != tc.address {
+			t.Errorf("%s: address = %s, want %s", tc.desc, address, tc.address)
+		}
+		if decoded, err := txbuilder.PayToAddrScript(address, tc.params); err != nil || !bytes.Equal(decoded, script) {
+			t.Errorf("%s: PayToAddrScript(%s) = %x, %v, want %x", tc.desc, address, decoded, err, script)
+		}
+
+		// The serialized descriptor parses back to the same descriptor and address
+		reparsed, err := Parse(desc.String(), tc.params)
+		if err != nil {
+			t.Errorf("Parse(%s): %v", desc.String(), err)
+			continue
+		}
+		if reparsed.String() != desc.String() {
+			t.Errorf("reparsed descriptor = %s, want %s", reparsed.String(), desc.String())
+		}
+		if again, err := reparsed.AddressAt(0); err != nil || again != address {
+			t.Errorf("%s: reparsed address = %s, %v, want %s", tc.desc, again, err, address)
+		}
+	}
+}
+
+func TestUncompressedKeys(t *testing.T) {
+	params := &chaincfg.MainNetParams
+
+	for _, key := range []string{keyOneUncompressed, keyOneUncompressedWIF} {
+		for _, text := range []string{
+			"pkh(" + key + ")",
+			"sh(multi(1," + key + "," + keyOnePub + "))",
+			"sh(sortedmulti(1," + key + "," + keyOnePub + "))",
+		} {
+			if _, err := Parse(text, params); err != nil {
+				t.Errorf("Parse(%s): %v", text, err)
+			}
+		}
+
+		for _, text := range []string{
+			"wpkh(" + key + ")",
+			"sh(wpkh(" + key + "))",
+			"wsh(multi(1," + key + "," + keyOnePub + "))",
+			"sh(wsh(multi(1," + key + "," + keyOnePub + ")))",
+			"tr(" + key + ")",
+		} {
+			if _, err := Parse(text, params); !errors.Is(err, ErrInvalidDescriptor) {
+				t.Errorf("Parse(%s) error = %v, want %v", text, err, ErrInvalidDescriptor)
+			}
+		}
+	}
+
+	// sh(multi()) hashes the 65-byte key into the redeem script
+	desc, err := Parse("sh(multi(1,"+keyOneUncompressed+"))", params)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	script, err := desc.ScriptAt(0)
+	if err != nil {
+		t.Fatalf("ScriptAt: %v", err)
+	}
+	uncompressed, _ := hex.DecodeString(keyOneUncompressed)
+	redeemScript := append(append([]byte{txbuilder.OpOne, 65}, uncompressed...), txbuilder.OpOne, txbuilder.OpCheckMultiSig)
+	if want := txbuilder.P2SHScript(crypto.NewBitcoinCrypto().Hash160(redeemScript)); !bytes.Equal(script, want) {
+		t.Errorf("script = %x, want %x", script, want)
+	}
+}
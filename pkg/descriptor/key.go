@@ -0,0 +1,304 @@
+package descriptor
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dhfai/go-wallet/pkg/crypto"
+)
+
+// Wildcard is the kind of trailing /* step of a ranged key
+type Wildcard int
+
+const (
+	WildcardNone       Wildcard = iota // Not ranged
+	WildcardUnhardened                 // /*
+	WildcardHardened                   // /*'
+)
+
+// KeyOrigin records where a key sits below a master key: [fingerprint/path]
+// KeyOrigin mencatat posisi kunci di bawah master key
+type KeyOrigin struct {
+	Fingerprint [4]byte
+	Path        []uint32
+}
+
+// Key is a descriptor key expression
+// Key adalah ekspresi kunci di dalam descriptor
+type Key struct {
+	Origin    *KeyOrigin          // Nil when the key has no [origin]
+	Encoded   string              // Hex public key, WIF or Base58 extended key as written
+	PubKey    []byte              // Public key of a single key as written, nil for extended keys
+	PrivKey   string              // Hex private scalar of a WIF key
+	Extended  *crypto.ExtendedKey // Extended key, nil for single keys
+	Path      []uint32            // Derivation steps below Extended
+	Multipath []uint32            // <a;b;...> alternatives for the step after Path (BIP389)
+	Wildcard  Wildcard            // Trailing /* step
+}
+
+// IsPrivate reports whether the key carries private key material
+func (k Key) IsPrivate() bool {
+	return k.PrivKey != "" || (k.Extended != nil && k.Extended.IsPrivate())
+}
+
+// PubKeyAt returns the public key at index; index is ignored for unranged keys.
+// Derived keys are always compressed.
+// PubKeyAt mengembalikan public key pada indeks tertentu
+func (k Key) PubKeyAt(index uint32) ([]byte, error) {
+	if k.Extended == nil {
+		return k.PubKey, nil
+	}
+	if len(k.Multipath) > 0 {
+		return nil, fmt.Errorf("%w: expand multipath descriptors before deriving", ErrInvalidDescriptor)
+	}
+
+	path := append([]uint32(nil), k.Path...)
+	switch k.Wildcard {
+	case WildcardUnhardened:
+		path = append(path, index)
+	case WildcardHardened:
+		path = append(path, index+crypto.HardenedKeyStart)
+	}
+
+	child, err := k.Extended.DerivePath(path...)
+	if err != nil {
+		return nil, err
+	}
+	return child.PublicKeyBytes(), nil
+}
+
+// String renders the key expression as it appears in a descriptor
+func (k Key) String() string {
+	var sb strings.Builder
+	if k.Origin != nil {
+		sb.WriteString("[")
+		sb.WriteString(hex.EncodeToString(k.Origin.Fingerprint[:]))
+		sb.WriteString(strings.TrimPrefix(crypto.FormatDerivationPath(k.Origin.Path), "m"))
+		sb.WriteString("]")
+	}
+	sb.WriteString(k.Encoded)
+	sb.WriteString(strings.TrimPrefix(crypto.FormatDerivationPath(k.Path), "m"))
+
+	if len(k.Multipath) > 0 {
+		steps := make([]string, len(k.Multipath))
+		for i, index := range k.Multipath {
+			steps[i] = strings.TrimPrefix(crypto.FormatDerivationPath([]uint32{index}), "m/")
+		}
+		sb.WriteString("/<" + strings.Join(steps, ";") + ">")
+	}
+
+	switch k.Wildcard {
+	case WildcardUnhardened:
+		sb.WriteString("/*")
+	case WildcardHardened:
+		sb.WriteString("/*'")
+	}
+	return sb.String()
+}
+
+// keyContext is the script a KEY expression appears in, which decides the key forms it may take
+type keyContext int
+
+const (
+	contextLegacy  keyContext = iota // pkh() and sh(multi()): compressed or uncompressed keys
+	contextSegwit                    // wpkh() and wsh(): compressed keys only
+	contextTaproot                   // tr(): compressed or x-only keys
+)
+
+// parseKey parses a KEY expression appearing in ctx
+func (p *parser) parseKey(s string, ctx keyContext) (Key, error) {
+	var key Key
+
+	if strings.HasPrefix(s, "[") {
+		end := strings.IndexByte(s, ']')
+		if end < 0 {
+			return key, fmt.Errorf("%w: unterminated key origin in %q", ErrInvalidDescriptor, s)
+		}
+		origin, err := parseOrigin(s[1:end])
+		if err != nil {
+			return key, err
+		}
+		key.Origin = origin
+		s = s[end+1:]
+	}
+
+	steps := strings.Split(s, "/")
+	key.Encoded = steps[0]
+	if err := p.parseKeyMaterial(&key, ctx); err != nil {
+		return key, err
+	}
+
+	steps = steps[1:]
+	if len(steps) > 0 && key.Extended == nil {
+		return key, fmt.Errorf("%w: only extended keys can have derivation steps", ErrInvalidDescriptor)
+	}
+	for i, step := range steps {
+		last := i == len(steps)-1
+		switch {
+		case step == "*":
+			if !last {
+				return key, fmt.Errorf("%w: /* must be the last step", ErrInvalidDescriptor)
+			}
+			key.Wildcard = WildcardUnhardened
+		case step == "*'" || step == "*h" || step == "*H":
+			if !last {
+				return key, fmt.Errorf("%w: /* must be the last step", ErrInvalidDescriptor)
+			}
+			key.Wildcard = WildcardHardened
+		case strings.HasPrefix(step, "<") && strings.HasSuffix(step, ">"):
+			if key.Multipath != nil {
+				return key, fmt.Errorf("%w: only one <a;b> step is allowed per key", ErrInvalidDescriptor)
+			}
+			if !last && !(i == len(steps)-2 && strings.HasPrefix(steps[i+1], "*")) {
+				return key, fmt.Errorf("%w: <a;b> must be the last step before /*", ErrInvalidDescriptor)
+			}
+			multipath, err := parseMultipath(step[1 : len(step)-1])
+			if err != nil {
+				return key, err
+			}
+			key.Multipath = multipath
+		default:
+			index, err := parseStep(step)
+			if err != nil {
+				return key, err
+			}
+			key.Path = append(key.Path, index)
+		}
+	}
+
+	if key.Extended != nil && !key.Extended.IsPrivate() && (key.Wildcard == WildcardHardened || hasHardened(key.Path) || hasHardened(key.Multipath)) {
+		return key, fmt.Errorf("%w: hardened derivation needs an extended private key", ErrInvalidDescriptor)
+	}
+	return key, nil
+}
+
+// parseKeyMaterial decodes a hex public key, WIF or extended key into key
+func (p *parser) parseKeyMaterial(key *Key, ctx keyContext) error {
+	encoded := key.Encoded
+
+	if raw, err := hex.DecodeString(encoded); err == nil {
+		switch {
+		case len(raw) == 33 && (raw[0] == 0x02 || raw[0] == 0x03):
+		case len(raw) == 32 && ctx == contextTaproot:
+			// An x-only key stands for the point with an even Y coordinate
+			raw = append([]byte{0x02}, raw...)
+		case len(raw) == 65 && raw[0] == 0x04:
+			if ctx != contextLegacy {
+				return fmt.Errorf("%w: uncompressed keys are not allowed in segwit or taproot descriptors", ErrInvalidDescriptor)
+			}
+		default:
+			return fmt.Errorf("%w: invalid public key %s", ErrInvalidDescriptor, encoded)
+		}
+		if _, err := p.bc.ParsePublicKey(hex.EncodeToString(raw)); err != nil {
+			return fmt.Errorf("%w: invalid public key %s: %v", ErrInvalidDescriptor, encoded, err)
+		}
+		key.PubKey = raw
+		return nil
+	}
+
+	// Serialized extended keys are 111 Base58 characters; WIF keys are 51 or 52
+	if len(encoded) > 100 {
+		extended, err := p.bc.ParseExtendedKey(encoded)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidDescriptor, err)
+		}
+		if !extended.IsForNetwork(p.params) {
+			return fmt.Errorf("%w: extended key is not for %s", ErrInvalidDescriptor, p.params.Name)
+		}
+		key.Extended = extended
+		return nil
+	}
+
+	privKeyHex, compressed, err := p.bc.DecodeWIF(encoded)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidDescriptor, err)
+	}
+	if !compressed && ctx != contextLegacy {
+		return fmt.Errorf("%w: uncompressed keys are not allowed in segwit or taproot descriptors", ErrInvalidDescriptor)
+	}
+	privKey, err := p.bc.PrivateKeyFromHex(privKeyHex)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidDescriptor, err)
+	}
+	key.PrivKey = privKeyHex
+	key.PubKey = p.bc.SerializeCompressed(&privKey.PublicKey)
+	if !compressed {
+		key.PubKey = p.bc.SerializeUncompressed(&privKey.PublicKey)
+	}
+	return nil
+}
+
+// parseOrigin parses the fingerprint/path text inside [...]
+func parseOrigin(s string) (*KeyOrigin, error) {
+	fp, path, _ := strings.Cut(s, "/")
+	raw, err := hex.DecodeString(fp)
+	if err != nil || len(raw) != 4 {
+		return nil, fmt.Errorf("%w: invalid key origin fingerprint %q", ErrInvalidDescriptor, fp)
+	}
+
+	origin := &KeyOrigin{}
+	copy(origin.Fingerprint[:], raw)
+	if path != "" {
+		for _, step := range strings.Split(path, "/") {
+			index, err := parseStep(step)
+			if err != nil {
+				return nil, err
+			}
+			origin.Path = append(origin.Path, index)
+		}
+	}
+	return origin, nil
+}
+
+// parseMultipath parses the a;b;... alternatives of a multipath step
+func parseMultipath(s string) ([]uint32, error) {
+	parts := strings.Split(s, ";")
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("%w: <a;b> needs at least two alternatives", ErrInvalidDescriptor)
+	}
+
+	indices := make([]uint32, 0, len(parts))
+	seen := make(map[uint32]bool, len(parts))
+	for _, part := range parts {
+		index, err := parseStep(part)
+		if err != nil {
+			return nil, err
+		}
+		if seen[index] {
+			return nil, fmt.Errorf("%w: duplicate multipath alternative %s", ErrInvalidDescriptor, part)
+		}
+		seen[index] = true
+		indices = append(indices, index)
+	}
+	return indices, nil
+}
+
+// parseStep parses one derivation step; hardened steps end in ' or h
+func parseStep(step string) (uint32, error) {
+	hardened := false
+	if strings.HasSuffix(step, "'") || strings.HasSuffix(step, "h") || strings.HasSuffix(step, "H") {
+		hardened = true
+		step = step[:len(step)-1]
+	}
+
+	value, err := strconv.ParseUint(step, 10, 32)
+	if err != nil || value >= uint64(crypto.HardenedKeyStart) {
+		return 0, fmt.Errorf("%w: invalid derivation step %q", ErrInvalidDescriptor, step)
+	}
+	if hardened {
+		return uint32(value) + crypto.HardenedKeyStart, nil
+	}
+	return uint32(value), nil
+}
+
+// hasHardened reports whether any index is a hardened step
+func hasHardened(indices []uint32) bool {
+	for _, index := range indices {
+		if index >= crypto.HardenedKeyStart {
+			return true
+		}
+	}
+	return false
+}
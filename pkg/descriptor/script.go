@@ -0,0 +1,91 @@
+package descriptor
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/dhfai/go-wallet/pkg/crypto"
+	"github.com/dhfai/go-wallet/pkg/txbuilder"
+)
+
+// maxRedeemScriptSize is the largest script a P2SH input can push (520 bytes)
+const maxRedeemScriptSize = 520
+
+// ScriptAt returns the output script the descriptor expands to at index.
+// Unranged descriptors ignore index; multipath descriptors must be expanded first.
+// ScriptAt mengembalikan script output descriptor pada indeks tertentu
+func (d *Descriptor) ScriptAt(index uint32) ([]byte, error) {
+	if d.Type == TypeAddr {
+		return txbuilder.PayToAddrScript(d.Address, d.params)
+	}
+
+	pubKeys := make([][]byte, len(d.Keys))
+	for i, key := range d.Keys {
+		pubKey, err := key.PubKeyAt(index)
+		if err != nil {
+			return nil, err
+		}
+		pubKeys[i] = pubKey
+	}
+
+	bc := crypto.NewBitcoinCryptoForNetwork(d.params)
+	switch d.Type {
+	case TypePKH:
+		return txbuilder.P2PKHScript(bc.Hash160(pubKeys[0])), nil
+	case TypeWPKH:
+		return txbuilder.WitnessScript(0, bc.Hash160(pubKeys[0])), nil
+	case TypeSHWPKH:
+		redeemScript := bc.NestedSegWitRedeemScript(bc.Hash160(pubKeys[0]))
+		return txbuilder.P2SHScript(bc.Hash160(redeemScript)), nil
+	case TypeTR:
+		outputKey, err := bc.TaprootOutputKey(pubKeys[0])
+		if err != nil {
+			return nil, err
+		}
+		return txbuilder.WitnessScript(1, outputKey), nil
+	case TypeSH:
+		redeemScript, err := d.multisigScript(pubKeys)
+		if err != nil {
+			return nil, err
+		}
+		if len(redeemScript) > maxRedeemScriptSize {
+			return nil, fmt.Errorf("%w: redeem script is %d bytes, more than %d", ErrInvalidDescriptor, len(redeemScript), maxRedeemScriptSize)
+		}
+		return txbuilder.P2SHScript(bc.Hash160(redeemScript)), nil
+	case TypeWSH, TypeSHWSH:
+		witnessScript, err := d.multisigScript(pubKeys)
+		if err != nil {
+			return nil, err
+		}
+		if d.Type == TypeWSH {
+			return txbuilder.P2WSHScript(witnessScript), nil
+		}
+		hash := sha256.Sum256(witnessScript)
+		redeemScript := txbuilder.WitnessScript(0, hash[:])
+		return txbuilder.P2SHScript(bc.Hash160(redeemScript)), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupported, d.Type)
+	}
+}
+
+// AddressAt returns the address of the output script at index
+// AddressAt mengembalikan alamat dari script output pada indeks tertentu
+func (d *Descriptor) AddressAt(index uint32) (string, error) {
+	if d.Type == TypeAddr {
+		return d.Address, nil
+	}
+
+	script, err := d.ScriptAt(index)
+	if err != nil {
+		return "", err
+	}
+	return txbuilder.ScriptAddress(script, d.params)
+}
+
+// multisigScript builds the multi() or sortedmulti() witness script from derived keys
+func (d *Descriptor) multisigScript(pubKeys [][]byte) ([]byte, error) {
+	if d.Sorted {
+		return txbuilder.MultisigScript(d.Threshold, pubKeys)
+	}
+	return txbuilder.UnsortedMultisigScript(d.Threshold, pubKeys)
+}
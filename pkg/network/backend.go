@@ -0,0 +1,52 @@
+package network
+
+import "context"
+
+// ChainBackend is a source of blockchain data for wallet addresses. The
+// Esplora BlockchainExplorer, the bitcoind BitcoindClient and the Electrum
+// ElectrumClient implement it. Every method gives up when ctx is cancelled.
+// ChainBackend adalah sumber data blockchain untuk alamat wallet
+type ChainBackend interface {
+	// GetAddressInfo returns the confirmed and mempool statistics of an address
+	GetAddressInfo(ctx context.Context, address string) (*AddressInfo, error)
+	// GetBalance returns the confirmed plus unconfirmed balance of an address in satoshis
+	GetBalance(ctx context.Context, address string) (int64, error)
+	// GetUTXOs returns the unspent outputs paying to an address
+	GetUTXOs(ctx context.Context, address string) ([]UTXOInfo, error)
+	// GetTransactionHistory returns every transaction touching an address, newest first
+	GetTransactionHistory(ctx context.Context, address string) ([]TxInfo, error)
+	// GetTransaction returns a transaction with its prevouts and status; ErrNotFound if unknown,
+	// or ErrNotIndexed if the backend cannot tell an unknown transaction from an unindexed one
+	GetTransaction(ctx context.Context, txID string) (*TxInfo, error)
+	// GetRawTransaction returns the serialized transaction in hex
+	GetRawTransaction(ctx context.Context, txID string) (string, error)
+	// GetOutspend returns whether an output is spent and, when known, by which transaction
+	GetOutspend(ctx context.Context, txID string, vout uint32) (*Outspend, error)
+	// BroadcastTransaction submits a signed transaction and returns its txid
+	BroadcastTransaction(ctx context.Context, txHex string) (string, error)
+	// GetFeeEstimates returns fee rates in sat/vB keyed by confirmation target
+	GetFeeEstimates(ctx context.Context) (FeeEstimates, error)
+	// GetTipHeight returns the height of the last block of the best chain
+	GetTipHeight(ctx context.Context) (int64, error)
+}
+
+// DescriptorImport is an output descriptor a backend should start watching
+type DescriptorImport struct {
+	Descriptor string // Descriptor with checksum
+	RangeEnd   uint32 // Last index to watch of a ranged descriptor
+	Timestamp  int64  // Unix time to rescan from; 0 rescans the whole chain
+}
+
+// DescriptorImporter is implemented by backends that only see addresses they
+// were told to watch beforehand, such as a bitcoind wallet
+// DescriptorImporter diimplementasikan backend yang perlu mengimpor descriptor terlebih dulu
+type DescriptorImporter interface {
+	ImportDescriptors(ctx context.Context, imports []DescriptorImport) error
+}
+
+var (
+	_ ChainBackend       = (*BlockchainExplorer)(nil)
+	_ ChainBackend       = (*BitcoindClient)(nil)
+	_ ChainBackend       = (*ElectrumClient)(nil)
+	_ DescriptorImporter = (*BitcoindClient)(nil)
+)
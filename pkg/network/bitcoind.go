@@ -0,0 +1,909 @@
+package network
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dhfai/go-wallet/pkg/chaincfg"
+)
+
+// bitcoind JSON-RPC error codes
+const (
+	rpcInvalidAddressOrKey = -5  // Unknown transaction, block or address
+	rpcWalletNotFound      = -18 // Wallet is not loaded or does not exist
+	rpcWalletAlreadyLoaded = -35 // Wallet is already loaded
+)
+
+// BitcoindConfig holds the connection settings of a bitcoind JSON-RPC server
+// BitcoindConfig menyimpan pengaturan koneksi JSON-RPC bitcoind
+type BitcoindConfig struct {
+	URL        string // Server URL; empty selects 127.0.0.1 and the network's RPC port
+	User       string // rpcuser; when empty the cookie file is used
+	Password   string // rpcpassword
+	CookieFile string // Path of bitcoind's .cookie file; empty selects the default data directory
+	Wallet     string // Watch-only descriptor wallet used to follow addresses; empty scans the UTXO set instead
+
+	Transport TransportConfig // Proxy, TLS roots and timeout; the default timeout is 5 minutes
+}
+
+// RPCError is an error returned by bitcoind
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("bitcoind error %d: %s", e.Code, e.Message)
+}
+
+// BitcoindClient is a ChainBackend talking to a local bitcoind over JSON-RPC.
+// With a wallet name it imports the addresses it is asked about into a
+// watch-only descriptor wallet and reads history from it; without one it
+// looks up unspent outputs with scantxoutset and only sees the transactions
+// that created them.
+// BitcoindClient adalah ChainBackend yang terhubung ke bitcoind lewat JSON-RPC
+type BitcoindClient struct {
+	url        string
+	user       string
+	password   string
+	cookieFile string
+	wallet     string
+	client     *http.Client
+	nextID     int
+
+	walletReady bool
+	txIndexed   bool               // -txindex is enabled and synced; checked until it is
+	watched     map[string]bool    // Addresses known to be watched by the wallet
+	txCache     map[string]*TxInfo // Decoded transactions keyed by txid and block hash
+	scans       map[string]scanResult
+}
+
+// scanResult is a cached scantxoutset result, valid while the tip stays at Height
+type scanResult struct {
+	Height   int64
+	Unspents []scannedOutput
+}
+
+// NewBitcoindClient creates a client for the bitcoind of the given network
+// NewBitcoindClient membuat client bitcoind untuk jaringan tertentu
+func NewBitcoindClient(cfg BitcoindConfig, params *chaincfg.Params) *BitcoindClient {
+	url := cfg.URL
+	if url == "" {
+		url = "http://127.0.0.1:" + params.RPCPort
+	}
+	cookieFile := cfg.CookieFile
+	if cookieFile == "" && cfg.User == "" {
+		cookieFile = DefaultCookieFile(params)
+	}
+
+	return &BitcoindClient{
+		url:        strings.TrimRight(url, "/"),
+		user:       cfg.User,
+		password:   cfg.Password,
+		cookieFile: cookieFile,
+		wallet:     cfg.Wallet,
+		client:     cfg.Transport.httpClient(5 * time.Minute), // scantxoutset and rescans can take a while
+		watched:    make(map[string]bool),
+		txCache:    make(map[string]*TxInfo),
+		scans:      make(map[string]scanResult),
+	}
+}
+
+// DefaultCookieFile returns the path of the .cookie file in bitcoind's default data directory
+// DefaultCookieFile mengembalikan lokasi file .cookie bawaan bitcoind
+func DefaultCookieFile(params *chaincfg.Params) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = "."
+	}
+
+	dataDir := filepath.Join(home, ".bitcoin")
+	switch runtime.GOOS {
+	case "darwin":
+		dataDir = filepath.Join(home, "Library", "Application Support", "Bitcoin")
+	case "windows":
+		if appData := os.Getenv("APPDATA"); appData != "" {
+			dataDir = filepath.Join(appData, "Bitcoin")
+		}
+	}
+	return filepath.Join(dataDir, params.DataSubdir, ".cookie")
+}
+
+// credentials returns the RPC user and password, reading the cookie file when no user is configured
+func (c *BitcoindClient) credentials() (string, string, error) {
+	if c.user != "" {
+		return c.user, c.password, nil
+	}
+
+	cookie, err := os.ReadFile(c.cookieFile)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read bitcoind cookie: %w", err)
+	}
+	user, password, ok := strings.Cut(strings.TrimSpace(string(cookie)), ":")
+	if !ok {
+		return "", "", fmt.Errorf("malformed bitcoind cookie file %s", c.cookieFile)
+	}
+	return user, password, nil
+}
+
+// call invokes a node RPC method and decodes its result into result
+func (c *BitcoindClient) call(ctx context.Context, method string, result interface{}, params ...interface{}) error {
+	return c.do(ctx, c.url, method, result, params)
+}
+
+// callWallet invokes a wallet RPC method on the configured wallet
+func (c *BitcoindClient) callWallet(ctx context.Context, method string, result interface{}, params ...interface{}) error {
+	if err := c.ensureWallet(ctx); err != nil {
+		return err
+	}
+	return c.do(ctx, c.url+"/wallet/"+c.wallet, method, result, params)
+}
+
+// do sends one JSON-RPC request to url
+func (c *BitcoindClient) do(ctx context.Context, url, method string, result interface{}, params []interface{}) error {
+	user, password, err := c.credentials()
+	if err != nil {
+		return err
+	}
+
+	if params == nil {
+		params = []interface{}{}
+	}
+	c.nextID++
+	payload, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "1.0",
+		"id":      c.nextID,
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth(user, password)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to query bitcoind: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return fmt.Errorf("bitcoind rejected the RPC credentials: status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	// bitcoind answers RPC errors with status 500 (404 for unknown methods) and a JSON body
+	var reply struct {
+		Result json.RawMessage `json:"result"`
+		Error  *RPCError       `json:"error"`
+	}
+	if err := json.Unmarshal(body, &reply); err != nil {
+		return fmt.Errorf("bitcoind API error: status %d", resp.StatusCode)
+	}
+	if reply.Error != nil {
+		return reply.Error
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(reply.Result, result); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+// ensureWallet loads the configured wallet, creating it as a blank watch-only
+// descriptor wallet if it does not exist yet
+func (c *BitcoindClient) ensureWallet(ctx context.Context) error {
+	if c.walletReady {
+		return nil
+	}
+	if c.wallet == "" {
+		return fmt.Errorf("no bitcoind wallet configured")
+	}
+
+	err := c.call(ctx, "loadwallet", nil, c.wallet)
+	if isRPCError(err, rpcWalletNotFound) {
+		// createwallet name disable_private_keys blank passphrase avoid_reuse descriptors load_on_startup
+		err = c.call(ctx, "createwallet", nil, c.wallet, true, true, "", false, true, true)
+	}
+	if err != nil && !isRPCError(err, rpcWalletAlreadyLoaded) {
+		return fmt.Errorf("failed to open bitcoind wallet %s: %w", c.wallet, err)
+	}
+
+	c.walletReady = true
+	return nil
+}
+
+// ImportDescriptors makes the wallet watch the given descriptors, rescanning
+// from their timestamps. Descriptors whose last address is already watched are skipped.
+// ImportDescriptors membuat wallet bitcoind memantau descriptor yang diberikan
+func (c *BitcoindClient) ImportDescriptors(ctx context.Context, imports []DescriptorImport) error {
+	if c.wallet == "" {
+		// Without a wallet every lookup scans the UTXO set, nothing to import
+		return nil
+	}
+
+	var requests []map[string]interface{}
+	for _, imp := range imports {
+		ranged := strings.Contains(imp.Descriptor, "*")
+
+		var addresses []string
+		var err error
+		if ranged {
+			err = c.call(ctx, "deriveaddresses", &addresses, imp.Descriptor, []uint32{imp.RangeEnd, imp.RangeEnd})
+		} else {
+			err = c.call(ctx, "deriveaddresses", &addresses, imp.Descriptor)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to derive addresses of %s: %w", imp.Descriptor, err)
+		}
+		if len(addresses) > 0 {
+			watched, err := c.isWatched(ctx, addresses[len(addresses)-1])
+			if err != nil {
+				return err
+			}
+			if watched {
+				continue
+			}
+		}
+
+		request := map[string]interface{}{
+			"desc":      imp.Descriptor,
+			"timestamp": imp.Timestamp,
+		}
+		if ranged {
+			request["range"] = []uint32{0, imp.RangeEnd}
+		}
+		requests = append(requests, request)
+	}
+
+	return c.importRequests(ctx, requests)
+}
+
+// importRequests sends importdescriptors requests and checks every result
+func (c *BitcoindClient) importRequests(ctx context.Context, requests []map[string]interface{}) error {
+	if len(requests) == 0 {
+		return nil
+	}
+
+	var results []struct {
+		Success bool      `json:"success"`
+		Error   *RPCError `json:"error"`
+	}
+	if err := c.callWallet(ctx, "importdescriptors", &results, requests); err != nil {
+		return fmt.Errorf("failed to import descriptors: %w", err)
+	}
+	for i, result := range results {
+		if !result.Success {
+			if result.Error != nil {
+				return fmt.Errorf("failed to import %v: %w", requests[i]["desc"], result.Error)
+			}
+			return fmt.Errorf("failed to import %v", requests[i]["desc"])
+		}
+	}
+	return nil
+}
+
+// isWatched reports whether the wallet already follows address
+func (c *BitcoindClient) isWatched(ctx context.Context, address string) (bool, error) {
+	if c.watched[address] {
+		return true, nil
+	}
+
+	var info struct {
+		IsMine      bool `json:"ismine"`
+		IsWatchOnly bool `json:"iswatchonly"`
+	}
+	if err := c.callWallet(ctx, "getaddressinfo", &info, address); err != nil {
+		return false, fmt.Errorf("failed to look up address %s: %w", address, err)
+	}
+	if info.IsMine || info.IsWatchOnly {
+		c.watched[address] = true
+	}
+	return c.watched[address], nil
+}
+
+// ensureWatched imports an addr() descriptor for address unless the wallet
+// already follows it, rescanning the whole chain
+func (c *BitcoindClient) ensureWatched(ctx context.Context, address string) error {
+	watched, err := c.isWatched(ctx, address)
+	if err != nil || watched {
+		return err
+	}
+
+	var info struct {
+		Descriptor string `json:"descriptor"`
+	}
+	if err := c.call(ctx, "getdescriptorinfo", &info, "addr("+address+")"); err != nil {
+		return fmt.Errorf("%w: %s", err, address)
+	}
+	if err := c.importRequests(ctx, []map[string]interface{}{{"desc": info.Descriptor, "timestamp": 0}}); err != nil {
+		return err
+	}
+	c.watched[address] = true
+	return nil
+}
+
+// GetTipHeight returns the height of the last block of the best chain
+func (c *BitcoindClient) GetTipHeight(ctx context.Context) (int64, error) {
+	var height int64
+	if err := c.call(ctx, "getblockcount", &height); err != nil {
+		return 0, err
+	}
+	return height, nil
+}
+
+// GetAddressInfo returns the balance and transaction count of an address.
+// bitcoind keeps no address index, so the funded sums hold the current
+// unspent outputs and nothing is reported as spent.
+func (c *BitcoindClient) GetAddressInfo(ctx context.Context, address string) (*AddressInfo, error) {
+	utxos, err := c.GetUTXOs(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &AddressInfo{Address: address}
+	txIDs := make(map[string]bool)
+	for _, utxo := range utxos {
+		stats := &info.ChainStats
+		if !utxo.Status.Confirmed {
+			stats = &info.MempoolStats
+		}
+		stats.FundedTxoCount++
+		stats.FundedTxoSum += utxo.Value
+		txIDs[utxo.TxID] = true
+	}
+	info.ChainStats.TxCount = int64(len(txIDs))
+
+	if c.wallet != "" {
+		// Count the transactions that paid to the address, including spent ones
+		entries, err := c.listTransactions(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if entry.Address == address {
+				txIDs[entry.TxID] = true
+			}
+		}
+		info.ChainStats.TxCount = int64(len(txIDs))
+	}
+	return info, nil
+}
+
+// GetBalance returns the confirmed plus unconfirmed balance of an address in satoshis
+func (c *BitcoindClient) GetBalance(ctx context.Context, address string) (int64, error) {
+	info, err := c.GetAddressInfo(ctx, address)
+	if err != nil {
+		return 0, err
+	}
+	return info.Balance(), nil
+}
+
+// GetUTXOs returns the unspent outputs of an address: from listunspent of the
+// wallet, or from scantxoutset (confirmed outputs only) without a wallet
+func (c *BitcoindClient) GetUTXOs(ctx context.Context, address string) ([]UTXOInfo, error) {
+	if c.wallet == "" {
+		return c.scanUTXOs(ctx, address)
+	}
+
+	if err := c.ensureWatched(ctx, address); err != nil {
+		return nil, err
+	}
+	tip, err := c.GetTipHeight(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var unspent []struct {
+		TxID          string  `json:"txid"`
+		Vout          int     `json:"vout"`
+		Amount        float64 `json:"amount"`
+		Confirmations int64   `json:"confirmations"`
+	}
+	if err := c.callWallet(ctx, "listunspent", &unspent, 0, 9999999, []string{address}, true); err != nil {
+		return nil, fmt.Errorf("failed to list unspent outputs: %w", err)
+	}
+
+	utxos := make([]UTXOInfo, 0, len(unspent))
+	for _, output := range unspent {
+		utxo := UTXOInfo{TxID: output.TxID, Vout: output.Vout, Value: btcToSatoshis(output.Amount)}
+		if output.Confirmations > 0 {
+			utxo.Status = TxStatus{Confirmed: true, BlockHeight: tip - output.Confirmations + 1}
+		}
+		utxos = append(utxos, utxo)
+	}
+	return utxos, nil
+}
+
+// scannedOutput is an unspent output found by scantxoutset
+type scannedOutput struct {
+	TxID   string  `json:"txid"`
+	Vout   int     `json:"vout"`
+	Amount float64 `json:"amount"`
+	Height int64   `json:"height"`
+}
+
+// scanUTXOs looks up the confirmed unspent outputs of address in the UTXO set
+func (c *BitcoindClient) scanUTXOs(ctx context.Context, address string) ([]UTXOInfo, error) {
+	outputs, err := c.scanTxOutSet(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+
+	utxos := make([]UTXOInfo, 0, len(outputs))
+	for _, output := range outputs {
+		utxos = append(utxos, UTXOInfo{
+			TxID:   output.TxID,
+			Vout:   output.Vout,
+			Value:  btcToSatoshis(output.Amount),
+			Status: TxStatus{Confirmed: true, BlockHeight: output.Height},
+		})
+	}
+	return utxos, nil
+}
+
+// scanTxOutSet runs scantxoutset for the addr() descriptor of address,
+// reusing the previous result while no new block has arrived
+func (c *BitcoindClient) scanTxOutSet(ctx context.Context, address string) ([]scannedOutput, error) {
+	if cached, ok := c.scans[address]; ok {
+		tip, err := c.GetTipHeight(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if tip == cached.Height {
+			return cached.Unspents, nil
+		}
+	}
+
+	var scan struct {
+		Success  bool            `json:"success"`
+		Height   int64           `json:"height"`
+		Unspents []scannedOutput `json:"unspents"`
+	}
+	if err := c.call(ctx, "scantxoutset", &scan, "start", []string{"addr(" + address + ")"}); err != nil {
+		return nil, fmt.Errorf("failed to scan the UTXO set: %w", err)
+	}
+	if !scan.Success {
+		return nil, fmt.Errorf("failed to scan the UTXO set for %s", address)
+	}
+	c.scans[address] = scanResult{Height: scan.Height, Unspents: scan.Unspents}
+	return scan.Unspents, nil
+}
+
+// walletEntry is one entry of listtransactions
+type walletEntry struct {
+	TxID          string `json:"txid"`
+	Address       string `json:"address"`
+	Category      string `json:"category"`
+	Confirmations int64  `json:"confirmations"`
+	BlockHash     string `json:"blockhash"`
+	BlockHeight   int64  `json:"blockheight"`
+	BlockTime     int64  `json:"blocktime"`
+}
+
+// listTransactions returns every listtransactions entry of the wallet
+func (c *BitcoindClient) listTransactions(ctx context.Context) ([]walletEntry, error) {
+	const pageSize = 1000
+
+	var entries []walletEntry
+	for skip := 0; ; skip += pageSize {
+		var page []walletEntry
+		if err := c.callWallet(ctx, "listtransactions", &page, "*", pageSize, skip, true); err != nil {
+			return nil, fmt.Errorf("failed to list wallet transactions: %w", err)
+		}
+		entries = append(entries, page...)
+		if len(page) < pageSize {
+			return entries, nil
+		}
+	}
+}
+
+// GetTransactionHistory returns every transaction touching an address, newest
+// first. Without a wallet only the transactions that created the address's
+// unspent outputs can be found.
+func (c *BitcoindClient) GetTransactionHistory(ctx context.Context, address string) ([]TxInfo, error) {
+	var txs []TxInfo
+	if c.wallet == "" {
+		outputs, err := c.scanTxOutSet(ctx, address)
+		if err != nil {
+			return nil, err
+		}
+		seen := make(map[string]bool)
+		for _, output := range outputs {
+			if seen[output.TxID] {
+				continue
+			}
+			seen[output.TxID] = true
+
+			var blockHash string
+			if err := c.call(ctx, "getblockhash", &blockHash, output.Height); err != nil {
+				return nil, err
+			}
+			tx, err := c.fetchTransaction(ctx, output.TxID, blockHash)
+			if err != nil {
+				return nil, err
+			}
+			txs = append(txs, *tx)
+		}
+	} else {
+		if err := c.ensureWatched(ctx, address); err != nil {
+			return nil, err
+		}
+		all, err := c.walletTransactions(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, tx := range all {
+			if touchesAddress(tx, address) {
+				txs = append(txs, tx)
+			}
+		}
+	}
+
+	sort.SliceStable(txs, func(i, j int) bool {
+		a, b := txs[i].Status, txs[j].Status
+		if a.Confirmed != b.Confirmed {
+			return !a.Confirmed
+		}
+		return a.BlockHeight > b.BlockHeight
+	})
+	return txs, nil
+}
+
+// walletTransactions decodes every transaction of the wallet that is in a
+// block or in the mempool, with the status listtransactions reports
+func (c *BitcoindClient) walletTransactions(ctx context.Context) ([]TxInfo, error) {
+	entries, err := c.listTransactions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var txs []TxInfo
+	for _, entry := range entries {
+		// Negative confirmations mark transactions conflicting with the chain
+		if seen[entry.TxID] || entry.Confirmations < 0 {
+			continue
+		}
+		seen[entry.TxID] = true
+
+		tx, err := c.fetchTransaction(ctx, entry.TxID, entry.BlockHash)
+		if err != nil {
+			return nil, err
+		}
+		decoded := *tx
+		decoded.Status = TxStatus{}
+		if entry.Confirmations > 0 {
+			decoded.Status = TxStatus{
+				Confirmed:   true,
+				BlockHeight: entry.BlockHeight,
+				BlockHash:   entry.BlockHash,
+				BlockTime:   entry.BlockTime,
+			}
+		}
+		txs = append(txs, decoded)
+	}
+	return txs, nil
+}
+
+// touchesAddress reports whether tx spends from or pays to address
+func touchesAddress(tx TxInfo, address string) bool {
+	for _, in := range tx.Vin {
+		if in.Prevout != nil && in.Prevout.ScriptPubKeyAddress == address {
+			return true
+		}
+	}
+	for _, out := range tx.Vout {
+		if out.ScriptPubKeyAddress == address {
+			return true
+		}
+	}
+	return false
+}
+
+// GetTransaction returns a transaction with its prevouts and status; it
+// needs -txindex unless the transaction is in the mempool or the wallet and
+// returns ErrNotIndexed when it cannot tell a mined transaction from a missing one
+func (c *BitcoindClient) GetTransaction(ctx context.Context, txID string) (*TxInfo, error) {
+	return c.fetchTransaction(ctx, txID, "")
+}
+
+// rawTransaction is the verbosity 2 output of getrawtransaction
+type rawTransaction struct {
+	TxID     string  `json:"txid"`
+	Version  int32   `json:"version"`
+	LockTime uint32  `json:"locktime"`
+	Size     int     `json:"size"`
+	Weight   int     `json:"weight"`
+	Fee      float64 `json:"fee"`
+	Vin      []struct {
+		TxID      string `json:"txid"`
+		Vout      uint32 `json:"vout"`
+		Coinbase  string `json:"coinbase"`
+		ScriptSig struct {
+			Hex string `json:"hex"`
+		} `json:"scriptSig"`
+		Witness  []string   `json:"txinwitness"`
+		Sequence uint32     `json:"sequence"`
+		Prevout  *rawOutput `json:"prevout"`
+	} `json:"vin"`
+	Vout          []rawOutput `json:"vout"`
+	BlockHash     string      `json:"blockhash"`
+	Confirmations int64       `json:"confirmations"`
+	BlockTime     int64       `json:"blocktime"`
+}
+
+// rawOutput is an output or prevout of getrawtransaction
+type rawOutput struct {
+	Value        float64 `json:"value"`
+	ScriptPubKey struct {
+		Hex       string   `json:"hex"`
+		Type      string   `json:"type"`
+		Address   string   `json:"address"`
+		Addresses []string `json:"addresses"` // Used instead of address before bitcoind 22
+	} `json:"scriptPubKey"`
+}
+
+// fetchTransaction decodes txID with getrawtransaction, looking in blockHash
+// when given or asking the wallet for the block of the transaction otherwise
+func (c *BitcoindClient) fetchTransaction(ctx context.Context, txID, blockHash string) (*TxInfo, error) {
+	key := txID + ":" + blockHash
+	if tx, ok := c.txCache[key]; ok && blockHash != "" {
+		return tx, nil
+	}
+
+	params := []interface{}{txID, 2}
+	if blockHash != "" {
+		params = append(params, blockHash)
+	}
+	var raw rawTransaction
+	err := c.do(ctx, c.url, "getrawtransaction", &raw, params)
+	if isRPCError(err, rpcInvalidAddressOrKey) && blockHash == "" && c.wallet != "" {
+		// Without -txindex only wallet transactions can be found, through their block
+		var walletTx struct {
+			BlockHash string `json:"blockhash"`
+		}
+		werr := c.callWallet(ctx, "gettransaction", &walletTx, txID, true)
+		if werr == nil && walletTx.BlockHash != "" {
+			return c.fetchTransaction(ctx, txID, walletTx.BlockHash)
+		}
+		if werr == nil {
+			// The wallet saw the transaction, which is in neither a block nor the mempool
+			return nil, fmt.Errorf("%w: transaction %s", ErrNotFound, txID)
+		}
+	}
+	if isRPCError(err, rpcInvalidAddressOrKey) {
+		return nil, c.missingTransaction(ctx, txID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tx := &TxInfo{
+		TxID:     raw.TxID,
+		Version:  raw.Version,
+		LockTime: raw.LockTime,
+		Size:     raw.Size,
+		Weight:   raw.Weight,
+		Fee:      btcToSatoshis(raw.Fee),
+	}
+	for _, in := range raw.Vin {
+		input := TxInput{
+			TxID:       in.TxID,
+			Vout:       in.Vout,
+			ScriptSig:  in.ScriptSig.Hex,
+			Witness:    in.Witness,
+			Sequence:   in.Sequence,
+			IsCoinbase: in.Coinbase != "",
+		}
+		if in.Prevout != nil {
+			prevout := in.Prevout.output()
+			input.Prevout = &prevout
+		}
+		tx.Vin = append(tx.Vin, input)
+	}
+	for _, out := range raw.Vout {
+		tx.Vout = append(tx.Vout, out.output())
+	}
+
+	if raw.Confirmations > 0 {
+		tip, err := c.GetTipHeight(ctx)
+		if err != nil {
+			return nil, err
+		}
+		tx.Status = TxStatus{
+			Confirmed:   true,
+			BlockHeight: tip - raw.Confirmations + 1,
+			BlockHash:   raw.BlockHash,
+			BlockTime:   raw.BlockTime,
+		}
+		c.txCache[txID+":"+raw.BlockHash] = tx
+	}
+	return tx, nil
+}
+
+// output converts a bitcoind output to the Esplora form
+func (o rawOutput) output() TxOutput {
+	address := o.ScriptPubKey.Address
+	if address == "" && len(o.ScriptPubKey.Addresses) == 1 {
+		address = o.ScriptPubKey.Addresses[0]
+	}
+	return TxOutput{
+		ScriptPubKey:        o.ScriptPubKey.Hex,
+		ScriptPubKeyType:    esploraScriptType(o.ScriptPubKey.Type),
+		ScriptPubKeyAddress: address,
+		Value:               btcToSatoshis(o.Value),
+	}
+}
+
+// esploraScriptType maps bitcoind script type names to the ones Esplora uses
+func esploraScriptType(coreType string) string {
+	switch coreType {
+	case "pubkeyhash":
+		return "p2pkh"
+	case "scripthash":
+		return "p2sh"
+	case "witness_v0_keyhash":
+		return "v0_p2wpkh"
+	case "witness_v0_scripthash":
+		return "v0_p2wsh"
+	case "witness_v1_taproot":
+		return "v1_p2tr"
+	case "pubkey":
+		return "p2pk"
+	case "nulldata":
+		return "op_return"
+	default:
+		return coreType
+	}
+}
+
+// GetRawTransaction returns the serialized transaction in hex
+func (c *BitcoindClient) GetRawTransaction(ctx context.Context, txID string) (string, error) {
+	var hex string
+	err := c.call(ctx, "getrawtransaction", &hex, txID, false)
+	if isRPCError(err, rpcInvalidAddressOrKey) && c.wallet != "" {
+		var walletTx struct {
+			Hex string `json:"hex"`
+		}
+		if werr := c.callWallet(ctx, "gettransaction", &walletTx, txID, true); werr == nil {
+			return walletTx.Hex, nil
+		}
+	}
+	if isRPCError(err, rpcInvalidAddressOrKey) {
+		return "", c.missingTransaction(ctx, txID)
+	}
+	return hex, err
+}
+
+// missingTransaction returns the error for a transaction getrawtransaction
+// does not know: ErrNotFound when the node has a transaction index, and
+// ErrNotIndexed otherwise, as bitcoind then only searches the mempool
+func (c *BitcoindClient) missingTransaction(ctx context.Context, txID string) error {
+	if c.hasTxIndex(ctx) {
+		return fmt.Errorf("%w: transaction %s", ErrNotFound, txID)
+	}
+	return fmt.Errorf("%w: transaction %s", ErrNotIndexed, txID)
+}
+
+// hasTxIndex reports whether bitcoind runs with a synced -txindex
+func (c *BitcoindClient) hasTxIndex(ctx context.Context) bool {
+	if c.txIndexed {
+		return true
+	}
+
+	var indexes map[string]struct {
+		Synced bool `json:"synced"`
+	}
+	if err := c.call(ctx, "getindexinfo", &indexes, "txindex"); err != nil {
+		// bitcoind before 0.21 has no getindexinfo
+		return false
+	}
+	c.txIndexed = indexes["txindex"].Synced
+	return c.txIndexed
+}
+
+// GetOutspend reports whether an output is spent. bitcoind only knows the
+// spending transaction when it belongs to the wallet.
+func (c *BitcoindClient) GetOutspend(ctx context.Context, txID string, vout uint32) (*Outspend, error) {
+	var txOut *json.RawMessage
+	if err := c.call(ctx, "gettxout", &txOut, txID, vout, true); err != nil {
+		return nil, err
+	}
+	if txOut != nil && string(*txOut) != "null" {
+		return &Outspend{}, nil
+	}
+
+	// gettxout has no entry for outputs that never existed either, so the
+	// output only counts as spent once its transaction is found
+	funding, err := c.fetchTransaction(ctx, txID, "")
+	if err != nil {
+		return nil, err
+	}
+	if int(vout) >= len(funding.Vout) {
+		return nil, fmt.Errorf("%w: output %s:%d", ErrNotFound, txID, vout)
+	}
+
+	outspend := &Outspend{Spent: true}
+	for _, tx := range c.txCache {
+		for i, in := range tx.Vin {
+			if in.TxID == txID && in.Vout == vout {
+				outspend.TxID = tx.TxID
+				outspend.Vin = uint32(i)
+				outspend.Status = tx.Status
+				return outspend, nil
+			}
+		}
+	}
+	return outspend, nil
+}
+
+// BroadcastTransaction submits a signed transaction with sendrawtransaction
+func (c *BitcoindClient) BroadcastTransaction(ctx context.Context, txHex string) (string, error) {
+	var txID string
+	err := c.call(ctx, "sendrawtransaction", &txID, txHex)
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) {
+		return "", classifyBroadcastError(rpcErr.Message)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to broadcast transaction: %w", err)
+	}
+	return txID, nil
+}
+
+// GetFeeEstimates returns estimatesmartfee rates in sat/vB for common confirmation targets
+func (c *BitcoindClient) GetFeeEstimates(ctx context.Context) (FeeEstimates, error) {
+	estimates := make(FeeEstimates)
+	for _, target := range []int{TargetNextBlock, 2, TargetThreeBlocks, TargetSixBlocks, 12, 24, TargetOneDay, 504, 1008} {
+		var estimate struct {
+			FeeRate float64 `json:"feerate"` // BTC/kvB
+		}
+		if err := c.call(ctx, "estimatesmartfee", &estimate, target); err != nil {
+			return nil, fmt.Errorf("failed to query fee estimates: %w", err)
+		}
+		if estimate.FeeRate > 0 {
+			estimates[target] = estimate.FeeRate * 1e8 / 1000
+		}
+	}
+
+	if len(estimates) == 0 {
+		return nil, fmt.Errorf("bitcoind returned no fee estimates")
+	}
+	return estimates, nil
+}
+
+// isRPCError reports whether err is a bitcoind error with the given code
+func isRPCError(err error, code int) bool {
+	var rpcErr *RPCError
+	return errors.As(err, &rpcErr) && rpcErr.Code == code
+}
+
+// btcToSatoshis converts a bitcoind BTC amount to satoshis
+func btcToSatoshis(btc float64) int64 {
+	return int64(math.Round(btc * 1e8))
+}
@@ -0,0 +1,397 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dhfai/go-wallet/internal/domain"
+	"github.com/dhfai/go-wallet/pkg/chaincfg"
+)
+
+const (
+	testAddress = "bcrt1qw508d6qejxtdg4y5r3zarvary0c5xw7kygt080"
+	testOther   = "bcrt1qq6hag67dl53wl99vzg42z8eyzfz2xlkvwk6f7m"
+	testTxID    = "1111111111111111111111111111111111111111111111111111111111111111"
+	testBlock   = "0000000000000000000000000000000000000000000000000000000000000abc"
+)
+
+// rpcCall is a request received by the stub server
+type rpcCall struct {
+	Path   string
+	Method string
+	Params []interface{}
+}
+
+// stubBitcoind serves canned JSON-RPC results keyed by method. A handler
+// returning an *RPCError is answered like bitcoind answers failed calls.
+func stubBitcoind(t *testing.T, user, password string, handlers map[string]func(rpcCall) interface{}) (*httptest.Server, *[]rpcCall) {
+	t.Helper()
+
+	var calls []rpcCall
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if u, p, ok := r.BasicAuth(); !ok || u != user || p != password {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var req struct {
+			ID     int           `json:"id"`
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("bad request body: %v", err)
+			return
+		}
+		call := rpcCall{Path: r.URL.Path, Method: req.Method, Params: req.Params}
+		calls = append(calls, call)
+
+		handler, ok := handlers[req.Method]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]interface{}{"id": req.ID, "result": nil, "error": RPCError{Code: -32601, Message: "Method not found"}})
+			return
+		}
+
+		result := handler(call)
+		if rpcErr, ok := result.(*RPCError); ok {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]interface{}{"id": req.ID, "result": nil, "error": rpcErr})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": req.ID, "result": result, "error": nil})
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func fixed(result interface{}) func(rpcCall) interface{} {
+	return func(rpcCall) interface{} { return result }
+}
+
+// testRawTransaction is a getrawtransaction verbosity 2 result paying 0.5 BTC to testAddress
+func testRawTransaction(confirmations int64) map[string]interface{} {
+	tx := map[string]interface{}{
+		"txid":     testTxID,
+		"version":  2,
+		"locktime": 0,
+		"size":     222,
+		"weight":   561,
+		"fee":      0.00000141,
+		"vin": []interface{}{map[string]interface{}{
+			"txid":     "2222222222222222222222222222222222222222222222222222222222222222",
+			"vout":     1,
+			"sequence": 4294967293,
+			"prevout": map[string]interface{}{
+				"value":        0.60000141,
+				"scriptPubKey": map[string]interface{}{"hex": "0014", "type": "witness_v0_keyhash", "address": testOther},
+			},
+		}},
+		"vout": []interface{}{
+			map[string]interface{}{"value": 0.5, "n": 0, "scriptPubKey": map[string]interface{}{"hex": "0014", "type": "witness_v0_keyhash", "address": testAddress}},
+			map[string]interface{}{"value": 0.1, "n": 1, "scriptPubKey": map[string]interface{}{"hex": "0014", "type": "witness_v0_keyhash", "address": testOther}},
+		},
+	}
+	if confirmations > 0 {
+		tx["blockhash"] = testBlock
+		tx["confirmations"] = confirmations
+		tx["blocktime"] = 1700000000
+	}
+	return tx
+}
+
+func TestBitcoindCookieAuth(t *testing.T) {
+	ctx := context.Background()
+	server, _ := stubBitcoind(t, "__cookie__", "s3cret", map[string]func(rpcCall) interface{}{
+		"getblockcount": fixed(120),
+	})
+
+	cookie := filepath.Join(t.TempDir(), ".cookie")
+	if err := os.WriteFile(cookie, []byte("__cookie__:s3cret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	client := NewBitcoindClient(BitcoindConfig{URL: server.URL, CookieFile: cookie}, &chaincfg.RegressionNetParams)
+	height, err := client.GetTipHeight(ctx)
+	if err != nil {
+		t.Fatalf("GetTipHeight: %v", err)
+	}
+	if height != 120 {
+		t.Errorf("height = %d, want 120", height)
+	}
+
+	wrong := NewBitcoindClient(BitcoindConfig{URL: server.URL, User: "user", Password: "wrong"}, &chaincfg.RegressionNetParams)
+	if _, err := wrong.GetTipHeight(ctx); err == nil {
+		t.Error("expected an error for wrong credentials")
+	}
+}
+
+func TestBitcoindScanTxOutSet(t *testing.T) {
+	ctx := context.Background()
+	server, calls := stubBitcoind(t, "user", "pass", map[string]func(rpcCall) interface{}{
+		"scantxoutset": fixed(map[string]interface{}{
+			"success": true,
+			"unspents": []interface{}{
+				map[string]interface{}{"txid": testTxID, "vout": 0, "amount": 0.5, "height": 101},
+				map[string]interface{}{"txid": testTxID, "vout": 2, "amount": 0.00012345, "height": 101},
+			},
+			"total_amount": 0.50012345,
+			"height":       110,
+		}),
+		"getblockhash":      fixed(testBlock),
+		"getblockcount":     fixed(110),
+		"getrawtransaction": fixed(testRawTransaction(10)),
+	})
+	client := NewBitcoindClient(BitcoindConfig{URL: server.URL, User: "user", Password: "pass"}, &chaincfg.RegressionNetParams)
+
+	utxos, err := client.GetUTXOs(ctx, testAddress)
+	if err != nil {
+		t.Fatalf("GetUTXOs: %v", err)
+	}
+	if len(utxos) != 2 || utxos[0].Value != 50000000 || utxos[1].Value != 12345 || utxos[1].Status.BlockHeight != 101 {
+		t.Errorf("unexpected UTXOs %+v", utxos)
+	}
+	if got := (*calls)[0].Params[1].([]interface{})[0]; got != "addr("+testAddress+")" {
+		t.Errorf("scantxoutset descriptor = %v", got)
+	}
+
+	info, err := client.GetAddressInfo(ctx, testAddress)
+	if err != nil {
+		t.Fatalf("GetAddressInfo: %v", err)
+	}
+	if info.Balance() != 50012345 || info.TxCount() != 1 || !info.IsUsed() {
+		t.Errorf("balance %d, tx count %d", info.Balance(), info.TxCount())
+	}
+
+	history, err := client.GetTransactionHistory(ctx, testAddress)
+	if err != nil {
+		t.Fatalf("GetTransactionHistory: %v", err)
+	}
+	if len(history) != 1 {
+		t.Fatalf("got %d transactions, want 1", len(history))
+	}
+	tx := history[0]
+	if tx.Fee != 141 || tx.VSize() != 141 || tx.Status.BlockHeight != 101 || !tx.Status.Confirmed {
+		t.Errorf("unexpected transaction %+v", tx)
+	}
+	if tx.Vin[0].Prevout == nil || tx.Vin[0].Prevout.Value != 60000141 || tx.Vout[0].ScriptPubKeyType != "v0_p2wpkh" {
+		t.Errorf("unexpected inputs or outputs %+v %+v", tx.Vin, tx.Vout)
+	}
+
+	// The tip did not move, so the UTXO set was scanned only once
+	scans := 0
+	for _, call := range *calls {
+		if call.Method == "scantxoutset" {
+			scans++
+		}
+	}
+	if scans != 1 {
+		t.Errorf("scantxoutset called %d times, want 1", scans)
+	}
+}
+
+func TestBitcoindWalletFlow(t *testing.T) {
+	ctx := context.Background()
+	watched := false
+	server, calls := stubBitcoind(t, "user", "pass", map[string]func(rpcCall) interface{}{
+		"loadwallet":   fixed(&RPCError{Code: rpcWalletNotFound, Message: "Path does not exist"}),
+		"createwallet": fixed(map[string]interface{}{"name": "watch"}),
+		"getaddressinfo": func(rpcCall) interface{} {
+			return map[string]interface{}{"ismine": watched}
+		},
+		"deriveaddresses": fixed([]string{testOther}),
+		"importdescriptors": func(call rpcCall) interface{} {
+			watched = true
+			return []interface{}{map[string]interface{}{"success": true}}
+		},
+		"getdescriptorinfo": fixed(map[string]interface{}{"descriptor": "addr(" + testAddress + ")#abcdefgh"}),
+		"getblockcount":     fixed(110),
+		"listunspent": fixed([]interface{}{
+			map[string]interface{}{"txid": testTxID, "vout": 0, "amount": 0.5, "confirmations": 10},
+			map[string]interface{}{"txid": testTxID, "vout": 3, "amount": 0.001, "confirmations": 0},
+		}),
+		"listtransactions": fixed([]interface{}{
+			map[string]interface{}{"txid": testTxID, "address": testAddress, "category": "receive", "confirmations": 10, "blockhash": testBlock, "blockheight": 101, "blocktime": 1700000000},
+			map[string]interface{}{"txid": "3333333333333333333333333333333333333333333333333333333333333333", "address": testAddress, "category": "receive", "confirmations": -2},
+		}),
+		"getrawtransaction": fixed(testRawTransaction(10)),
+	})
+	client := NewBitcoindClient(BitcoindConfig{URL: server.URL, User: "user", Password: "pass", Wallet: "watch"}, &chaincfg.RegressionNetParams)
+
+	err := client.ImportDescriptors(ctx, []DescriptorImport{{Descriptor: "wpkh(tpub/0/*)#checksum", RangeEnd: 999}})
+	if err != nil {
+		t.Fatalf("ImportDescriptors: %v", err)
+	}
+	var imported []interface{}
+	for _, call := range *calls {
+		if call.Method == "importdescriptors" {
+			imported = call.Params[0].([]interface{})
+			if call.Path != "/wallet/watch" {
+				t.Errorf("importdescriptors sent to %s", call.Path)
+			}
+		}
+	}
+	if len(imported) != 1 || imported[0].(map[string]interface{})["range"] == nil {
+		t.Errorf("unexpected import requests %v", imported)
+	}
+
+	// The descriptor is now watched, a second import must not trigger another rescan
+	*calls = nil
+	if err := client.ImportDescriptors(ctx, []DescriptorImport{{Descriptor: "wpkh(tpub/0/*)#checksum", RangeEnd: 999}}); err != nil {
+		t.Fatalf("ImportDescriptors: %v", err)
+	}
+	for _, call := range *calls {
+		if call.Method == "importdescriptors" {
+			t.Error("already watched descriptor was imported again")
+		}
+	}
+
+	utxos, err := client.GetUTXOs(ctx, testAddress)
+	if err != nil {
+		t.Fatalf("GetUTXOs: %v", err)
+	}
+	if len(utxos) != 2 || utxos[0].Status.BlockHeight != 101 || utxos[1].Status.Confirmed {
+		t.Errorf("unexpected UTXOs %+v", utxos)
+	}
+
+	history, err := client.GetTransactionHistory(ctx, testAddress)
+	if err != nil {
+		t.Fatalf("GetTransactionHistory: %v", err)
+	}
+	if len(history) != 1 || history[0].TxID != testTxID || history[0].Status.BlockHash != testBlock {
+		t.Errorf("unexpected history %+v", history)
+	}
+
+	info, err := client.GetAddressInfo(ctx, testAddress)
+	if err != nil {
+		t.Fatalf("GetAddressInfo: %v", err)
+	}
+	if info.ChainStats.FundedTxoSum != 50000000 || info.MempoolStats.FundedTxoSum != 100000 || info.TxCount() != 2 {
+		t.Errorf("unexpected address info %+v", info)
+	}
+}
+
+func TestBitcoindErrors(t *testing.T) {
+	ctx := context.Background()
+	server, _ := stubBitcoind(t, "user", "pass", map[string]func(rpcCall) interface{}{
+		"getrawtransaction":  fixed(&RPCError{Code: rpcInvalidAddressOrKey, Message: "No such mempool or blockchain transaction"}),
+		"sendrawtransaction": fixed(&RPCError{Code: -26, Message: "min relay fee not met, 100 < 141"}),
+		"estimatesmartfee": func(call rpcCall) interface{} {
+			if call.Params[0].(float64) == 1 {
+				return map[string]interface{}{"feerate": 0.00021, "blocks": 2}
+			}
+			return map[string]interface{}{"errors": []string{"Insufficient data or no feerate found"}, "blocks": 0}
+		},
+		"gettxout":     fixed(nil),
+		"getindexinfo": fixed(map[string]interface{}{"txindex": map[string]interface{}{"synced": true, "best_block_height": 120}}),
+	})
+	client := NewBitcoindClient(BitcoindConfig{URL: server.URL, User: "user", Password: "pass"}, &chaincfg.RegressionNetParams)
+
+	if _, err := client.GetTransaction(ctx, testTxID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetTransaction error = %v, want ErrNotFound", err)
+	}
+	if _, err := client.BroadcastTransaction(ctx, "00"); !errors.Is(err, domain.ErrFeeTooLow) {
+		t.Errorf("BroadcastTransaction error = %v, want ErrFeeTooLow", err)
+	}
+
+	estimates, err := client.GetFeeEstimates(ctx)
+	if err != nil {
+		t.Fatalf("GetFeeEstimates: %v", err)
+	}
+	if rate, ok := estimates.RateForTarget(6); !ok || rate != 21 {
+		t.Errorf("rate for 6 blocks = %v, want 21 sat/vB", rate)
+	}
+
+	// gettxout has no entry, but neither has the transaction index
+	if _, err := client.GetOutspend(ctx, testTxID, 0); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetOutspend of an unknown transaction error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestBitcoindWithoutTxIndex(t *testing.T) {
+	ctx := context.Background()
+	notInMempool := fixed(&RPCError{Code: rpcInvalidAddressOrKey, Message: "No such mempool transaction. Use -txindex or provide a block hash to enable blockchain transaction queries."})
+
+	for _, tc := range []struct {
+		name     string
+		wallet   string
+		handlers map[string]func(rpcCall) interface{}
+		want     error
+	}{
+		{"no index", "", map[string]func(rpcCall) interface{}{
+			"getindexinfo": fixed(map[string]interface{}{}),
+		}, ErrNotIndexed},
+		{"index still syncing", "", map[string]func(rpcCall) interface{}{
+			"getindexinfo": fixed(map[string]interface{}{"txindex": map[string]interface{}{"synced": false, "best_block_height": 50}}),
+		}, ErrNotIndexed},
+		{"node without getindexinfo", "", map[string]func(rpcCall) interface{}{}, ErrNotIndexed},
+		{"not a wallet transaction", "watch", map[string]func(rpcCall) interface{}{
+			"loadwallet":     fixed(map[string]interface{}{"name": "watch"}),
+			"gettransaction": fixed(&RPCError{Code: rpcInvalidAddressOrKey, Message: "Invalid or non-wallet transaction id"}),
+		}, ErrNotIndexed},
+		{"wallet transaction dropped from the mempool", "watch", map[string]func(rpcCall) interface{}{
+			"loadwallet":     fixed(map[string]interface{}{"name": "watch"}),
+			"gettransaction": fixed(map[string]interface{}{"txid": testTxID, "confirmations": 0}),
+		}, ErrNotFound},
+	} {
+		tc.handlers["getrawtransaction"] = notInMempool
+		server, _ := stubBitcoind(t, "user", "pass", tc.handlers)
+		client := NewBitcoindClient(BitcoindConfig{URL: server.URL, User: "user", Password: "pass", Wallet: tc.wallet}, &chaincfg.RegressionNetParams)
+
+		_, err := client.GetTransaction(ctx, testTxID)
+		if !errors.Is(err, tc.want) {
+			t.Errorf("%s: GetTransaction error = %v, want %v", tc.name, err, tc.want)
+		}
+		if tc.want == ErrNotIndexed && errors.Is(err, ErrNotFound) {
+			t.Errorf("%s: GetTransaction error %v also matches ErrNotFound", tc.name, err)
+		}
+		if tc.wallet == "" {
+			if _, err := client.GetRawTransaction(ctx, testTxID); !errors.Is(err, tc.want) {
+				t.Errorf("%s: GetRawTransaction error = %v, want %v", tc.name, err, tc.want)
+			}
+		}
+	}
+}
+
+func TestBitcoindOutspend(t *testing.T) {
+	ctx := context.Background()
+	unspent := false
+	server, _ := stubBitcoind(t, "user", "pass", map[string]func(rpcCall) interface{}{
+		"gettxout": func(rpcCall) interface{} {
+			if unspent {
+				return map[string]interface{}{"value": 0.5, "confirmations": 0}
+			}
+			return nil
+		},
+		"getrawtransaction": fixed(testRawTransaction(0)),
+	})
+	client := NewBitcoindClient(BitcoindConfig{URL: server.URL, User: "user", Password: "pass"}, &chaincfg.RegressionNetParams)
+
+	outspend, err := client.GetOutspend(ctx, testTxID, 1)
+	if err != nil {
+		t.Fatalf("GetOutspend: %v", err)
+	}
+	if !outspend.Spent {
+		t.Error("existing output missing from the UTXO set should be reported spent")
+	}
+
+	// testRawTransaction has two outputs
+	if _, err := client.GetOutspend(ctx, testTxID, 2); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetOutspend of a missing output error = %v, want ErrNotFound", err)
+	}
+
+	unspent = true
+	outspend, err = client.GetOutspend(ctx, testTxID, 0)
+	if err != nil {
+		t.Fatalf("GetOutspend: %v", err)
+	}
+	if outspend.Spent {
+		t.Error("output in the UTXO set reported spent")
+	}
+}
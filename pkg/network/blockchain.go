@@ -1,26 +1,97 @@
 package network
 
 import (
+	"context"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/dhfai/go-wallet/internal/domain"
+	"github.com/dhfai/go-wallet/pkg/chaincfg"
 )
 
+// ErrNotFound is returned when the explorer does not know the requested transaction or output
+var ErrNotFound = errors.New("not found on the blockchain")
+
+// ErrNotIndexed is returned when a backend cannot tell a mined transaction
+// from one that never existed, as bitcoind without -txindex
+var ErrNotIndexed = errors.New("transaction lookup needs bitcoind -txindex")
+
+// ErrEndpointsDisagree is returned when explorer endpoints at the same block
+// height report different confirmed balances for an address
+var ErrEndpointsDisagree = errors.New("explorer endpoints disagree")
+
 type BlockchainExplorer struct {
-	baseURL string
-	client  *http.Client
+	endpoints  []string
+	client     *http.Client
+	retry      RetryPolicy
+	limiter    *rateLimiter
+	crossCheck bool
+}
+
+// ExplorerConfig describes a set of Esplora endpoints and how to talk to them
+// ExplorerConfig berisi daftar endpoint Esplora dan cara menghubunginya
+type ExplorerConfig struct {
+	Endpoints  []string        // API base URLs; the first is used while it answers, the rest are failovers
+	Transport  TransportConfig // Proxy, TLS roots and timeout
+	Retry      RetryPolicy     // Retries per endpoint; zero delays select DefaultRetryPolicy's
+	RateLimit  RateLimit       // Token bucket per host; a zero rate disables it
+	CrossCheck bool            // Compare address balances across all endpoints
 }
 
 func NewBlockchainExplorer() *BlockchainExplorer {
-	baseURL := "https://blockstream.info/api"
+	return NewBlockchainExplorerForNetwork(&chaincfg.MainNetParams)
+}
+
+func NewBlockchainExplorerForNetwork(params *chaincfg.Params) *BlockchainExplorer {
+	return NewBlockchainExplorerWithURL(params.ExplorerURL)
+}
+
+func NewBlockchainExplorerWithURL(baseURL string) *BlockchainExplorer {
+	return NewBlockchainExplorerWithTransport(baseURL, TransportConfig{})
+}
+
+// NewBlockchainExplorerWithTransport creates a client for the Esplora API at
+// baseURL, e.g. a self-hosted Esplora or mempool.space instance, connecting
+// through the proxy and TLS roots of transport
+// NewBlockchainExplorerWithTransport membuat client Esplora dengan pengaturan koneksi tertentu
+func NewBlockchainExplorerWithTransport(baseURL string, transport TransportConfig) *BlockchainExplorer {
+	return NewBlockchainExplorerWithConfig(ExplorerConfig{
+		Endpoints: []string{baseURL},
+		Transport: transport,
+		Retry:     DefaultRetryPolicy,
+		RateLimit: DefaultRateLimit,
+	})
+}
+
+// NewBlockchainExplorerWithConfig creates a client that retries failed
+// requests with backoff, limits the request rate per host and fails over
+// through cfg.Endpoints in order
+// NewBlockchainExplorerWithConfig membuat client Esplora dengan retry, rate limit dan failover
+func NewBlockchainExplorerWithConfig(cfg ExplorerConfig) *BlockchainExplorer {
+	endpoints := make([]string, 0, len(cfg.Endpoints))
+	for _, endpoint := range cfg.Endpoints {
+		endpoints = append(endpoints, strings.TrimRight(endpoint, "/"))
+	}
+	retry := cfg.Retry
+	if retry.BaseDelay <= 0 {
+		retry.BaseDelay = DefaultRetryPolicy.BaseDelay
+	}
+	if retry.MaxDelay <= 0 {
+		retry.MaxDelay = DefaultRetryPolicy.MaxDelay
+	}
 
 	return &BlockchainExplorer{
-		baseURL: baseURL,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		endpoints:  endpoints,
+		client:     cfg.Transport.httpClient(30 * time.Second),
+		retry:      retry,
+		limiter:    newRateLimiter(cfg.RateLimit),
+		crossCheck: cfg.CrossCheck,
 	}
 }
 
@@ -39,131 +110,475 @@ type AddressStats struct {
 }
 
 type UTXOInfo struct {
-	TxID   string `json:"txid"`
-	Vout   int    `json:"vout"`
-	Value  int64  `json:"value"`
-	Height int    `json:"status.block_height"`
+	TxID   string   `json:"txid"`
+	Vout   int      `json:"vout"`
+	Value  int64    `json:"value"`
+	Status TxStatus `json:"status"`
 }
 
-func (be *BlockchainExplorer) GetBalance(address string) (float64, error) {
-	url := fmt.Sprintf("%s/address/%s", be.baseURL, address)
+type TxStatus struct {
+	Confirmed   bool   `json:"confirmed"`
+	BlockHeight int64  `json:"block_height,omitempty"`
+	BlockHash   string `json:"block_hash,omitempty"`
+	BlockTime   int64  `json:"block_time,omitempty"`
+}
 
-	resp, err := be.client.Get(url)
-	if err != nil {
-		return 0, fmt.Errorf("failed to query blockchain: %w", err)
+type TxInfo struct {
+	TxID     string     `json:"txid"`
+	Version  int32      `json:"version"`
+	LockTime uint32     `json:"locktime"`
+	Vin      []TxInput  `json:"vin"`
+	Vout     []TxOutput `json:"vout"`
+	Size     int        `json:"size"`
+	Weight   int        `json:"weight"`
+	Fee      int64      `json:"fee"`
+	Status   TxStatus   `json:"status"`
+}
+
+type TxInput struct {
+	TxID       string    `json:"txid"`
+	Vout       uint32    `json:"vout"`
+	Prevout    *TxOutput `json:"prevout"` // Output being spent; nil for coinbase inputs
+	ScriptSig  string    `json:"scriptsig"`
+	Witness    []string  `json:"witness"`
+	Sequence   uint32    `json:"sequence"`
+	IsCoinbase bool      `json:"is_coinbase"`
+}
+
+type TxOutput struct {
+	ScriptPubKey        string `json:"scriptpubkey"`
+	ScriptPubKeyType    string `json:"scriptpubkey_type"`
+	ScriptPubKeyAddress string `json:"scriptpubkey_address"`
+	Value               int64  `json:"value"`
+}
+
+// VSize returns the virtual size of the transaction in vbytes
+func (tx *TxInfo) VSize() int {
+	return (tx.Weight + 3) / 4
+}
+
+// Balance returns the confirmed plus unconfirmed balance of the address in satoshis
+func (info *AddressInfo) Balance() int64 {
+	confirmed := info.ChainStats.FundedTxoSum - info.ChainStats.SpentTxoSum
+	mempool := info.MempoolStats.FundedTxoSum - info.MempoolStats.SpentTxoSum
+	return confirmed + mempool
+}
+
+// TxCount returns the number of confirmed and unconfirmed transactions touching the address
+func (info *AddressInfo) TxCount() int64 {
+	return info.ChainStats.TxCount + info.MempoolStats.TxCount
+}
+
+// IsUsed reports whether the address has appeared in any transaction
+func (info *AddressInfo) IsUsed() bool {
+	return info.TxCount() > 0 || info.ChainStats.FundedTxoCount > 0 || info.MempoolStats.FundedTxoCount > 0 ||
+		info.ChainStats.FundedTxoSum > 0 || info.MempoolStats.FundedTxoSum > 0
+}
+
+// GetAddressInfo returns the confirmed and mempool statistics of an address.
+// With cross-checking enabled every endpoint is asked; see crossCheckAddress.
+func (be *BlockchainExplorer) GetAddressInfo(ctx context.Context, address string) (*AddressInfo, error) {
+	if be.crossCheck && len(be.endpoints) > 1 {
+		return be.crossCheckAddress(ctx, address)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("blockchain API error: status %d", resp.StatusCode)
+	var info AddressInfo
+	if err := be.getJSON(ctx, "/address/"+address, &info); err != nil {
+		return nil, err
 	}
+	return &info, nil
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return 0, fmt.Errorf("failed to read response: %w", err)
+// endpointAnswer is the address statistics reported by one endpoint
+type endpointAnswer struct {
+	endpoint string
+	info     *AddressInfo
+	tip      int64
+}
+
+// crossCheckAddress asks every endpoint for the address statistics. When the
+// confirmed balances agree the first answer is returned. Otherwise the
+// endpoints that lag behind are ignored, as an explorer still indexing a
+// block reports a stale balance, and the answer of the endpoint with the
+// highest tip is returned. Endpoints at the same height that still disagree
+// cannot be told apart and yield ErrEndpointsDisagree.
+func (be *BlockchainExplorer) crossCheckAddress(ctx context.Context, address string) (*AddressInfo, error) {
+	var answers []endpointAnswer
+	var lastErr error
+	for _, endpoint := range be.endpoints {
+		var info AddressInfo
+		if err := be.getJSONFrom(ctx, endpoint, "/address/"+address, &info); err != nil {
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			lastErr = err
+			continue
+		}
+		answers = append(answers, endpointAnswer{endpoint: endpoint, info: &info})
+	}
+	if len(answers) == 0 {
+		return nil, lastErr
+	}
+	if confirmedBalancesAgree(answers) {
+		return answers[0].info, nil
 	}
 
-	var info AddressInfo
-	if err := json.Unmarshal(body, &info); err != nil {
-		return 0, fmt.Errorf("failed to parse response: %w", err)
+	bestTip := int64(-1)
+	for i := range answers {
+		tip, err := be.tipHeightFrom(ctx, answers[i].endpoint)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			answers[i].tip = -1
+			continue
+		}
+		answers[i].tip = tip
+		if tip > bestTip {
+			bestTip = tip
+		}
 	}
 
-	// Calculate balance: (total received - total spent) from confirmed + mempool
-	confirmedBalance := info.ChainStats.FundedTxoSum - info.ChainStats.SpentTxoSum
-	mempoolBalance := info.MempoolStats.FundedTxoSum - info.MempoolStats.SpentTxoSum
-	totalSatoshis := confirmedBalance + mempoolBalance
+	var best []endpointAnswer
+	for _, answer := range answers {
+		if answer.tip == bestTip && bestTip >= 0 {
+			best = append(best, answer)
+		}
+	}
+	if len(best) > 0 && confirmedBalancesAgree(best) {
+		return best[0].info, nil
+	}
 
-	// Convert satoshis to BTC
-	btc := float64(totalSatoshis) / 100000000.0
-	return btc, nil
+	reports := make([]string, len(answers))
+	for i, answer := range answers {
+		reports[i] = fmt.Sprintf("%s: %d sat at height %d", endpointHost(answer.endpoint), confirmedBalance(answer.info), answer.tip)
+	}
+	return nil, fmt.Errorf("%w on the balance of %s (%s)", ErrEndpointsDisagree, address, strings.Join(reports, ", "))
 }
 
-func (be *BlockchainExplorer) GetUTXOs(address string) ([]UTXOInfo, error) {
-	url := fmt.Sprintf("%s/address/%s/utxo", be.baseURL, address)
+// confirmedBalance returns the balance of the address in confirmed transactions
+func confirmedBalance(info *AddressInfo) int64 {
+	return info.ChainStats.FundedTxoSum - info.ChainStats.SpentTxoSum
+}
 
-	resp, err := be.client.Get(url)
+// confirmedBalancesAgree reports whether all answers have the same confirmed balance.
+// Mempool balances are not compared, as transactions reach explorers at different times.
+func confirmedBalancesAgree(answers []endpointAnswer) bool {
+	for _, answer := range answers[1:] {
+		if confirmedBalance(answer.info) != confirmedBalance(answers[0].info) {
+			return false
+		}
+	}
+	return true
+}
+
+func (be *BlockchainExplorer) GetBalance(ctx context.Context, address string) (int64, error) {
+	info, err := be.GetAddressInfo(ctx, address)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query blockchain: %w", err)
+		return 0, err
+	}
+
+	// Calculate balance: (total received - total spent) from confirmed + mempool
+	return info.Balance(), nil
+}
+
+func (be *BlockchainExplorer) GetUTXOs(ctx context.Context, address string) ([]UTXOInfo, error) {
+	var utxos []UTXOInfo
+	if err := be.getJSON(ctx, fmt.Sprintf("/address/%s/utxo", address), &utxos); err != nil {
+		return nil, err
 	}
-	defer resp.Body.Close()
+	return utxos, nil
+}
+
+func (be *BlockchainExplorer) GetTransaction(ctx context.Context, txID string) (*TxInfo, error) {
+	var tx TxInfo
+	if err := be.getJSON(ctx, "/tx/"+txID, &tx); err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
+
+// Outspend tells whether a transaction output has been spent and by which input
+type Outspend struct {
+	Spent  bool     `json:"spent"`
+	TxID   string   `json:"txid,omitempty"`
+	Vin    uint32   `json:"vin,omitempty"`
+	Status TxStatus `json:"status"`
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("blockchain API error: status %d", resp.StatusCode)
+// GetOutspend returns the spending status of output vout of transaction txID
+func (be *BlockchainExplorer) GetOutspend(ctx context.Context, txID string, vout uint32) (*Outspend, error) {
+	var outspend Outspend
+	if err := be.getJSON(ctx, fmt.Sprintf("/tx/%s/outspend/%d", txID, vout), &outspend); err != nil {
+		return nil, err
 	}
+	return &outspend, nil
+}
 
-	body, err := io.ReadAll(resp.Body)
+// GetTipHeight returns the height of the last block of the best chain
+func (be *BlockchainExplorer) GetTipHeight(ctx context.Context) (int64, error) {
+	body, err := be.getBody(ctx, "/blocks/tip/height")
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return 0, err
 	}
+	return parseTipHeight(body)
+}
 
-	var utxos []UTXOInfo
-	if err := json.Unmarshal(body, &utxos); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+// tipHeightFrom returns the tip height known to one endpoint
+func (be *BlockchainExplorer) tipHeightFrom(ctx context.Context, endpoint string) (int64, error) {
+	resp, err := be.fetchFrom(ctx, endpoint, http.MethodGet, "/blocks/tip/height", nil)
+	if err != nil {
+		return 0, err
 	}
+	body, err := checkResponse(resp, "/blocks/tip/height")
+	if err != nil {
+		return 0, err
+	}
+	return parseTipHeight(body)
+}
 
-	return utxos, nil
+func parseTipHeight(body []byte) (int64, error) {
+	height, err := strconv.ParseInt(strings.TrimSpace(string(body)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return height, nil
 }
 
-func (be *BlockchainExplorer) BroadcastTransaction(txHex string) (string, error) {
-	url := fmt.Sprintf("%s/tx", be.baseURL)
+func (be *BlockchainExplorer) GetRawTransaction(ctx context.Context, txID string) (string, error) {
+	body, err := be.getBody(ctx, fmt.Sprintf("/tx/%s/hex", txID))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(body)), nil
+}
 
-	resp, err := be.client.Post(url, "text/plain", nil)
+func (be *BlockchainExplorer) BroadcastTransaction(ctx context.Context, txHex string) (string, error) {
+	resp, err := be.fetch(ctx, http.MethodPost, "/tx", []byte(txHex))
 	if err != nil {
 		return "", fmt.Errorf("failed to broadcast transaction: %w", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("broadcast failed: %s", string(body))
+	if resp.Status != http.StatusOK {
+		return "", classifyBroadcastError(string(resp.Body))
 	}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
+	txID := strings.TrimSpace(string(resp.Body))
+	if !isValidTxID(txID) {
+		return "", fmt.Errorf("unexpected broadcast response: %q", txID)
 	}
 
-	return string(body), nil
+	return txID, nil
 }
 
-func (be *BlockchainExplorer) GetTransactionHistory(address string) ([]map[string]interface{}, error) {
-	url := fmt.Sprintf("%s/address/%s/txs", be.baseURL, address)
+// classifyBroadcastError maps node rejection reasons relayed by the explorer to domain errors
+func classifyBroadcastError(message string) error {
+	message = strings.TrimSpace(message)
+
+	switch {
+	case strings.Contains(message, "min relay fee not met"),
+		strings.Contains(message, "mempool min fee not met"),
+		strings.Contains(message, "insufficient fee"):
+		return fmt.Errorf("%w: %s", domain.ErrFeeTooLow, message)
+	case strings.Contains(message, "bad-txns-inputs-missingorspent"),
+		strings.Contains(message, "missing-inputs"):
+		return fmt.Errorf("%w: %s", domain.ErrInputsMissingOrSpent, message)
+	case strings.Contains(message, "txn-mempool-conflict"):
+		return fmt.Errorf("%w: %s", domain.ErrMempoolConflict, message)
+	default:
+		return fmt.Errorf("%w: %s", domain.ErrBroadcastRejected, message)
+	}
+}
 
-	resp, err := be.client.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query blockchain: %w", err)
+func isValidTxID(txID string) bool {
+	if len(txID) != 64 {
+		return false
 	}
-	defer resp.Body.Close()
+	_, err := hex.DecodeString(txID)
+	return err == nil
+}
+
+// chainPageSize is the number of confirmed transactions per page of /address/:address/txs/chain
+const chainPageSize = 25
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("blockchain API error: status %d", resp.StatusCode)
+// GetTransactionHistory returns every transaction touching an address, newest
+// first: unconfirmed transactions, then all confirmed ones fetched page by
+// page through /address/:address/txs/chain/:last_seen_txid
+func (be *BlockchainExplorer) GetTransactionHistory(ctx context.Context, address string) ([]TxInfo, error) {
+	var txs []TxInfo
+	if err := be.getJSON(ctx, fmt.Sprintf("/address/%s/txs", address), &txs); err != nil {
+		return nil, err
 	}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+	// The first page holds the mempool transactions and up to chainPageSize confirmed ones
+	confirmed, lastSeen := 0, ""
+	for _, tx := range txs {
+		if tx.Status.Confirmed {
+			confirmed++
+			lastSeen = tx.TxID
+		}
 	}
 
-	var txs []map[string]interface{}
-	if err := json.Unmarshal(body, &txs); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	for confirmed >= chainPageSize {
+		var page []TxInfo
+		if err := be.getJSON(ctx, fmt.Sprintf("/address/%s/txs/chain/%s", address, lastSeen), &page); err != nil {
+			return nil, err
+		}
+		if len(page) == 0 || page[len(page)-1].TxID == lastSeen {
+			break
+		}
+
+		txs = append(txs, page...)
+		confirmed = len(page)
+		lastSeen = page[len(page)-1].TxID
 	}
 
 	return txs, nil
 }
 
-func (be *BlockchainExplorer) VerifyAddress(address string) (bool, error) {
-	url := fmt.Sprintf("%s/address/%s", be.baseURL, address)
+// getBody fetches path from the first endpoint that answers and returns the body of a 200 response
+func (be *BlockchainExplorer) getBody(ctx context.Context, path string) ([]byte, error) {
+	resp, err := be.fetch(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return checkResponse(resp, path)
+}
+
+// checkResponse returns the body of a 200 response and an error for any other status
+func checkResponse(resp *apiResponse, path string) ([]byte, error) {
+	switch resp.Status {
+	case http.StatusOK:
+		return resp.Body, nil
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, path)
+	default:
+		return nil, fmt.Errorf("blockchain API error: status %d", resp.Status)
+	}
+}
+
+// getJSON fetches path from the explorer and decodes the JSON response into v
+func (be *BlockchainExplorer) getJSON(ctx context.Context, path string, v interface{}) error {
+	body, err := be.getBody(ctx, path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
 
-	resp, err := be.client.Get(url)
+// getJSONFrom is getJSON against a single endpoint, without failover
+func (be *BlockchainExplorer) getJSONFrom(ctx context.Context, endpoint, path string, v interface{}) error {
+	resp, err := be.fetchFrom(ctx, endpoint, http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+	body, err := checkResponse(resp, path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to parse response from %s: %w", endpointHost(endpoint), err)
+	}
+	return nil
+}
+
+func (be *BlockchainExplorer) VerifyAddress(ctx context.Context, address string) (bool, error) {
+	resp, err := be.fetch(ctx, http.MethodGet, "/address/"+address, nil)
 	if err != nil {
 		return false, fmt.Errorf("failed to verify address: %w", err)
 	}
-	defer resp.Body.Close()
+	return resp.Status == http.StatusOK, nil
+}
+
+// Confirmation targets, in blocks, of the recommended fee rates
+const (
+	TargetNextBlock   = 1
+	TargetThreeBlocks = 3
+	TargetSixBlocks   = 6
+	TargetOneDay      = 144
+)
+
+// FeeEstimates maps a confirmation target in blocks to a fee rate in sat/vB
+type FeeEstimates map[int]float64
+
+// RateForTarget returns the estimate for target, or for the closest faster target
+// the explorer reports when it has no estimate for target itself
+func (f FeeEstimates) RateForTarget(target int) (float64, bool) {
+	if rate, ok := f[target]; ok {
+		return rate, true
+	}
 
-	return resp.StatusCode == http.StatusOK, nil
+	best := 0
+	for blocks := range f {
+		if blocks < target && blocks > best {
+			best = blocks
+		}
+	}
+	if best == 0 {
+		return 0, false
+	}
+	return f[best], true
 }
 
-func (be *BlockchainExplorer) GetRecommendedFee() (float64, error) {
-	return 0.00001, nil
+type RecommendedFees struct {
+	NextBlock   float64 `json:"next_block"`   // sat/vB to confirm in the next block
+	ThreeBlocks float64 `json:"three_blocks"` // sat/vB to confirm within 3 blocks (~30 minutes)
+	SixBlocks   float64 `json:"six_blocks"`   // sat/vB to confirm within 6 blocks (~1 hour)
+	OneDay      float64 `json:"one_day"`      // sat/vB to confirm within 144 blocks (~1 day)
+}
+
+func (be *BlockchainExplorer) GetFeeEstimates(ctx context.Context) (FeeEstimates, error) {
+	// Esplora keys the estimates by target as a string: {"1": 87.882, "2": 87.882, ...}
+	var raw map[string]float64
+	if err := be.getJSON(ctx, "/fee-estimates", &raw); err != nil {
+		return nil, fmt.Errorf("failed to query fee estimates: %w", err)
+	}
+
+	estimates := make(FeeEstimates, len(raw))
+	for key, rate := range raw {
+		target, err := strconv.Atoi(key)
+		if err != nil || target <= 0 || rate <= 0 {
+			continue
+		}
+		estimates[target] = rate
+	}
+
+	if len(estimates) == 0 {
+		return nil, fmt.Errorf("explorer returned no fee estimates")
+	}
+
+	return estimates, nil
+}
+
+func (be *BlockchainExplorer) GetRecommendedFee(ctx context.Context) (*RecommendedFees, error) {
+	estimates, err := be.GetFeeEstimates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return estimates.Recommended()
+}
+
+// Recommended picks the rates for the next block and 3, 6 and 144 blocks
+func (f FeeEstimates) Recommended() (*RecommendedFees, error) {
+	var fees RecommendedFees
+	for _, target := range []struct {
+		blocks int
+		rate   *float64
+	}{
+		{TargetNextBlock, &fees.NextBlock},
+		{TargetThreeBlocks, &fees.ThreeBlocks},
+		{TargetSixBlocks, &fees.SixBlocks},
+		{TargetOneDay, &fees.OneDay},
+	} {
+		rate, ok := f.RateForTarget(target.blocks)
+		if !ok {
+			return nil, fmt.Errorf("backend has no fee estimate for %d blocks", target.blocks)
+		}
+		*target.rate = rate
+	}
+
+	return &fees, nil
 }
@@ -0,0 +1,127 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dhfai/go-wallet/internal/domain"
+)
+
+func TestBroadcastTransaction(t *testing.T) {
+	ctx := context.Background()
+	const txHex = "0200000001aa"
+	txID := strings.Repeat("5e", 32)
+
+	var reply string
+	var status int
+	var received []string
+	esplora := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/tx" {
+			http.NotFound(w, r)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		received = append(received, string(body))
+		w.WriteHeader(status)
+		io.WriteString(w, reply)
+	}))
+	defer esplora.Close()
+	explorer := NewBlockchainExplorerWithURL(esplora.URL)
+
+	status, reply = http.StatusOK, txID+"\n"
+	got, err := explorer.BroadcastTransaction(ctx, txHex)
+	if err != nil {
+		t.Fatalf("BroadcastTransaction: %v", err)
+	}
+	if got != txID {
+		t.Errorf("txid = %s, want %s", got, txID)
+	}
+	if len(received) != 1 || received[0] != txHex {
+		t.Errorf("explorer received %q, want the raw hex %q", received, txHex)
+	}
+
+	for _, invalid := range []string{"ok", strings.Repeat("5e", 31), strings.Repeat("zz", 32)} {
+		status, reply = http.StatusOK, invalid
+		if _, err := explorer.BroadcastTransaction(ctx, txHex); err == nil {
+			t.Errorf("broadcast response %q was accepted as a txid", invalid)
+		}
+	}
+
+	for _, tc := range []struct {
+		reason string
+		want   error
+	}{
+		{"sendrawtransaction RPC error: {\"code\":-26,\"message\":\"min relay fee not met, 110 < 141\"}", domain.ErrFeeTooLow},
+		{"sendrawtransaction RPC error: {\"code\":-25,\"message\":\"bad-txns-inputs-missingorspent\"}", domain.ErrInputsMissingOrSpent},
+		{"sendrawtransaction RPC error: {\"code\":-26,\"message\":\"txn-mempool-conflict\"}", domain.ErrMempoolConflict},
+		{"sendrawtransaction RPC error: {\"code\":-26,\"message\":\"non-mandatory-script-verify-flag\"}", domain.ErrBroadcastRejected},
+	} {
+		status, reply = http.StatusBadRequest, tc.reason
+		_, err := explorer.BroadcastTransaction(ctx, txHex)
+		if !errors.Is(err, tc.want) {
+			t.Errorf("rejection %q: error = %v, want %v", tc.reason, err, tc.want)
+		}
+	}
+}
+
+func TestTransactionHistoryPagination(t *testing.T) {
+	ctx := context.Background()
+	for _, confirmed := range []int{3, 25, 53} {
+		// Two mempool transactions, then the confirmed ones newest first
+		var history []TxInfo
+		for i := 0; i < 2; i++ {
+			history = append(history, TxInfo{TxID: fmt.Sprintf("%064x", 1000+i)})
+		}
+		for i := 0; i < confirmed; i++ {
+			history = append(history, TxInfo{TxID: fmt.Sprintf("%064x", i), Status: TxStatus{Confirmed: true, BlockHeight: int64(1000 - i)}})
+		}
+
+		var paths []string
+		esplora := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			paths = append(paths, r.URL.Path)
+			var page []TxInfo
+			switch {
+			case r.URL.Path == "/address/addr/txs":
+				page = history[:min(len(history), 2+chainPageSize)]
+			case strings.HasPrefix(r.URL.Path, "/address/addr/txs/chain/"):
+				lastSeen := strings.TrimPrefix(r.URL.Path, "/address/addr/txs/chain/")
+				for i, tx := range history {
+					if tx.TxID == lastSeen {
+						page = history[i+1 : min(len(history), i+1+chainPageSize)]
+					}
+				}
+			default:
+				http.NotFound(w, r)
+				return
+			}
+			json.NewEncoder(w).Encode(page)
+		}))
+		explorer := NewBlockchainExplorerWithURL(esplora.URL)
+
+		txs, err := explorer.GetTransactionHistory(ctx, "addr")
+		esplora.Close()
+		if err != nil {
+			t.Fatalf("%d confirmed: GetTransactionHistory: %v", confirmed, err)
+		}
+		if len(txs) != len(history) {
+			t.Fatalf("%d confirmed: got %d transactions, want %d", confirmed, len(txs), len(history))
+		}
+		for i := range txs {
+			if txs[i].TxID != history[i].TxID {
+				t.Errorf("%d confirmed: transaction %d = %s, want %s", confirmed, i, txs[i].TxID, history[i].TxID)
+				break
+			}
+		}
+		// One request per full page of confirmed transactions, plus the first
+		if want := 1 + confirmed/chainPageSize; len(paths) != want {
+			t.Errorf("%d confirmed: %d requests %v, want %d", confirmed, len(paths), paths, want)
+		}
+	}
+}
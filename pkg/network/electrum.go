@@ -0,0 +1,846 @@
+package network
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dhfai/go-wallet/pkg/chaincfg"
+	"github.com/dhfai/go-wallet/pkg/crypto"
+)
+
+// electrumProtocolVersion is the protocol version negotiated with server.version
+const electrumProtocolVersion = "1.4"
+
+// maxElectrumLine bounds a single response line; histories of busy addresses are large
+const maxElectrumLine = 64 << 20
+
+// ElectrumConfig holds the connection settings of an Electrum server
+// ElectrumConfig menyimpan pengaturan koneksi server Electrum
+type ElectrumConfig struct {
+	Server     string // tcp://host:port for plain TCP, ssl://host:port (or host:port) for TLS
+	SkipVerify bool   // Accept any TLS certificate, e.g. the self-signed one of a personal server
+
+	Transport TransportConfig // Proxy, TLS roots and the dial and per-request timeout (default 30 seconds)
+}
+
+// ElectrumError is an error returned by an Electrum server
+type ElectrumError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *ElectrumError) Error() string {
+	return fmt.Sprintf("electrum error %d: %s", e.Code, e.Message)
+}
+
+// ElectrumNotification is a change pushed by the server for a subscription
+type ElectrumNotification struct {
+	Method     string // blockchain.scripthash.subscribe or blockchain.headers.subscribe
+	ScriptHash string // Script hash whose history changed
+	Status     string // New status of the script hash; empty when it has no history
+	Height     int64  // New tip height of a header notification
+}
+
+// ElectrumClient is a ChainBackend talking to an Electrum server (ElectrumX,
+// Fulcrum, electrs) over newline-delimited JSON-RPC on TCP or TLS. Addresses
+// are looked up by script hash, so the server needs no wallet or import. The
+// connection is opened on the first request and reopened once when it breaks.
+// ElectrumClient adalah ChainBackend yang terhubung ke server Electrum lewat TCP atau TLS
+type ElectrumClient struct {
+	address   string
+	tlsConfig *tls.Config // nil for plain TCP
+	transport TransportConfig
+	timeout   time.Duration
+	params    *chaincfg.Params
+
+	mu         sync.Mutex // Serializes connecting and requests
+	conn       *electrumConn
+	nextID     int
+	subscribed map[string]bool // Script hashes to subscribe again after reconnecting
+
+	notifications chan ElectrumNotification
+	txCache       map[string]*rawTransaction // Confirmed verbose transactions keyed by txid
+}
+
+// electrumConn is one session with the server and its requests awaiting a response
+type electrumConn struct {
+	net.Conn
+	mu      sync.Mutex
+	pending map[int]chan electrumResponse
+	err     error // Set once the session is closed
+}
+
+// electrumResponse is the outcome of one request
+type electrumResponse struct {
+	Result json.RawMessage
+	Err    error
+}
+
+// electrumMessage is a response or, without an id, a notification
+type electrumMessage struct {
+	ID     *int            `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  json.RawMessage `json:"error"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+// electrumHeader is a block header announced by blockchain.headers.subscribe
+type electrumHeader struct {
+	Height int64  `json:"height"`
+	Hex    string `json:"hex"`
+}
+
+// electrumHistoryEntry is one transaction of blockchain.scripthash.get_history
+type electrumHistoryEntry struct {
+	TxHash string `json:"tx_hash"`
+	Height int64  `json:"height"` // 0 in the mempool, -1 in the mempool with unconfirmed parents
+}
+
+// electrumUnspent is one output of blockchain.scripthash.listunspent
+type electrumUnspent struct {
+	TxHash string `json:"tx_hash"`
+	TxPos  int    `json:"tx_pos"`
+	Height int64  `json:"height"`
+	Value  int64  `json:"value"`
+}
+
+// NewElectrumClient creates a client for the Electrum server of the given network
+// NewElectrumClient membuat client server Electrum untuk jaringan tertentu
+func NewElectrumClient(cfg ElectrumConfig, params *chaincfg.Params) (*ElectrumClient, error) {
+	address, useTLS, err := parseElectrumServer(cfg.Server)
+	if err != nil {
+		return nil, err
+	}
+	client := &ElectrumClient{
+		address:       address,
+		transport:     cfg.Transport,
+		timeout:       cfg.Transport.timeout(30 * time.Second),
+		params:        params,
+		subscribed:    make(map[string]bool),
+		notifications: make(chan ElectrumNotification, 64),
+		txCache:       make(map[string]*rawTransaction),
+	}
+	if useTLS {
+		host, _, _ := net.SplitHostPort(address)
+		client.tlsConfig = &tls.Config{
+			ServerName:         host,
+			RootCAs:            cfg.Transport.RootCAs,
+			InsecureSkipVerify: cfg.SkipVerify,
+		}
+	}
+	return client, nil
+}
+
+// parseElectrumServer splits a server setting into host:port and whether to use TLS.
+// Besides URLs it accepts Electrum's own host:port:s and host:port:t notation.
+func parseElectrumServer(server string) (string, bool, error) {
+	server = strings.TrimSpace(server)
+	useTLS := true
+	switch {
+	case strings.HasPrefix(server, "tcp://"):
+		server, useTLS = strings.TrimPrefix(server, "tcp://"), false
+	case strings.HasPrefix(server, "ssl://"):
+		server = strings.TrimPrefix(server, "ssl://")
+	case strings.HasPrefix(server, "tls://"):
+		server = strings.TrimPrefix(server, "tls://")
+	case strings.HasSuffix(server, ":t"):
+		server, useTLS = strings.TrimSuffix(server, ":t"), false
+	case strings.HasSuffix(server, ":s"):
+		server = strings.TrimSuffix(server, ":s")
+	}
+	server = strings.TrimRight(server, "/")
+
+	host, port, err := net.SplitHostPort(server)
+	if err != nil || host == "" || port == "" {
+		return "", false, fmt.Errorf("invalid electrum server %q: expected tcp://host:port or ssl://host:port", server)
+	}
+	return server, useTLS, nil
+}
+
+// Notifications returns the changes pushed for subscribed addresses and new blocks.
+// Notifications that are not read in time are dropped.
+func (c *ElectrumClient) Notifications() <-chan ElectrumNotification {
+	return c.notifications
+}
+
+// Close ends the session with the server
+func (c *ElectrumClient) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+// call sends a request and decodes its result into result, reconnecting and
+// retrying once when the connection fails. Errors reported by the server are
+// returned as *ElectrumError without a retry.
+func (c *ElectrumClient) call(ctx context.Context, method string, result interface{}, params ...interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if c.conn == nil {
+			if err = c.connect(ctx); err != nil {
+				if ctx.Err() != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		err = c.roundTrip(ctx, method, result, params)
+		var serverErr *ElectrumError
+		if err == nil || errors.As(err, &serverErr) || ctx.Err() != nil {
+			return err
+		}
+		c.conn.Close()
+		c.conn = nil
+	}
+	return err
+}
+
+// connect opens a session, negotiates the protocol version and renews the
+// subscriptions of the previous session; c.mu must be held
+func (c *ElectrumClient) connect(ctx context.Context) error {
+	raw, err := c.transport.dialContext(ctx, "tcp", c.address)
+	if err != nil {
+		return fmt.Errorf("failed to connect to electrum server %s: %w", c.address, err)
+	}
+	if c.tlsConfig != nil {
+		dialCtx, cancel := context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+		conn := tls.Client(raw, c.tlsConfig)
+		if err := conn.HandshakeContext(dialCtx); err != nil {
+			raw.Close()
+			return fmt.Errorf("failed to connect to electrum server %s: %w", c.address, err)
+		}
+		raw = conn
+	}
+
+	c.conn = &electrumConn{Conn: raw, pending: make(map[int]chan electrumResponse)}
+	go c.readLoop(c.conn)
+
+	// server.version must be the first request of a session
+	var version []string
+	if err := c.roundTrip(ctx, "server.version", &version, []interface{}{"go-wallet", electrumProtocolVersion}); err != nil {
+		c.conn.Close()
+		c.conn = nil
+		return fmt.Errorf("electrum handshake with %s failed: %w", c.address, err)
+	}
+	for scriptHash := range c.subscribed {
+		if err := c.roundTrip(ctx, "blockchain.scripthash.subscribe", nil, []interface{}{scriptHash}); err != nil {
+			c.conn.Close()
+			c.conn = nil
+			return fmt.Errorf("failed to renew subscription: %w", err)
+		}
+	}
+	return nil
+}
+
+// roundTrip writes one request on the current session and waits for its response; c.mu must be held
+func (c *ElectrumClient) roundTrip(ctx context.Context, method string, result interface{}, params []interface{}) error {
+	if params == nil {
+		params = []interface{}{}
+	}
+	c.nextID++
+	id := c.nextID
+	request, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      id,
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	conn := c.conn
+	responses, err := conn.register(id)
+	if err != nil {
+		return err
+	}
+	conn.SetWriteDeadline(time.Now().Add(c.timeout))
+	if _, err := conn.Write(append(request, '\n')); err != nil {
+		conn.forget(id)
+		return fmt.Errorf("failed to send %s: %w", method, err)
+	}
+
+	timer := time.NewTimer(c.timeout)
+	defer timer.Stop()
+	select {
+	case resp := <-responses:
+		if resp.Err != nil {
+			return resp.Err
+		}
+		if result == nil {
+			return nil
+		}
+		if err := json.Unmarshal(resp.Result, result); err != nil {
+			return &ElectrumError{Message: fmt.Sprintf("unexpected %s response: %v", method, err)}
+		}
+		return nil
+	case <-timer.C:
+		conn.forget(id)
+		return fmt.Errorf("electrum request %s timed out after %s", method, c.timeout)
+	case <-ctx.Done():
+		conn.forget(id)
+		return ctx.Err()
+	}
+}
+
+// register makes room for the response to request id
+func (conn *electrumConn) register(id int) (chan electrumResponse, error) {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	if conn.err != nil {
+		return nil, conn.err
+	}
+	responses := make(chan electrumResponse, 1)
+	conn.pending[id] = responses
+	return responses, nil
+}
+
+// forget drops a request that will not be waited for anymore
+func (conn *electrumConn) forget(id int) {
+	conn.mu.Lock()
+	delete(conn.pending, id)
+	conn.mu.Unlock()
+}
+
+// readLoop dispatches the responses and notifications of a session until it closes
+func (c *ElectrumClient) readLoop(conn *electrumConn) {
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 64<<10), maxElectrumLine)
+	for scanner.Scan() {
+		var msg electrumMessage
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			continue
+		}
+		if msg.ID == nil {
+			c.notify(msg)
+			continue
+		}
+
+		conn.mu.Lock()
+		responses, ok := conn.pending[*msg.ID]
+		delete(conn.pending, *msg.ID)
+		conn.mu.Unlock()
+		if ok {
+			responses <- electrumResponse{Result: msg.Result, Err: parseElectrumError(msg.Error)}
+		}
+	}
+
+	err := scanner.Err()
+	if err == nil {
+		err = io.EOF
+	}
+	conn.mu.Lock()
+	conn.err = fmt.Errorf("electrum connection closed: %w", err)
+	for id, responses := range conn.pending {
+		responses <- electrumResponse{Err: conn.err}
+		delete(conn.pending, id)
+	}
+	conn.mu.Unlock()
+}
+
+// notify forwards a subscription notification without blocking the reader
+func (c *ElectrumClient) notify(msg electrumMessage) {
+	notification := ElectrumNotification{Method: msg.Method}
+	switch msg.Method {
+	case "blockchain.scripthash.subscribe":
+		var params []*string
+		if err := json.Unmarshal(msg.Params, &params); err != nil || len(params) < 2 || params[0] == nil {
+			return
+		}
+		notification.ScriptHash = *params[0]
+		if params[1] != nil {
+			notification.Status = *params[1]
+		}
+	case "blockchain.headers.subscribe":
+		var headers []electrumHeader
+		if err := json.Unmarshal(msg.Params, &headers); err != nil || len(headers) == 0 {
+			return
+		}
+		notification.Height = headers[len(headers)-1].Height
+	default:
+		return
+	}
+
+	select {
+	case c.notifications <- notification:
+	default:
+	}
+}
+
+// parseElectrumError decodes the error member of a response; servers send either an object or a string
+func parseElectrumError(raw json.RawMessage) error {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	var serverErr ElectrumError
+	if err := json.Unmarshal(raw, &serverErr); err == nil && serverErr.Message != "" {
+		return &serverErr
+	}
+	var message string
+	if err := json.Unmarshal(raw, &message); err == nil {
+		return &ElectrumError{Message: message}
+	}
+	return &ElectrumError{Message: string(raw)}
+}
+
+// isElectrumNotFound reports whether the server rejected a request for an unknown transaction
+func isElectrumNotFound(err error) bool {
+	var serverErr *ElectrumError
+	if !errors.As(err, &serverErr) {
+		return false
+	}
+	message := strings.ToLower(serverErr.Message)
+	return strings.Contains(message, "no such mempool or blockchain transaction") ||
+		strings.Contains(message, "not found")
+}
+
+// ScriptHash returns the Electrum script hash of an output script: its SHA256
+// in reversed byte order, hex encoded
+// ScriptHash mengembalikan script hash Electrum dari sebuah script output
+func ScriptHash(script []byte) string {
+	hash := sha256.Sum256(script)
+	for i, j := 0, len(hash)-1; i < j; i, j = i+1, j-1 {
+		hash[i], hash[j] = hash[j], hash[i]
+	}
+	return hex.EncodeToString(hash[:])
+}
+
+// AddressScriptHash returns the Electrum script hash of the output script paying to address
+// AddressScriptHash mengembalikan script hash Electrum dari sebuah alamat
+func AddressScriptHash(address string, params *chaincfg.Params) (string, error) {
+	script, err := addressScript(address, params)
+	if err != nil {
+		return "", err
+	}
+	return ScriptHash(script), nil
+}
+
+// addressScript returns the output script paying to address. It mirrors
+// txbuilder.PayToAddrScript, which imports this package.
+func addressScript(address string, params *chaincfg.Params) ([]byte, error) {
+	bc := crypto.NewBitcoinCryptoForNetwork(params)
+
+	if strings.HasPrefix(strings.ToLower(address), params.Bech32HRP+"1") {
+		version, program, err := bc.DecodeSegWitAddress(params.Bech32HRP, address)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address %s: %w", address, err)
+		}
+		op := byte(0x00) // OP_0
+		if version > 0 {
+			op = 0x50 + version // OP_1 to OP_16
+		}
+		return append([]byte{op, byte(len(program))}, program...), nil
+	}
+
+	version, payload, err := bc.DecodeBase58Check(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address %s: %w", address, err)
+	}
+	if len(payload) != 20 {
+		return nil, fmt.Errorf("invalid address %s: unexpected payload length", address)
+	}
+	switch version {
+	case params.PubKeyHashAddrID:
+		// OP_DUP OP_HASH160 <hash> OP_EQUALVERIFY OP_CHECKSIG
+		return append(append([]byte{0x76, 0xa9, 20}, payload...), 0x88, 0xac), nil
+	case params.ScriptHashAddrID:
+		// OP_HASH160 <hash> OP_EQUAL
+		return append(append([]byte{0xa9, 20}, payload...), 0x87), nil
+	default:
+		return nil, fmt.Errorf("invalid address %s: not a %s address (version byte 0x%02x)", address, params.Name, version)
+	}
+}
+
+// SubscribeAddress asks the server to announce changes of an address's
+// history on Notifications and returns its current status, empty when the
+// address has no history
+// SubscribeAddress berlangganan perubahan riwayat sebuah alamat
+func (c *ElectrumClient) SubscribeAddress(ctx context.Context, address string) (string, error) {
+	scriptHash, err := AddressScriptHash(address, c.params)
+	if err != nil {
+		return "", err
+	}
+
+	var status *string
+	if err := c.call(ctx, "blockchain.scripthash.subscribe", &status, scriptHash); err != nil {
+		return "", fmt.Errorf("failed to subscribe to %s: %w", address, err)
+	}
+	c.mu.Lock()
+	c.subscribed[scriptHash] = true
+	c.mu.Unlock()
+
+	if status == nil {
+		return "", nil
+	}
+	return *status, nil
+}
+
+// GetTipHeight returns the height of the last block of the best chain
+func (c *ElectrumClient) GetTipHeight(ctx context.Context) (int64, error) {
+	var header electrumHeader
+	if err := c.call(ctx, "blockchain.headers.subscribe", &header); err != nil {
+		return 0, fmt.Errorf("failed to query blockchain: %w", err)
+	}
+	return header.Height, nil
+}
+
+// history returns the get_history entries of an address
+func (c *ElectrumClient) history(ctx context.Context, address string) ([]electrumHistoryEntry, error) {
+	scriptHash, err := AddressScriptHash(address, c.params)
+	if err != nil {
+		return nil, err
+	}
+	return c.scriptHistory(ctx, scriptHash)
+}
+
+// scriptHistory returns the get_history entries of a script hash
+func (c *ElectrumClient) scriptHistory(ctx context.Context, scriptHash string) ([]electrumHistoryEntry, error) {
+	var entries []electrumHistoryEntry
+	if err := c.call(ctx, "blockchain.scripthash.get_history", &entries, scriptHash); err != nil {
+		return nil, fmt.Errorf("failed to fetch history: %w", err)
+	}
+	return entries, nil
+}
+
+// GetAddressInfo returns the statistics of an address. Electrum servers only
+// report balances, so the funded sums hold the confirmed and mempool balance
+// and a negative mempool balance is reported as spent.
+func (c *ElectrumClient) GetAddressInfo(ctx context.Context, address string) (*AddressInfo, error) {
+	scriptHash, err := AddressScriptHash(address, c.params)
+	if err != nil {
+		return nil, err
+	}
+
+	var balance struct {
+		Confirmed   int64 `json:"confirmed"`
+		Unconfirmed int64 `json:"unconfirmed"`
+	}
+	if err := c.call(ctx, "blockchain.scripthash.get_balance", &balance, scriptHash); err != nil {
+		return nil, fmt.Errorf("failed to fetch balance: %w", err)
+	}
+	entries, err := c.scriptHistory(ctx, scriptHash)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &AddressInfo{Address: address}
+	info.ChainStats.FundedTxoSum = balance.Confirmed
+	if balance.Unconfirmed >= 0 {
+		info.MempoolStats.FundedTxoSum = balance.Unconfirmed
+	} else {
+		info.MempoolStats.SpentTxoSum = -balance.Unconfirmed
+	}
+	for _, entry := range entries {
+		if entry.Height > 0 {
+			info.ChainStats.TxCount++
+		} else {
+			info.MempoolStats.TxCount++
+		}
+	}
+	return info, nil
+}
+
+// GetBalance returns the confirmed plus unconfirmed balance of an address in satoshis
+func (c *ElectrumClient) GetBalance(ctx context.Context, address string) (int64, error) {
+	info, err := c.GetAddressInfo(ctx, address)
+	if err != nil {
+		return 0, err
+	}
+	return info.Balance(), nil
+}
+
+// GetUTXOs returns the confirmed and mempool unspent outputs of an address
+func (c *ElectrumClient) GetUTXOs(ctx context.Context, address string) ([]UTXOInfo, error) {
+	scriptHash, err := AddressScriptHash(address, c.params)
+	if err != nil {
+		return nil, err
+	}
+	unspent, err := c.listUnspent(ctx, scriptHash)
+	if err != nil {
+		return nil, err
+	}
+
+	utxos := make([]UTXOInfo, 0, len(unspent))
+	for _, output := range unspent {
+		utxo := UTXOInfo{TxID: output.TxHash, Vout: output.TxPos, Value: output.Value}
+		if output.Height > 0 {
+			utxo.Status = TxStatus{Confirmed: true, BlockHeight: output.Height}
+		}
+		utxos = append(utxos, utxo)
+	}
+	return utxos, nil
+}
+
+// listUnspent returns the listunspent outputs of a script hash
+func (c *ElectrumClient) listUnspent(ctx context.Context, scriptHash string) ([]electrumUnspent, error) {
+	var unspent []electrumUnspent
+	if err := c.call(ctx, "blockchain.scripthash.listunspent", &unspent, scriptHash); err != nil {
+		return nil, fmt.Errorf("failed to list unspent outputs: %w", err)
+	}
+	return unspent, nil
+}
+
+// GetTransactionHistory returns every transaction touching an address, newest first
+func (c *ElectrumClient) GetTransactionHistory(ctx context.Context, address string) ([]TxInfo, error) {
+	entries, err := c.history(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+
+	txs := make([]TxInfo, 0, len(entries))
+	for _, entry := range entries {
+		raw, err := c.rawTransaction(ctx, entry.TxHash)
+		if err != nil {
+			return nil, err
+		}
+		tx, err := c.decode(ctx, raw)
+		if err != nil {
+			return nil, err
+		}
+		if entry.Height > 0 {
+			tx.Status = TxStatus{
+				Confirmed:   true,
+				BlockHeight: entry.Height,
+				BlockHash:   raw.BlockHash,
+				BlockTime:   raw.BlockTime,
+			}
+		}
+		txs = append(txs, *tx)
+	}
+
+	sort.SliceStable(txs, func(i, j int) bool {
+		a, b := txs[i].Status, txs[j].Status
+		if a.Confirmed != b.Confirmed {
+			return !a.Confirmed
+		}
+		return a.BlockHeight > b.BlockHeight
+	})
+	return txs, nil
+}
+
+// GetTransaction returns a transaction with its prevouts and status
+func (c *ElectrumClient) GetTransaction(ctx context.Context, txID string) (*TxInfo, error) {
+	raw, err := c.rawTransaction(ctx, txID)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := c.decode(ctx, raw)
+	if err != nil {
+		return nil, err
+	}
+
+	if raw.Confirmations > 0 {
+		tip, err := c.GetTipHeight(ctx)
+		if err != nil {
+			return nil, err
+		}
+		tx.Status = TxStatus{
+			Confirmed:   true,
+			BlockHeight: tip - raw.Confirmations + 1,
+			BlockHash:   raw.BlockHash,
+			BlockTime:   raw.BlockTime,
+		}
+	}
+	return tx, nil
+}
+
+// rawTransaction fetches the verbose form of a transaction, which servers
+// relay from bitcoind's getrawtransaction. Confirmed transactions are cached.
+func (c *ElectrumClient) rawTransaction(ctx context.Context, txID string) (*rawTransaction, error) {
+	if raw, ok := c.txCache[txID]; ok {
+		return raw, nil
+	}
+
+	var raw rawTransaction
+	err := c.call(ctx, "blockchain.transaction.get", &raw, txID, true)
+	if isElectrumNotFound(err) {
+		return nil, fmt.Errorf("%w: transaction %s", ErrNotFound, txID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch transaction %s: %w", txID, err)
+	}
+
+	if raw.BlockHash != "" {
+		c.txCache[txID] = &raw
+	}
+	return &raw, nil
+}
+
+// decode converts a verbose transaction to the Esplora form, looking up the
+// outputs its inputs spend to fill in prevouts and the fee
+func (c *ElectrumClient) decode(ctx context.Context, raw *rawTransaction) (*TxInfo, error) {
+	tx := &TxInfo{
+		TxID:     raw.TxID,
+		Version:  raw.Version,
+		LockTime: raw.LockTime,
+		Size:     raw.Size,
+		Weight:   raw.Weight,
+	}
+
+	var in, out int64
+	coinbase := false
+	for _, vin := range raw.Vin {
+		input := TxInput{
+			TxID:       vin.TxID,
+			Vout:       vin.Vout,
+			ScriptSig:  vin.ScriptSig.Hex,
+			Witness:    vin.Witness,
+			Sequence:   vin.Sequence,
+			IsCoinbase: vin.Coinbase != "",
+		}
+		if input.IsCoinbase {
+			coinbase = true
+		} else {
+			parent, err := c.rawTransaction(ctx, vin.TxID)
+			if err != nil {
+				return nil, err
+			}
+			if int(vin.Vout) >= len(parent.Vout) {
+				return nil, fmt.Errorf("transaction %s spends missing output %s:%d", raw.TxID, vin.TxID, vin.Vout)
+			}
+			prevout := parent.Vout[vin.Vout].output()
+			input.Prevout = &prevout
+			in += prevout.Value
+		}
+		tx.Vin = append(tx.Vin, input)
+	}
+	for _, vout := range raw.Vout {
+		output := vout.output()
+		out += output.Value
+		tx.Vout = append(tx.Vout, output)
+	}
+	if !coinbase {
+		tx.Fee = in - out
+	}
+	return tx, nil
+}
+
+// GetRawTransaction returns the serialized transaction in hex
+func (c *ElectrumClient) GetRawTransaction(ctx context.Context, txID string) (string, error) {
+	var txHex string
+	err := c.call(ctx, "blockchain.transaction.get", &txHex, txID, false)
+	if isElectrumNotFound(err) {
+		return "", fmt.Errorf("%w: transaction %s", ErrNotFound, txID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch transaction %s: %w", txID, err)
+	}
+	return txHex, nil
+}
+
+// GetOutspend reports whether an output is spent, looking for the spending
+// transaction in the history of the script the output pays to
+func (c *ElectrumClient) GetOutspend(ctx context.Context, txID string, vout uint32) (*Outspend, error) {
+	raw, err := c.rawTransaction(ctx, txID)
+	if err != nil {
+		return nil, err
+	}
+	if int(vout) >= len(raw.Vout) {
+		return nil, fmt.Errorf("%w: output %s:%d", ErrNotFound, txID, vout)
+	}
+	script, err := hex.DecodeString(raw.Vout[vout].ScriptPubKey.Hex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid output script of %s:%d: %w", txID, vout, err)
+	}
+	scriptHash := ScriptHash(script)
+
+	unspent, err := c.listUnspent(ctx, scriptHash)
+	if err != nil {
+		return nil, err
+	}
+	for _, output := range unspent {
+		if output.TxHash == txID && output.TxPos == int(vout) {
+			return &Outspend{}, nil
+		}
+	}
+
+	outspend := &Outspend{Spent: true}
+	entries, err := c.scriptHistory(ctx, scriptHash)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.TxHash == txID {
+			continue
+		}
+		spender, err := c.rawTransaction(ctx, entry.TxHash)
+		if err != nil {
+			return nil, err
+		}
+		for i, in := range spender.Vin {
+			if in.TxID == txID && in.Vout == vout {
+				outspend.TxID = entry.TxHash
+				outspend.Vin = uint32(i)
+				if entry.Height > 0 {
+					outspend.Status = TxStatus{
+						Confirmed:   true,
+						BlockHeight: entry.Height,
+						BlockHash:   spender.BlockHash,
+						BlockTime:   spender.BlockTime,
+					}
+				}
+				return outspend, nil
+			}
+		}
+	}
+	return outspend, nil
+}
+
+// BroadcastTransaction submits a signed transaction and returns its txid
+func (c *ElectrumClient) BroadcastTransaction(ctx context.Context, txHex string) (string, error) {
+	var txID string
+	err := c.call(ctx, "blockchain.transaction.broadcast", &txID, txHex)
+	var serverErr *ElectrumError
+	if errors.As(err, &serverErr) {
+		return "", classifyBroadcastError(serverErr.Message)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to broadcast transaction: %w", err)
+	}
+	if !isValidTxID(txID) {
+		return "", fmt.Errorf("unexpected broadcast response: %q", txID)
+	}
+	return txID, nil
+}
+
+// GetFeeEstimates returns blockchain.estimatefee rates in sat/vB for common confirmation targets
+func (c *ElectrumClient) GetFeeEstimates(ctx context.Context) (FeeEstimates, error) {
+	estimates := make(FeeEstimates)
+	for _, target := range []int{TargetNextBlock, 2, TargetThreeBlocks, TargetSixBlocks, 12, 24, TargetOneDay, 504, 1008} {
+		var rate float64 // BTC/kB, -1 when the server has no estimate
+		if err := c.call(ctx, "blockchain.estimatefee", &rate, target); err != nil {
+			return nil, fmt.Errorf("failed to query fee estimates: %w", err)
+		}
+		if rate > 0 {
+			estimates[target] = rate * 1e8 / 1000
+		}
+	}
+
+	if len(estimates) == 0 {
+		return nil, fmt.Errorf("electrum server returned no fee estimates")
+	}
+	return estimates, nil
+}
@@ -0,0 +1,443 @@
+package network
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dhfai/go-wallet/internal/domain"
+	"github.com/dhfai/go-wallet/pkg/chaincfg"
+)
+
+const (
+	testParentTxID  = "2222222222222222222222222222222222222222222222222222222222222222"
+	testMempoolTxID = "4444444444444444444444444444444444444444444444444444444444444444"
+)
+
+// fakeElectrum is an in-process Electrum server answering newline-delimited
+// JSON-RPC requests with canned results keyed by method
+type fakeElectrum struct {
+	listener net.Listener
+	handlers map[string]func(rpcCall) interface{}
+
+	mu    sync.Mutex
+	calls []rpcCall
+	conns []net.Conn
+}
+
+// startFakeElectrum listens on a local port, with TLS when tlsConfig is set.
+// A handler returning an *ElectrumError is answered with an error member.
+func startFakeElectrum(t *testing.T, tlsConfig *tls.Config, handlers map[string]func(rpcCall) interface{}) *fakeElectrum {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tlsConfig != nil {
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+	server := &fakeElectrum{listener: listener, handlers: handlers}
+	t.Cleanup(func() {
+		listener.Close()
+		server.dropConnections()
+	})
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			server.mu.Lock()
+			server.conns = append(server.conns, conn)
+			server.mu.Unlock()
+			go server.serve(conn)
+		}
+	}()
+	return server
+}
+
+func (s *fakeElectrum) serve(conn net.Conn) {
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		var req struct {
+			ID     int           `json:"id"`
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			return
+		}
+		call := rpcCall{Method: req.Method, Params: req.Params}
+		s.mu.Lock()
+		s.calls = append(s.calls, call)
+		s.mu.Unlock()
+
+		response := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+		if req.Method == "server.version" {
+			response["result"] = []string{"FakeElectrum 1.0", electrumProtocolVersion}
+		} else if handler, ok := s.handlers[req.Method]; !ok {
+			response["error"] = ElectrumError{Code: -32601, Message: "unknown method " + req.Method}
+		} else if result := handler(call); isElectrumErrorResult(result) {
+			response["error"] = result
+		} else {
+			response["result"] = result
+		}
+		if err := s.write(conn, response); err != nil {
+			return
+		}
+	}
+}
+
+func isElectrumErrorResult(result interface{}) bool {
+	_, ok := result.(*ElectrumError)
+	return ok
+}
+
+func (s *fakeElectrum) write(conn net.Conn, message interface{}) error {
+	line, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Write(append(line, '\n'))
+	return err
+}
+
+// push sends a notification on every open connection
+func (s *fakeElectrum) push(method string, params ...interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conn := range s.conns {
+		s.write(conn, map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params})
+	}
+}
+
+// dropConnections closes every open connection, as a restarting server would
+func (s *fakeElectrum) dropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+	s.conns = nil
+}
+
+// methods returns the methods called so far, in order
+func (s *fakeElectrum) methods() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var methods []string
+	for _, call := range s.calls {
+		methods = append(methods, call.Method)
+	}
+	return methods
+}
+
+// verboseTransaction is a transaction.get verbose result spending parent:vout and paying outputs
+func verboseTransaction(txID, parent string, vout int, confirmations int64, outputs ...map[string]interface{}) map[string]interface{} {
+	vin := map[string]interface{}{"txid": parent, "vout": vout, "sequence": 4294967293}
+	if parent == "" {
+		vin = map[string]interface{}{"coinbase": "03650000", "sequence": 4294967295}
+	}
+	var vouts []interface{}
+	for _, output := range outputs {
+		vouts = append(vouts, output)
+	}
+	tx := map[string]interface{}{
+		"txid":     txID,
+		"version":  2,
+		"locktime": 0,
+		"size":     222,
+		"weight":   561,
+		"vin":      []interface{}{vin},
+		"vout":     vouts,
+	}
+	if confirmations > 0 {
+		tx["blockhash"] = testBlock
+		tx["confirmations"] = confirmations
+		tx["blocktime"] = 1700000000
+	}
+	return tx
+}
+
+// verboseOutput is an output of value BTC paying to address
+func verboseOutput(t *testing.T, address string, value float64) map[string]interface{} {
+	t.Helper()
+	script, err := addressScript(address, &chaincfg.RegressionNetParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return map[string]interface{}{
+		"value":        value,
+		"scriptPubKey": map[string]interface{}{"hex": hex.EncodeToString(script), "type": "witness_v0_keyhash", "address": address},
+	}
+}
+
+func TestScriptHash(t *testing.T) {
+	// Example from the Electrum protocol documentation
+	hash, err := AddressScriptHash("1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", &chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("AddressScriptHash: %v", err)
+	}
+	if want := "8b01df4e368ea28f8dc0423bcf7a4923e3a12d307c875e47a0cfbf90b5c39161"; hash != want {
+		t.Errorf("script hash = %s, want %s", hash, want)
+	}
+
+	script, err := addressScript(testAddress, &chaincfg.RegressionNetParams)
+	if err != nil {
+		t.Fatalf("addressScript: %v", err)
+	}
+	if got := hex.EncodeToString(script); got != "0014751e76e8199196d454941c45d1b3a323f1433bd6" {
+		t.Errorf("script = %s", got)
+	}
+	if _, err := AddressScriptHash(testAddress, &chaincfg.MainNetParams); err == nil {
+		t.Error("expected an error for an address of another network")
+	}
+}
+
+func TestElectrumHistoryAndBalance(t *testing.T) {
+	ctx := context.Background()
+	scriptHash, err := AddressScriptHash(testAddress, &chaincfg.RegressionNetParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transactions := map[string]interface{}{
+		testParentTxID:  verboseTransaction(testParentTxID, "", 0, 20, verboseOutput(t, testOther, 0.4), verboseOutput(t, testOther, 0.60000141)),
+		testTxID:        verboseTransaction(testTxID, testParentTxID, 1, 10, verboseOutput(t, testAddress, 0.5), verboseOutput(t, testOther, 0.1)),
+		testMempoolTxID: verboseTransaction(testMempoolTxID, testTxID, 0, 0, verboseOutput(t, testOther, 0.2), verboseOutput(t, testAddress, 0.29999)),
+	}
+	server := startFakeElectrum(t, nil, map[string]func(rpcCall) interface{}{
+		"blockchain.headers.subscribe": fixed(map[string]interface{}{"height": 110, "hex": "00"}),
+		"blockchain.scripthash.get_balance": func(call rpcCall) interface{} {
+			if call.Params[0] != scriptHash {
+				return &ElectrumError{Code: 1, Message: "unexpected script hash"}
+			}
+			return map[string]interface{}{"confirmed": 50000000, "unconfirmed": -20001000}
+		},
+		"blockchain.scripthash.get_history": fixed([]interface{}{
+			map[string]interface{}{"tx_hash": testTxID, "height": 101},
+			map[string]interface{}{"tx_hash": testMempoolTxID, "height": 0, "fee": 1000},
+		}),
+		"blockchain.scripthash.listunspent": fixed([]interface{}{
+			map[string]interface{}{"tx_hash": testMempoolTxID, "tx_pos": 1, "height": 0, "value": 29999000},
+		}),
+		"blockchain.transaction.get": func(call rpcCall) interface{} {
+			tx, ok := transactions[call.Params[0].(string)]
+			if !ok {
+				return &ElectrumError{Code: 2, Message: "daemon error: No such mempool or blockchain transaction"}
+			}
+			return tx
+		},
+	})
+	client, err := NewElectrumClient(ElectrumConfig{Server: "tcp://" + server.listener.Addr().String()}, &chaincfg.RegressionNetParams)
+	if err != nil {
+		t.Fatalf("NewElectrumClient: %v", err)
+	}
+	defer client.Close()
+
+	info, err := client.GetAddressInfo(ctx, testAddress)
+	if err != nil {
+		t.Fatalf("GetAddressInfo: %v", err)
+	}
+	if info.Balance() != 29999000 || info.ChainStats.TxCount != 1 || info.MempoolStats.TxCount != 1 || !info.IsUsed() {
+		t.Errorf("unexpected address info %+v", info)
+	}
+	if methods := server.methods(); methods[0] != "server.version" {
+		t.Errorf("first request was %s, want server.version", methods[0])
+	}
+
+	utxos, err := client.GetUTXOs(ctx, testAddress)
+	if err != nil {
+		t.Fatalf("GetUTXOs: %v", err)
+	}
+	if len(utxos) != 1 || utxos[0].Vout != 1 || utxos[0].Value != 29999000 || utxos[0].Status.Confirmed {
+		t.Errorf("unexpected UTXOs %+v", utxos)
+	}
+
+	history, err := client.GetTransactionHistory(ctx, testAddress)
+	if err != nil {
+		t.Fatalf("GetTransactionHistory: %v", err)
+	}
+	if len(history) != 2 || history[0].TxID != testMempoolTxID || history[1].TxID != testTxID {
+		t.Fatalf("unexpected history order %+v", history)
+	}
+	if tx := history[0]; tx.Status.Confirmed || tx.Fee != 1000 || tx.Vin[0].Prevout.ScriptPubKeyAddress != testAddress {
+		t.Errorf("unexpected mempool transaction %+v", tx)
+	}
+	if tx := history[1]; !tx.Status.Confirmed || tx.Status.BlockHeight != 101 || tx.Status.BlockHash != testBlock || tx.Fee != 141 {
+		t.Errorf("unexpected confirmed transaction %+v", tx)
+	}
+
+	tx, err := client.GetTransaction(ctx, testParentTxID)
+	if err != nil {
+		t.Fatalf("GetTransaction: %v", err)
+	}
+	if !tx.Vin[0].IsCoinbase || tx.Vin[0].Prevout != nil || tx.Fee != 0 || tx.Status.BlockHeight != 91 {
+		t.Errorf("unexpected coinbase transaction %+v", tx)
+	}
+	if _, err := client.GetTransaction(ctx, "5555555555555555555555555555555555555555555555555555555555555555"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetTransaction error = %v, want ErrNotFound", err)
+	}
+
+	outspend, err := client.GetOutspend(ctx, testTxID, 0)
+	if err != nil {
+		t.Fatalf("GetOutspend: %v", err)
+	}
+	if !outspend.Spent || outspend.TxID != testMempoolTxID || outspend.Status.Confirmed {
+		t.Errorf("unexpected outspend %+v", outspend)
+	}
+	outspend, err = client.GetOutspend(ctx, testMempoolTxID, 1)
+	if err != nil {
+		t.Fatalf("GetOutspend: %v", err)
+	}
+	if outspend.Spent {
+		t.Error("listed unspent output reported spent")
+	}
+}
+
+func TestElectrumSubscribeAndBroadcast(t *testing.T) {
+	ctx := context.Background()
+	server := startFakeElectrum(t, nil, map[string]func(rpcCall) interface{}{
+		"blockchain.scripthash.subscribe": fixed(nil),
+		"blockchain.transaction.broadcast": func(call rpcCall) interface{} {
+			if call.Params[0] == "00" {
+				return &ElectrumError{Code: 1, Message: "the transaction was rejected by network rules.\n\nmin relay fee not met, 100 < 141"}
+			}
+			return testTxID
+		},
+		"blockchain.estimatefee": func(call rpcCall) interface{} {
+			if call.Params[0].(float64) == 1 {
+				return 0.00021
+			}
+			return -1
+		},
+	})
+	client, err := NewElectrumClient(ElectrumConfig{Server: server.listener.Addr().String() + ":t"}, &chaincfg.RegressionNetParams)
+	if err != nil {
+		t.Fatalf("NewElectrumClient: %v", err)
+	}
+	defer client.Close()
+
+	status, err := client.SubscribeAddress(ctx, testAddress)
+	if err != nil {
+		t.Fatalf("SubscribeAddress: %v", err)
+	}
+	if status != "" {
+		t.Errorf("status of an unused address = %q, want empty", status)
+	}
+
+	scriptHash, _ := AddressScriptHash(testAddress, &chaincfg.RegressionNetParams)
+	server.push("blockchain.scripthash.subscribe", scriptHash, "f00d")
+	select {
+	case notification := <-client.Notifications():
+		if notification.ScriptHash != scriptHash || notification.Status != "f00d" {
+			t.Errorf("unexpected notification %+v", notification)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no notification received")
+	}
+
+	txID, err := client.BroadcastTransaction(ctx, "0200")
+	if err != nil || txID != testTxID {
+		t.Errorf("BroadcastTransaction = %s, %v", txID, err)
+	}
+	if _, err := client.BroadcastTransaction(ctx, "00"); !errors.Is(err, domain.ErrFeeTooLow) {
+		t.Errorf("BroadcastTransaction error = %v, want ErrFeeTooLow", err)
+	}
+
+	estimates, err := client.GetFeeEstimates(ctx)
+	if err != nil {
+		t.Fatalf("GetFeeEstimates: %v", err)
+	}
+	if rate, ok := estimates.RateForTarget(6); !ok || rate != 21 || len(estimates) != 1 {
+		t.Errorf("estimates = %v, want 21 sat/vB for the next block only", estimates)
+	}
+}
+
+func TestElectrumTLSReconnect(t *testing.T) {
+	ctx := context.Background()
+	server := startFakeElectrum(t, selfSignedTLS(t), map[string]func(rpcCall) interface{}{
+		"blockchain.headers.subscribe":    fixed(map[string]interface{}{"height": 110, "hex": "00"}),
+		"blockchain.scripthash.subscribe": fixed("abcd"),
+	})
+	address := "ssl://" + server.listener.Addr().String()
+
+	strict, err := NewElectrumClient(ElectrumConfig{Server: address, Transport: TransportConfig{Timeout: 5 * time.Second}}, &chaincfg.RegressionNetParams)
+	if err != nil {
+		t.Fatalf("NewElectrumClient: %v", err)
+	}
+	if _, err := strict.GetTipHeight(ctx); err == nil {
+		t.Error("expected the self-signed certificate to be rejected")
+	}
+
+	client, err := NewElectrumClient(ElectrumConfig{Server: address, SkipVerify: true, Transport: TransportConfig{Timeout: 5 * time.Second}}, &chaincfg.RegressionNetParams)
+	if err != nil {
+		t.Fatalf("NewElectrumClient: %v", err)
+	}
+	defer client.Close()
+	if _, err := client.SubscribeAddress(ctx, testAddress); err != nil {
+		t.Fatalf("SubscribeAddress: %v", err)
+	}
+
+	// The server goes away between requests; the client reconnects, redoes
+	// the handshake and renews its subscription
+	server.dropConnections()
+	height, err := client.GetTipHeight(ctx)
+	if err != nil {
+		t.Fatalf("GetTipHeight after reconnect: %v", err)
+	}
+	if height != 110 {
+		t.Errorf("height = %d, want 110", height)
+	}
+
+	want := []string{"server.version", "blockchain.scripthash.subscribe", "server.version", "blockchain.scripthash.subscribe", "blockchain.headers.subscribe"}
+	got := server.methods()
+	if len(got) != len(want) {
+		t.Fatalf("methods = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("methods = %v, want %v", got, want)
+		}
+	}
+
+	if _, err := NewElectrumClient(ElectrumConfig{Server: "electrum.example.com"}, &chaincfg.RegressionNetParams); err == nil {
+		t.Error("expected an error for a server without a port")
+	}
+}
+
+// selfSignedTLS returns a server TLS configuration with a fresh self-signed certificate for 127.0.0.1
+func selfSignedTLS(t *testing.T) *tls.Config {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "electrum.local"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+}
@@ -0,0 +1,244 @@
+package network
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RetryPolicy controls how requests failing with a network error, 429 or a
+// 5xx status are retried before failing over to the next endpoint
+// RetryPolicy mengatur pengulangan request yang gagal
+type RetryPolicy struct {
+	MaxRetries int           // Retries per endpoint after the first attempt
+	BaseDelay  time.Duration // Delay before the first retry, doubled for each further one
+	MaxDelay   time.Duration // Longest delay; a longer Retry-After fails over instead of waiting
+}
+
+// DefaultRetryPolicy retries three times per endpoint after about 0.5, 1 and 2 seconds
+var DefaultRetryPolicy = RetryPolicy{MaxRetries: 3, BaseDelay: 500 * time.Millisecond, MaxDelay: 30 * time.Second}
+
+// RateLimit caps the requests sent to each host with a token bucket
+// RateLimit membatasi jumlah request ke setiap host
+type RateLimit struct {
+	PerSecond float64 // Sustained requests per second; 0 disables the limit
+	Burst     int     // Requests that may be sent at once after a quiet period
+}
+
+// DefaultRateLimit stays below the limits of public Esplora instances
+var DefaultRateLimit = RateLimit{PerSecond: 5, Burst: 10}
+
+// backoff returns the delay before retry number attempt (0 for the first
+// retry): BaseDelay doubled per attempt, capped at MaxDelay, of which the
+// upper half is random so clients that failed together do not retry together
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 0; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(delay-half)+1))
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date
+func retryAfter(header string) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if when, err := http.ParseTime(header); err == nil {
+		if delay := time.Until(when); delay > 0 {
+			return delay
+		}
+	}
+	return 0
+}
+
+// isRetryableStatus reports whether an HTTP status is worth retrying
+func isRetryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// tokenBucket holds the request allowance of one host
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter keeps a token bucket per host
+type rateLimiter struct {
+	limit   RateLimit
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+func newRateLimiter(limit RateLimit) *rateLimiter {
+	if limit.Burst < 1 {
+		limit.Burst = 1
+	}
+	return &rateLimiter{limit: limit, buckets: make(map[string]*tokenBucket)}
+}
+
+// wait blocks until a request to host is allowed or ctx is cancelled
+func (l *rateLimiter) wait(ctx context.Context, host string) error {
+	if l.limit.PerSecond <= 0 {
+		return nil
+	}
+
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		bucket, ok := l.buckets[host]
+		if !ok {
+			bucket = &tokenBucket{tokens: float64(l.limit.Burst), last: now}
+			l.buckets[host] = bucket
+		}
+		bucket.tokens += now.Sub(bucket.last).Seconds() * l.limit.PerSecond
+		if bucket.tokens > float64(l.limit.Burst) {
+			bucket.tokens = float64(l.limit.Burst)
+		}
+		bucket.last = now
+		if bucket.tokens >= 1 {
+			bucket.tokens--
+			l.mu.Unlock()
+			return nil
+		}
+		wait := time.Duration((1 - bucket.tokens) / l.limit.PerSecond * float64(time.Second))
+		l.mu.Unlock()
+
+		if err := sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// sleep waits for d or until ctx is cancelled
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// apiResponse is the final answer of an endpoint to a request
+type apiResponse struct {
+	Endpoint string
+	Status   int
+	Body     []byte
+}
+
+// fetch sends a request to the endpoints in order. Network errors, 429 and
+// 5xx answers are retried with backoff; once an endpoint has used up its
+// retries the next one is tried. Any other answer, including 404, is
+// returned for the caller to interpret.
+func (be *BlockchainExplorer) fetch(ctx context.Context, method, path string, body []byte) (*apiResponse, error) {
+	var lastErr error
+	for _, endpoint := range be.endpoints {
+		resp, err := be.fetchFrom(ctx, endpoint, method, path, body)
+		if err == nil {
+			return resp, nil
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// fetchFrom sends a request to one endpoint, retrying it under the retry policy
+func (be *BlockchainExplorer) fetchFrom(ctx context.Context, endpoint, method, path string, body []byte) (*apiResponse, error) {
+	host := endpointHost(endpoint)
+	for attempt := 0; ; attempt++ {
+		if err := be.limiter.wait(ctx, host); err != nil {
+			return nil, err
+		}
+
+		resp, wait, err := be.send(ctx, endpoint, method, path, body)
+		if err == nil && !isRetryableStatus(resp.Status) {
+			return resp, nil
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			err = fmt.Errorf("failed to query blockchain: %w", err)
+		} else {
+			err = fmt.Errorf("blockchain API error: status %d from %s", resp.Status, host)
+		}
+		if attempt >= be.retry.MaxRetries {
+			return nil, err
+		}
+
+		delay := be.retry.backoff(attempt)
+		if wait > 0 {
+			if wait > be.retry.MaxDelay {
+				return nil, fmt.Errorf("%w (retry after %s)", err, wait)
+			}
+			delay = wait
+		}
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// send performs a single request and returns the answer and the delay asked for by Retry-After
+func (be *BlockchainExplorer) send(ctx context.Context, endpoint, method, path string, body []byte) (*apiResponse, time.Duration, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint+path, reader)
+	if err != nil {
+		return nil, 0, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "text/plain")
+	}
+
+	resp, err := be.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read response: %w", err)
+	}
+	return &apiResponse{Endpoint: endpoint, Status: resp.StatusCode, Body: data}, retryAfter(resp.Header.Get("Retry-After")), nil
+}
+
+// endpointHost returns the host:port an endpoint URL points to, the key of its rate limit
+func endpointHost(endpoint string) string {
+	if parsed, err := url.Parse(endpoint); err == nil && parsed.Host != "" {
+		return parsed.Host
+	}
+	return endpoint
+}
@@ -0,0 +1,221 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// faultyEsplora serves the tip height and a fixed address balance, failing
+// the first requests with the statuses in faults
+type faultyEsplora struct {
+	*httptest.Server
+	faults   []int
+	header   http.Header
+	tip      int64
+	balance  int64
+	requests atomic.Int32
+}
+
+func startFaultyEsplora(t *testing.T, tip, balance int64, faults ...int) *faultyEsplora {
+	t.Helper()
+
+	esplora := &faultyEsplora{faults: faults, header: http.Header{}, tip: tip, balance: balance}
+	esplora.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(esplora.requests.Add(1)) - 1
+		if n < len(esplora.faults) {
+			for key, values := range esplora.header {
+				w.Header()[key] = values
+			}
+			w.WriteHeader(esplora.faults[n])
+			return
+		}
+
+		switch {
+		case r.URL.Path == "/blocks/tip/height":
+			fmt.Fprint(w, esplora.tip)
+		case strings.HasPrefix(r.URL.Path, "/address/"):
+			fmt.Fprintf(w, `{"address":%q,"chain_stats":{"funded_txo_count":1,"funded_txo_sum":%d,"tx_count":1},"mempool_stats":{}}`,
+				strings.TrimPrefix(r.URL.Path, "/address/"), esplora.balance)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(esplora.Close)
+	return esplora
+}
+
+// fastRetry keeps the tests quick while still exercising the backoff
+var fastRetry = RetryPolicy{MaxRetries: 2, BaseDelay: 10 * time.Millisecond, MaxDelay: 2 * time.Second}
+
+func TestExplorerRetry(t *testing.T) {
+	ctx := context.Background()
+
+	esplora := startFaultyEsplora(t, 800000, 0, http.StatusServiceUnavailable, http.StatusBadGateway)
+	explorer := NewBlockchainExplorerWithConfig(ExplorerConfig{Endpoints: []string{esplora.URL}, Retry: fastRetry})
+	height, err := explorer.GetTipHeight(ctx)
+	if err != nil {
+		t.Fatalf("GetTipHeight after two 5xx answers: %v", err)
+	}
+	if height != 800000 || esplora.requests.Load() != 3 {
+		t.Errorf("height = %d after %d requests, want 800000 after 3", height, esplora.requests.Load())
+	}
+
+	// 429 waits for Retry-After instead of the much shorter backoff
+	limited := startFaultyEsplora(t, 800000, 0, http.StatusTooManyRequests)
+	limited.header.Set("Retry-After", "1")
+	explorer = NewBlockchainExplorerWithConfig(ExplorerConfig{Endpoints: []string{limited.URL}, Retry: fastRetry})
+	start := time.Now()
+	if _, err := explorer.GetTipHeight(ctx); err != nil {
+		t.Fatalf("GetTipHeight after 429: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %s, want the 1s of Retry-After", elapsed)
+	}
+
+	// Client errors are final
+	esplora = startFaultyEsplora(t, 800000, 0, http.StatusBadRequest)
+	explorer = NewBlockchainExplorerWithConfig(ExplorerConfig{Endpoints: []string{esplora.URL}, Retry: fastRetry})
+	if _, err := explorer.GetTipHeight(ctx); err == nil || !strings.Contains(err.Error(), "status 400") {
+		t.Errorf("GetTipHeight after 400: %v", err)
+	}
+	if esplora.requests.Load() != 1 {
+		t.Errorf("400 was retried: %d requests", esplora.requests.Load())
+	}
+}
+
+func TestExplorerFailover(t *testing.T) {
+	ctx := context.Background()
+
+	down := startFaultyEsplora(t, 0, 0, 502, 502, 502, 502)
+	backup := startFaultyEsplora(t, 800001, 0)
+	explorer := NewBlockchainExplorerWithConfig(ExplorerConfig{Endpoints: []string{down.URL, backup.URL}, Retry: fastRetry})
+	height, err := explorer.GetTipHeight(ctx)
+	if err != nil {
+		t.Fatalf("GetTipHeight with a failing primary: %v", err)
+	}
+	if height != 800001 {
+		t.Errorf("height = %d, want 800001 from the backup", height)
+	}
+	if down.requests.Load() != int32(fastRetry.MaxRetries+1) {
+		t.Errorf("primary got %d requests, want %d", down.requests.Load(), fastRetry.MaxRetries+1)
+	}
+
+	// A Retry-After longer than MaxDelay fails over at once
+	limited := startFaultyEsplora(t, 0, 0, http.StatusTooManyRequests)
+	limited.header.Set("Retry-After", "3600")
+	explorer = NewBlockchainExplorerWithConfig(ExplorerConfig{Endpoints: []string{limited.URL, backup.URL}, Retry: fastRetry})
+	if height, err := explorer.GetTipHeight(ctx); err != nil || height != 800001 {
+		t.Errorf("GetTipHeight = %d, %v; want 800001 from the backup", height, err)
+	}
+	if limited.requests.Load() != 1 {
+		t.Errorf("rate limited primary got %d requests, want 1", limited.requests.Load())
+	}
+
+	// A 404 is an answer, not a failure: the backup is not asked
+	requests := backup.requests.Load()
+	explorer = NewBlockchainExplorerWithConfig(ExplorerConfig{Endpoints: []string{down.URL, backup.URL}, Retry: fastRetry})
+	if _, err := explorer.GetTransaction(ctx, strings.Repeat("ab", 32)); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetTransaction error = %v, want ErrNotFound", err)
+	}
+	if backup.requests.Load() != requests {
+		t.Error("backup was asked after the primary answered 404")
+	}
+
+	// Every endpoint down: the last error is returned
+	other := startFaultyEsplora(t, 0, 0, 503, 503, 503)
+	down.requests.Store(0)
+	explorer = NewBlockchainExplorerWithConfig(ExplorerConfig{Endpoints: []string{down.URL, other.URL}, Retry: fastRetry})
+	if _, err := explorer.GetTipHeight(ctx); err == nil || !strings.Contains(err.Error(), "status 503") {
+		t.Errorf("GetTipHeight with every endpoint down: %v", err)
+	}
+}
+
+func TestExplorerRateLimit(t *testing.T) {
+	ctx := context.Background()
+
+	esplora := startFaultyEsplora(t, 800000, 0)
+	explorer := NewBlockchainExplorerWithConfig(ExplorerConfig{
+		Endpoints: []string{esplora.URL},
+		RateLimit: RateLimit{PerSecond: 20, Burst: 2},
+	})
+
+	// Two requests use the burst, the other four wait 50ms each
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		if _, err := explorer.GetTipHeight(ctx); err != nil {
+			t.Fatalf("GetTipHeight: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 180*time.Millisecond {
+		t.Errorf("6 requests at 20/s with a burst of 2 took %s, want at least 200ms", elapsed)
+	}
+}
+
+func TestExplorerCancelDuringBackoff(t *testing.T) {
+	esplora := startFaultyEsplora(t, 0, 0, 503, 503, 503, 503)
+	explorer := NewBlockchainExplorerWithConfig(ExplorerConfig{
+		Endpoints: []string{esplora.URL},
+		Retry:     RetryPolicy{MaxRetries: 3, BaseDelay: 10 * time.Second, MaxDelay: time.Minute},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	if _, err := explorer.GetTipHeight(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("GetTipHeight error = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("cancelled backoff took %s", elapsed)
+	}
+}
+
+func TestExplorerCrossCheck(t *testing.T) {
+	ctx := context.Background()
+	crossCheck := func(endpoints ...*faultyEsplora) *BlockchainExplorer {
+		cfg := ExplorerConfig{Retry: fastRetry, CrossCheck: true}
+		for _, endpoint := range endpoints {
+			cfg.Endpoints = append(cfg.Endpoints, endpoint.URL)
+		}
+		return NewBlockchainExplorerWithConfig(cfg)
+	}
+
+	primary := startFaultyEsplora(t, 800000, 50000)
+	agreeing := startFaultyEsplora(t, 800000, 50000)
+	balance, err := crossCheck(primary, agreeing).GetBalance(ctx, testAddress)
+	if err != nil || balance != 50000 {
+		t.Errorf("GetBalance = %d, %v; want 50000", balance, err)
+	}
+	if agreeing.requests.Load() == 0 {
+		t.Error("second endpoint was not asked")
+	}
+
+	// The primary lags a block behind: the endpoint with the higher tip wins
+	ahead := startFaultyEsplora(t, 800001, 75000)
+	balance, err = crossCheck(primary, ahead).GetBalance(ctx, testAddress)
+	if err != nil || balance != 75000 {
+		t.Errorf("GetBalance = %d, %v; want 75000 from the endpoint ahead", balance, err)
+	}
+
+	// Same height, different balances
+	wrong := startFaultyEsplora(t, 800000, 1)
+	_, err = crossCheck(primary, wrong).GetBalance(ctx, testAddress)
+	if !errors.Is(err, ErrEndpointsDisagree) {
+		t.Fatalf("GetBalance error = %v, want ErrEndpointsDisagree", err)
+	}
+	if !strings.Contains(err.Error(), "50000 sat") || !strings.Contains(err.Error(), "1 sat") {
+		t.Errorf("error does not list both balances: %v", err)
+	}
+
+	// An endpoint that is down does not block the others
+	down := startFaultyEsplora(t, 0, 0, 503, 503, 503)
+	if balance, err := crossCheck(down, primary).GetBalance(ctx, testAddress); err != nil || balance != 50000 {
+		t.Errorf("GetBalance with a failing endpoint = %d, %v; want 50000", balance, err)
+	}
+}
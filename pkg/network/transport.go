@@ -0,0 +1,234 @@
+package network
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+)
+
+// TorProxy is the SOCKS5 port a local Tor daemon listens on by default
+const TorProxy = "socks5://127.0.0.1:9050"
+
+// TransportConfig holds the connection settings shared by all backends
+// TransportConfig menyimpan pengaturan koneksi yang dipakai semua backend
+type TransportConfig struct {
+	Proxy   *url.URL       // SOCKS5 proxy, socks5://[user:password@]host:port; nil connects directly
+	RootCAs *x509.CertPool // Trusted TLS roots; nil uses the system roots
+	Timeout time.Duration  // Per-request timeout; 0 selects the backend's default
+}
+
+// ParseProxy parses a SOCKS5 proxy URL. Host names are always resolved by
+// the proxy, so socks5:// and socks5h:// behave the same and .onion
+// addresses work through Tor.
+// ParseProxy mengurai URL proxy SOCKS5
+func ParseProxy(raw string) (*url.URL, error) {
+	proxy, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy %q: %w", raw, err)
+	}
+	if proxy.Scheme != "socks5" && proxy.Scheme != "socks5h" {
+		return nil, fmt.Errorf("invalid proxy %q: only socks5:// proxies are supported", raw)
+	}
+	if proxy.Hostname() == "" || proxy.Port() == "" {
+		return nil, fmt.Errorf("invalid proxy %q: expected socks5://host:port", raw)
+	}
+	return proxy, nil
+}
+
+// LoadCertPool returns the system roots plus the PEM certificates in file
+// LoadCertPool mengembalikan root sistem ditambah sertifikat PEM dari file
+func LoadCertPool(file string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA file: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no PEM certificates found in %s", file)
+	}
+	return pool, nil
+}
+
+// timeout returns the configured timeout or fallback when none is set
+func (t TransportConfig) timeout(fallback time.Duration) time.Duration {
+	if t.Timeout > 0 {
+		return t.Timeout
+	}
+	return fallback
+}
+
+// httpClient returns an HTTP client dialing through the proxy and trusting RootCAs
+func (t TransportConfig) httpClient(defaultTimeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = t.dialContext
+	transport.TLSClientConfig = &tls.Config{RootCAs: t.RootCAs}
+	return &http.Client{
+		Transport: transport,
+		Timeout:   t.timeout(defaultTimeout),
+	}
+}
+
+// dialContext opens a TCP connection to address, through the SOCKS5 proxy when one is set
+func (t TransportConfig) dialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: t.timeout(30 * time.Second)}
+	if t.Proxy == nil {
+		return dialer.DialContext(ctx, network, address)
+	}
+
+	conn, err := dialer.DialContext(ctx, "tcp", t.Proxy.Host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to proxy %s: %w", t.Proxy.Host, err)
+	}
+	// Abort the handshake when ctx is cancelled or the dial timeout passes
+	conn.SetDeadline(time.Now().Add(dialer.Timeout))
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	if err := socks5Connect(conn, t.Proxy.User, address); err != nil {
+		conn.Close()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("proxy %s: %w", t.Proxy.Host, err)
+	}
+	conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+// socks5Replies are the failure reasons of a SOCKS5 CONNECT reply (RFC 1928)
+var socks5Replies = map[byte]string{
+	1: "general failure",
+	2: "connection not allowed by ruleset",
+	3: "network unreachable",
+	4: "host unreachable",
+	5: "connection refused",
+	6: "TTL expired",
+	7: "command not supported",
+	8: "address type not supported",
+}
+
+// socks5Connect asks the proxy on conn to connect to address, authenticating
+// with user when given. Host names are sent to the proxy unresolved.
+func socks5Connect(conn net.Conn, user *url.Userinfo, address string) error {
+	host, portText, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	port, err := strconv.ParseUint(portText, 10, 16)
+	if err != nil {
+		return fmt.Errorf("invalid port in %s", address)
+	}
+
+	// Greeting: version 5 with "no authentication" and, with credentials, "username/password"
+	greeting := []byte{5, 1, 0}
+	if user != nil {
+		greeting = []byte{5, 2, 0, 2}
+	}
+	if _, err := conn.Write(greeting); err != nil {
+		return err
+	}
+	var choice [2]byte
+	if _, err := io.ReadFull(conn, choice[:]); err != nil {
+		return err
+	}
+	if choice[0] != 5 {
+		return errors.New("not a SOCKS5 proxy")
+	}
+	switch choice[1] {
+	case 0:
+	case 2:
+		if user == nil {
+			return errors.New("proxy requires a username and password")
+		}
+		if err := socks5Authenticate(conn, user); err != nil {
+			return err
+		}
+	default:
+		return errors.New("proxy accepts none of the offered authentication methods")
+	}
+
+	request := []byte{5, 1, 0} // CONNECT
+	if ip := net.ParseIP(host); ip != nil && ip.To4() != nil {
+		request = append(append(request, 1), ip.To4()...)
+	} else if ip != nil {
+		request = append(append(request, 4), ip.To16()...)
+	} else {
+		if len(host) > 255 {
+			return fmt.Errorf("host name %s is too long", host)
+		}
+		request = append(append(request, 3, byte(len(host))), host...)
+	}
+	request = binary.BigEndian.AppendUint16(request, uint16(port))
+	if _, err := conn.Write(request); err != nil {
+		return err
+	}
+
+	var reply [4]byte
+	if _, err := io.ReadFull(conn, reply[:]); err != nil {
+		return err
+	}
+	if reply[1] != 0 {
+		reason, ok := socks5Replies[reply[1]]
+		if !ok {
+			reason = fmt.Sprintf("error %d", reply[1])
+		}
+		return fmt.Errorf("connect to %s failed: %s", address, reason)
+	}
+
+	// Skip the bound address and port
+	var skip int
+	switch reply[3] {
+	case 1:
+		skip = net.IPv4len + 2
+	case 4:
+		skip = net.IPv6len + 2
+	case 3:
+		var length [1]byte
+		if _, err := io.ReadFull(conn, length[:]); err != nil {
+			return err
+		}
+		skip = int(length[0]) + 2
+	default:
+		return fmt.Errorf("unexpected address type %d in proxy reply", reply[3])
+	}
+	_, err = io.ReadFull(conn, make([]byte, skip))
+	return err
+}
+
+// socks5Authenticate performs the username/password sub-negotiation (RFC 1929)
+func socks5Authenticate(conn net.Conn, user *url.Userinfo) error {
+	name := user.Username()
+	password, _ := user.Password()
+	if len(name) > 255 || len(password) > 255 {
+		return errors.New("proxy username or password is too long")
+	}
+
+	request := append([]byte{1, byte(len(name))}, name...)
+	request = append(append(request, byte(len(password))), password...)
+	if _, err := conn.Write(request); err != nil {
+		return err
+	}
+	var status [2]byte
+	if _, err := io.ReadFull(conn, status[:]); err != nil {
+		return err
+	}
+	if status[1] != 0 {
+		return errors.New("proxy rejected the username and password")
+	}
+	return nil
+}
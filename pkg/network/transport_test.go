@@ -0,0 +1,183 @@
+package network
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startFakeSOCKS5 runs a SOCKS5 proxy requiring user:password that connects
+// every CONNECT request to upstream, whatever host it names, and reports the
+// requested host:port on targets
+func startFakeSOCKS5(t *testing.T, user, password, upstream string) (string, <-chan string) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	targets := make(chan string, 16)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				target, err := acceptSOCKS5(conn, user, password)
+				if err != nil {
+					return
+				}
+				targets <- target
+
+				upstreamConn, err := net.Dial("tcp", upstream)
+				if err != nil {
+					conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+					return
+				}
+				defer upstreamConn.Close()
+				conn.Write([]byte{5, 0, 0, 1, 127, 0, 0, 1, 0, 0})
+				go io.Copy(upstreamConn, conn)
+				io.Copy(conn, upstreamConn)
+			}()
+		}
+	}()
+	return listener.Addr().String(), targets
+}
+
+// acceptSOCKS5 performs the server side of the handshake and returns the requested host:port
+func acceptSOCKS5(conn net.Conn, user, password string) (string, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return "", err
+	}
+	methods := make([]byte, header[1])
+	if _, err := io.ReadFull(conn, methods); err != nil {
+		return "", err
+	}
+	if !strings.Contains(string(methods), "\x02") {
+		conn.Write([]byte{5, 0xff})
+		return "", errors.New("client offered no password authentication")
+	}
+	conn.Write([]byte{5, 2})
+
+	auth := make([]byte, 2)
+	if _, err := io.ReadFull(conn, auth); err != nil {
+		return "", err
+	}
+	name := make([]byte, auth[1])
+	io.ReadFull(conn, name)
+	length := make([]byte, 1)
+	io.ReadFull(conn, length)
+	pass := make([]byte, length[0])
+	io.ReadFull(conn, pass)
+	if string(name) != user || string(pass) != password {
+		conn.Write([]byte{1, 1})
+		return "", errors.New("bad credentials")
+	}
+	conn.Write([]byte{1, 0})
+
+	request := make([]byte, 4)
+	if _, err := io.ReadFull(conn, request); err != nil {
+		return "", err
+	}
+	var host string
+	switch request[3] {
+	case 1:
+		ip := make([]byte, 4)
+		io.ReadFull(conn, ip)
+		host = net.IP(ip).String()
+	case 3:
+		io.ReadFull(conn, length)
+		name := make([]byte, length[0])
+		io.ReadFull(conn, name)
+		host = string(name)
+	default:
+		return "", fmt.Errorf("unsupported address type %d", request[3])
+	}
+	port := make([]byte, 2)
+	if _, err := io.ReadFull(conn, port); err != nil {
+		return "", err
+	}
+	return net.JoinHostPort(host, fmt.Sprint(binary.BigEndian.Uint16(port))), nil
+}
+
+func TestExplorerThroughSOCKS5(t *testing.T) {
+	ctx := context.Background()
+	esplora := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/blocks/tip/height" {
+			fmt.Fprint(w, "123")
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer esplora.Close()
+
+	proxyAddress, targets := startFakeSOCKS5(t, "alice", "s3cret", esplora.Listener.Addr().String())
+	proxy, err := ParseProxy("socks5://alice:s3cret@" + proxyAddress)
+	if err != nil {
+		t.Fatalf("ParseProxy: %v", err)
+	}
+
+	// The .onion name only resolves at the proxy, as with Tor
+	explorer := NewBlockchainExplorerWithTransport("http://esplorav3.onion:8080/api/", TransportConfig{Proxy: proxy})
+	height, err := explorer.GetTipHeight(ctx)
+	if err != nil {
+		t.Fatalf("GetTipHeight: %v", err)
+	}
+	if height != 123 {
+		t.Errorf("height = %d, want 123", height)
+	}
+	if target := <-targets; target != "esplorav3.onion:8080" {
+		t.Errorf("proxy asked to connect to %s", target)
+	}
+
+	wrong, _ := url.Parse("socks5://alice:wrong@" + proxyAddress)
+	explorer = NewBlockchainExplorerWithTransport("http://esplorav3.onion:8080/api", TransportConfig{Proxy: wrong})
+	if _, err := explorer.GetTipHeight(ctx); err == nil || !strings.Contains(err.Error(), "rejected the username and password") {
+		t.Errorf("GetTipHeight with wrong credentials: %v", err)
+	}
+
+	for _, invalid := range []string{"http://127.0.0.1:8080", "socks5://127.0.0.1", "127.0.0.1:9050"} {
+		if _, err := ParseProxy(invalid); err == nil {
+			t.Errorf("ParseProxy(%q) accepted an invalid proxy", invalid)
+		}
+	}
+}
+
+func TestExplorerCancel(t *testing.T) {
+	release := make(chan struct{})
+	esplora := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer esplora.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	explorer := NewBlockchainExplorerWithURL(esplora.URL)
+	start := time.Now()
+	_, err := explorer.GetUTXOs(ctx, testAddress)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("GetUTXOs error = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("cancelled request took %s", elapsed)
+	}
+}
@@ -0,0 +1,650 @@
+package psbt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/dhfai/go-wallet/pkg/txbuilder"
+)
+
+// Global key types
+const (
+	globalUnsignedTx byte = 0x00
+	globalXPub       byte = 0x01
+	globalVersion    byte = 0xfb
+)
+
+// Input key types
+const (
+	inNonWitnessUTXO     byte = 0x00
+	inWitnessUTXO        byte = 0x01
+	inPartialSig         byte = 0x02
+	inSighashType        byte = 0x03
+	inRedeemScript       byte = 0x04
+	inWitnessScript      byte = 0x05
+	inBip32Derivation    byte = 0x06
+	inFinalScriptSig     byte = 0x07
+	inFinalScriptWitness byte = 0x08
+	inTaprootKeySig      byte = 0x13
+	inTaprootBip32       byte = 0x16
+	inTaprootInternalKey byte = 0x17
+)
+
+// Output key types
+const (
+	outRedeemScript       byte = 0x00
+	outWitnessScript      byte = 0x01
+	outBip32Derivation    byte = 0x02
+	outTaprootInternalKey byte = 0x05
+	outTaprootBip32       byte = 0x07
+)
+
+// Serialize encodes the packet in the BIP174 binary format
+// Serialize mengenkode packet dalam format biner BIP174
+func (p *Packet) Serialize() ([]byte, error) {
+	if p.UnsignedTx == nil {
+		return nil, fmt.Errorf("%w: no unsigned transaction", ErrInvalidPacket)
+	}
+	if len(p.Inputs) != len(p.UnsignedTx.Inputs) || len(p.Outputs) != len(p.UnsignedTx.Outputs) {
+		return nil, fmt.Errorf("%w: input or output count does not match the transaction", ErrInvalidPacket)
+	}
+
+	var buf bytes.Buffer
+	buf.Write(Magic)
+
+	// Global map
+	writePair(&buf, []byte{globalUnsignedTx}, p.UnsignedTx.SerializeNoWitness())
+	for _, xpub := range p.XPubs {
+		writePair(&buf, append([]byte{globalXPub}, xpub.ExtendedKey...), encodeOrigin(xpub.Fingerprint, xpub.Path))
+	}
+	if p.Version != 0 {
+		writePair(&buf, []byte{globalVersion}, uint32Bytes(p.Version))
+	}
+	writeUnknowns(&buf, p.Unknowns)
+	buf.WriteByte(0x00)
+
+	for i := range p.Inputs {
+		p.Inputs[i].serialize(&buf)
+	}
+	for i := range p.Outputs {
+		p.Outputs[i].serialize(&buf)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// B64Encode returns the packet as base64, the usual form for copying between wallets
+// B64Encode mengembalikan packet dalam format base64
+func (p *Packet) B64Encode() (string, error) {
+	raw, err := p.Serialize()
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(raw), nil
+}
+
+func (in *PInput) serialize(buf *bytes.Buffer) {
+	if in.NonWitnessUTXO != nil {
+		writePair(buf, []byte{inNonWitnessUTXO}, in.NonWitnessUTXO.Serialize())
+	}
+	if in.WitnessUTXO != nil {
+		var value bytes.Buffer
+		value.Write(uint64Bytes(uint64(in.WitnessUTXO.Value)))
+		writeVarBytes(&value, in.WitnessUTXO.PkScript)
+		writePair(buf, []byte{inWitnessUTXO}, value.Bytes())
+	}
+
+	if !in.IsFinalized() {
+		sigs := append([]PartialSig(nil), in.PartialSigs...)
+		sort.Slice(sigs, func(i, j int) bool { return bytes.Compare(sigs[i].PubKey, sigs[j].PubKey) < 0 })
+		for _, sig := range sigs {
+			writePair(buf, append([]byte{inPartialSig}, sig.PubKey...), sig.Signature)
+		}
+		if in.SighashType != 0 {
+			writePair(buf, []byte{inSighashType}, uint32Bytes(uint32(in.SighashType)))
+		}
+		if in.RedeemScript != nil {
+			writePair(buf, []byte{inRedeemScript}, in.RedeemScript)
+		}
+		if in.WitnessScript != nil {
+			writePair(buf, []byte{inWitnessScript}, in.WitnessScript)
+		}
+		for _, d := range in.Bip32Derivation {
+			writePair(buf, append([]byte{inBip32Derivation}, d.PubKey...), encodeOrigin(d.Fingerprint, d.Path))
+		}
+	}
+
+	if in.FinalScriptSig != nil {
+		writePair(buf, []byte{inFinalScriptSig}, in.FinalScriptSig)
+	}
+	if in.FinalScriptWitness != nil {
+		writePair(buf, []byte{inFinalScriptWitness}, encodeWitness(in.FinalScriptWitness))
+	}
+
+	if !in.IsFinalized() {
+		if in.TaprootKeySig != nil {
+			writePair(buf, []byte{inTaprootKeySig}, in.TaprootKeySig)
+		}
+		for _, d := range in.TaprootBip32Derivation {
+			writePair(buf, append([]byte{inTaprootBip32}, d.XOnlyPubKey...), encodeTaprootOrigin(d))
+		}
+		if in.TaprootInternalKey != nil {
+			writePair(buf, []byte{inTaprootInternalKey}, in.TaprootInternalKey)
+		}
+	}
+
+	writeUnknowns(buf, in.Unknowns)
+	buf.WriteByte(0x00)
+}
+
+func (out *POutput) serialize(buf *bytes.Buffer) {
+	if out.RedeemScript != nil {
+		writePair(buf, []byte{outRedeemScript}, out.RedeemScript)
+	}
+	if out.WitnessScript != nil {
+		writePair(buf, []byte{outWitnessScript}, out.WitnessScript)
+	}
+	for _, d := range out.Bip32Derivation {
+		writePair(buf, append([]byte{outBip32Derivation}, d.PubKey...), encodeOrigin(d.Fingerprint, d.Path))
+	}
+	if out.TaprootInternalKey != nil {
+		writePair(buf, []byte{outTaprootInternalKey}, out.TaprootInternalKey)
+	}
+	for _, d := range out.TaprootBip32Derivation {
+		writePair(buf, append([]byte{outTaprootBip32}, d.XOnlyPubKey...), encodeTaprootOrigin(d))
+	}
+
+	writeUnknowns(buf, out.Unknowns)
+	buf.WriteByte(0x00)
+}
+
+// Parse decodes a packet in the BIP174 binary format
+// Parse mendekode packet dalam format biner BIP174
+func Parse(raw []byte) (*Packet, error) {
+	if !bytes.HasPrefix(raw, Magic) {
+		return nil, ErrInvalidMagic
+	}
+	r := bytes.NewReader(raw[len(Magic):])
+
+	p := &Packet{}
+	seen := make(map[string]bool)
+	for {
+		key, value, err := readPair(r)
+		if err != nil {
+			return nil, err
+		}
+		if key == nil {
+			break
+		}
+		if seen[string(key)] {
+			return nil, fmt.Errorf("%w: global 0x%x", ErrDuplicateKey, key)
+		}
+		seen[string(key)] = true
+
+		switch key[0] {
+		case globalUnsignedTx:
+			if len(key) != 1 {
+				return nil, fmt.Errorf("%w: unsigned transaction key has data", ErrInvalidPacket)
+			}
+			tx, err := txbuilder.ParseLegacyTransaction(value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: unsigned transaction: %v", ErrInvalidPacket, err)
+			}
+			for i, in := range tx.Inputs {
+				if len(in.ScriptSig) > 0 || len(in.Witness) > 0 {
+					return nil, fmt.Errorf("%w: input %d of the unsigned transaction is signed", ErrInvalidPacket, i)
+				}
+			}
+			p.UnsignedTx = tx
+		case globalXPub:
+			if len(key) != 79 {
+				return nil, fmt.Errorf("%w: extended public key must be 78 bytes", ErrInvalidPacket)
+			}
+			fingerprint, path, err := decodeOrigin(value)
+			if err != nil {
+				return nil, err
+			}
+			p.XPubs = append(p.XPubs, XPub{ExtendedKey: key[1:], Fingerprint: fingerprint, Path: path})
+		case globalVersion:
+			if len(key) != 1 || len(value) != 4 {
+				return nil, fmt.Errorf("%w: malformed version", ErrInvalidPacket)
+			}
+			p.Version = binary.LittleEndian.Uint32(value)
+			if p.Version != 0 {
+				return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidPacket, p.Version)
+			}
+		default:
+			p.Unknowns = append(p.Unknowns, Unknown{Key: key, Value: value})
+		}
+	}
+
+	if p.UnsignedTx == nil {
+		return nil, fmt.Errorf("%w: no unsigned transaction", ErrInvalidPacket)
+	}
+
+	p.Inputs = make([]PInput, len(p.UnsignedTx.Inputs))
+	for i := range p.Inputs {
+		if err := p.Inputs[i].parse(r); err != nil {
+			return nil, fmt.Errorf("input %d: %w", i, err)
+		}
+		if utxo := p.Inputs[i].NonWitnessUTXO; utxo != nil {
+			if utxo.TxID() != txbuilder.FormatTxID(p.UnsignedTx.Inputs[i].PrevTxID) {
+				return nil, fmt.Errorf("%w: input %d non-witness UTXO does not match the outpoint", ErrInvalidPacket, i)
+			}
+		}
+	}
+
+	p.Outputs = make([]POutput, len(p.UnsignedTx.Outputs))
+	for i := range p.Outputs {
+		if err := p.Outputs[i].parse(r); err != nil {
+			return nil, fmt.Errorf("output %d: %w", i, err)
+		}
+	}
+
+	if r.Len() != 0 {
+		return nil, fmt.Errorf("%w: %d trailing bytes", ErrInvalidPacket, r.Len())
+	}
+
+	return p, nil
+}
+
+// ParseBase64 decodes a base64-encoded packet
+// ParseBase64 mendekode packet dalam format base64
+func ParseBase64(encoded string) (*Packet, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid base64: %v", ErrInvalidPacket, err)
+	}
+	return Parse(raw)
+}
+
+// Decode accepts a packet in binary, base64 or hex form, as found in .psbt files
+// Decode menerima packet dalam format biner, base64 atau hex
+func Decode(data []byte) (*Packet, error) {
+	if bytes.HasPrefix(data, Magic) {
+		return Parse(data)
+	}
+
+	text := strings.TrimSpace(string(data))
+	if strings.HasPrefix(text, hex.EncodeToString(Magic)) {
+		raw, err := hex.DecodeString(text)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid hex: %v", ErrInvalidPacket, err)
+		}
+		return Parse(raw)
+	}
+	return ParseBase64(text)
+}
+
+func (in *PInput) parse(r *bytes.Reader) error {
+	seen := make(map[string]bool)
+	for {
+		key, value, err := readPair(r)
+		if err != nil {
+			return err
+		}
+		if key == nil {
+			return nil
+		}
+		if seen[string(key)] {
+			return fmt.Errorf("%w: 0x%x", ErrDuplicateKey, key)
+		}
+		seen[string(key)] = true
+
+		keyType, keyData := key[0], key[1:]
+		if isKnownInputKey(keyType) && len(keyData) != 0 {
+			return fmt.Errorf("%w: key 0x%02x has unexpected key data", ErrInvalidPacket, keyType)
+		}
+
+		switch keyType {
+		case inNonWitnessUTXO:
+			tx, err := txbuilder.ParseTransaction(value)
+			if err != nil {
+				return fmt.Errorf("%w: non-witness UTXO: %v", ErrInvalidPacket, err)
+			}
+			in.NonWitnessUTXO = tx
+		case inWitnessUTXO:
+			vr := bytes.NewReader(value)
+			amount, err := readUint64(vr)
+			if err != nil {
+				return fmt.Errorf("%w: witness UTXO: %v", ErrInvalidPacket, err)
+			}
+			script, err := readVarBytes(vr)
+			if err != nil || vr.Len() != 0 {
+				return fmt.Errorf("%w: malformed witness UTXO", ErrInvalidPacket)
+			}
+			in.WitnessUTXO = &txbuilder.TxOut{Value: int64(amount), PkScript: script}
+		case inPartialSig:
+			if !validPubKey(keyData) {
+				return fmt.Errorf("%w: partial signature public key", ErrInvalidPacket)
+			}
+			in.PartialSigs = append(in.PartialSigs, PartialSig{PubKey: keyData, Signature: value})
+		case inSighashType:
+			if len(value) != 4 {
+				return fmt.Errorf("%w: malformed sighash type", ErrInvalidPacket)
+			}
+			in.SighashType = txbuilder.SigHashType(binary.LittleEndian.Uint32(value))
+		case inRedeemScript:
+			in.RedeemScript = value
+		case inWitnessScript:
+			in.WitnessScript = value
+		case inBip32Derivation:
+			if !validPubKey(keyData) {
+				return fmt.Errorf("%w: BIP32 derivation public key", ErrInvalidPacket)
+			}
+			fingerprint, path, err := decodeOrigin(value)
+			if err != nil {
+				return err
+			}
+			in.Bip32Derivation = append(in.Bip32Derivation, Bip32Derivation{PubKey: keyData, Fingerprint: fingerprint, Path: path})
+		case inFinalScriptSig:
+			in.FinalScriptSig = value
+		case inFinalScriptWitness:
+			witness, err := decodeWitness(value)
+			if err != nil {
+				return err
+			}
+			in.FinalScriptWitness = witness
+		case inTaprootKeySig:
+			if len(value) != 64 && len(value) != 65 {
+				return fmt.Errorf("%w: Taproot key signature must be 64 or 65 bytes", ErrInvalidPacket)
+			}
+			in.TaprootKeySig = value
+		case inTaprootBip32:
+			d, err := decodeTaprootOrigin(keyData, value)
+			if err != nil {
+				return err
+			}
+			in.TaprootBip32Derivation = append(in.TaprootBip32Derivation, d)
+		case inTaprootInternalKey:
+			if len(value) != 32 {
+				return fmt.Errorf("%w: Taproot internal key must be 32 bytes", ErrInvalidPacket)
+			}
+			in.TaprootInternalKey = value
+		default:
+			in.Unknowns = append(in.Unknowns, Unknown{Key: key, Value: value})
+		}
+	}
+}
+
+func (out *POutput) parse(r *bytes.Reader) error {
+	seen := make(map[string]bool)
+	for {
+		key, value, err := readPair(r)
+		if err != nil {
+			return err
+		}
+		if key == nil {
+			return nil
+		}
+		if seen[string(key)] {
+			return fmt.Errorf("%w: 0x%x", ErrDuplicateKey, key)
+		}
+		seen[string(key)] = true
+
+		keyType, keyData := key[0], key[1:]
+		if isKnownOutputKey(keyType) && len(keyData) != 0 {
+			return fmt.Errorf("%w: key 0x%02x has unexpected key data", ErrInvalidPacket, keyType)
+		}
+
+		switch keyType {
+		case outRedeemScript:
+			out.RedeemScript = value
+		case outWitnessScript:
+			out.WitnessScript = value
+		case outBip32Derivation:
+			if !validPubKey(keyData) {
+				return fmt.Errorf("%w: BIP32 derivation public key", ErrInvalidPacket)
+			}
+			fingerprint, path, err := decodeOrigin(value)
+			if err != nil {
+				return err
+			}
+			out.Bip32Derivation = append(out.Bip32Derivation, Bip32Derivation{PubKey: keyData, Fingerprint: fingerprint, Path: path})
+		case outTaprootInternalKey:
+			if len(value) != 32 {
+				return fmt.Errorf("%w: Taproot internal key must be 32 bytes", ErrInvalidPacket)
+			}
+			out.TaprootInternalKey = value
+		case outTaprootBip32:
+			d, err := decodeTaprootOrigin(keyData, value)
+			if err != nil {
+				return err
+			}
+			out.TaprootBip32Derivation = append(out.TaprootBip32Derivation, d)
+		default:
+			out.Unknowns = append(out.Unknowns, Unknown{Key: key, Value: value})
+		}
+	}
+}
+
+// isKnownInputKey reports whether keyType is an input key this package interprets that carries no key data
+func isKnownInputKey(keyType byte) bool {
+	switch keyType {
+	case inNonWitnessUTXO, inWitnessUTXO, inSighashType, inRedeemScript, inWitnessScript,
+		inFinalScriptSig, inFinalScriptWitness, inTaprootKeySig, inTaprootInternalKey:
+		return true
+	}
+	return false
+}
+
+// isKnownOutputKey reports whether keyType is an output key this package interprets that carries no key data
+func isKnownOutputKey(keyType byte) bool {
+	switch keyType {
+	case outRedeemScript, outWitnessScript, outTaprootInternalKey:
+		return true
+	}
+	return false
+}
+
+// validPubKey reports whether b has the length of a compressed or uncompressed public key
+func validPubKey(b []byte) bool {
+	return (len(b) == 33 && (b[0] == 0x02 || b[0] == 0x03)) || (len(b) == 65 && b[0] == 0x04)
+}
+
+// encodeOrigin encodes a key origin: master fingerprint followed by the path indices
+func encodeOrigin(fingerprint [4]byte, path []uint32) []byte {
+	out := append([]byte(nil), fingerprint[:]...)
+	for _, index := range path {
+		out = append(out, uint32Bytes(index)...)
+	}
+	return out
+}
+
+func decodeOrigin(value []byte) ([4]byte, []uint32, error) {
+	var fingerprint [4]byte
+	if len(value) < 4 || len(value)%4 != 0 {
+		return fingerprint, nil, fmt.Errorf("%w: malformed key origin", ErrInvalidPacket)
+	}
+	copy(fingerprint[:], value[:4])
+
+	path := make([]uint32, 0, len(value)/4-1)
+	for i := 4; i < len(value); i += 4 {
+		path = append(path, binary.LittleEndian.Uint32(value[i:i+4]))
+	}
+	return fingerprint, path, nil
+}
+
+func encodeTaprootOrigin(d TaprootBip32Derivation) []byte {
+	var buf bytes.Buffer
+	writeVarInt(&buf, uint64(len(d.LeafHashes)))
+	for _, leaf := range d.LeafHashes {
+		buf.Write(leaf)
+	}
+	buf.Write(encodeOrigin(d.Fingerprint, d.Path))
+	return buf.Bytes()
+}
+
+func decodeTaprootOrigin(keyData, value []byte) (TaprootBip32Derivation, error) {
+	d := TaprootBip32Derivation{XOnlyPubKey: keyData}
+	if len(keyData) != 32 {
+		return d, fmt.Errorf("%w: Taproot derivation key must be 32 bytes", ErrInvalidPacket)
+	}
+
+	r := bytes.NewReader(value)
+	count, err := readVarInt(r)
+	if err != nil || count > uint64(r.Len()/32) {
+		return d, fmt.Errorf("%w: malformed Taproot leaf hashes", ErrInvalidPacket)
+	}
+	for i := uint64(0); i < count; i++ {
+		leaf := make([]byte, 32)
+		if _, err := io.ReadFull(r, leaf); err != nil {
+			return d, err
+		}
+		d.LeafHashes = append(d.LeafHashes, leaf)
+	}
+
+	rest := make([]byte, r.Len())
+	_, _ = io.ReadFull(r, rest)
+	d.Fingerprint, d.Path, err = decodeOrigin(rest)
+	return d, err
+}
+
+func encodeWitness(witness [][]byte) []byte {
+	var buf bytes.Buffer
+	writeVarInt(&buf, uint64(len(witness)))
+	for _, item := range witness {
+		writeVarBytes(&buf, item)
+	}
+	return buf.Bytes()
+}
+
+func decodeWitness(value []byte) ([][]byte, error) {
+	r := bytes.NewReader(value)
+	count, err := readVarInt(r)
+	if err != nil || count > uint64(len(value)) {
+		return nil, fmt.Errorf("%w: malformed final witness", ErrInvalidPacket)
+	}
+
+	witness := make([][]byte, 0, count)
+	for i := uint64(0); i < count; i++ {
+		item, err := readVarBytes(r)
+		if err != nil {
+			return nil, fmt.Errorf("%w: malformed final witness", ErrInvalidPacket)
+		}
+		witness = append(witness, item)
+	}
+	if r.Len() != 0 {
+		return nil, fmt.Errorf("%w: malformed final witness", ErrInvalidPacket)
+	}
+	return witness, nil
+}
+
+func writeUnknowns(buf *bytes.Buffer, unknowns []Unknown) {
+	for _, u := range unknowns {
+		writePair(buf, u.Key, u.Value)
+	}
+}
+
+func writePair(buf *bytes.Buffer, key, value []byte) {
+	writeVarBytes(buf, key)
+	writeVarBytes(buf, value)
+}
+
+// readPair reads one key-value pair; a nil key marks the end of a map
+func readPair(r *bytes.Reader) ([]byte, []byte, error) {
+	key, err := readVarBytes(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidPacket, err)
+	}
+	if len(key) == 0 {
+		return nil, nil, nil
+	}
+
+	value, err := readVarBytes(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidPacket, err)
+	}
+	return key, value, nil
+}
+
+func uint32Bytes(v uint32) []byte {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], v)
+	return b[:]
+}
+
+func uint64Bytes(v uint64) []byte {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	return b[:]
+}
+
+func writeVarInt(w *bytes.Buffer, v uint64) {
+	switch {
+	case v < 0xfd:
+		w.WriteByte(byte(v))
+	case v <= 0xffff:
+		w.WriteByte(0xfd)
+		var b [2]byte
+		binary.LittleEndian.PutUint16(b[:], uint16(v))
+		w.Write(b[:])
+	case v <= 0xffffffff:
+		w.WriteByte(0xfe)
+		w.Write(uint32Bytes(uint32(v)))
+	default:
+		w.WriteByte(0xff)
+		w.Write(uint64Bytes(v))
+	}
+}
+
+func writeVarBytes(w *bytes.Buffer, b []byte) {
+	writeVarInt(w, uint64(len(b)))
+	w.Write(b)
+}
+
+func readUint64(r *bytes.Reader) (uint64, error) {
+	var b [8]byte
+	if _, err := io.ReadFull(r, b[:]); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(b[:]), nil
+}
+
+func readVarInt(r *bytes.Reader) (uint64, error) {
+	prefix, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+
+	switch prefix {
+	case 0xfd:
+		var b [2]byte
+		if _, err := io.ReadFull(r, b[:]); err != nil {
+			return 0, err
+		}
+		return uint64(binary.LittleEndian.Uint16(b[:])), nil
+	case 0xfe:
+		var b [4]byte
+		if _, err := io.ReadFull(r, b[:]); err != nil {
+			return 0, err
+		}
+		return uint64(binary.LittleEndian.Uint32(b[:])), nil
+	case 0xff:
+		return readUint64(r)
+	default:
+		return uint64(prefix), nil
+	}
+}
+
+func readVarBytes(r *bytes.Reader) ([]byte, error) {
+	length, err := readVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+	if length > uint64(r.Len()) {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	b := make([]byte, length)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
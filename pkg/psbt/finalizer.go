@@ -0,0 +1,302 @@
+package psbt
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/dhfai/go-wallet/pkg/txbuilder"
+)
+
+// Combine merges packets for the same transaction into one (Combiner role),
+// e.g. after each cosigner signed their own copy
+// Combine menggabungkan beberapa packet untuk transaksi yang sama (peran Combiner)
+func Combine(packets ...*Packet) (*Packet, error) {
+	if len(packets) == 0 {
+		return nil, fmt.Errorf("%w: nothing to combine", ErrInvalidPacket)
+	}
+
+	// Work on a copy so the first packet is left untouched
+	raw, err := packets[0].Serialize()
+	if err != nil {
+		return nil, err
+	}
+	combined, err := Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	txID := combined.UnsignedTx.TxID()
+	for _, other := range packets[1:] {
+		if other.UnsignedTx == nil || other.UnsignedTx.TxID() != txID {
+			return nil, ErrDifferentTransactions
+		}
+
+		for _, xpub := range other.XPubs {
+			if !containsXPub(combined.XPubs, xpub) {
+				combined.XPubs = append(combined.XPubs, xpub)
+			}
+		}
+		combined.Unknowns = mergeUnknowns(combined.Unknowns, other.Unknowns)
+
+		for i := range combined.Inputs {
+			combined.Inputs[i].merge(&other.Inputs[i])
+		}
+		for i := range combined.Outputs {
+			combined.Outputs[i].merge(&other.Outputs[i])
+		}
+	}
+
+	return combined, nil
+}
+
+func (in *PInput) merge(other *PInput) {
+	if in.NonWitnessUTXO == nil {
+		in.NonWitnessUTXO = other.NonWitnessUTXO
+	}
+	if in.WitnessUTXO == nil {
+		in.WitnessUTXO = other.WitnessUTXO
+	}
+	for _, sig := range other.PartialSigs {
+		if !in.hasPartialSig(sig.PubKey) {
+			in.PartialSigs = append(in.PartialSigs, sig)
+		}
+	}
+	if in.SighashType == 0 {
+		in.SighashType = other.SighashType
+	}
+	if in.RedeemScript == nil {
+		in.RedeemScript = other.RedeemScript
+	}
+	if in.WitnessScript == nil {
+		in.WitnessScript = other.WitnessScript
+	}
+	for _, d := range other.Bip32Derivation {
+		if !containsDerivation(in.Bip32Derivation, d.PubKey) {
+			in.Bip32Derivation = append(in.Bip32Derivation, d)
+		}
+	}
+	if in.FinalScriptSig == nil {
+		in.FinalScriptSig = other.FinalScriptSig
+	}
+	if in.FinalScriptWitness == nil {
+		in.FinalScriptWitness = other.FinalScriptWitness
+	}
+	if in.TaprootKeySig == nil {
+		in.TaprootKeySig = other.TaprootKeySig
+	}
+	for _, d := range other.TaprootBip32Derivation {
+		if !containsTaprootDerivation(in.TaprootBip32Derivation, d.XOnlyPubKey) {
+			in.TaprootBip32Derivation = append(in.TaprootBip32Derivation, d)
+		}
+	}
+	if in.TaprootInternalKey == nil {
+		in.TaprootInternalKey = other.TaprootInternalKey
+	}
+	in.Unknowns = mergeUnknowns(in.Unknowns, other.Unknowns)
+}
+
+func (out *POutput) merge(other *POutput) {
+	if out.RedeemScript == nil {
+		out.RedeemScript = other.RedeemScript
+	}
+	if out.WitnessScript == nil {
+		out.WitnessScript = other.WitnessScript
+	}
+	for _, d := range other.Bip32Derivation {
+		if !containsDerivation(out.Bip32Derivation, d.PubKey) {
+			out.Bip32Derivation = append(out.Bip32Derivation, d)
+		}
+	}
+	if out.TaprootInternalKey == nil {
+		out.TaprootInternalKey = other.TaprootInternalKey
+	}
+	for _, d := range other.TaprootBip32Derivation {
+		if !containsTaprootDerivation(out.TaprootBip32Derivation, d.XOnlyPubKey) {
+			out.TaprootBip32Derivation = append(out.TaprootBip32Derivation, d)
+		}
+	}
+	out.Unknowns = mergeUnknowns(out.Unknowns, other.Unknowns)
+}
+
+// Finalize builds the final scriptSig and witness of every input (Finalizer role)
+// Finalize menyusun scriptSig dan witness final untuk setiap input (peran Finalizer)
+func (p *Packet) Finalize() error {
+	for i := range p.Inputs {
+		if err := p.FinalizeInput(i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FinalizeInput builds the final scriptSig and witness of input i from its
+// signatures and drops the data only signers need
+// FinalizeInput menyusun scriptSig dan witness final untuk input i
+func (p *Packet) FinalizeInput(i int) error {
+	utxo, err := p.InputUTXO(i)
+	if err != nil {
+		return err
+	}
+
+	in := &p.Inputs[i]
+	if in.IsFinalized() {
+		return nil
+	}
+
+	script := utxo.PkScript
+	var scriptSig []byte
+	if txbuilder.ClassifyScript(script) == txbuilder.ScriptP2SH {
+		if in.RedeemScript == nil {
+			return fmt.Errorf("%w: input %d needs its redeem script", ErrIncomplete, i)
+		}
+		scriptSig = txbuilder.PushData(in.RedeemScript)
+		script = in.RedeemScript
+	}
+
+	var witness [][]byte
+	switch txbuilder.ClassifyScript(script) {
+	case txbuilder.ScriptP2WPKH:
+		sig, ok := in.sigForKeyHash(script[2:])
+		if !ok {
+			return fmt.Errorf("%w: input %d", ErrIncomplete, i)
+		}
+		witness = [][]byte{sig.Signature, sig.PubKey}
+
+	case txbuilder.ScriptP2WSH:
+		if in.WitnessScript == nil {
+			return fmt.Errorf("%w: input %d needs its witness script", ErrIncomplete, i)
+		}
+		threshold, pubKeys, err := txbuilder.ParseMultisigScript(in.WitnessScript)
+		if err != nil {
+			return fmt.Errorf("%w: input %d: %v", ErrUnsupportedScript, i, err)
+		}
+
+		// OP_CHECKMULTISIG pops one extra item and expects signatures in key order
+		witness = [][]byte{{}}
+		for _, pubKey := range pubKeys {
+			if len(witness) == threshold+1 {
+				break
+			}
+			for _, sig := range in.PartialSigs {
+				if bytes.Equal(sig.PubKey, pubKey) {
+					witness = append(witness, sig.Signature)
+					break
+				}
+			}
+		}
+		if len(witness) < threshold+1 {
+			return fmt.Errorf("%w: input %d has %d of %d signatures", ErrIncomplete, i, len(witness)-1, threshold)
+		}
+		witness = append(witness, in.WitnessScript)
+
+	case txbuilder.ScriptP2PKH:
+		if scriptSig != nil {
+			return fmt.Errorf("%w: input %d is P2SH-wrapped P2PKH", ErrUnsupportedScript, i)
+		}
+		sig, ok := in.sigForKeyHash(script[3:23])
+		if !ok {
+			return fmt.Errorf("%w: input %d", ErrIncomplete, i)
+		}
+		scriptSig = append(txbuilder.PushData(sig.Signature), txbuilder.PushData(sig.PubKey)...)
+
+	case txbuilder.ScriptP2TR:
+		if in.TaprootKeySig == nil {
+			return fmt.Errorf("%w: input %d", ErrIncomplete, i)
+		}
+		witness = [][]byte{in.TaprootKeySig}
+
+	default:
+		return fmt.Errorf("%w: input %d is %s", ErrUnsupportedScript, i, txbuilder.ClassifyScript(script))
+	}
+
+	// Only the UTXOs, final scripts and unknown pairs remain after finalizing
+	*in = PInput{
+		NonWitnessUTXO:     in.NonWitnessUTXO,
+		WitnessUTXO:        in.WitnessUTXO,
+		FinalScriptSig:     scriptSig,
+		FinalScriptWitness: witness,
+		Unknowns:           in.Unknowns,
+	}
+
+	return nil
+}
+
+// Extract returns the signed network transaction of a finalized packet (Extractor role)
+// Extract mengembalikan transaksi bertanda tangan dari packet yang sudah final (peran Extractor)
+func (p *Packet) Extract() (*txbuilder.Transaction, error) {
+	tx := p.UnsignedTx.Copy()
+	for i := range p.Inputs {
+		in := &p.Inputs[i]
+		if !in.IsFinalized() {
+			return nil, fmt.Errorf("%w: input %d", ErrNotFinalized, i)
+		}
+		tx.Inputs[i].ScriptSig = append([]byte(nil), in.FinalScriptSig...)
+		tx.Inputs[i].Witness = nil
+		for _, item := range in.FinalScriptWitness {
+			tx.Inputs[i].Witness = append(tx.Inputs[i].Witness, append([]byte(nil), item...))
+		}
+	}
+	return tx, nil
+}
+
+// sigForKeyHash returns the partial signature by the key hashing to pubKeyHash
+func (in *PInput) sigForKeyHash(pubKeyHash []byte) (PartialSig, bool) {
+	for _, sig := range in.PartialSigs {
+		if bytes.Equal(bc.Hash160(sig.PubKey), pubKeyHash) {
+			return sig, true
+		}
+	}
+	return PartialSig{}, false
+}
+
+func (in *PInput) hasPartialSig(pubKey []byte) bool {
+	for _, sig := range in.PartialSigs {
+		if bytes.Equal(sig.PubKey, pubKey) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsDerivation(derivations []Bip32Derivation, pubKey []byte) bool {
+	for _, d := range derivations {
+		if bytes.Equal(d.PubKey, pubKey) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsTaprootDerivation(derivations []TaprootBip32Derivation, xOnlyPubKey []byte) bool {
+	for _, d := range derivations {
+		if bytes.Equal(d.XOnlyPubKey, xOnlyPubKey) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsXPub(xpubs []XPub, xpub XPub) bool {
+	for _, existing := range xpubs {
+		if bytes.Equal(existing.ExtendedKey, xpub.ExtendedKey) {
+			return true
+		}
+	}
+	return false
+}
+
+func mergeUnknowns(unknowns, others []Unknown) []Unknown {
+	for _, other := range others {
+		found := false
+		for _, u := range unknowns {
+			if bytes.Equal(u.Key, other.Key) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			unknowns = append(unknowns, other)
+		}
+	}
+	return unknowns
+}
@@ -0,0 +1,178 @@
+// Package psbt implements Partially Signed Bitcoin Transactions (BIP174).
+// A Packet carries an unsigned transaction together with the data each
+// signer needs, so creating, signing and broadcasting a transaction can
+// happen on different machines.
+//
+// Package psbt mengimplementasikan Partially Signed Bitcoin Transaction (BIP174).
+package psbt
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dhfai/go-wallet/pkg/txbuilder"
+)
+
+// Magic is the prefix of every serialized PSBT: "psbt" followed by 0xff
+var Magic = []byte{0x70, 0x73, 0x62, 0x74, 0xff}
+
+var (
+	ErrInvalidMagic          = errors.New("psbt: invalid magic bytes")
+	ErrInvalidPacket         = errors.New("psbt: invalid packet")
+	ErrDuplicateKey          = errors.New("psbt: duplicate key")
+	ErrMissingUTXO           = errors.New("psbt: input is missing the output it spends")
+	ErrKeyMismatch           = errors.New("psbt: key does not control the input")
+	ErrUnsupportedScript     = errors.New("psbt: unsupported script type")
+	ErrIncomplete            = errors.New("psbt: input is not fully signed")
+	ErrNotFinalized          = errors.New("psbt: packet is not finalized")
+	ErrDifferentTransactions = errors.New("psbt: packets are for different transactions")
+)
+
+// Packet is a partially signed transaction
+// Packet adalah transaksi yang ditandatangani sebagian
+type Packet struct {
+	UnsignedTx *txbuilder.Transaction // Transaction with empty scriptSigs and witnesses
+	Version    uint32                 // PSBT version, always 0
+	XPubs      []XPub                 // Extended public keys used by the signers
+	Inputs     []PInput               // One per transaction input
+	Outputs    []POutput              // One per transaction output
+	Unknowns   []Unknown              // Global pairs this package does not interpret
+}
+
+// PInput holds what is known about the output spent by a transaction input
+// PInput berisi data tentang output yang dibelanjakan oleh input transaksi
+type PInput struct {
+	NonWitnessUTXO         *txbuilder.Transaction // Full previous transaction
+	WitnessUTXO            *txbuilder.TxOut       // Previous output, enough for SegWit inputs
+	PartialSigs            []PartialSig
+	SighashType            txbuilder.SigHashType // Zero when unset: SIGHASH_ALL, or SIGHASH_DEFAULT for Taproot
+	RedeemScript           []byte
+	WitnessScript          []byte
+	Bip32Derivation        []Bip32Derivation
+	FinalScriptSig         []byte
+	FinalScriptWitness     [][]byte
+	TaprootKeySig          []byte
+	TaprootBip32Derivation []TaprootBip32Derivation
+	TaprootInternalKey     []byte
+	Unknowns               []Unknown
+}
+
+// POutput holds what is known about a transaction output, mainly so a signer
+// can recognise its own change
+// POutput berisi data tentang output transaksi, terutama untuk mengenali kembalian
+type POutput struct {
+	RedeemScript           []byte
+	WitnessScript          []byte
+	Bip32Derivation        []Bip32Derivation
+	TaprootInternalKey     []byte
+	TaprootBip32Derivation []TaprootBip32Derivation
+	Unknowns               []Unknown
+}
+
+// PartialSig is an ECDSA signature, with its sighash byte, by one public key
+type PartialSig struct {
+	PubKey    []byte // Compressed public key
+	Signature []byte // DER signature followed by the sighash byte
+}
+
+// Bip32Derivation records the BIP32 origin of a public key
+type Bip32Derivation struct {
+	PubKey      []byte
+	Fingerprint [4]byte  // Fingerprint of the master key
+	Path        []uint32 // Derivation path from the master key
+}
+
+// TaprootBip32Derivation records the BIP32 origin of an x-only public key
+type TaprootBip32Derivation struct {
+	XOnlyPubKey []byte
+	LeafHashes  [][]byte // Script leaves the key appears in; empty for the key path
+	Fingerprint [4]byte
+	Path        []uint32
+}
+
+// XPub is a global extended public key with its origin
+type XPub struct {
+	ExtendedKey []byte // 78-byte serialized extended public key
+	Fingerprint [4]byte
+	Path        []uint32
+}
+
+// Unknown is a key-value pair kept as-is so it survives a round trip
+type Unknown struct {
+	Key   []byte
+	Value []byte
+}
+
+// New creates a packet for an unsigned transaction (Creator role)
+// New membuat packet untuk transaksi yang belum ditandatangani (peran Creator)
+func New(tx *txbuilder.Transaction) (*Packet, error) {
+	if tx == nil || len(tx.Inputs) == 0 {
+		return nil, fmt.Errorf("%w: transaction has no inputs", ErrInvalidPacket)
+	}
+	for i, in := range tx.Inputs {
+		if len(in.ScriptSig) > 0 || len(in.Witness) > 0 {
+			return nil, fmt.Errorf("%w: input %d is already signed", ErrInvalidPacket, i)
+		}
+	}
+
+	return &Packet{
+		UnsignedTx: tx.Copy(),
+		Inputs:     make([]PInput, len(tx.Inputs)),
+		Outputs:    make([]POutput, len(tx.Outputs)),
+	}, nil
+}
+
+// IsFinalized reports whether the input has its final scriptSig or witness
+func (in *PInput) IsFinalized() bool {
+	return in.FinalScriptSig != nil || in.FinalScriptWitness != nil
+}
+
+// IsComplete reports whether every input is finalized
+// IsComplete melaporkan apakah semua input sudah final
+func (p *Packet) IsComplete() bool {
+	for i := range p.Inputs {
+		if !p.Inputs[i].IsFinalized() {
+			return false
+		}
+	}
+	return true
+}
+
+// InputUTXO returns the output spent by input i
+// InputUTXO mengembalikan output yang dibelanjakan oleh input i
+func (p *Packet) InputUTXO(i int) (*txbuilder.TxOut, error) {
+	if i < 0 || i >= len(p.Inputs) {
+		return nil, fmt.Errorf("input index %d out of range", i)
+	}
+
+	in := &p.Inputs[i]
+	if in.WitnessUTXO != nil {
+		return in.WitnessUTXO, nil
+	}
+	if in.NonWitnessUTXO != nil {
+		index := p.UnsignedTx.Inputs[i].PrevIndex
+		if int(index) >= len(in.NonWitnessUTXO.Outputs) {
+			return nil, fmt.Errorf("%w: input %d spends output %d of a transaction with %d outputs",
+				ErrInvalidPacket, i, index, len(in.NonWitnessUTXO.Outputs))
+		}
+		return in.NonWitnessUTXO.Outputs[index], nil
+	}
+	return nil, fmt.Errorf("%w: input %d", ErrMissingUTXO, i)
+}
+
+// Fee returns the fee paid by the transaction in satoshis; every input must carry its UTXO
+// Fee mengembalikan fee transaksi dalam satoshi
+func (p *Packet) Fee() (int64, error) {
+	var fee int64
+	for i := range p.Inputs {
+		utxo, err := p.InputUTXO(i)
+		if err != nil {
+			return 0, err
+		}
+		fee += utxo.Value
+	}
+	for _, out := range p.UnsignedTx.Outputs {
+		fee -= out.Value
+	}
+	return fee, nil
+}
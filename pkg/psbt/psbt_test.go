@@ -0,0 +1,212 @@
+package psbt
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/dhfai/go-wallet/pkg/chaincfg"
+	"github.com/dhfai/go-wallet/pkg/txbuilder"
+)
+
+// testKey returns the private key with scalar n
+func testKey(t *testing.T, n int) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := bc.PrivateKeyFromHex(fmt.Sprintf("%064x", n))
+	if err != nil {
+		t.Fatalf("PrivateKeyFromHex(%d): %v", n, err)
+	}
+	return key
+}
+
+// transfer serializes and parses p, as when a packet is passed to another signer
+func transfer(t *testing.T, p *Packet) *Packet {
+	t.Helper()
+	raw, err := p.Serialize()
+	if err != nil {
+		t.Fatalf("Serialize: %v", err)
+	}
+	parsed, err := Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	return parsed
+}
+
+// verifyECDSA checks a DER signature with its sighash byte against hash and pubKey
+func verifyECDSA(t *testing.T, hash, sig, pubKey []byte) {
+	t.Helper()
+	ok, err := bc.VerifySignature(hex.EncodeToString(hash), hex.EncodeToString(sig[:len(sig)-1]), hex.EncodeToString(pubKey))
+	if err != nil || !ok {
+		t.Errorf("signature %x does not verify for key %x: %v", sig, pubKey, err)
+	}
+}
+
+func TestSingleSigRoundTrip(t *testing.T) {
+	segwitKey, taprootKey := testKey(t, 11), testKey(t, 12)
+	segwitPubKey := bc.SerializeCompressed(&segwitKey.PublicKey)
+	outputKey, err := bc.TaprootOutputKey(bc.SerializeCompressed(&taprootKey.PublicKey))
+	if err != nil {
+		t.Fatalf("TaprootOutputKey: %v", err)
+	}
+
+	// Creator: a P2WPKH and a Taproot input paying one output
+	builder := txbuilder.NewBuilder(&chaincfg.MainNetParams)
+	if err := builder.AddInput(fmt.Sprintf("%064x", 1), 0, 60000, txbuilder.WitnessScript(0, bc.Hash160(segwitPubKey))); err != nil {
+		t.Fatalf("AddInput: %v", err)
+	}
+	if err := builder.AddInput(fmt.Sprintf("%064x", 2), 1, 40000, txbuilder.WitnessScript(1, outputKey)); err != nil {
+		t.Fatalf("AddInput: %v", err)
+	}
+	if err := builder.AddOutput("bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", 98000); err != nil {
+		t.Fatalf("AddOutput: %v", err)
+	}
+
+	packet, err := New(builder.Transaction())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	for i := range packet.Inputs {
+		if err := packet.SetWitnessUTXO(i, builder.PrevOut(i)); err != nil {
+			t.Fatalf("SetWitnessUTXO(%d): %v", i, err)
+		}
+	}
+	if fee, err := packet.Fee(); err != nil || fee != 2000 {
+		t.Errorf("Fee = %d, %v, want 2000", fee, err)
+	}
+
+	// Signers: each key signs its own copy; a key that does not control an input is refused
+	segwitCopy, taprootCopy := transfer(t, packet), transfer(t, packet)
+	if err := segwitCopy.Sign(1, segwitKey); !errors.Is(err, ErrKeyMismatch) {
+		t.Errorf("Sign with the wrong key: error = %v, want %v", err, ErrKeyMismatch)
+	}
+	if err := segwitCopy.Sign(0, segwitKey); err != nil {
+		t.Fatalf("Sign(0): %v", err)
+	}
+	if err := taprootCopy.Sign(1, taprootKey); err != nil {
+		t.Fatalf("Sign(1): %v", err)
+	}
+	if err := transfer(t, segwitCopy).Finalize(); !errors.Is(err, ErrIncomplete) {
+		t.Errorf("Finalize with an unsigned input: error = %v, want %v", err, ErrIncomplete)
+	}
+
+	// Combiner, Finalizer and Extractor
+	combined, err := Combine(transfer(t, segwitCopy), transfer(t, taprootCopy))
+	if err != nil {
+		t.Fatalf("Combine: %v", err)
+	}
+	if err := combined.Finalize(); err != nil {
+		t.Fatalf("Finalize: %v", err)
+	}
+	if !combined.IsComplete() {
+		t.Error("finalized packet is not complete")
+	}
+	tx, err := transfer(t, combined).Extract()
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+
+	if tx.TxID() != packet.UnsignedTx.TxID() {
+		t.Errorf("extracted txid = %s, want %s", tx.TxID(), packet.UnsignedTx.TxID())
+	}
+
+	witness := tx.Inputs[0].Witness
+	if len(witness) != 2 || !bytes.Equal(witness[1], segwitPubKey) {
+		t.Fatalf("P2WPKH witness = %x, want [signature, public key]", witness)
+	}
+	sigHash, _ := txbuilder.WitnessV0SigHash(tx, 0, txbuilder.P2WPKHScriptCode(bc.Hash160(segwitPubKey)), 60000, txbuilder.SigHashAll)
+	verifyECDSA(t, sigHash, witness[0], segwitPubKey)
+
+	witness = tx.Inputs[1].Witness
+	if len(witness) != 1 || len(witness[0]) != 64 {
+		t.Fatalf("Taproot witness = %x, want one 64-byte signature", witness)
+	}
+	sigHash, _ = txbuilder.TaprootSigHash(tx, 1, []*txbuilder.TxOut{builder.PrevOut(0), builder.PrevOut(1)}, txbuilder.SigHashDefault)
+	if !bc.VerifySchnorr(sigHash, witness[0], outputKey) {
+		t.Errorf("Taproot signature %x does not verify", witness[0])
+	}
+}
+
+func TestMultisigRoundTrip(t *testing.T) {
+	keys := []*ecdsa.PrivateKey{testKey(t, 21), testKey(t, 22), testKey(t, 23)}
+	pubKeys := make([][]byte, len(keys))
+	for i, key := range keys {
+		pubKeys[i] = bc.SerializeCompressed(&key.PublicKey)
+	}
+
+	// wsh(multi(2,...)) with the keys in the order given
+	witnessScript, err := txbuilder.UnsortedMultisigScript(2, pubKeys)
+	if err != nil {
+		t.Fatalf("UnsortedMultisigScript: %v", err)
+	}
+
+	builder := txbuilder.NewBuilder(&chaincfg.MainNetParams)
+	if err := builder.AddInput(fmt.Sprintf("%064x", 3), 2, 100000, txbuilder.P2WSHScript(witnessScript)); err != nil {
+		t.Fatalf("AddInput: %v", err)
+	}
+	if err := builder.AddOutput("bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", 99000); err != nil {
+		t.Fatalf("AddOutput: %v", err)
+	}
+
+	packet, err := New(builder.Transaction())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := packet.SetWitnessUTXO(0, builder.PrevOut(0)); err != nil {
+		t.Fatalf("SetWitnessUTXO: %v", err)
+	}
+	packet.Inputs[0].WitnessScript = witnessScript
+
+	// The first and third cosigners sign; one signature is not enough
+	first, third := transfer(t, packet), transfer(t, packet)
+	if err := first.Sign(0, keys[0]); err != nil {
+		t.Fatalf("Sign by cosigner 1: %v", err)
+	}
+	if err := third.Sign(0, keys[2]); err != nil {
+		t.Fatalf("Sign by cosigner 3: %v", err)
+	}
+	if err := first.Sign(0, testKey(t, 24)); !errors.Is(err, ErrKeyMismatch) {
+		t.Errorf("Sign by an outsider: error = %v, want %v", err, ErrKeyMismatch)
+	}
+	if err := transfer(t, first).Finalize(); !errors.Is(err, ErrIncomplete) {
+		t.Errorf("Finalize with 1 of 2 signatures: error = %v, want %v", err, ErrIncomplete)
+	}
+
+	combined, err := Combine(transfer(t, third), transfer(t, first))
+	if err != nil {
+		t.Fatalf("Combine: %v", err)
+	}
+	if len(combined.Inputs[0].PartialSigs) != 2 {
+		t.Fatalf("combined packet has %d partial signatures, want 2", len(combined.Inputs[0].PartialSigs))
+	}
+	if err := combined.Finalize(); err != nil {
+		t.Fatalf("Finalize: %v", err)
+	}
+	tx, err := transfer(t, combined).Extract()
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+
+	if tx.TxID() != packet.UnsignedTx.TxID() {
+		t.Errorf("extracted txid = %s, want %s", tx.TxID(), packet.UnsignedTx.TxID())
+	}
+
+	// OP_CHECKMULTISIG: the dummy item, signatures in key order, then the script
+	witness := tx.Inputs[0].Witness
+	if len(witness) != 4 || len(witness[0]) != 0 || !bytes.Equal(witness[3], witnessScript) {
+		t.Fatalf("P2WSH witness = %x, want [empty, sig 1, sig 3, witness script]", witness)
+	}
+	sigHash, _ := txbuilder.WitnessV0SigHash(tx, 0, witnessScript, 100000, txbuilder.SigHashAll)
+	verifyECDSA(t, sigHash, witness[1], pubKeys[0])
+	verifyECDSA(t, sigHash, witness[2], pubKeys[2])
+
+	// A packet for another transaction cannot be combined
+	other := transfer(t, packet)
+	other.UnsignedTx.LockTime++
+	if _, err := Combine(first, other); !errors.Is(err, ErrDifferentTransactions) {
+		t.Errorf("Combine of different transactions: error = %v, want %v", err, ErrDifferentTransactions)
+	}
+}
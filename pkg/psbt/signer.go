@@ -0,0 +1,156 @@
+package psbt
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/dhfai/go-wallet/pkg/crypto"
+	"github.com/dhfai/go-wallet/pkg/txbuilder"
+)
+
+// bc provides hashing and signing; none of it depends on the network
+var bc = crypto.NewBitcoinCrypto()
+
+// Sign signs input i with privKey and stores the signature in the packet
+// (Signer role). P2PKH, P2WPKH, P2SH-P2WPKH, multisig P2WSH (native or nested)
+// and Taproot key-path inputs are supported; legacy inputs need the full
+// previous transaction and P2WSH inputs their witness script.
+// Sign menandatangani input i dengan privKey (peran Signer)
+func (p *Packet) Sign(i int, privKey *ecdsa.PrivateKey) error {
+	utxo, err := p.InputUTXO(i)
+	if err != nil {
+		return err
+	}
+
+	in := &p.Inputs[i]
+	if in.IsFinalized() {
+		return nil
+	}
+
+	pubKey := bc.SerializeCompressed(&privKey.PublicKey)
+	pubKeyHash := bc.Hash160(pubKey)
+
+	script := utxo.PkScript
+	if txbuilder.ClassifyScript(script) == txbuilder.ScriptP2SH {
+		if in.RedeemScript == nil {
+			return fmt.Errorf("%w: input %d needs its redeem script", ErrMissingUTXO, i)
+		}
+		if !bytes.Equal(script[2:22], bc.Hash160(in.RedeemScript)) {
+			return fmt.Errorf("%w: input %d redeem script does not match the output", ErrInvalidPacket, i)
+		}
+		script = in.RedeemScript
+	}
+
+	hashType := in.SighashType
+	if hashType == 0 {
+		hashType = txbuilder.SigHashAll
+	}
+
+	switch txbuilder.ClassifyScript(script) {
+	case txbuilder.ScriptP2WPKH:
+		if !bytes.Equal(script[2:], pubKeyHash) {
+			return fmt.Errorf("%w: input %d", ErrKeyMismatch, i)
+		}
+
+		sigHash, err := txbuilder.WitnessV0SigHash(p.UnsignedTx, i, txbuilder.P2WPKHScriptCode(pubKeyHash), utxo.Value, hashType)
+		if err != nil {
+			return err
+		}
+		in.addPartialSig(pubKey, append(bc.SignHash(sigHash, privKey), byte(hashType)))
+
+	case txbuilder.ScriptP2WSH:
+		if in.WitnessScript == nil {
+			return fmt.Errorf("%w: input %d needs its witness script", ErrMissingUTXO, i)
+		}
+		scriptHash := sha256.Sum256(in.WitnessScript)
+		if !bytes.Equal(script[2:], scriptHash[:]) {
+			return fmt.Errorf("%w: input %d witness script does not match the output", ErrInvalidPacket, i)
+		}
+
+		_, pubKeys, err := txbuilder.ParseMultisigScript(in.WitnessScript)
+		if err != nil {
+			return fmt.Errorf("%w: input %d: %v", ErrUnsupportedScript, i, err)
+		}
+		if !containsKey(pubKeys, pubKey) {
+			return fmt.Errorf("%w: input %d", ErrKeyMismatch, i)
+		}
+
+		sigHash, err := txbuilder.WitnessV0SigHash(p.UnsignedTx, i, in.WitnessScript, utxo.Value, hashType)
+		if err != nil {
+			return err
+		}
+		in.addPartialSig(pubKey, append(bc.SignHash(sigHash, privKey), byte(hashType)))
+
+	case txbuilder.ScriptP2PKH:
+		if !bytes.Equal(script[3:23], pubKeyHash) {
+			return fmt.Errorf("%w: input %d", ErrKeyMismatch, i)
+		}
+		if in.NonWitnessUTXO == nil {
+			return fmt.Errorf("%w: legacy input %d needs the full previous transaction", ErrMissingUTXO, i)
+		}
+
+		sigHash, err := txbuilder.LegacySigHash(p.UnsignedTx, i, script, hashType)
+		if err != nil {
+			return err
+		}
+		in.addPartialSig(pubKey, append(bc.SignHash(sigHash, privKey), byte(hashType)))
+
+	case txbuilder.ScriptP2TR:
+		outputKey, err := bc.TaprootOutputKey(pubKey)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(script[2:], outputKey) {
+			return fmt.Errorf("%w: input %d", ErrKeyMismatch, i)
+		}
+
+		// The Taproot sighash commits to every output being spent
+		prevOuts := make([]*txbuilder.TxOut, len(p.Inputs))
+		for j := range p.Inputs {
+			if prevOuts[j], err = p.InputUTXO(j); err != nil {
+				return fmt.Errorf("Taproot signing needs every input's UTXO: %w", err)
+			}
+		}
+
+		sigHash, err := txbuilder.TaprootSigHash(p.UnsignedTx, i, prevOuts, in.SighashType)
+		if err != nil {
+			return err
+		}
+		sig, err := bc.SignTaprootKeyPath(sigHash, privKey)
+		if err != nil {
+			return err
+		}
+		if in.SighashType != txbuilder.SigHashDefault {
+			sig = append(sig, byte(in.SighashType))
+		}
+		in.TaprootKeySig = sig
+
+	default:
+		return fmt.Errorf("%w: input %d is %s", ErrUnsupportedScript, i, txbuilder.ClassifyScript(script))
+	}
+
+	return nil
+}
+
+// addPartialSig stores sig for pubKey, replacing an earlier signature by the same key
+func (in *PInput) addPartialSig(pubKey, sig []byte) {
+	for j := range in.PartialSigs {
+		if bytes.Equal(in.PartialSigs[j].PubKey, pubKey) {
+			in.PartialSigs[j].Signature = sig
+			return
+		}
+	}
+	in.PartialSigs = append(in.PartialSigs, PartialSig{PubKey: pubKey, Signature: sig})
+}
+
+// containsKey reports whether pubKey is one of pubKeys
+func containsKey(pubKeys [][]byte, pubKey []byte) bool {
+	for _, key := range pubKeys {
+		if bytes.Equal(key, pubKey) {
+			return true
+		}
+	}
+	return false
+}
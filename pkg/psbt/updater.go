@@ -0,0 +1,77 @@
+package psbt
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/dhfai/go-wallet/pkg/txbuilder"
+)
+
+// SetWitnessUTXO records the output spent by input i (Updater role)
+// SetWitnessUTXO mencatat output yang dibelanjakan oleh input i (peran Updater)
+func (p *Packet) SetWitnessUTXO(i int, utxo *txbuilder.TxOut) error {
+	if i < 0 || i >= len(p.Inputs) {
+		return fmt.Errorf("input index %d out of range", i)
+	}
+	p.Inputs[i].WitnessUTXO = &txbuilder.TxOut{Value: utxo.Value, PkScript: append([]byte(nil), utxo.PkScript...)}
+	return nil
+}
+
+// SetNonWitnessUTXO records the full transaction whose output input i spends
+// SetNonWitnessUTXO mencatat transaksi lengkap yang outputnya dibelanjakan oleh input i
+func (p *Packet) SetNonWitnessUTXO(i int, prevTx *txbuilder.Transaction) error {
+	if i < 0 || i >= len(p.Inputs) {
+		return fmt.Errorf("input index %d out of range", i)
+	}
+
+	in := p.UnsignedTx.Inputs[i]
+	if prevTx.TxID() != txbuilder.FormatTxID(in.PrevTxID) {
+		return fmt.Errorf("%w: transaction %s is not spent by input %d", ErrInvalidPacket, prevTx.TxID(), i)
+	}
+	if int(in.PrevIndex) >= len(prevTx.Outputs) {
+		return fmt.Errorf("%w: transaction %s has no output %d", ErrInvalidPacket, prevTx.TxID(), in.PrevIndex)
+	}
+
+	p.Inputs[i].NonWitnessUTXO = prevTx.Copy()
+	return nil
+}
+
+// AddInputDerivation records the BIP32 origin of a key that can sign input i
+// AddInputDerivation mencatat asal BIP32 dari kunci yang dapat menandatangani input i
+func (p *Packet) AddInputDerivation(i int, d Bip32Derivation) error {
+	if i < 0 || i >= len(p.Inputs) {
+		return fmt.Errorf("input index %d out of range", i)
+	}
+	if !validPubKey(d.PubKey) {
+		return fmt.Errorf("%w: invalid public key", ErrInvalidPacket)
+	}
+
+	in := &p.Inputs[i]
+	for _, existing := range in.Bip32Derivation {
+		if bytes.Equal(existing.PubKey, d.PubKey) {
+			return nil
+		}
+	}
+	in.Bip32Derivation = append(in.Bip32Derivation, d)
+	return nil
+}
+
+// AddOutputDerivation records the BIP32 origin of a key output i pays to, marking it as change
+// AddOutputDerivation mencatat asal BIP32 dari kunci penerima output i
+func (p *Packet) AddOutputDerivation(i int, d Bip32Derivation) error {
+	if i < 0 || i >= len(p.Outputs) {
+		return fmt.Errorf("output index %d out of range", i)
+	}
+	if !validPubKey(d.PubKey) {
+		return fmt.Errorf("%w: invalid public key", ErrInvalidPacket)
+	}
+
+	out := &p.Outputs[i]
+	for _, existing := range out.Bip32Derivation {
+		if bytes.Equal(existing.PubKey, d.PubKey) {
+			return nil
+		}
+	}
+	out.Bip32Derivation = append(out.Bip32Derivation, d)
+	return nil
+}
@@ -0,0 +1,228 @@
+package txbuilder
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"fmt"
+
+	"github.com/dhfai/go-wallet/pkg/chaincfg"
+	"github.com/dhfai/go-wallet/pkg/crypto"
+	"github.com/dhfai/go-wallet/pkg/network"
+)
+
+// DustLimit is the smallest output value relayed by default policy for any standard script
+const DustLimit int64 = 546
+
+// Builder assembles and signs a transaction from wallet UTXOs
+// Builder menyusun dan menandatangani transaksi dari UTXO wallet
+type Builder struct {
+	params   *chaincfg.Params
+	crypto   *crypto.BitcoinCrypto
+	tx       *Transaction
+	prevOuts []*TxOut
+	sequence uint32
+}
+
+// NewBuilder creates a new Builder for a version 2 transaction paying to addresses of params.
+// Inputs signal replace-by-fee (BIP125) unless SetRBF(false) is called.
+// NewBuilder membuat Builder baru untuk transaksi versi 2
+func NewBuilder(params *chaincfg.Params) *Builder {
+	return &Builder{
+		params:   params,
+		crypto:   crypto.NewBitcoinCryptoForNetwork(params),
+		tx:       NewTransaction(),
+		sequence: RBFSequence,
+	}
+}
+
+// SetRBF sets whether the inputs signal BIP125 replace-by-fee
+// SetRBF mengatur apakah input menandakan replace-by-fee BIP125
+func (b *Builder) SetRBF(enabled bool) {
+	b.sequence = DefaultSequence
+	if enabled {
+		b.sequence = RBFSequence
+	}
+	for _, input := range b.tx.Inputs {
+		input.Sequence = b.sequence
+	}
+}
+
+// AddUTXO adds an explorer UTXO as an input; pkScript is the script of the output being spent
+// AddUTXO menambahkan UTXO dari explorer sebagai input
+func (b *Builder) AddUTXO(utxo network.UTXOInfo, pkScript []byte) error {
+	return b.AddInput(utxo.TxID, uint32(utxo.Vout), utxo.Value, pkScript)
+}
+
+// AddInput adds an input spending txid:vout worth value satoshis
+// AddInput menambahkan input yang membelanjakan txid:vout senilai value satoshi
+func (b *Builder) AddInput(txid string, vout uint32, value int64, pkScript []byte) error {
+	prevTxID, err := ParseTxID(txid)
+	if err != nil {
+		return err
+	}
+	if value <= 0 {
+		return fmt.Errorf("input %s:%d has non-positive value", txid, vout)
+	}
+
+	b.tx.Inputs = append(b.tx.Inputs, &TxIn{
+		PrevTxID:  prevTxID,
+		PrevIndex: vout,
+		Sequence:  b.sequence,
+	})
+	b.prevOuts = append(b.prevOuts, &TxOut{Value: value, PkScript: pkScript})
+
+	return nil
+}
+
+// AddOutput adds an output paying value satoshis to address
+// AddOutput menambahkan output yang membayar value satoshi ke address
+func (b *Builder) AddOutput(address string, value int64) error {
+	if value < DustLimit {
+		return fmt.Errorf("output value %d is below dust limit %d", value, DustLimit)
+	}
+
+	pkScript, err := PayToAddrScript(address, b.params)
+	if err != nil {
+		return err
+	}
+
+	b.tx.Outputs = append(b.tx.Outputs, &TxOut{Value: value, PkScript: pkScript})
+	return nil
+}
+
+// InputValue returns the total value of all inputs in satoshis
+func (b *Builder) InputValue() int64 {
+	var total int64
+	for _, prev := range b.prevOuts {
+		total += prev.Value
+	}
+	return total
+}
+
+// OutputValue returns the total value of all outputs in satoshis
+func (b *Builder) OutputValue() int64 {
+	var total int64
+	for _, out := range b.tx.Outputs {
+		total += out.Value
+	}
+	return total
+}
+
+// Fee returns the implied fee (inputs minus outputs) in satoshis
+func (b *Builder) Fee() int64 {
+	return b.InputValue() - b.OutputValue()
+}
+
+// Sign signs every input with privKey using SIGHASH_ALL
+// Sign menandatangani setiap input dengan privKey menggunakan SIGHASH_ALL
+func (b *Builder) Sign(privKey *ecdsa.PrivateKey) error {
+	if len(b.tx.Inputs) == 0 {
+		return fmt.Errorf("transaction has no inputs")
+	}
+
+	for i := range b.tx.Inputs {
+		if err := b.SignInput(i, privKey); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SignInput signs a single input with privKey using SIGHASH_ALL
+// SignInput menandatangani satu input dengan privKey menggunakan SIGHASH_ALL
+func (b *Builder) SignInput(i int, privKey *ecdsa.PrivateKey) error {
+	if i < 0 || i >= len(b.tx.Inputs) {
+		return fmt.Errorf("input index %d out of range", i)
+	}
+	if b.Fee() < 0 {
+		return fmt.Errorf("outputs exceed inputs by %d satoshis", -b.Fee())
+	}
+
+	pubKey := b.crypto.SerializeCompressed(&privKey.PublicKey)
+	pubKeyHash := b.crypto.Hash160(pubKey)
+	prev := b.prevOuts[i]
+
+	switch ClassifyScript(prev.PkScript) {
+	case ScriptP2WPKH:
+		if !bytes.Equal(prev.PkScript[2:], pubKeyHash) {
+			return fmt.Errorf("input %d is not controlled by the signing key", i)
+		}
+
+		sigHash, err := WitnessV0SigHash(b.tx, i, P2WPKHScriptCode(pubKeyHash), prev.Value, SigHashAll)
+		if err != nil {
+			return err
+		}
+		sig := append(b.crypto.SignHash(sigHash, privKey), byte(SigHashAll))
+
+		b.tx.Inputs[i].ScriptSig = nil
+		b.tx.Inputs[i].Witness = [][]byte{sig, pubKey}
+
+	case ScriptP2SH:
+		// Only P2SH-wrapped P2WPKH is supported
+		redeemScript := b.crypto.NestedSegWitRedeemScript(pubKeyHash)
+		if !bytes.Equal(prev.PkScript[2:22], b.crypto.Hash160(redeemScript)) {
+			return fmt.Errorf("input %d is not a P2SH-P2WPKH output of the signing key", i)
+		}
+
+		sigHash, err := WitnessV0SigHash(b.tx, i, P2WPKHScriptCode(pubKeyHash), prev.Value, SigHashAll)
+		if err != nil {
+			return err
+		}
+		sig := append(b.crypto.SignHash(sigHash, privKey), byte(SigHashAll))
+
+		b.tx.Inputs[i].ScriptSig = PushData(redeemScript)
+		b.tx.Inputs[i].Witness = [][]byte{sig, pubKey}
+
+	case ScriptP2TR:
+		outputKey, err := b.crypto.TaprootOutputKey(pubKey)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(prev.PkScript[2:], outputKey) {
+			return fmt.Errorf("input %d is not controlled by the signing key", i)
+		}
+
+		sigHash, err := TaprootSigHash(b.tx, i, b.prevOuts, SigHashDefault)
+		if err != nil {
+			return err
+		}
+		sig, err := b.crypto.SignTaprootKeyPath(sigHash, privKey)
+		if err != nil {
+			return err
+		}
+
+		b.tx.Inputs[i].ScriptSig = nil
+		b.tx.Inputs[i].Witness = [][]byte{sig}
+
+	case ScriptP2PKH:
+		if !bytes.Equal(prev.PkScript[3:23], pubKeyHash) {
+			return fmt.Errorf("input %d is not controlled by the signing key", i)
+		}
+
+		sigHash, err := LegacySigHash(b.tx, i, prev.PkScript, SigHashAll)
+		if err != nil {
+			return err
+		}
+		sig := append(b.crypto.SignHash(sigHash, privKey), byte(SigHashAll))
+
+		b.tx.Inputs[i].ScriptSig = append(PushData(sig), PushData(pubKey)...)
+		b.tx.Inputs[i].Witness = nil
+
+	default:
+		return fmt.Errorf("input %d: unsupported script type %s", i, ClassifyScript(prev.PkScript))
+	}
+
+	return nil
+}
+
+// PrevOut returns the output spent by input i
+func (b *Builder) PrevOut(i int) *TxOut {
+	return b.prevOuts[i]
+}
+
+// Transaction returns the transaction being built
+// Transaction mengembalikan transaksi yang sedang disusun
+func (b *Builder) Transaction() *Transaction {
+	return b.tx
+}
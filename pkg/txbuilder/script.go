@@ -0,0 +1,220 @@
+package txbuilder
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dhfai/go-wallet/pkg/chaincfg"
+	"github.com/dhfai/go-wallet/pkg/crypto"
+)
+
+// Script opcodes used by standard output templates
+const (
+	OpZero        byte = 0x00
+	OpPushData1   byte = 0x4c
+	OpOne         byte = 0x51
+	OpReturn      byte = 0x6a
+	OpDup         byte = 0x76
+	OpEqual       byte = 0x87
+	OpEqualVerify byte = 0x88
+	OpHash160     byte = 0xa9
+	OpCheckSig    byte = 0xac
+
+	OpCheckMultiSig byte = 0xae
+)
+
+// MaxMultisigKeys is the largest number of keys in a standard P2SH-wrapped multisig script
+const MaxMultisigKeys = 15
+
+// ScriptType identifies a standard output script template
+// ScriptType mengidentifikasi template script output standar
+type ScriptType string
+
+const (
+	ScriptP2PKH       ScriptType = "p2pkh"
+	ScriptP2SH        ScriptType = "p2sh"
+	ScriptP2WPKH      ScriptType = "p2wpkh"
+	ScriptP2WSH       ScriptType = "p2wsh"
+	ScriptP2TR        ScriptType = "p2tr"
+	ScriptNullData    ScriptType = "nulldata"
+	ScriptNonStandard ScriptType = "nonstandard"
+)
+
+// P2PKHScript returns OP_DUP OP_HASH160 <hash> OP_EQUALVERIFY OP_CHECKSIG
+func P2PKHScript(pubKeyHash []byte) []byte {
+	script := []byte{OpDup, OpHash160, byte(len(pubKeyHash))}
+	script = append(script, pubKeyHash...)
+	return append(script, OpEqualVerify, OpCheckSig)
+}
+
+// P2SHScript returns OP_HASH160 <hash> OP_EQUAL
+func P2SHScript(scriptHash []byte) []byte {
+	script := []byte{OpHash160, byte(len(scriptHash))}
+	script = append(script, scriptHash...)
+	return append(script, OpEqual)
+}
+
+// WitnessScript returns OP_n <program> for a SegWit output
+func WitnessScript(version byte, program []byte) []byte {
+	op := OpZero
+	if version > 0 {
+		op = OpOne + version - 1
+	}
+	return append([]byte{op, byte(len(program))}, program...)
+}
+
+// P2WSHScript returns the output script paying to the SHA256 hash of witnessScript
+func P2WSHScript(witnessScript []byte) []byte {
+	hash := sha256.Sum256(witnessScript)
+	return WitnessScript(0, hash[:])
+}
+
+// MultisigScript returns OP_m <pubkey>... OP_n OP_CHECKMULTISIG with the
+// public keys sorted lexicographically (BIP67)
+// MultisigScript mengembalikan script multisig m-of-n dengan kunci terurut (BIP67)
+func MultisigScript(threshold int, pubKeys [][]byte) ([]byte, error) {
+	sorted := make([][]byte, len(pubKeys))
+	copy(sorted, pubKeys)
+	sort.Slice(sorted, func(i, j int) bool { return bytes.Compare(sorted[i], sorted[j]) < 0 })
+
+	return UnsortedMultisigScript(threshold, sorted)
+}
+
+// UnsortedMultisigScript returns an m-of-n OP_CHECKMULTISIG script with the
+// public keys in the order given, as used by the multi() descriptor.
+// Uncompressed keys are only valid in P2SH redeem scripts, not witness scripts.
+// UnsortedMultisigScript mengembalikan script multisig dengan urutan kunci apa adanya
+func UnsortedMultisigScript(threshold int, pubKeys [][]byte) ([]byte, error) {
+	if len(pubKeys) == 0 || len(pubKeys) > MaxMultisigKeys {
+		return nil, fmt.Errorf("multisig needs 1 to %d keys, got %d", MaxMultisigKeys, len(pubKeys))
+	}
+	if threshold < 1 || threshold > len(pubKeys) {
+		return nil, fmt.Errorf("invalid multisig threshold %d of %d", threshold, len(pubKeys))
+	}
+
+	script := []byte{OpOne + byte(threshold) - 1}
+	for _, pubKey := range pubKeys {
+		if len(pubKey) != 33 && len(pubKey) != 65 {
+			return nil, fmt.Errorf("multisig keys must be 33 or 65 bytes, got %d", len(pubKey))
+		}
+		script = append(script, PushData(pubKey)...)
+	}
+	return append(script, OpOne+byte(len(pubKeys))-1, OpCheckMultiSig), nil
+}
+
+// ParseMultisigScript returns the threshold and public keys of an m-of-n OP_CHECKMULTISIG script
+// ParseMultisigScript mengembalikan threshold dan public key dari script multisig
+func ParseMultisigScript(script []byte) (int, [][]byte, error) {
+	if len(script) < 3 || script[len(script)-1] != OpCheckMultiSig {
+		return 0, nil, fmt.Errorf("not a multisig script")
+	}
+
+	threshold := int(script[0]) - int(OpOne) + 1
+	count := int(script[len(script)-2]) - int(OpOne) + 1
+	if count < 1 || count > MaxMultisigKeys || threshold < 1 || threshold > count {
+		return 0, nil, fmt.Errorf("not a multisig script")
+	}
+
+	pubKeys := make([][]byte, 0, count)
+	body := script[1 : len(script)-2]
+	for len(body) > 0 {
+		size := int(body[0])
+		if size != 33 || len(body) < 1+size {
+			return 0, nil, fmt.Errorf("not a multisig script")
+		}
+		pubKeys = append(pubKeys, body[1:1+size])
+		body = body[1+size:]
+	}
+	if len(pubKeys) != count {
+		return 0, nil, fmt.Errorf("not a multisig script")
+	}
+
+	return threshold, pubKeys, nil
+}
+
+// PayToAddrScript returns the output script that pays to an address of the given network
+// PayToAddrScript mengembalikan script output yang membayar ke alamat pada jaringan tertentu
+func PayToAddrScript(address string, params *chaincfg.Params) ([]byte, error) {
+	bc := crypto.NewBitcoinCryptoForNetwork(params)
+
+	if strings.HasPrefix(strings.ToLower(address), params.Bech32HRP+"1") {
+		version, program, err := bc.DecodeSegWitAddress(params.Bech32HRP, address)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address %s: %w", address, err)
+		}
+		return WitnessScript(version, program), nil
+	}
+
+	version, payload, err := bc.DecodeBase58Check(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address %s: %w", address, err)
+	}
+	if len(payload) != 20 {
+		return nil, fmt.Errorf("invalid address %s: unexpected payload length", address)
+	}
+
+	switch version {
+	case params.PubKeyHashAddrID:
+		return P2PKHScript(payload), nil
+	case params.ScriptHashAddrID:
+		return P2SHScript(payload), nil
+	default:
+		return nil, fmt.Errorf("invalid address %s: not a %s address (version byte 0x%02x)", address, params.Name, version)
+	}
+}
+
+// ScriptAddress returns the address of params an output script pays to
+// ScriptAddress mengembalikan alamat yang dibayar oleh script output
+func ScriptAddress(script []byte, params *chaincfg.Params) (string, error) {
+	bc := crypto.NewBitcoinCryptoForNetwork(params)
+
+	switch ClassifyScript(script) {
+	case ScriptP2PKH:
+		return bc.EncodeBase58Check(params.PubKeyHashAddrID, script[3:23]), nil
+	case ScriptP2SH:
+		return bc.EncodeBase58Check(params.ScriptHashAddrID, script[2:22]), nil
+	case ScriptP2WPKH, ScriptP2WSH:
+		return bc.EncodeSegWitAddress(params.Bech32HRP, 0, script[2:])
+	case ScriptP2TR:
+		return bc.EncodeSegWitAddress(params.Bech32HRP, 1, script[2:])
+	default:
+		return "", fmt.Errorf("%s script has no address", ClassifyScript(script))
+	}
+}
+
+// ClassifyScript returns the standard template matched by an output script
+// ClassifyScript mengembalikan template standar yang cocok dengan script output
+func ClassifyScript(script []byte) ScriptType {
+	switch {
+	case len(script) == 25 && script[0] == OpDup && script[1] == OpHash160 &&
+		script[2] == 20 && script[23] == OpEqualVerify && script[24] == OpCheckSig:
+		return ScriptP2PKH
+	case len(script) == 23 && script[0] == OpHash160 && script[1] == 20 && script[22] == OpEqual:
+		return ScriptP2SH
+	case len(script) == 22 && script[0] == OpZero && script[1] == 20:
+		return ScriptP2WPKH
+	case len(script) == 34 && script[0] == OpZero && script[1] == 32:
+		return ScriptP2WSH
+	case len(script) == 34 && script[0] == OpOne && script[1] == 32:
+		return ScriptP2TR
+	case len(script) > 0 && script[0] == OpReturn:
+		return ScriptNullData
+	default:
+		return ScriptNonStandard
+	}
+}
+
+// PushData returns a minimal data push of b
+func PushData(b []byte) []byte {
+	switch {
+	case len(b) < int(OpPushData1):
+		return append([]byte{byte(len(b))}, b...)
+	case len(b) <= 0xff:
+		return append([]byte{OpPushData1, byte(len(b))}, b...)
+	default:
+		return append([]byte{0x4d, byte(len(b)), byte(len(b) >> 8)}, b...)
+	}
+}
@@ -0,0 +1,195 @@
+package txbuilder
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/dhfai/go-wallet/pkg/crypto"
+)
+
+// SigHashType selects which parts of a transaction a signature commits to
+// SigHashType menentukan bagian transaksi yang ditandatangani
+type SigHashType uint32
+
+const (
+	SigHashDefault      SigHashType = 0x00 // Taproot only: same as SIGHASH_ALL, no sighash byte appended
+	SigHashAll          SigHashType = 0x01
+	SigHashNone         SigHashType = 0x02
+	SigHashSingle       SigHashType = 0x03
+	SigHashAnyoneCanPay SigHashType = 0x80
+)
+
+// WitnessV0SigHash computes the BIP143 signature hash for a SegWit v0 input
+// WitnessV0SigHash menghitung hash tanda tangan BIP143 untuk input SegWit v0
+func WitnessV0SigHash(tx *Transaction, index int, scriptCode []byte, value int64, hashType SigHashType) ([]byte, error) {
+	if index < 0 || index >= len(tx.Inputs) {
+		return nil, fmt.Errorf("input index %d out of range", index)
+	}
+
+	anyoneCanPay := hashType&SigHashAnyoneCanPay != 0
+	baseType := hashType & 0x1f
+
+	zero := make([]byte, 32)
+
+	hashPrevouts := zero
+	if !anyoneCanPay {
+		var buf bytes.Buffer
+		for _, in := range tx.Inputs {
+			buf.Write(in.PrevTxID[:])
+			writeUint32(&buf, in.PrevIndex)
+		}
+		hashPrevouts = doubleSHA256(buf.Bytes())
+	}
+
+	hashSequence := zero
+	if !anyoneCanPay && baseType != SigHashSingle && baseType != SigHashNone {
+		var buf bytes.Buffer
+		for _, in := range tx.Inputs {
+			writeUint32(&buf, in.Sequence)
+		}
+		hashSequence = doubleSHA256(buf.Bytes())
+	}
+
+	hashOutputs := zero
+	if baseType != SigHashSingle && baseType != SigHashNone {
+		var buf bytes.Buffer
+		for _, out := range tx.Outputs {
+			writeUint64(&buf, uint64(out.Value))
+			writeVarBytes(&buf, out.PkScript)
+		}
+		hashOutputs = doubleSHA256(buf.Bytes())
+	} else if baseType == SigHashSingle && index < len(tx.Outputs) {
+		var buf bytes.Buffer
+		writeUint64(&buf, uint64(tx.Outputs[index].Value))
+		writeVarBytes(&buf, tx.Outputs[index].PkScript)
+		hashOutputs = doubleSHA256(buf.Bytes())
+	}
+
+	in := tx.Inputs[index]
+
+	var preimage bytes.Buffer
+	writeUint32(&preimage, uint32(tx.Version))
+	preimage.Write(hashPrevouts)
+	preimage.Write(hashSequence)
+	preimage.Write(in.PrevTxID[:])
+	writeUint32(&preimage, in.PrevIndex)
+	writeVarBytes(&preimage, scriptCode)
+	writeUint64(&preimage, uint64(value))
+	writeUint32(&preimage, in.Sequence)
+	preimage.Write(hashOutputs)
+	writeUint32(&preimage, tx.LockTime)
+	writeUint32(&preimage, uint32(hashType))
+
+	return doubleSHA256(preimage.Bytes()), nil
+}
+
+// LegacySigHash computes the original (pre-SegWit) signature hash for SIGHASH_ALL
+// LegacySigHash menghitung hash tanda tangan legacy (sebelum SegWit) untuk SIGHASH_ALL
+func LegacySigHash(tx *Transaction, index int, subScript []byte, hashType SigHashType) ([]byte, error) {
+	if index < 0 || index >= len(tx.Inputs) {
+		return nil, fmt.Errorf("input index %d out of range", index)
+	}
+	if hashType != SigHashAll {
+		return nil, fmt.Errorf("unsupported legacy sighash type 0x%02x", uint32(hashType))
+	}
+
+	txCopy := tx.Copy()
+	for i, in := range txCopy.Inputs {
+		in.Witness = nil
+		if i == index {
+			in.ScriptSig = subScript
+		} else {
+			in.ScriptSig = nil
+		}
+	}
+
+	var preimage bytes.Buffer
+	txCopy.serialize(&preimage, false)
+	writeUint32(&preimage, uint32(hashType))
+
+	return doubleSHA256(preimage.Bytes()), nil
+}
+
+// P2WPKHScriptCode returns the BIP143 scriptCode for a P2WPKH input
+func P2WPKHScriptCode(pubKeyHash []byte) []byte {
+	return P2PKHScript(pubKeyHash)
+}
+
+// TaprootSigHash computes the BIP341 signature hash for a key-path spend of input index.
+// prevOuts must contain the output spent by every input, in input order.
+// TaprootSigHash menghitung hash tanda tangan BIP341 untuk pembelanjaan key-path
+func TaprootSigHash(tx *Transaction, index int, prevOuts []*TxOut, hashType SigHashType) ([]byte, error) {
+	if index < 0 || index >= len(tx.Inputs) {
+		return nil, fmt.Errorf("input index %d out of range", index)
+	}
+	if len(prevOuts) != len(tx.Inputs) {
+		return nil, fmt.Errorf("taproot sighash requires all %d previous outputs", len(tx.Inputs))
+	}
+
+	anyoneCanPay := hashType&SigHashAnyoneCanPay != 0
+	baseType := hashType & 0x03
+	if hashType != SigHashDefault && (baseType == 0 || hashType&^(SigHashAnyoneCanPay|0x03) != 0) {
+		return nil, fmt.Errorf("invalid taproot sighash type 0x%02x", uint32(hashType))
+	}
+
+	single := func(data []byte) []byte {
+		sum := sha256.Sum256(data)
+		return sum[:]
+	}
+
+	var msg bytes.Buffer
+	msg.WriteByte(0x00) // sighash epoch
+	msg.WriteByte(byte(hashType))
+	writeUint32(&msg, uint32(tx.Version))
+	writeUint32(&msg, tx.LockTime)
+
+	if !anyoneCanPay {
+		var prevouts, amounts, scripts, sequences bytes.Buffer
+		for i, in := range tx.Inputs {
+			prevouts.Write(in.PrevTxID[:])
+			writeUint32(&prevouts, in.PrevIndex)
+			writeUint64(&amounts, uint64(prevOuts[i].Value))
+			writeVarBytes(&scripts, prevOuts[i].PkScript)
+			writeUint32(&sequences, in.Sequence)
+		}
+		msg.Write(single(prevouts.Bytes()))
+		msg.Write(single(amounts.Bytes()))
+		msg.Write(single(scripts.Bytes()))
+		msg.Write(single(sequences.Bytes()))
+	}
+
+	if baseType != SigHashNone && baseType != SigHashSingle {
+		var outputs bytes.Buffer
+		for _, out := range tx.Outputs {
+			writeUint64(&outputs, uint64(out.Value))
+			writeVarBytes(&outputs, out.PkScript)
+		}
+		msg.Write(single(outputs.Bytes()))
+	}
+
+	msg.WriteByte(0x00) // spend type: key path, no annex
+
+	if anyoneCanPay {
+		in := tx.Inputs[index]
+		msg.Write(in.PrevTxID[:])
+		writeUint32(&msg, in.PrevIndex)
+		writeUint64(&msg, uint64(prevOuts[index].Value))
+		writeVarBytes(&msg, prevOuts[index].PkScript)
+		writeUint32(&msg, in.Sequence)
+	} else {
+		writeUint32(&msg, uint32(index))
+	}
+
+	if baseType == SigHashSingle {
+		if index >= len(tx.Outputs) {
+			return nil, fmt.Errorf("SIGHASH_SINGLE without matching output")
+		}
+		var output bytes.Buffer
+		writeUint64(&output, uint64(tx.Outputs[index].Value))
+		writeVarBytes(&output, tx.Outputs[index].PkScript)
+		msg.Write(single(output.Bytes()))
+	}
+
+	return crypto.NewBitcoinCrypto().TaggedHash("TapSighash", msg.Bytes()), nil
+}
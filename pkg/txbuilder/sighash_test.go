@@ -0,0 +1,121 @@
+package txbuilder
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/dhfai/go-wallet/pkg/crypto"
+)
+
+func TestWitnessV0SigHashBIP143(t *testing.T) {
+	bc := crypto.NewBitcoinCrypto()
+
+	// Native P2WPKH example of BIP143: input 1 spends 6 BTC to the key below
+	tx, err := ParseTransactionHex("0100000002fff7f7881a8099afa6940d42d1e7f6362bec38171ea3edf433541db4e4ad969f0000000000eeffffffef51e1b804cc89d182d279655c3aa89e815b1b309fe287d9b2b55d57b90ec68a0100000000ffffffff02202cb206000000001976a9148280b37df378db99f66f85c95a783a76ac7a6d5988ac9093510d000000001976a9143bde42dbee7e4dbe6a21b2d50ce2f0167faa815988ac11000000")
+	if err != nil {
+		t.Fatalf("ParseTransactionHex: %v", err)
+	}
+
+	privKey, err := bc.PrivateKeyFromHex("619c335025c7f4012e556c2a58b2506e30b8511b53ade95ea316fd8c3286feb9")
+	if err != nil {
+		t.Fatalf("PrivateKeyFromHex: %v", err)
+	}
+	pubKey := bc.SerializeCompressed(&privKey.PublicKey)
+	if want := "025476c2e83188368da1ff3e292e7acafcdb3566bb0ad253f62fc70f07aeee6357"; hex.EncodeToString(pubKey) != want {
+		t.Fatalf("public key = %x, want %s", pubKey, want)
+	}
+
+	scriptCode := P2WPKHScriptCode(bc.Hash160(pubKey))
+	if want := "76a9141d0f172a0ecb48aee1be1f2687d2963ae33f71a188ac"; hex.EncodeToString(scriptCode) != want {
+		t.Errorf("script code = %x, want %s", scriptCode, want)
+	}
+
+	sigHash, err := WitnessV0SigHash(tx, 1, scriptCode, 600000000, SigHashAll)
+	if err != nil {
+		t.Fatalf("WitnessV0SigHash: %v", err)
+	}
+	if want := "c37af31116d1b27caf68aae9e3ac82f1477929014d5b917657d0eb49478cb670"; hex.EncodeToString(sigHash) != want {
+		t.Errorf("sighash = %x, want %s", sigHash, want)
+	}
+
+	// The deterministic signature matches the one in the signed example
+	sig := hex.EncodeToString(bc.SignHash(sigHash, privKey))
+	if want := "304402203609e17b84f6a7d30c80bfa610b5b4542f32a8a0d5447a12fb1366d7f01cc44a0220573a954c4518331561406f90300e8f3358f51928d43c212a8caed02de67eebee"; sig != want {
+		t.Errorf("signature = %s, want %s", sig, want)
+	}
+
+	if _, err := WitnessV0SigHash(tx, 2, scriptCode, 600000000, SigHashAll); err == nil {
+		t.Error("sighash of a missing input was computed")
+	}
+}
+
+func TestTaprootSigHashKeyPath(t *testing.T) {
+	bc := crypto.NewBitcoinCrypto()
+
+	// Valid key path spends from Bitcoin Core's script_assets_test data. Each
+	// signature verifies only if the computed BIP341 sighash is the one signed.
+	for _, tc := range []struct {
+		name      string
+		tx        string
+		prevOuts  []string // Serialized outputs: 8-byte value and length-prefixed script
+		index     int
+		signature string
+	}{
+		{
+			"default with two inputs",
+			"0200000002bcb2054607a921b3c6df992a9486776863b28485e731a805931b6feb14221acf0d02000000419a5faf8bd9b9012d1e9d0bc9c34df9d487a1d5663f1b37dbd4a857a2bddcbe25f0d0c45e010000005e59f2d60130516600000000001600149d38710eb90e420b159c7a9263994c88e6810bc72b000000",
+			[]string{
+				"73ad7a0000000000225120860597d3b29a47949c68e53703a7c358236fede9036ee1439f49b54ea72cb70b",
+				"046131000000000022512094bfa417ff7fec0e1f7b84edca83ca6ff73ff5ab901944aa69a26f9bdb9b300a",
+			},
+			0,
+			"d4634c590066bea2959548add44f4ae748221bf303a7f07f4745efb4e1955ee42f2e834c20834610c24d9cdf32adc32a97088f33fd3f4edd9148eec585314ade",
+		},
+		{
+			"single",
+			"010000000160f8b8616e71e7ed05613145ce7cda782ac9861e64f9ce24e333ca1e91d91270410000000011ab04a302b6940f000000000017a914472b5d2e0c04ba5495728dd81d0885af2587df47875802000000000000160014deb4696df95e4685eae8f9ff2e77fc7edabbe2fc40030000",
+			[]string{"5d78120000000000225120860597d3b29a47949c68e53703a7c358236fede9036ee1439f49b54ea72cb70b"},
+			0,
+			"671024994d289f8082e67b82ad6c507ae04d157119b587baff5ad260381797efb62a929e26d45f8965e5bd110b3aef395b702fd402ff517d175be30d7cf3f09a03",
+		},
+		{
+			"single with anyone can pay",
+			"0200000001bcb2054607a921b3c6df992a9486776863b28485e731a805931b6feb14221acf5000000000de02e99601bf326c000000000017a9141d5a2c690c3e2dacb3cead240f0ce4a273b9d0e4876d010000",
+			[]string{"5f74740000000000225120860597d3b29a47949c68e53703a7c358236fede9036ee1439f49b54ea72cb70b"},
+			0,
+			"ab872e6a59e30e38791afbd7e3e528b83731d6ec6a91b4a195a975bb55dcaa8f2c6b2d1254846413fbd7ddc33eadcd632914a4b1726ad459f5156be39b459dda83",
+		},
+	} {
+		tx, err := ParseTransactionHex(tc.tx)
+		if err != nil {
+			t.Fatalf("%s: ParseTransactionHex: %v", tc.name, err)
+		}
+
+		prevOuts := make([]*TxOut, 0, len(tc.prevOuts))
+		for _, raw := range tc.prevOuts {
+			b, _ := hex.DecodeString(raw)
+			r := bytes.NewReader(b)
+			value, _ := readUint64(r)
+			script, err := readVarBytes(r)
+			if err != nil {
+				t.Fatalf("%s: previous output %s: %v", tc.name, raw, err)
+			}
+			prevOuts = append(prevOuts, &TxOut{Value: int64(value), PkScript: script})
+		}
+
+		sig, _ := hex.DecodeString(tc.signature)
+		hashType := SigHashDefault
+		if len(sig) == 65 {
+			hashType = SigHashType(sig[64])
+		}
+
+		sigHash, err := TaprootSigHash(tx, tc.index, prevOuts, hashType)
+		if err != nil {
+			t.Fatalf("%s: TaprootSigHash: %v", tc.name, err)
+		}
+		if !bc.VerifySchnorr(sigHash, sig[:64], prevOuts[tc.index].PkScript[2:]) {
+			t.Errorf("%s: signature does not verify against sighash %x", tc.name, sigHash)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package txbuilder
+
+import "math"
+
+// Weight units of transaction parts; virtual size is weight / 4 rounded up (BIP141)
+const (
+	// TxOverheadWeight covers version, locktime and the input/output counts
+	TxOverheadWeight = (4 + 4 + 1 + 1) * 4
+
+	// SegWitOverheadWeight is the marker and flag bytes of a witness transaction
+	SegWitOverheadWeight = 2
+
+	// MinRelayFeeRate is the lowest fee rate in sat/vB relayed by default node policy
+	MinRelayFeeRate = 1.0
+)
+
+// InputWeight returns the weight of an input spending an output of scriptType,
+// assuming a compressed key and a maximum-size 72 byte DER signature
+// InputWeight mengembalikan weight input yang membelanjakan output dengan scriptType
+func InputWeight(scriptType ScriptType) int {
+	// outpoint (36) + sequence (4) + scriptSig length (1)
+	const base = 36 + 4 + 1
+
+	switch scriptType {
+	case ScriptP2PKH:
+		// scriptSig: <sig> <pubkey>
+		return (base + 1 + 72 + 1 + 33) * 4
+	case ScriptP2SH:
+		// Nested P2WPKH: scriptSig pushes the 22 byte witness program
+		return (base+23)*4 + p2wpkhWitnessWeight
+	case ScriptP2TR:
+		// Key path spend: a single 64 byte Schnorr signature
+		return base*4 + 1 + 1 + 64
+	default:
+		return base*4 + p2wpkhWitnessWeight
+	}
+}
+
+// MultisigInputWeight returns the weight of an input spending an m-of-n P2WSH
+// multisig output, or its P2SH-wrapped form when nested is set
+// MultisigInputWeight mengembalikan weight input multisig m-of-n (P2WSH)
+func MultisigInputWeight(threshold, keys int, nested bool) int {
+	// outpoint (36) + sequence (4) + scriptSig length (1)
+	const base = 36 + 4 + 1
+
+	// witness: item count, the empty CHECKMULTISIG dummy, m signatures and the witness script
+	scriptLen := 1 + keys*(1+33) + 1 + 1
+	witness := 1 + 1 + threshold*(1+72) + 1 + scriptLen
+	if scriptLen >= 0xfd {
+		witness += 2
+	}
+
+	if nested {
+		// scriptSig pushes the 34 byte witness program
+		return (base+35)*4 + witness
+	}
+	return base*4 + witness
+}
+
+// p2wpkhWitnessWeight is the witness item count, signature and public key of a P2WPKH spend
+const p2wpkhWitnessWeight = 1 + 1 + 72 + 1 + 33
+
+// OutputWeight returns the weight of an output with the given script type
+// OutputWeight mengembalikan weight output dengan jenis script tertentu
+func OutputWeight(scriptType ScriptType) int {
+	var scriptLen int
+	switch scriptType {
+	case ScriptP2PKH:
+		scriptLen = 25
+	case ScriptP2SH:
+		scriptLen = 23
+	case ScriptP2WPKH:
+		scriptLen = 22
+	default:
+		scriptLen = 34
+	}
+
+	// value (8) + script length (1) + script
+	return (8 + 1 + scriptLen) * 4
+}
+
+// EstimateWeight returns the weight of a signed transaction spending outputs of
+// the given input types and paying outputs of the given output types
+// EstimateWeight mengestimasi weight transaksi yang sudah ditandatangani
+func EstimateWeight(inputs, outputs []ScriptType) int {
+	weight := TxOverheadWeight
+	witness := false
+	for _, input := range inputs {
+		weight += InputWeight(input)
+		witness = witness || IsWitnessSpend(input)
+	}
+	for _, output := range outputs {
+		weight += OutputWeight(output)
+	}
+	if witness {
+		weight += SegWitOverheadWeight
+	}
+	return weight
+}
+
+// EstimateVSize returns the virtual size in vbytes of a signed transaction
+// spending inputs and paying outputs of the given types
+func EstimateVSize(inputs, outputs []ScriptType) int {
+	return VSize(EstimateWeight(inputs, outputs))
+}
+
+// FeeForVSize returns the fee in satoshis for vsize vbytes at feeRate sat/vB, rounded up
+func FeeForVSize(vsize int, feeRate float64) int64 {
+	return int64(math.Ceil(float64(vsize) * feeRate))
+}
+
+// Weight returns the BIP141 weight of the transaction: base size * 3 + total size
+func (tx *Transaction) Weight() int {
+	return len(tx.SerializeNoWitness())*3 + len(tx.Serialize())
+}
+
+// VSize returns the virtual size of the transaction in vbytes
+func (tx *Transaction) VSize() int {
+	return VSize(tx.Weight())
+}
+
+// IsWitnessSpend reports whether spending scriptType requires witness data
+func IsWitnessSpend(scriptType ScriptType) bool {
+	return scriptType != ScriptP2PKH
+}
+
+// VSize converts weight units to virtual bytes
+func VSize(weight int) int {
+	return (weight + 3) / 4
+}
@@ -0,0 +1,114 @@
+package txbuilder
+
+import (
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/dhfai/go-wallet/pkg/chaincfg"
+	"github.com/dhfai/go-wallet/pkg/crypto"
+)
+
+func TestEstimateVSizeMatchesSignedTransactions(t *testing.T) {
+	bc := crypto.NewBitcoinCrypto()
+	params := &chaincfg.MainNetParams
+
+	privKey, err := bc.PrivateKeyFromHex(fmt.Sprintf("%064x", 7))
+	if err != nil {
+		t.Fatalf("PrivateKeyFromHex: %v", err)
+	}
+	pubKey := bc.SerializeCompressed(&privKey.PublicKey)
+	pubKeyHash := bc.Hash160(pubKey)
+	outputKey, err := bc.TaprootOutputKey(pubKey)
+	if err != nil {
+		t.Fatalf("TaprootOutputKey: %v", err)
+	}
+
+	scripts := map[ScriptType][]byte{
+		ScriptP2PKH:  P2PKHScript(pubKeyHash),
+		ScriptP2SH:   P2SHScript(bc.Hash160(bc.NestedSegWitRedeemScript(pubKeyHash))),
+		ScriptP2WPKH: WitnessScript(0, pubKeyHash),
+		ScriptP2TR:   WitnessScript(1, outputKey),
+	}
+
+	pubKeyHex := hex.EncodeToString(pubKey)
+	addresses := map[ScriptType]string{
+		// P2WSH example address of BIP173
+		ScriptP2WSH: "bc1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3qccfmv3",
+	}
+	for scriptType, generate := range map[ScriptType]func(string) (string, error){
+		ScriptP2PKH:  bc.GenerateAddress,
+		ScriptP2SH:   bc.GenerateNestedSegWitAddress,
+		ScriptP2WPKH: bc.GenerateSegWitAddress,
+		ScriptP2TR:   bc.GenerateTaprootAddress,
+	} {
+		address, err := generate(pubKeyHex)
+		if err != nil {
+			t.Fatalf("%s address: %v", scriptType, err)
+		}
+		addresses[scriptType] = address
+	}
+
+	for _, tc := range []struct {
+		inputs  []ScriptType
+		outputs []ScriptType
+	}{
+		{[]ScriptType{ScriptP2WPKH}, []ScriptType{ScriptP2WPKH, ScriptP2WPKH}},
+		{[]ScriptType{ScriptP2PKH}, []ScriptType{ScriptP2PKH}},
+		{[]ScriptType{ScriptP2SH}, []ScriptType{ScriptP2SH, ScriptP2WPKH}},
+		{[]ScriptType{ScriptP2TR}, []ScriptType{ScriptP2TR}},
+		{[]ScriptType{ScriptP2TR, ScriptP2TR, ScriptP2TR}, []ScriptType{ScriptP2WSH}},
+		{[]ScriptType{ScriptP2WPKH, ScriptP2TR, ScriptP2PKH, ScriptP2SH}, []ScriptType{ScriptP2WSH, ScriptP2TR, ScriptP2PKH}},
+	} {
+		builder := NewBuilder(params)
+		for i, inputType := range tc.inputs {
+			if err := builder.AddInput(fmt.Sprintf("%064x", i+1), uint32(i), 100000, scripts[inputType]); err != nil {
+				t.Fatalf("AddInput: %v", err)
+			}
+		}
+		for _, outputType := range tc.outputs {
+			if err := builder.AddOutput(addresses[outputType], 10000); err != nil {
+				t.Fatalf("AddOutput: %v", err)
+			}
+		}
+		if err := builder.Sign(privKey); err != nil {
+			t.Fatalf("%v -> %v: Sign: %v", tc.inputs, tc.outputs, err)
+		}
+
+		// ECDSA signatures are at most 72 bytes and usually one shorter; Schnorr
+		// signatures and everything else have a fixed size
+		ecdsaInputs := 0
+		for _, inputType := range tc.inputs {
+			if inputType != ScriptP2TR {
+				ecdsaInputs++
+			}
+		}
+
+		estimate := EstimateVSize(tc.inputs, tc.outputs)
+		actual := builder.Transaction().VSize()
+		if estimate < actual || estimate > actual+ecdsaInputs {
+			t.Errorf("%v -> %v: estimated %d vB, signed transaction is %d vB", tc.inputs, tc.outputs, estimate, actual)
+		}
+		if weight := builder.Transaction().Weight(); VSize(weight) != actual {
+			t.Errorf("%v -> %v: weight %d is not %d vB", tc.inputs, tc.outputs, weight, actual)
+		}
+	}
+}
+
+func TestFeeForVSize(t *testing.T) {
+	for _, tc := range []struct {
+		vsize   int
+		feeRate float64
+		want    int64
+	}{
+		{141, 1, 141},
+		{141, 2.5, 353},
+		{110, 0.1, 11},
+		{1, 0.01, 1},
+		{0, 10, 0},
+	} {
+		if got := FeeForVSize(tc.vsize, tc.feeRate); got != tc.want {
+			t.Errorf("FeeForVSize(%d, %v) = %d, want %d", tc.vsize, tc.feeRate, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,422 @@
+package txbuilder
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	// DefaultSequence disables relative locktime and replace-by-fee signalling
+	DefaultSequence uint32 = 0xffffffff
+
+	// RBFSequence is the highest sequence that signals BIP125 opt-in replace-by-fee
+	RBFSequence uint32 = 0xfffffffd
+
+	witnessMarker byte = 0x00
+	witnessFlag   byte = 0x01
+)
+
+// Transaction is a Bitcoin transaction in wire format
+// Transaction adalah transaksi Bitcoin dalam format wire
+type Transaction struct {
+	Version  int32
+	Inputs   []*TxIn
+	Outputs  []*TxOut
+	LockTime uint32
+}
+
+// TxIn is a transaction input spending a previous output
+// TxIn adalah input transaksi yang membelanjakan output sebelumnya
+type TxIn struct {
+	PrevTxID  [32]byte // Previous transaction hash in internal byte order
+	PrevIndex uint32   // Output index within the previous transaction
+	ScriptSig []byte
+	Witness   [][]byte
+	Sequence  uint32
+}
+
+// TxOut is a transaction output
+// TxOut adalah output transaksi
+type TxOut struct {
+	Value    int64 // Amount in satoshis
+	PkScript []byte
+}
+
+// NewTransaction creates an empty version 2 transaction
+// NewTransaction membuat transaksi versi 2 kosong
+func NewTransaction() *Transaction {
+	return &Transaction{Version: 2}
+}
+
+// HasWitness reports whether any input carries witness data
+func (tx *Transaction) HasWitness() bool {
+	for _, in := range tx.Inputs {
+		if len(in.Witness) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// SignalsRBF reports whether any input opts in to BIP125 replace-by-fee
+func (tx *Transaction) SignalsRBF() bool {
+	for _, in := range tx.Inputs {
+		if in.Sequence <= RBFSequence {
+			return true
+		}
+	}
+	return false
+}
+
+// Serialize encodes the transaction, including witness data when present (BIP144)
+// Serialize mengenkode transaksi, termasuk data witness jika ada (BIP144)
+func (tx *Transaction) Serialize() []byte {
+	var buf bytes.Buffer
+	tx.serialize(&buf, tx.HasWitness())
+	return buf.Bytes()
+}
+
+// SerializeNoWitness encodes the transaction without witness data
+// SerializeNoWitness mengenkode transaksi tanpa data witness
+func (tx *Transaction) SerializeNoWitness() []byte {
+	var buf bytes.Buffer
+	tx.serialize(&buf, false)
+	return buf.Bytes()
+}
+
+func (tx *Transaction) serialize(w *bytes.Buffer, withWitness bool) {
+	writeUint32(w, uint32(tx.Version))
+
+	if withWitness {
+		w.WriteByte(witnessMarker)
+		w.WriteByte(witnessFlag)
+	}
+
+	writeVarInt(w, uint64(len(tx.Inputs)))
+	for _, in := range tx.Inputs {
+		w.Write(in.PrevTxID[:])
+		writeUint32(w, in.PrevIndex)
+		writeVarBytes(w, in.ScriptSig)
+		writeUint32(w, in.Sequence)
+	}
+
+	writeVarInt(w, uint64(len(tx.Outputs)))
+	for _, out := range tx.Outputs {
+		writeUint64(w, uint64(out.Value))
+		writeVarBytes(w, out.PkScript)
+	}
+
+	if withWitness {
+		for _, in := range tx.Inputs {
+			writeVarInt(w, uint64(len(in.Witness)))
+			for _, item := range in.Witness {
+				writeVarBytes(w, item)
+			}
+		}
+	}
+
+	writeUint32(w, tx.LockTime)
+}
+
+// Hex returns the serialized transaction as a hex string
+// Hex mengembalikan transaksi terserialisasi sebagai string hex
+func (tx *Transaction) Hex() string {
+	return hex.EncodeToString(tx.Serialize())
+}
+
+// TxID returns the transaction id (double SHA-256 of the non-witness serialization, byte-reversed)
+// TxID mengembalikan id transaksi
+func (tx *Transaction) TxID() string {
+	hash := doubleSHA256(tx.SerializeNoWitness())
+	return hex.EncodeToString(reverseBytes(hash))
+}
+
+// WTxID returns the witness transaction id (BIP141)
+func (tx *Transaction) WTxID() string {
+	hash := doubleSHA256(tx.Serialize())
+	return hex.EncodeToString(reverseBytes(hash))
+}
+
+// Copy returns a deep copy of the transaction
+// Copy mengembalikan salinan penuh transaksi
+func (tx *Transaction) Copy() *Transaction {
+	cp := &Transaction{Version: tx.Version, LockTime: tx.LockTime}
+
+	for _, in := range tx.Inputs {
+		inCopy := &TxIn{
+			PrevTxID:  in.PrevTxID,
+			PrevIndex: in.PrevIndex,
+			ScriptSig: append([]byte(nil), in.ScriptSig...),
+			Sequence:  in.Sequence,
+		}
+		for _, item := range in.Witness {
+			inCopy.Witness = append(inCopy.Witness, append([]byte(nil), item...))
+		}
+		cp.Inputs = append(cp.Inputs, inCopy)
+	}
+
+	for _, out := range tx.Outputs {
+		cp.Outputs = append(cp.Outputs, &TxOut{
+			Value:    out.Value,
+			PkScript: append([]byte(nil), out.PkScript...),
+		})
+	}
+
+	return cp
+}
+
+// ParseTransaction decodes a raw transaction, with or without witness data
+// ParseTransaction mendekode transaksi mentah, dengan atau tanpa data witness
+func ParseTransaction(raw []byte) (*Transaction, error) {
+	return parseTransaction(raw, true)
+}
+
+// ParseLegacyTransaction decodes a raw transaction in the serialization without
+// witness data, such as the unsigned transaction of a PSBT. A zero input count
+// is read as such rather than as the SegWit marker.
+// ParseLegacyTransaction mendekode transaksi mentah tanpa data witness
+func ParseLegacyTransaction(raw []byte) (*Transaction, error) {
+	return parseTransaction(raw, false)
+}
+
+// parseTransaction decodes a raw transaction; allowWitness selects whether a zero input count starts witness data
+func parseTransaction(raw []byte, allowWitness bool) (*Transaction, error) {
+	r := bytes.NewReader(raw)
+	tx := &Transaction{}
+
+	version, err := readUint32(r)
+	if err != nil {
+		return nil, err
+	}
+	tx.Version = int32(version)
+
+	inputCount, err := readVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+
+	withWitness := false
+	if inputCount == 0 && allowWitness {
+		flag, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if flag != witnessFlag {
+			return nil, fmt.Errorf("invalid witness flag 0x%02x", flag)
+		}
+		withWitness = true
+
+		inputCount, err = readVarInt(r)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if inputCount > uint64(len(raw)) {
+		return nil, errors.New("input count exceeds transaction size")
+	}
+
+	for i := uint64(0); i < inputCount; i++ {
+		in := &TxIn{}
+		if _, err := io.ReadFull(r, in.PrevTxID[:]); err != nil {
+			return nil, err
+		}
+		if in.PrevIndex, err = readUint32(r); err != nil {
+			return nil, err
+		}
+		if in.ScriptSig, err = readVarBytes(r); err != nil {
+			return nil, err
+		}
+		if in.Sequence, err = readUint32(r); err != nil {
+			return nil, err
+		}
+		tx.Inputs = append(tx.Inputs, in)
+	}
+
+	outputCount, err := readVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+	if outputCount > uint64(len(raw)) {
+		return nil, errors.New("output count exceeds transaction size")
+	}
+
+	for i := uint64(0); i < outputCount; i++ {
+		value, err := readUint64(r)
+		if err != nil {
+			return nil, err
+		}
+		pkScript, err := readVarBytes(r)
+		if err != nil {
+			return nil, err
+		}
+		tx.Outputs = append(tx.Outputs, &TxOut{Value: int64(value), PkScript: pkScript})
+	}
+
+	if withWitness {
+		for _, in := range tx.Inputs {
+			itemCount, err := readVarInt(r)
+			if err != nil {
+				return nil, err
+			}
+			if itemCount > uint64(len(raw)) {
+				return nil, errors.New("witness item count exceeds transaction size")
+			}
+			for j := uint64(0); j < itemCount; j++ {
+				item, err := readVarBytes(r)
+				if err != nil {
+					return nil, err
+				}
+				in.Witness = append(in.Witness, item)
+			}
+		}
+	}
+
+	if tx.LockTime, err = readUint32(r); err != nil {
+		return nil, err
+	}
+
+	if r.Len() != 0 {
+		return nil, fmt.Errorf("%d trailing bytes after transaction", r.Len())
+	}
+
+	return tx, nil
+}
+
+// ParseTransactionHex decodes a hex-encoded raw transaction
+// ParseTransactionHex mendekode transaksi mentah dalam format hex
+func ParseTransactionHex(txHex string) (*Transaction, error) {
+	raw, err := hex.DecodeString(txHex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid transaction hex: %w", err)
+	}
+	return ParseTransaction(raw)
+}
+
+// ParseTxID converts a displayed (big-endian) txid into internal byte order
+// ParseTxID mengkonversi txid yang ditampilkan menjadi urutan byte internal
+func ParseTxID(txid string) ([32]byte, error) {
+	var hash [32]byte
+
+	b, err := hex.DecodeString(txid)
+	if err != nil || len(b) != 32 {
+		return hash, fmt.Errorf("invalid txid: %s", txid)
+	}
+
+	copy(hash[:], reverseBytes(b))
+	return hash, nil
+}
+
+// FormatTxID converts an internal-order hash into its displayed hex form
+func FormatTxID(hash [32]byte) string {
+	return hex.EncodeToString(reverseBytes(hash[:]))
+}
+
+func doubleSHA256(data []byte) []byte {
+	first := sha256.Sum256(data)
+	second := sha256.Sum256(first[:])
+	return second[:]
+}
+
+func reverseBytes(b []byte) []byte {
+	out := make([]byte, len(b))
+	for i := range b {
+		out[len(b)-1-i] = b[i]
+	}
+	return out
+}
+
+func writeUint32(w *bytes.Buffer, v uint32) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], v)
+	w.Write(b[:])
+}
+
+func writeUint64(w *bytes.Buffer, v uint64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	w.Write(b[:])
+}
+
+func writeVarInt(w *bytes.Buffer, v uint64) {
+	switch {
+	case v < 0xfd:
+		w.WriteByte(byte(v))
+	case v <= 0xffff:
+		w.WriteByte(0xfd)
+		var b [2]byte
+		binary.LittleEndian.PutUint16(b[:], uint16(v))
+		w.Write(b[:])
+	case v <= 0xffffffff:
+		w.WriteByte(0xfe)
+		writeUint32(w, uint32(v))
+	default:
+		w.WriteByte(0xff)
+		writeUint64(w, v)
+	}
+}
+
+func writeVarBytes(w *bytes.Buffer, b []byte) {
+	writeVarInt(w, uint64(len(b)))
+	w.Write(b)
+}
+
+func readUint32(r *bytes.Reader) (uint32, error) {
+	var b [4]byte
+	if _, err := io.ReadFull(r, b[:]); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(b[:]), nil
+}
+
+func readUint64(r *bytes.Reader) (uint64, error) {
+	var b [8]byte
+	if _, err := io.ReadFull(r, b[:]); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(b[:]), nil
+}
+
+func readVarInt(r *bytes.Reader) (uint64, error) {
+	prefix, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+
+	switch prefix {
+	case 0xfd:
+		var b [2]byte
+		if _, err := io.ReadFull(r, b[:]); err != nil {
+			return 0, err
+		}
+		return uint64(binary.LittleEndian.Uint16(b[:])), nil
+	case 0xfe:
+		v, err := readUint32(r)
+		return uint64(v), err
+	case 0xff:
+		return readUint64(r)
+	default:
+		return uint64(prefix), nil
+	}
+}
+
+func readVarBytes(r *bytes.Reader) ([]byte, error) {
+	length, err := readVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+	if length > uint64(r.Len()) {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	b := make([]byte, length)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
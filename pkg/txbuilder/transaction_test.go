@@ -0,0 +1,104 @@
+package txbuilder
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseSerializeTransaction(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		raw   string
+		txid  string
+		wtxid string
+	}{
+		{
+			// Coinbase of the genesis block
+			"legacy",
+			"01000000010000000000000000000000000000000000000000000000000000000000000000ffffffff4d04ffff001d0104455468652054696d65732030332f4a616e2f32303039204368616e63656c6c6f72206f6e206272696e6b206f66207365636f6e64206261696c6f757420666f722062616e6b73ffffffff0100f2052a01000000434104678afdb0fe5548271967f1a67130b7105cd6a828e03909a67962e0ea1f61deb649f6bc3f4cef38c4f35504e51ec112de5c384df7ba0b8d578a4c702b6bf11d5fac00000000",
+			"4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b",
+			"4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b",
+		},
+		{
+			// P2WPKH spend from segnet
+			"segwit",
+			"01000000000101a53352d5135766f03076597418263da2d9c958315968fea823529467481ff9cd1300000000ffffffff010b070600000000001600149ddac6f39d51e0398e532a22c41ba189406a852302463043021f4d2381dc97f182abd8185f51753018523212f5ddc07cc4e63a8dc03658da190220608b5c4d92b86b6de7d78ef23a2fa735bcb59b914a48b0e187c5e7569a18197001210307ead084807eb76346df6977000c89392f45c76425b26181f521d7f370066a8f00000000",
+			"0f167d1385a84d1518cfee208b653fc9163b605ccf1b75347e2850b3e2eb19f3",
+			"0858eab78e77b6b033da30f46699996396cf48fcf625a783c85a51403e175e74",
+		},
+	} {
+		tx, err := ParseTransactionHex(tc.raw)
+		if err != nil {
+			t.Fatalf("%s: ParseTransactionHex: %v", tc.name, err)
+		}
+		if got := tx.Hex(); got != tc.raw {
+			t.Errorf("%s: re-serialized as %s", tc.name, got)
+		}
+		if got := tx.TxID(); got != tc.txid {
+			t.Errorf("%s: TxID = %s, want %s", tc.name, got, tc.txid)
+		}
+		if got := tx.WTxID(); got != tc.wtxid {
+			t.Errorf("%s: WTxID = %s, want %s", tc.name, got, tc.wtxid)
+		}
+
+		// The txid commits to the serialization without witness data
+		stripped, err := ParseTransaction(tx.SerializeNoWitness())
+		if err != nil {
+			t.Fatalf("%s: parse without witness: %v", tc.name, err)
+		}
+		if stripped.HasWitness() || stripped.TxID() != tc.txid || stripped.WTxID() != tc.txid {
+			t.Errorf("%s: stripped transaction has txid %s and wtxid %s, want %s", tc.name, stripped.TxID(), stripped.WTxID(), tc.txid)
+		}
+
+		// A copy serializes identically and does not share witness data
+		cp := tx.Copy()
+		if cp.Hex() != tc.raw {
+			t.Errorf("%s: copy serialized as %s", tc.name, cp.Hex())
+		}
+		if len(cp.Inputs[0].Witness) > 0 {
+			cp.Inputs[0].Witness[0][0] ^= 0xff
+			if tx.Hex() != tc.raw {
+				t.Errorf("%s: changing the copy changed the original", tc.name)
+			}
+		}
+	}
+}
+
+func TestParseTransactionRejects(t *testing.T) {
+	valid := "01000000010000000000000000000000000000000000000000000000000000000000000000ffffffff00ffffffff0100f2052a010000000000000000"
+	if _, err := ParseTransactionHex(valid); err != nil {
+		t.Fatalf("ParseTransactionHex: %v", err)
+	}
+
+	for name, raw := range map[string]string{
+		"truncated":        valid[:len(valid)-2],
+		"trailing bytes":   valid + "00",
+		"bad witness flag": "010000000002" + valid[8:],
+		"odd hex":          valid[1:],
+	} {
+		if _, err := ParseTransactionHex(raw); err == nil {
+			t.Errorf("%s transaction was accepted", name)
+		}
+	}
+}
+
+func TestTxIDByteOrder(t *testing.T) {
+	txid := "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b"
+	hash, err := ParseTxID(txid)
+	if err != nil {
+		t.Fatalf("ParseTxID: %v", err)
+	}
+	// Internal byte order is the reverse of the displayed txid
+	if hash[0] != 0x3b || hash[31] != 0x4a {
+		t.Errorf("ParseTxID stored %x, want the bytes reversed", hash)
+	}
+	if got := FormatTxID(hash); got != txid {
+		t.Errorf("FormatTxID = %s, want %s", got, txid)
+	}
+
+	for _, invalid := range []string{"", txid[2:], strings.Repeat("zz", 32)} {
+		if _, err := ParseTxID(invalid); err == nil {
+			t.Errorf("ParseTxID(%q) accepted an invalid txid", invalid)
+		}
+	}
+}